@@ -30,7 +30,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
 	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
@@ -45,6 +48,7 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	kedacontrollers "github.com/kedacore/keda/v2/controllers/keda"
 	prommetrics "github.com/kedacore/keda/v2/pkg/metrics"
+	"github.com/kedacore/keda/v2/pkg/metricsservice"
 	kedaprovider "github.com/kedacore/keda/v2/pkg/provider"
 	"github.com/kedacore/keda/v2/pkg/scaling"
 	kedautil "github.com/kedacore/keda/v2/pkg/util"
@@ -66,6 +70,10 @@ var (
 	prometheusMetricsPath     string
 	adapterClientRequestQPS   float32
 	adapterClientRequestBurst int
+	metricsServiceAddress     string
+	metricsServiceCertDir     string
+	metricsServiceShards      int
+	queryBatchWindow          time.Duration
 )
 
 func (a *Adapter) makeProvider(ctx context.Context, globalHTTPTimeout time.Duration, maxConcurrentReconciles int) (provider.MetricsProvider, <-chan struct{}, error) {
@@ -105,9 +113,22 @@ func (a *Adapter) makeProvider(ctx context.Context, globalHTTPTimeout time.Durat
 		return nil, nil, err
 	}
 
+	clientset, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		logger.Error(err, "failed to create discovery client")
+		return nil, nil, err
+	}
+	scaleClient := scale.New(
+		clientset.RESTClient(), mgr.GetRESTMapper(),
+		dynamic.LegacyAPIPathResolverFunc,
+		scale.NewDiscoveryScaleKindResolver(clientset),
+	)
+
 	broadcaster := record.NewBroadcaster()
 	recorder := broadcaster.NewRecorder(scheme, corev1.EventSource{Component: "keda-metrics-adapter"})
-	handler := scaling.NewScaleHandler(mgr.GetClient(), nil, scheme, globalHTTPTimeout, recorder)
+	handler := scaling.NewScaleHandler(mgr.GetClient(), scaleClient, scheme, globalHTTPTimeout, recorder, scaling.ScaleHandlerOptions{
+		QueryBatchWindow: queryBatchWindow,
+	})
 	externalMetricsInfo := &[]provider.ExternalMetricInfo{}
 	externalMetricsInfoLock := &sync.RWMutex{}
 
@@ -115,11 +136,30 @@ func (a *Adapter) makeProvider(ctx context.Context, globalHTTPTimeout time.Durat
 	go func() { prometheusServer.NewServer(fmt.Sprintf(":%v", prometheusMetricsPort), prometheusMetricsPath) }()
 	stopCh := make(chan struct{})
 
+	var grpcClient *metricsservice.GrpcClient
+	if metricsServiceAddress != "" {
+		grpcClient, err = metricsservice.NewShardedGrpcClient(metricsServiceAddress, metricsServiceShards, metricsServiceCertDir)
+		if err != nil {
+			logger.Error(err, "failed to create Metrics Service gRPC client")
+			return nil, nil, fmt.Errorf("failed to create Metrics Service gRPC client (%s)", err)
+		}
+		logger.Info("Metrics are obtained from KEDA Operator", "address", metricsServiceAddress, "shards", metricsServiceShards)
+
+		// the connection is closed once the manager is stopped on shutdown of the adapter
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return grpcClient.Close()
+		})); err != nil {
+			logger.Error(err, "failed to add Metrics Service gRPC client to the manager")
+			return nil, nil, err
+		}
+	}
+
 	if err := runScaledObjectController(ctx, mgr, handler, logger, externalMetricsInfo, externalMetricsInfoLock, maxConcurrentReconciles, stopCh); err != nil {
 		return nil, nil, err
 	}
 
-	return kedaprovider.NewProvider(ctx, logger, handler, mgr.GetClient(), namespace, externalMetricsInfo, externalMetricsInfoLock), stopCh, nil
+	return kedaprovider.NewProvider(ctx, logger, handler, mgr.GetClient(), scaleClient, grpcClient, namespace, externalMetricsInfo, externalMetricsInfoLock), stopCh, nil
 }
 
 func runScaledObjectController(ctx context.Context, mgr manager.Manager, scaleHandler scaling.ScaleHandler, logger logr.Logger, externalMetricsInfo *[]provider.ExternalMetricInfo, externalMetricsInfoLock *sync.RWMutex, maxConcurrentReconciles int, stopCh chan<- struct{}) error {
@@ -183,6 +223,10 @@ func main() {
 	cmd.Flags().StringVar(&prometheusMetricsPath, "metrics-path", "/metrics", "Set the path for the prometheus metrics endpoint")
 	cmd.Flags().Float32Var(&adapterClientRequestQPS, "kube-api-qps", 20.0, "Set the QPS rate for throttling requests sent to the apiserver")
 	cmd.Flags().IntVar(&adapterClientRequestBurst, "kube-api-burst", 30, "Set the burst for throttling requests sent to the apiserver")
+	cmd.Flags().StringVar(&metricsServiceAddress, "metrics-service-address", "", "The address of the gRPC Metrics Service served by KEDA Operator, eg. keda-operator.keda.svc.cluster.local:9666. If empty, the scalers are queried directly and the values of triggers with prediction are not forecasted")
+	cmd.Flags().IntVar(&metricsServiceShards, "metrics-service-shards", 1, "The number of shards of KEDA Operator (see --shards of KEDA Operator), each request is sent to the shard owning the ScaledObject. With more than 1 shard, {shard} in --metrics-service-address is replaced by the shard index, eg. keda-operator-{shard}.keda.svc.cluster.local:9666")
+	cmd.Flags().StringVar(&metricsServiceCertDir, "metrics-service-cert-dir", "/certs", "The directory with tls.crt, tls.key and ca.crt used for mTLS connection to the Metrics Service")
+	cmd.Flags().DurationVar(&queryBatchWindow, "query-batch-window", 0, "For how long are the metric queries of the Prometheus, RabbitMQ, AWS CloudWatch and Kafka scalers sharing a backend collected before they are executed together, eg. 100ms. The window is started by the first query, which waits for the whole window, so batching helps only the objects polled within the same window, see the keda_scaler_query_batch_size metric. If 0, the queries are not batched")
 	if err := cmd.Flags().Parse(os.Args); err != nil {
 		return
 	}
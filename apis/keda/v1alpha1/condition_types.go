@@ -32,6 +32,8 @@ const (
 	ConditionActive ConditionType = "Active"
 	// ConditionFallback specifies that the resource has a fallback active.
 	ConditionFallback ConditionType = "Fallback"
+	// ConditionPaused specifies that the autoscaling of the resource is paused.
+	ConditionPaused ConditionType = "Paused"
 )
 
 const (
@@ -39,6 +41,18 @@ const (
 	ScaledObjectConditionReadySucccesReason = "ScaledObjectReady"
 	// ScaledObjectConditionReadySuccessMessage defines the default Message for correct ScaledObject
 	ScaledObjectConditionReadySuccessMessage = "ScaledObject is defined correctly and is ready for scaling"
+	// ScaledObjectConditionPausedReason defines the Reason for paused ScaledObject
+	ScaledObjectConditionPausedReason = "ScaledObjectPaused"
+	// ScaledObjectConditionPausedMessage defines the Message for paused ScaledObject
+	ScaledObjectConditionPausedMessage = "ScaledObject is paused, scaling is not performed"
+	// ScaledJobConditionPausedReason defines the Reason for paused ScaledJob
+	ScaledJobConditionPausedReason = "ScaledJobPaused"
+	// ScaledJobConditionPausedMessage defines the Message for paused ScaledJob
+	ScaledJobConditionPausedMessage = "ScaledJob is paused, new Jobs are not created"
+	// ConditionUnpausedReason defines the Reason for resource that isn't paused
+	ConditionUnpausedReason = "Unpaused"
+	// ConditionUnpausedMessage defines the Message for resource that isn't paused
+	ConditionUnpausedMessage = "Scaling is not paused"
 )
 
 // Condition to store the condition state
@@ -70,6 +84,7 @@ func (c *Conditions) AreInitialized() bool {
 	foundReady := false
 	foundActive := false
 	foundFallback := false
+	foundPaused := false
 	if *c != nil {
 		for _, condition := range *c {
 			if condition.Type == ConditionReady {
@@ -89,14 +104,35 @@ func (c *Conditions) AreInitialized() bool {
 				break
 			}
 		}
+		for _, condition := range *c {
+			if condition.Type == ConditionPaused {
+				foundPaused = true
+				break
+			}
+		}
 	}
 
-	return foundReady && foundActive && foundFallback
+	return foundReady && foundActive && foundFallback && foundPaused
 }
 
 // GetInitializedConditions returns Conditions initialized to the default -> Status: Unknown
 func GetInitializedConditions() *Conditions {
-	return &Conditions{{Type: ConditionReady, Status: metav1.ConditionUnknown}, {Type: ConditionActive, Status: metav1.ConditionUnknown}, {Type: ConditionFallback, Status: metav1.ConditionUnknown}}
+	return &Conditions{{Type: ConditionReady, Status: metav1.ConditionUnknown}, {Type: ConditionActive, Status: metav1.ConditionUnknown}, {Type: ConditionFallback, Status: metav1.ConditionUnknown}, {Type: ConditionPaused, Status: metav1.ConditionUnknown}}
+}
+
+// GetInitializedMissing returns copy of the Conditions with the missing ones initialized to the default -> Status: Unknown,
+// the existing Conditions are kept, so a condition introduced by a newer version doesn't reset the others on upgrade
+func (c *Conditions) GetInitializedMissing() *Conditions {
+	conditions := Conditions{}
+	if *c != nil {
+		conditions = append(conditions, *c...)
+	}
+	for _, initialized := range *GetInitializedConditions() {
+		if conditions.getCondition(initialized.Type).Type == "" {
+			conditions = append(conditions, initialized)
+		}
+	}
+	return &conditions
 }
 
 // IsTrue is true if the condition is True
@@ -147,6 +183,14 @@ func (c *Conditions) SetFallbackCondition(status metav1.ConditionStatus, reason
 	c.setCondition(ConditionFallback, status, reason, message)
 }
 
+// SetPausedCondition modifies Paused Condition according to input parameters
+func (c *Conditions) SetPausedCondition(status metav1.ConditionStatus, reason string, message string) {
+	if *c == nil {
+		c = GetInitializedConditions()
+	}
+	c.setCondition(ConditionPaused, status, reason, message)
+}
+
 // GetActiveCondition returns Condition of type Active
 func (c *Conditions) GetActiveCondition() Condition {
 	if *c == nil {
@@ -171,6 +215,14 @@ func (c *Conditions) GetFallbackCondition() Condition {
 	return c.getCondition(ConditionFallback)
 }
 
+// GetPausedCondition returns Condition of type Paused
+func (c *Conditions) GetPausedCondition() Condition {
+	if *c == nil {
+		c = GetInitializedConditions()
+	}
+	return c.getCondition(ConditionPaused)
+}
+
 func (c Conditions) getCondition(conditionType ConditionType) Condition {
 	for i := range c {
 		if c[i].Type == conditionType {
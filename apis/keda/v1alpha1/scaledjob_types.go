@@ -30,6 +30,8 @@ import (
 // +kubebuilder:printcolumn:name="Authentication",type="string",JSONPath=".spec.triggers[*].authenticationRef.name"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
 // +kubebuilder:printcolumn:name="Active",type="string",JSONPath=".status.conditions[?(@.type==\"Active\")].status"
+// +kubebuilder:printcolumn:name="Fallback",type="string",JSONPath=".status.conditions[?(@.type==\"Fallback\")].status"
+// +kubebuilder:printcolumn:name="Paused",type="string",JSONPath=".status.conditions[?(@.type==\"Paused\")].status"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ScaledJob is the Schema for the scaledjobs API
@@ -59,6 +61,19 @@ type ScaledJobSpec struct {
 	// +optional
 	ScalingStrategy ScalingStrategy `json:"scalingStrategy,omitempty"`
 	Triggers        []ScaleTriggers `json:"triggers"`
+	// +optional
+	Fallback *ScaledJobFallback `json:"fallback,omitempty"`
+	// DryRun runs the scale loop and the metric pipeline without creating jobs,
+	// the number of jobs that would be created is recorded in status, events and Prometheus metrics instead
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ScaledJobFallback is the spec for fallback options of ScaledJob
+type ScaledJobFallback struct {
+	FailureThreshold int32 `json:"failureThreshold"`
+	// JobCount is the number of jobs created in each polling interval while falling back, 0 stops creating jobs
+	JobCount int32 `json:"jobCount"`
 }
 
 // ScaledJobStatus defines the observed state of ScaledJob
@@ -68,6 +83,10 @@ type ScaledJobStatus struct {
 	LastActiveTime *metav1.Time `json:"lastActiveTime,omitempty"`
 	// +optional
 	Conditions Conditions `json:"conditions,omitempty"`
+	// +optional
+	Health map[string]HealthStatus `json:"health,omitempty"`
+	// +optional
+	DryRun *DryRunStatus `json:"dryRun,omitempty"`
 }
 
 // ScaledJobList contains a list of ScaledJob
@@ -91,6 +110,15 @@ type ScalingStrategy struct {
 	PendingPodConditions []string `json:"pendingPodConditions,omitempty"`
 	// +optional
 	MultipleScalersCalculation string `json:"multipleScalersCalculation,omitempty"`
+	// InFlightJobs is the number of unfinished jobs kept by the inflight strategy while the triggers report pending work
+	// +optional
+	InFlightJobs *int32 `json:"inFlightJobs,omitempty"`
+	// MaxJobsCreatedPerInterval limits the number of jobs created within JobCreationIntervalSeconds, on top of the strategy
+	// +optional
+	MaxJobsCreatedPerInterval *int32 `json:"maxJobsCreatedPerInterval,omitempty"`
+	// JobCreationIntervalSeconds is the interval MaxJobsCreatedPerInterval applies to, the pollingInterval is used if it isn't specified
+	// +optional
+	JobCreationIntervalSeconds *int32 `json:"jobCreationIntervalSeconds,omitempty"`
 }
 
 func init() {
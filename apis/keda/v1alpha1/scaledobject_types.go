@@ -17,7 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
-	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -34,6 +34,7 @@ import (
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
 // +kubebuilder:printcolumn:name="Active",type="string",JSONPath=".status.conditions[?(@.type==\"Active\")].status"
 // +kubebuilder:printcolumn:name="Fallback",type="string",JSONPath=".status.conditions[?(@.type==\"Fallback\")].status"
+// +kubebuilder:printcolumn:name="Paused",type="string",JSONPath=".status.conditions[?(@.type==\"Paused\")].status"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ScaledObject is a specification for a ScaledObject resource
@@ -65,6 +66,49 @@ const (
 	HealthStatusFailing HealthStatusType = "Failing"
 )
 
+// DryRunStatus records the scaling decisions made while ScaledObject or ScaledJob is in dry-run mode
+type DryRunStatus struct {
+	// WouldActivate is true if the triggers are active, ie. the scale target would be activated or jobs would be created
+	// +optional
+	WouldActivate bool `json:"wouldActivate,omitempty"`
+	// DesiredReplicas is the replica count the scale loop of KEDA would scale the target to
+	// +optional
+	DesiredReplicas *int32 `json:"desiredReplicas,omitempty"`
+	// HPADesiredReplicas is the replica count the HPA would scale the target to, computed from the metric values and targets
+	// +optional
+	HPADesiredReplicas *int32 `json:"hpaDesiredReplicas,omitempty"`
+	// JobsToCreate is the number of jobs that would have been created in the last polling interval
+	// +optional
+	JobsToCreate *int64 `json:"jobsToCreate,omitempty"`
+	// +optional
+	LastDecisionTime *metav1.Time `json:"lastDecisionTime,omitempty"`
+}
+
+// TriggerStatus is the last observed state of a trigger of ScaledObject
+type TriggerStatus struct {
+	// Name is the name of the trigger, or its index if the trigger isn't named
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// +optional
+	MetricName string `json:"metricName,omitempty"`
+	// Value is the last metric value of the trigger observed by KEDA Operator, ie. queried by the scale loop
+	// (eg. with metrics freshness window or adaptive polling) or served to the HPA by the Metrics Service
+	// +optional
+	Value string `json:"value,omitempty"`
+	// Target is the target value (or average value or utilization) of the metric used by the HPA
+	// +optional
+	Target string `json:"target,omitempty"`
+	// Active is true if the trigger was active at the last check of the scale loop, it isn't evaluated
+	// per trigger if the triggers are composed by scalingModifiers
+	// +optional
+	Active bool `json:"active,omitempty"`
+	// +optional
+	LastSuccessTime *metav1.Time `json:"lastSuccessTime,omitempty"`
+	// LastError is the error of the last failed check or metric query, it is cleared once the trigger succeeds
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
 // ScaledObjectSpec is the spec for a ScaledObject resource
 type ScaledObjectSpec struct {
 	ScaleTargetRef *ScaleTarget `json:"scaleTargetRef"`
@@ -84,26 +128,101 @@ type ScaledObjectSpec struct {
 	Triggers []ScaleTriggers `json:"triggers"`
 	// +optional
 	Fallback *Fallback `json:"fallback,omitempty"`
+	// +optional
+	ScheduledWindows []ScheduledWindow `json:"scheduledWindows,omitempty"`
+	// DryRun runs the scale loop and the metric pipeline without scaling the target or creating the HPA,
+	// the scaling decisions are recorded in status, events and Prometheus metrics instead
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+	// AdaptivePolling adjusts the polling interval to the activity of ScaledObject,
+	// pollingInterval is used as the initial interval
+	// +optional
+	AdaptivePolling *AdaptivePolling `json:"adaptivePolling,omitempty"`
+}
+
+// AdaptivePolling speeds up polling of the triggers while the scale target is active or the metric values
+// change quickly, and backs off while the scale target is idle or the triggers fail
+type AdaptivePolling struct {
+	// MinPollingInterval is the interval in seconds used while the scale target is active or the metric values change quickly
+	MinPollingInterval int32 `json:"minPollingInterval"`
+	// MaxPollingInterval is the ceiling in seconds of the back off while the scale target is idle or the triggers fail
+	MaxPollingInterval int32 `json:"maxPollingInterval"`
+	// ChangeThreshold is the change of a metric value between two polls, in percent of the previous value,
+	// which is considered as a quick change, defaults to 20
+	// +optional
+	ChangeThreshold *int32 `json:"changeThreshold,omitempty"`
+}
+
+// ScheduledWindow is a time window defined by cron expressions, which temporarily overrides
+// the replica count bounds of ScaledObject while it is open
+type ScheduledWindow struct {
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+	// Start is a cron expression at which the window opens
+	Start string `json:"start"`
+	// End is a cron expression at which the window closes
+	End string `json:"end"`
+	// +optional
+	MinReplicaCount *int32 `json:"minReplicaCount,omitempty"`
+	// +optional
+	MaxReplicaCount *int32 `json:"maxReplicaCount,omitempty"`
+	// DisableScaleToZero keeps at least one replica of the scale target while the window is open
+	// +optional
+	DisableScaleToZero bool `json:"disableScaleToZero,omitempty"`
 }
 
 // Fallback is the spec for fallback options
 type Fallback struct {
 	FailureThreshold int32 `json:"failureThreshold"`
 	Replicas         int32 `json:"replicas"`
+	// Behavior specifies how the fallback replica count is determined, defaults to static
+	// +optional
+	Behavior FallbackBehavior `json:"behavior,omitempty"`
 }
 
+// FallbackBehavior specifies how the fallback replica count is determined
+// +kubebuilder:validation:Enum=static;currentReplicas;currentReplicasIfHigher
+type FallbackBehavior string
+
+const (
+	// FallbackBehaviorStatic scales the target to Fallback.Replicas
+	FallbackBehaviorStatic FallbackBehavior = "static"
+	// FallbackBehaviorCurrentReplicas keeps the current replica count of the target
+	FallbackBehaviorCurrentReplicas FallbackBehavior = "currentReplicas"
+	// FallbackBehaviorCurrentReplicasIfHigher keeps the current replica count if it is higher than Fallback.Replicas
+	FallbackBehaviorCurrentReplicasIfHigher FallbackBehavior = "currentReplicasIfHigher"
+)
+
 // AdvancedConfig specifies advance scaling options
 type AdvancedConfig struct {
 	// +optional
 	HorizontalPodAutoscalerConfig *HorizontalPodAutoscalerConfig `json:"horizontalPodAutoscalerConfig,omitempty"`
 	// +optional
 	RestoreToOriginalReplicaCount bool `json:"restoreToOriginalReplicaCount,omitempty"`
+	// +optional
+	ScalingModifiers *ScalingModifiers `json:"scalingModifiers,omitempty"`
+}
+
+// ScalingModifiers describes a formula that composes metrics of named triggers into a single metric
+type ScalingModifiers struct {
+	Formula string `json:"formula"`
+	Target  string `json:"target"`
+	// +optional
+	ActivationTarget string `json:"activationTarget,omitempty"`
+	// +optional
+	MetricType autoscalingv2.MetricTargetType `json:"metricType,omitempty"`
 }
 
+// CompositeMetricName is the name of the external metric exposed to the HPA when ScalingModifiers are used
+const CompositeMetricName = "composite-metric"
+
 // HorizontalPodAutoscalerConfig specifies horizontal scale config
 type HorizontalPodAutoscalerConfig struct {
 	// +optional
-	Behavior *autoscalingv2beta2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+	// Metrics are passed to the HPA in addition to the metrics of triggers, e.g. ContainerResource, Pods or Object metrics
+	// +optional
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
 }
 
 // ScaleTarget holds the a reference to the scale target Object
@@ -126,9 +245,58 @@ type ScaleTriggers struct {
 	// +optional
 	AuthenticationRef *ScaledObjectAuthRef `json:"authenticationRef,omitempty"`
 	// +optional
-	MetricType autoscalingv2beta2.MetricTargetType `json:"metricType,omitempty"`
+	MetricType autoscalingv2.MetricTargetType `json:"metricType,omitempty"`
+	// ActivationThreshold is the metric value the trigger has to exceed to activate the scale target (scale from 0 to 1),
+	// it is independent from the target used for scaling by the HPA. For cpu/memory triggers it is compared with
+	// the resource usage of the scale target pods, so they can take part in scaling to zero: the scale target goes idle
+	// once the usage stays below the threshold for the cooldownPeriod, there isn't any separate idle window.
+	// ScaledJob ignores cpu/memory triggers. It is rejected by the triggers whose activity isn't decided by the value
+	// of a single metric, eg. cron or external
+	// +optional
+	ActivationThreshold string `json:"activationThreshold,omitempty"`
+	// UseCachedMetrics enables caching of the metric value fetched during the polling loop,
+	// the cached value is returned to the HPA until it expires
+	// +optional
+	UseCachedMetrics bool `json:"useCachedMetrics,omitempty"`
+	// CachedMetricsTTL is the number of seconds the cached metric value is valid for, defaults to pollingInterval
+	// +optional
+	CachedMetricsTTL *int32 `json:"cachedMetricsTTL,omitempty"`
+	// Prediction makes the metric value of the trigger lead demand by forecasting it from the history of its values
+	// +optional
+	Prediction *TriggerPrediction `json:"prediction,omitempty"`
+}
+
+// TriggerPrediction configures forecasting of the metric value of a trigger from the history of its values,
+// the higher of the current and the forecasted value is used for scaling
+type TriggerPrediction struct {
+	// HorizonSeconds is how many seconds ahead the metric value is forecasted
+	HorizonSeconds int32 `json:"horizonSeconds"`
+	// Method used for forecasting, defaults to seasonalAverage
+	// +kubebuilder:validation:Enum=seasonalAverage;holtWinters
+	// +optional
+	Method PredictionMethod `json:"method,omitempty"`
+	// SeasonSeconds is the length of the period the metric values repeat in, defaults to one day
+	// +optional
+	SeasonSeconds *int32 `json:"seasonSeconds,omitempty"`
+	// Seasons is the number of previous seasons kept in the history and used for forecasting, defaults to 7
+	// +optional
+	Seasons *int32 `json:"seasons,omitempty"`
+	// HistoryConfigMap is the name of a ConfigMap in the namespace of the ScaledObject the history is persisted to,
+	// so it survives restarts of KEDA, the history is kept only in memory if it isn't specified
+	// +optional
+	HistoryConfigMap string `json:"historyConfigMap,omitempty"`
 }
 
+// PredictionMethod is the method used to forecast the metric value of a trigger
+type PredictionMethod string
+
+const (
+	// PredictionMethodSeasonalAverage forecasts the value as the average of the values at the same time in the previous seasons
+	PredictionMethodSeasonalAverage PredictionMethod = "seasonalAverage"
+	// PredictionMethodHoltWinters forecasts the value by additive Holt-Winters (triple exponential smoothing)
+	PredictionMethodHoltWinters PredictionMethod = "holtWinters"
+)
+
 // +k8s:openapi-gen=true
 
 // ScaledObjectStatus is the status for a ScaledObject resource
@@ -140,6 +308,9 @@ type ScaledObjectStatus struct {
 	ScaleTargetGVKR *GroupVersionKindResource `json:"scaleTargetGVKR,omitempty"`
 	// +optional
 	OriginalReplicaCount *int32 `json:"originalReplicaCount,omitempty"`
+	// HpaName is the name of the HPA managed by the ScaledObject, created or adopted
+	// +optional
+	HpaName string `json:"hpaName,omitempty"`
 	// +optional
 	LastActiveTime *metav1.Time `json:"lastActiveTime,omitempty"`
 	// +optional
@@ -152,6 +323,22 @@ type ScaledObjectStatus struct {
 	Health map[string]HealthStatus `json:"health,omitempty"`
 	// +optional
 	PausedReplicaCount *int32 `json:"pausedReplicaCount,omitempty"`
+	// +optional
+	ActiveScheduledWindow string `json:"activeScheduledWindow,omitempty"`
+	// +optional
+	DryRun *DryRunStatus `json:"dryRun,omitempty"`
+	// PollingInterval is the effective polling interval in seconds, if adaptive polling is enabled
+	// +optional
+	PollingInterval *int32 `json:"pollingInterval,omitempty"`
+	// Triggers is the last observed state of each trigger, the status is updated right away once the activity
+	// or errors of the triggers change, the values and success times are updated at most every 30 seconds
+	// +optional
+	Triggers []TriggerStatus `json:"triggers,omitempty"`
+}
+
+// HasScalingModifiers returns true if the ScaledObject composes its triggers via ScalingModifiers formula
+func (so *ScaledObject) HasScalingModifiers() bool {
+	return so.Spec.Advanced != nil && so.Spec.Advanced.ScalingModifiers != nil && so.Spec.Advanced.ScalingModifiers.Formula != ""
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,137 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/scaling/resolver"
+)
+
+// containerResourceMetricsMinorVersion is the first Kubernetes version with ContainerResource metrics
+// of HPA enabled by default, older versions drop them unless HPAContainerMetrics feature gate is enabled
+const containerResourceMetricsMinorVersion = 27
+
+// getResourceTriggerContainerName returns the container of cpu or memory trigger, empty if the trigger uses metrics of the whole pod
+func getResourceTriggerContainerName(trigger kedav1alpha1.ScaleTriggers) string {
+	if trigger.Type != "cpu" && trigger.Type != "memory" {
+		return ""
+	}
+	return trigger.Metadata["containerName"]
+}
+
+// hasContainerResourceTriggers checks whether any cpu or memory trigger uses metrics of a single container
+func hasContainerResourceTriggers(triggers []kedav1alpha1.ScaleTriggers) bool {
+	for _, trigger := range triggers {
+		if getResourceTriggerContainerName(trigger) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkResourceTriggerContainersExist checks that the containers referenced by cpu and memory triggers exist in the pod template
+func checkResourceTriggerContainersExist(triggers []kedav1alpha1.ScaleTriggers, podTemplateSpec *corev1.PodTemplateSpec) error {
+	for i, trigger := range triggers {
+		containerName := getResourceTriggerContainerName(trigger)
+		if containerName == "" {
+			continue
+		}
+		found := false
+		for _, container := range podTemplateSpec.Spec.Containers {
+			if container.Name == containerName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("trigger %d: container %s doesn't exist in the pod template of scale target", i, containerName)
+		}
+	}
+	return nil
+}
+
+// checkResourceTriggerContainers resolves the pod template of scale target and checks that it contains
+// the containers referenced by cpu and memory triggers
+func (r *ScaledObjectReconciler) checkResourceTriggerContainers(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) error {
+	if !hasContainerResourceTriggers(scaledObject.Spec.Triggers) {
+		return nil
+	}
+
+	podTemplateSpec, _, err := resolver.ResolveScaleTargetPodSpec(ctx, r.Client, logger, scaledObject)
+	if err != nil {
+		return err
+	}
+	return checkResourceTriggerContainersExist(scaledObject.Spec.Triggers, podTemplateSpec)
+}
+
+// containerResourceMetricsSupported checks whether the HPA of the cluster supports ContainerResource metrics,
+// they are expected to be supported if the Kubernetes version is unknown
+func (r *ScaledObjectReconciler) containerResourceMetricsSupported() bool {
+	return !r.kubeVersion.Parsed || r.kubeVersion.MinorVersion >= containerResourceMetricsMinorVersion
+}
+
+// hasContainerResourceMetrics checks whether any of the metrics is ContainerResource metric
+func hasContainerResourceMetrics(metricSpecs []autoscalingv2.MetricSpec) bool {
+	for _, metricSpec := range metricSpecs {
+		if metricSpec.ContainerResource != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// fallbackToResourceMetrics replaces ContainerResource metrics by Resource metrics of the whole pod,
+// which are used on clusters without support for ContainerResource metrics. The HPA gets a single metric
+// per resource, the Resource metric defined for the whole pod or the first ContainerResource metric otherwise,
+// the names of containers whose metrics are dropped are returned
+func fallbackToResourceMetrics(metricSpecs []autoscalingv2.MetricSpec) ([]autoscalingv2.MetricSpec, []string) {
+	resources := map[corev1.ResourceName]bool{}
+	for _, metricSpec := range metricSpecs {
+		if metricSpec.Resource != nil {
+			resources[metricSpec.Resource.Name] = true
+		}
+	}
+
+	var droppedContainers []string
+	resourceMetricSpecs := make([]autoscalingv2.MetricSpec, 0, len(metricSpecs))
+	for _, metricSpec := range metricSpecs {
+		if metricSpec.ContainerResource == nil {
+			resourceMetricSpecs = append(resourceMetricSpecs, metricSpec)
+			continue
+		}
+		if resources[metricSpec.ContainerResource.Name] {
+			droppedContainers = append(droppedContainers, metricSpec.ContainerResource.Container)
+			continue
+		}
+		resources[metricSpec.ContainerResource.Name] = true
+		resourceMetricSpecs = append(resourceMetricSpecs, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name:   metricSpec.ContainerResource.Name,
+				Target: metricSpec.ContainerResource.Target,
+			},
+		})
+	}
+	return resourceMetricSpecs, droppedContainers
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
+	"github.com/kedacore/keda/v2/pkg/mock/mock_scaling"
+	"github.com/kedacore/keda/v2/pkg/scalers"
+	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
+)
+
+var _ = Describe("container resource triggers", func() {
+	var (
+		scheme       *runtime.Scheme
+		scaledObject *v1alpha1.ScaledObject
+	)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}}},
+			},
+		},
+	}
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		scaledObject = &v1alpha1.ScaledObject{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: v1alpha1.ScaledObjectSpec{
+				ScaleTargetRef: &v1alpha1.ScaleTarget{Name: "app"},
+				Triggers: []v1alpha1.ScaleTriggers{
+					{Type: "cpu", MetricType: autoscalingv2.UtilizationMetricType, Metadata: map[string]string{"value": "50"}},
+					{Type: "memory", MetricType: autoscalingv2.UtilizationMetricType, Metadata: map[string]string{"value": "50", "containerName": "app"}},
+				},
+			},
+			Status: v1alpha1.ScaledObjectStatus{
+				ScaleTargetGVKR: &v1alpha1.GroupVersionKindResource{Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments"},
+			},
+		}
+	})
+
+	It("checks containers of cpu and memory triggers exist in the scale target", func() {
+		reconciler := &ScaledObjectReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment.DeepCopy()).Build()}
+		Expect(reconciler.checkResourceTriggerContainers(context.Background(), logr.Discard(), scaledObject)).To(Succeed())
+
+		scaledObject.Spec.Triggers[1].Metadata["containerName"] = "missing"
+		err := reconciler.checkResourceTriggerContainers(context.Background(), logr.Discard(), scaledObject)
+		Expect(err).To(MatchError("trigger 1: container missing doesn't exist in the pod template of scale target"))
+	})
+
+	It("doesn't resolve the scale target without container triggers", func() {
+		scaledObject.Spec.Triggers = scaledObject.Spec.Triggers[:1]
+		reconciler := &ScaledObjectReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).Build()}
+		Expect(reconciler.checkResourceTriggerContainers(context.Background(), logr.Discard(), scaledObject)).To(Succeed())
+	})
+
+	Context("HPA metrics", func() {
+		var (
+			ctrl         *gomock.Controller
+			scaleHandler *mock_scaling.MockScaleHandler
+			scaler       *mock_scalers.MockScaler
+		)
+
+		BeforeEach(func() {
+			ctrl = gomock.NewController(GinkgoT())
+			scaleHandler = mock_scaling.NewMockScaleHandler(ctrl)
+			scaler = mock_scalers.NewMockScaler(ctrl)
+			scalersCache := cache.ScalersCache{
+				Scalers: []cache.ScalerBuilder{{
+					Scaler: scaler,
+					Factory: func() (scalers.Scaler, error) {
+						return scaler, nil
+					},
+				}},
+				Logger: logr.Discard(),
+			}
+			averageUtilization := int32(50)
+			scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ContainerResourceMetricSourceType,
+				ContainerResource: &autoscalingv2.ContainerResourceMetricSource{
+					Name:      corev1.ResourceMemory,
+					Container: "app",
+					Target:    autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &averageUtilization},
+				},
+			}})
+			scaleHandler.EXPECT().GetScalersCache(gomock.Any(), gomock.Any()).Return(&scalersCache, nil)
+		})
+
+		AfterEach(func() {
+			ctrl.Finish()
+		})
+
+		newReconciler := func(minorVersion int) *ScaledObjectReconciler {
+			return &ScaledObjectReconciler{
+				Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(scaledObject).Build(),
+				Recorder:     record.NewFakeRecorder(10),
+				scaleHandler: scaleHandler,
+				kubeVersion:  kedautil.K8sVersion{MinorVersion: minorVersion, Parsed: true, PrettyVersion: fmt.Sprintf("1.%d", minorVersion)},
+			}
+		}
+
+		It("passes ContainerResource metrics to the HPA", func() {
+			metricSpecs, err := newReconciler(27).getScaledObjectMetricSpecs(context.Background(), logr.Discard(), scaledObject)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metricSpecs).To(HaveLen(1))
+			Expect(metricSpecs[0].ContainerResource.Container).To(Equal("app"))
+			Expect(scaledObject.Status.ResourceMetricNames).To(Equal([]string{"memory"}))
+		})
+
+		It("falls back to Resource metrics if ContainerResource metrics aren't supported", func() {
+			reconciler := newReconciler(26)
+			metricSpecs, err := reconciler.getScaledObjectMetricSpecs(context.Background(), logr.Discard(), scaledObject)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(metricSpecs).To(HaveLen(1))
+			Expect(metricSpecs[0].Type).To(Equal(autoscalingv2.ResourceMetricSourceType))
+			Expect(metricSpecs[0].ContainerResource).To(BeNil())
+			Expect(metricSpecs[0].Resource.Name).To(Equal(corev1.ResourceMemory))
+			Expect(*metricSpecs[0].Resource.Target.AverageUtilization).To(Equal(int32(50)))
+			Expect(reconciler.Recorder.(*record.FakeRecorder).Events).To(Receive(Equal(
+				"Warning ScaledObjectContainerMetricsFallback ContainerResource metrics aren't supported on Kubernetes 1.26, Resource metrics of the whole pod are used instead")))
+		})
+	})
+
+	It("falls back to a single Resource metric per resource", func() {
+		averageUtilization := func(value int32) autoscalingv2.MetricTarget {
+			return autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &value}
+		}
+		containerMetric := func(resource corev1.ResourceName, container string, value int32) autoscalingv2.MetricSpec {
+			return autoscalingv2.MetricSpec{
+				Type:              autoscalingv2.ContainerResourceMetricSourceType,
+				ContainerResource: &autoscalingv2.ContainerResourceMetricSource{Name: resource, Container: container, Target: averageUtilization(value)},
+			}
+		}
+		resourceMetric := func(resource corev1.ResourceName, value int32) autoscalingv2.MetricSpec {
+			return autoscalingv2.MetricSpec{
+				Type:     autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{Name: resource, Target: averageUtilization(value)},
+			}
+		}
+
+		metricSpecs, droppedContainers := fallbackToResourceMetrics([]autoscalingv2.MetricSpec{
+			containerMetric(corev1.ResourceMemory, "app", 50),
+			containerMetric(corev1.ResourceMemory, "sidecar", 70),
+			containerMetric(corev1.ResourceCPU, "app", 60),
+			resourceMetric(corev1.ResourceCPU, 80),
+		})
+		Expect(metricSpecs).To(Equal([]autoscalingv2.MetricSpec{
+			resourceMetric(corev1.ResourceMemory, 50),
+			resourceMetric(corev1.ResourceCPU, 80),
+		}))
+		Expect(droppedContainers).To(Equal([]string{"sidecar", "app"}))
+	})
+})
@@ -21,17 +21,24 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/go-logr/logr"
-	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
+	"github.com/kedacore/keda/v2/pkg/eventreason"
 	"github.com/kedacore/keda/v2/pkg/scaling/executor"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
+	"github.com/kedacore/keda/v2/pkg/scaling/schedule"
 	version "github.com/kedacore/keda/v2/version"
 )
 
@@ -50,7 +57,7 @@ func (r *ScaledObjectReconciler) createAndDeployNewHPA(ctx context.Context, logg
 		return err
 	}
 
-	err = r.Client.Create(ctx, hpa)
+	err = r.createHPA(ctx, hpa)
 	if err != nil {
 		logger.Error(err, "Failed to create new HPA in cluster", "HPA.Namespace", scaledObject.Namespace, "HPA.Name", hpaName)
 		return err
@@ -59,14 +66,42 @@ func (r *ScaledObjectReconciler) createAndDeployNewHPA(ctx context.Context, logg
 	return nil
 }
 
+// deleteHPAForScaledObject deletes HPA of the ScaledObject from the cluster, if it exists
+func (r *ScaledObjectReconciler) deleteHPAForScaledObject(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) error {
+	// adopted HPA is not deleted, its scaling is disabled instead, it is restored to the original state
+	// only once the ScaledObject is deleted
+	disabled, err := r.disableAdoptedHPA(ctx, logger, scaledObject)
+	if err != nil || disabled {
+		return err
+	}
+
+	hpaName := getHPAName(scaledObject)
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err = r.getHPA(ctx, types.NamespacedName{Name: hpaName, Namespace: scaledObject.Namespace}, hpa)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		logger.Error(err, "Failed to get HPA from cluster")
+		return err
+	}
+
+	logger.Info("Deleting HPA", "HPA.Namespace", scaledObject.Namespace, "HPA.Name", hpaName)
+	if err := r.deleteHPA(ctx, hpa); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Failed to delete HPA from cluster", "HPA.Namespace", scaledObject.Namespace, "HPA.Name", hpaName)
+		return err
+	}
+	return nil
+}
+
 // newHPAForScaledObject returns HPA as it is specified in ScaledObject
-func (r *ScaledObjectReconciler) newHPAForScaledObject(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, gvkr *kedav1alpha1.GroupVersionKindResource) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+func (r *ScaledObjectReconciler) newHPAForScaledObject(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, gvkr *kedav1alpha1.GroupVersionKindResource) (*autoscalingv2.HorizontalPodAutoscaler, error) {
 	scaledObjectMetricSpecs, err := r.getScaledObjectMetricSpecs(ctx, logger, scaledObject)
 	if err != nil {
 		return nil, err
 	}
 
-	var behavior *autoscalingv2beta2.HorizontalPodAutoscalerBehavior
+	var behavior *autoscalingv2.HorizontalPodAutoscalerBehavior
 	if r.kubeVersion.MinorVersion >= 18 && scaledObject.Spec.Advanced != nil && scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig != nil {
 		behavior = scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig.Behavior
 	} else {
@@ -107,13 +142,13 @@ func (r *ScaledObjectReconciler) newHPAForScaledObject(ctx context.Context, logg
 		maxReplicas = *pausedCount
 	}
 
-	hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{
-		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
 			MinReplicas: minReplicas,
 			MaxReplicas: maxReplicas,
 			Metrics:     scaledObjectMetricSpecs,
 			Behavior:    behavior,
-			ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 				Name:       scaledObject.Spec.ScaleTargetRef.Name,
 				Kind:       gvkr.Kind,
 				APIVersion: gvkr.GroupVersion().String(),
@@ -125,7 +160,8 @@ func (r *ScaledObjectReconciler) newHPAForScaledObject(ctx context.Context, logg
 			Annotations: scaledObject.Annotations,
 		},
 		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v2beta2",
+			APIVersion: "autoscaling/v2",
+			Kind:       "HorizontalPodAutoscaler",
 		},
 	}
 
@@ -138,17 +174,30 @@ func (r *ScaledObjectReconciler) newHPAForScaledObject(ctx context.Context, logg
 }
 
 // updateHPAIfNeeded checks whether update of HPA is needed
-func (r *ScaledObjectReconciler) updateHPAIfNeeded(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, foundHpa *autoscalingv2beta2.HorizontalPodAutoscaler, gvkr *kedav1alpha1.GroupVersionKindResource) error {
+func (r *ScaledObjectReconciler) updateHPAIfNeeded(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, foundHpa *autoscalingv2.HorizontalPodAutoscaler, gvkr *kedav1alpha1.GroupVersionKindResource) error {
 	hpa, err := r.newHPAForScaledObject(ctx, logger, scaledObject, gvkr)
 	if err != nil {
 		logger.Error(err, "Failed to create new HPA resource", "HPA.Namespace", scaledObject.Namespace, "HPA.Name", getHPAName(scaledObject))
 		return err
 	}
 
+	// HPA adopted by ScaledObject keeps its original resource metrics and behavior
+	original, err := getOriginalHPA(foundHpa)
+	if err != nil {
+		return err
+	}
+	if original != nil {
+		mergeOriginalHPA(hpa, original, foundHpa.Annotations[originalHPAAnnotation])
+		// adopted HPA can still be modified by its original owner, don't overwrite the changes blindly
+		hpa.ResourceVersion = foundHpa.ResourceVersion
+	}
+
 	// DeepDerivative ignores extra entries in arrays which makes removing the last trigger not update things, so trigger and update any time the metrics count is different.
-	if len(hpa.Spec.Metrics) != len(foundHpa.Spec.Metrics) || !equality.Semantic.DeepDerivative(hpa.Spec, foundHpa.Spec) {
+	// scaling of adopted HPA disabled while ScaledObject was paused or in dry-run mode is enabled again
+	scalingDisabled := isHPAScalingDisabled(foundHpa) && !isHPAScalingDisabled(hpa)
+	if len(hpa.Spec.Metrics) != len(foundHpa.Spec.Metrics) || !equality.Semantic.DeepDerivative(hpa.Spec, foundHpa.Spec) || scalingDisabled {
 		logger.V(1).Info("Found difference in the HPA spec accordint to ScaledObject", "currentHPA", foundHpa.Spec, "newHPA", hpa.Spec)
-		if err = r.Client.Update(ctx, hpa); err != nil {
+		if err = r.updateHPA(ctx, hpa); err != nil {
 			foundHpa.Spec = hpa.Spec
 			logger.Error(err, "Failed to update HPA", "HPA.Namespace", foundHpa.Namespace, "HPA.Name", foundHpa.Name)
 			return err
@@ -161,7 +210,7 @@ func (r *ScaledObjectReconciler) updateHPAIfNeeded(ctx context.Context, logger l
 
 	if !equality.Semantic.DeepDerivative(hpa.ObjectMeta.Labels, foundHpa.ObjectMeta.Labels) {
 		logger.V(1).Info("Found difference in the HPA labels accordint to ScaledObject", "currentHPA", foundHpa.ObjectMeta.Labels, "newHPA", hpa.ObjectMeta.Labels)
-		if err = r.Client.Update(ctx, hpa); err != nil {
+		if err = r.updateHPA(ctx, hpa); err != nil {
 			foundHpa.ObjectMeta.Labels = hpa.ObjectMeta.Labels
 			logger.Error(err, "Failed to update HPA", "HPA.Namespace", foundHpa.Namespace, "HPA.Name", foundHpa.Name)
 			return err
@@ -173,8 +222,8 @@ func (r *ScaledObjectReconciler) updateHPAIfNeeded(ctx context.Context, logger l
 }
 
 // getScaledObjectMetricSpecs returns MetricSpec for HPA, generater from Triggers defitinion in ScaledObject
-func (r *ScaledObjectReconciler) getScaledObjectMetricSpecs(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) ([]autoscalingv2beta2.MetricSpec, error) {
-	var scaledObjectMetricSpecs []autoscalingv2beta2.MetricSpec
+func (r *ScaledObjectReconciler) getScaledObjectMetricSpecs(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) ([]autoscalingv2.MetricSpec, error) {
+	var scaledObjectMetricSpecs []autoscalingv2.MetricSpec
 	var externalMetricNames []string
 	var resourceMetricNames []string
 
@@ -190,6 +239,9 @@ func (r *ScaledObjectReconciler) getScaledObjectMetricSpecs(ctx context.Context,
 		if metricSpec.Resource != nil {
 			resourceMetricNames = append(resourceMetricNames, string(metricSpec.Resource.Name))
 		}
+		if metricSpec.ContainerResource != nil {
+			resourceMetricNames = append(resourceMetricNames, string(metricSpec.ContainerResource.Name))
+		}
 
 		if metricSpec.External != nil {
 			externalMetricName := metricSpec.External.Metric.Name
@@ -203,8 +255,46 @@ func (r *ScaledObjectReconciler) getScaledObjectMetricSpecs(ctx context.Context,
 			externalMetricNames = append(externalMetricNames, externalMetricName)
 		}
 	}
+
+	if scaledObject.HasScalingModifiers() {
+		// the HPA gets just a single composite metric instead of external metrics of all triggers,
+		// resource metrics (cpu/memory) are kept as they are handled by the HPA directly
+		compositeMetricSpec, err := modifiers.GetCompositeMetricSpec(scaledObject)
+		if err != nil {
+			logger.Error(err, "Error getting composite metric spec")
+			return nil, err
+		}
+		compositeMetricSpec.External.Metric.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"scaledobject.keda.sh/name": scaledObject.Name}}
+
+		var filteredMetricSpecs []autoscalingv2.MetricSpec
+		for _, metricSpec := range metricSpecs {
+			if metricSpec.External == nil {
+				filteredMetricSpecs = append(filteredMetricSpecs, metricSpec)
+			}
+		}
+		metricSpecs = append(filteredMetricSpecs, compositeMetricSpec)
+		externalMetricNames = []string{kedav1alpha1.CompositeMetricName}
+	}
 	scaledObjectMetricSpecs = append(scaledObjectMetricSpecs, metricSpecs...)
 
+	// metrics handled by the HPA directly, e.g. ContainerResource metrics, are passed as they are defined
+	if scaledObject.Spec.Advanced != nil && scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig != nil {
+		for _, metricSpec := range scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig.Metrics {
+			scaledObjectMetricSpecs = append(scaledObjectMetricSpecs, *metricSpec.DeepCopy())
+		}
+	}
+
+	if !r.containerResourceMetricsSupported() && hasContainerResourceMetrics(scaledObjectMetricSpecs) {
+		var droppedContainers []string
+		scaledObjectMetricSpecs, droppedContainers = fallbackToResourceMetrics(scaledObjectMetricSpecs)
+		msg := fmt.Sprintf("ContainerResource metrics aren't supported on Kubernetes %s, Resource metrics of the whole pod are used instead", r.kubeVersion.PrettyVersion)
+		if len(droppedContainers) > 0 {
+			msg += fmt.Sprintf(", metrics of containers %s are dropped as the HPA gets a single metric per resource", strings.Join(droppedContainers, ", "))
+		}
+		logger.Info("Warning: "+msg, "kubernetes.version", r.kubeVersion.PrettyVersion)
+		r.Recorder.Event(scaledObject, corev1.EventTypeWarning, eventreason.ScaledObjectContainerMetricsFallback, msg)
+	}
+
 	// sort metrics in ScaledObject, this way we always check the same resource in Reconcile loop and we can prevent unnecessary HPA updates,
 	// see https://github.com/kedacore/keda/issues/1531 for details
 	sort.Slice(scaledObjectMetricSpecs, func(i, j int) bool {
@@ -248,15 +338,18 @@ func (r *ScaledObjectReconciler) checkMinK8sVersionforHPABehavior(logger logr.Lo
 	}
 }
 
-// getHPAName returns generated HPA name for ScaledObject specified in the parameter
+// getHPAName returns generated HPA name for ScaledObject specified in the parameter or name of the adopted HPA
 func getHPAName(scaledObject *kedav1alpha1.ScaledObject) string {
+	if name, adopted := getAdoptedHPAName(scaledObject); adopted {
+		return name
+	}
 	return fmt.Sprintf("keda-hpa-%s", scaledObject.Name)
 }
 
 // getHPAMinReplicas returns MinReplicas based on definition in ScaledObject or default value if not defined
 func getHPAMinReplicas(scaledObject *kedav1alpha1.ScaledObject) *int32 {
-	if scaledObject.Spec.MinReplicaCount != nil && *scaledObject.Spec.MinReplicaCount > 0 {
-		return scaledObject.Spec.MinReplicaCount
+	if minReplicaCount := schedule.GetMinReplicaCount(scaledObject, time.Now()); minReplicaCount != nil && *minReplicaCount > 0 {
+		return minReplicaCount
 	}
 	tmp := defaultHPAMinReplicas
 	return &tmp
@@ -264,8 +357,8 @@ func getHPAMinReplicas(scaledObject *kedav1alpha1.ScaledObject) *int32 {
 
 // getHPAMaxReplicas returns MaxReplicas based on definition in ScaledObject or default value if not defined
 func getHPAMaxReplicas(scaledObject *kedav1alpha1.ScaledObject) int32 {
-	if scaledObject.Spec.MaxReplicaCount != nil {
-		return *scaledObject.Spec.MaxReplicaCount
+	if maxReplicaCount := schedule.GetMaxReplicaCount(scaledObject, time.Now()); maxReplicaCount != nil {
+		return *maxReplicaCount
 	}
 	return defaultHPAMaxReplicas
 }
@@ -0,0 +1,311 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
+)
+
+// originalHPAAnnotation stores the state of an adopted HPA from before the adoption, so it can be restored later
+const originalHPAAnnotation = "autoscaling.keda.sh/original-hpa"
+
+// originalHPA is the state of an adopted HPA which is restored once the ScaledObject stops managing it
+type originalHPA struct {
+	Labels      map[string]string                         `json:"labels,omitempty"`
+	Annotations map[string]string                         `json:"annotations,omitempty"`
+	Spec        autoscalingv2.HorizontalPodAutoscalerSpec `json:"spec"`
+}
+
+// getAdoptedHPAName returns name of the existing HPA the ScaledObject should adopt, if there's any
+func getAdoptedHPAName(scaledObject *kedav1alpha1.ScaledObject) (string, bool) {
+	name, found := scaledObject.GetAnnotations()[kedacontrollerutil.AdoptHPAAnnotation]
+	return name, found && name != ""
+}
+
+// adoptHPA transfers the ownership of an existing user-managed HPA to the ScaledObject,
+// the original state of the HPA is stored in the annotation so it can be restored later
+func (r *ScaledObjectReconciler) adoptHPA(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, hpa *autoscalingv2.HorizontalPodAutoscaler, gvkr *kedav1alpha1.GroupVersionKindResource) error {
+	if owner := metav1.GetControllerOf(hpa); owner != nil {
+		return fmt.Errorf("HPA %s is already controlled by %s %s", hpa.Name, owner.Kind, owner.Name)
+	}
+	targetRef := hpa.Spec.ScaleTargetRef
+	if !isSameScaleTarget(targetRef.APIVersion, targetRef.Kind, targetRef.Name, gvkr.GroupVersion().String(), gvkr.Kind, scaledObject.Spec.ScaleTargetRef.Name) {
+		return fmt.Errorf("HPA %s scales %s %s, not the scaleTarget of ScaledObject", hpa.Name, targetRef.Kind, targetRef.Name)
+	}
+
+	if _, found := hpa.Annotations[originalHPAAnnotation]; !found {
+		original, err := json.Marshal(originalHPA{
+			Labels:      hpa.Labels,
+			Annotations: hpa.Annotations,
+			Spec:        hpa.Spec,
+		})
+		if err != nil {
+			return err
+		}
+		if hpa.Annotations == nil {
+			hpa.Annotations = map[string]string{}
+		}
+		hpa.Annotations[originalHPAAnnotation] = string(original)
+	}
+
+	if err := controllerutil.SetControllerReference(scaledObject, hpa, r.Scheme); err != nil {
+		return err
+	}
+
+	logger.Info("Adopting existing HPA", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
+	if err := r.updateHPA(ctx, hpa); err != nil {
+		logger.Error(err, "Failed to adopt HPA", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
+		return err
+	}
+	return nil
+}
+
+// getOriginalHPA returns the state of the HPA from before it was adopted, nil if the HPA wasn't adopted
+func getOriginalHPA(hpa *autoscalingv2.HorizontalPodAutoscaler) (*originalHPA, error) {
+	value, found := hpa.Annotations[originalHPAAnnotation]
+	if !found {
+		return nil, nil
+	}
+	original := &originalHPA{}
+	if err := json.Unmarshal([]byte(value), original); err != nil {
+		return nil, fmt.Errorf("error parsing %s annotation of HPA %s: %s", originalHPAAnnotation, hpa.Name, err)
+	}
+	return original, nil
+}
+
+// mergeOriginalHPA merges resource metrics and behavior of the adopted HPA into the HPA generated for ScaledObject,
+// metrics and behavior defined in ScaledObject take precedence
+func mergeOriginalHPA(hpa *autoscalingv2.HorizontalPodAutoscaler, original *originalHPA, originalAnnotation string) {
+	for _, metric := range original.Spec.Metrics {
+		if metric.Type != autoscalingv2.ResourceMetricSourceType || metric.Resource == nil {
+			continue
+		}
+		if !hasResourceMetric(hpa.Spec.Metrics, metric.Resource.Name) {
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, metric)
+		}
+	}
+	sort.SliceStable(hpa.Spec.Metrics, func(i, j int) bool {
+		return hpa.Spec.Metrics[i].Type < hpa.Spec.Metrics[j].Type
+	})
+
+	if hpa.Spec.Behavior == nil {
+		hpa.Spec.Behavior = original.Spec.Behavior
+	}
+
+	// annotations are shared with ScaledObject, don't modify them in place
+	annotations := make(map[string]string, len(hpa.Annotations)+1)
+	for key, value := range hpa.Annotations {
+		annotations[key] = value
+	}
+	annotations[originalHPAAnnotation] = originalAnnotation
+	hpa.Annotations = annotations
+}
+
+// hasResourceMetric checks whether metrics contain a resource metric with the specified name
+func hasResourceMetric(metrics []autoscalingv2.MetricSpec, name corev1.ResourceName) bool {
+	for _, metric := range metrics {
+		if metric.Resource != nil && metric.Resource.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreAdoptedHPA restores the HPA adopted by ScaledObject to its original state and releases it,
+// returns false if the HPA of ScaledObject wasn't adopted
+func (r *ScaledObjectReconciler) restoreAdoptedHPA(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) (bool, error) {
+	hpaName, adopted := getAdoptedHPAName(scaledObject)
+	if !adopted {
+		return false, nil
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.getHPA(ctx, types.NamespacedName{Name: hpaName, Namespace: scaledObject.Namespace}, hpa)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		logger.Error(err, "Failed to get HPA from cluster")
+		return false, err
+	}
+
+	original, err := getOriginalHPA(hpa)
+	if err != nil {
+		return false, err
+	}
+	if original == nil {
+		// the HPA was created by ScaledObject, there's nothing to restore
+		return false, nil
+	}
+	if err := r.restoreHPA(ctx, logger, scaledObject, hpa, original); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// disableAdoptedHPA disables scaling of the HPA adopted by ScaledObject in both directions, it is used while
+// the ScaledObject is paused or in dry-run mode instead of deleting the HPA, so the adopted HPA is kept and
+// it isn't handed back to its original owner until the ScaledObject is deleted.
+// Returns false if the HPA of ScaledObject wasn't adopted
+func (r *ScaledObjectReconciler) disableAdoptedHPA(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) (bool, error) {
+	hpaName, adopted := getAdoptedHPAName(scaledObject)
+	if !adopted {
+		return false, nil
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.getHPA(ctx, types.NamespacedName{Name: hpaName, Namespace: scaledObject.Namespace}, hpa)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		logger.Error(err, "Failed to get HPA from cluster")
+		return false, err
+	}
+
+	original, err := getOriginalHPA(hpa)
+	if err != nil {
+		return false, err
+	}
+	if original == nil {
+		// the HPA was created by ScaledObject, it can be deleted
+		return false, nil
+	}
+	if !metav1.IsControlledBy(hpa, scaledObject) || isHPAScalingDisabled(hpa) {
+		return true, nil
+	}
+
+	disabled := autoscalingv2.DisabledPolicySelect
+	behavior := &autoscalingv2.HorizontalPodAutoscalerBehavior{}
+	if hpa.Spec.Behavior != nil {
+		behavior = hpa.Spec.Behavior.DeepCopy()
+	}
+	if behavior.ScaleUp == nil {
+		behavior.ScaleUp = &autoscalingv2.HPAScalingRules{}
+	}
+	if behavior.ScaleDown == nil {
+		behavior.ScaleDown = &autoscalingv2.HPAScalingRules{}
+	}
+	behavior.ScaleUp.SelectPolicy = &disabled
+	behavior.ScaleDown.SelectPolicy = &disabled
+	hpa.Spec.Behavior = behavior
+
+	logger.Info("Disabling scaling of adopted HPA", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
+	if err := r.updateHPA(ctx, hpa); err != nil {
+		logger.Error(err, "Failed to disable scaling of adopted HPA", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
+		return false, err
+	}
+	return true, nil
+}
+
+// isHPAScalingDisabled checks whether scaling of the HPA is disabled in both directions
+func isHPAScalingDisabled(hpa *autoscalingv2.HorizontalPodAutoscaler) bool {
+	behavior := hpa.Spec.Behavior
+	if behavior == nil || behavior.ScaleUp == nil || behavior.ScaleDown == nil {
+		return false
+	}
+	isDisabled := func(policy *autoscalingv2.ScalingPolicySelect) bool {
+		return policy != nil && *policy == autoscalingv2.DisabledPolicySelect
+	}
+	return isDisabled(behavior.ScaleUp.SelectPolicy) && isDisabled(behavior.ScaleDown.SelectPolicy)
+}
+
+// releaseReplacedHPA releases the HPA recorded in the status of ScaledObject if it isn't its current HPA anymore,
+// it is left behind once the adoption annotation is added, changed or removed. The previously adopted HPA is restored
+// to its original state and the HPA previously created for ScaledObject is deleted, so ScaledObject never controls two HPAs.
+func (r *ScaledObjectReconciler) releaseReplacedHPA(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) error {
+	replacedName := scaledObject.Status.HpaName
+	hpaName := getHPAName(scaledObject)
+	if replacedName == "" || replacedName == hpaName {
+		return nil
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.getHPA(ctx, types.NamespacedName{Name: replacedName, Namespace: scaledObject.Namespace}, hpa)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		logger.Error(err, "Failed to get HPA from cluster")
+		return err
+	}
+	if !metav1.IsControlledBy(hpa, scaledObject) {
+		return nil
+	}
+
+	original, err := getOriginalHPA(hpa)
+	if err != nil {
+		return err
+	}
+	if original != nil {
+		return r.restoreHPA(ctx, logger, scaledObject, hpa, original)
+	}
+
+	logger.Info("Deleting HPA replaced by another one", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name, "replacement", hpaName)
+	if err := r.deleteHPA(ctx, hpa); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Failed to delete HPA from cluster", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
+		return err
+	}
+	return nil
+}
+
+// updateHPANameInStatus records the name of the current HPA of ScaledObject in its status,
+// so the HPA can be released once it is replaced by another one
+func (r *ScaledObjectReconciler) updateHPANameInStatus(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) error {
+	hpaName := getHPAName(scaledObject)
+	if scaledObject.Status.HpaName == hpaName {
+		return nil
+	}
+	status := scaledObject.Status.DeepCopy()
+	status.HpaName = hpaName
+	return kedacontrollerutil.UpdateScaledObjectStatus(ctx, r.Client, logger, scaledObject, status)
+}
+
+// restoreHPA restores the HPA adopted by ScaledObject to its original state and removes ScaledObject from its owners
+func (r *ScaledObjectReconciler) restoreHPA(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, hpa *autoscalingv2.HorizontalPodAutoscaler, original *originalHPA) error {
+	var ownerReferences []metav1.OwnerReference
+	for _, owner := range hpa.OwnerReferences {
+		if owner.UID != scaledObject.UID {
+			ownerReferences = append(ownerReferences, owner)
+		}
+	}
+	hpa.OwnerReferences = ownerReferences
+	hpa.Labels = original.Labels
+	hpa.Annotations = original.Annotations
+	hpa.Spec = original.Spec
+
+	logger.Info("Restoring adopted HPA", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
+	if err := r.updateHPA(ctx, hpa); err != nil {
+		logger.Error(err, "Failed to restore adopted HPA", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
+		return err
+	}
+	return nil
+}
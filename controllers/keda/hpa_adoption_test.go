@@ -0,0 +1,299 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
+	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
+	"github.com/kedacore/keda/v2/pkg/mock/mock_scaling"
+	"github.com/kedacore/keda/v2/pkg/scalers"
+	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+)
+
+var _ = Describe("hpa adoption", func() {
+	var (
+		scheme       *runtime.Scheme
+		scaleHandler *mock_scaling.MockScaleHandler
+		scaler       *mock_scalers.MockScaler
+		ctrl         *gomock.Controller
+		gvkr         *v1alpha1.GroupVersionKindResource
+	)
+
+	averageUtilization := int32(80)
+	cpuMetric := autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name:   corev1.ResourceCPU,
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &averageUtilization},
+		},
+	}
+	stabilizationWindow := int32(120)
+	behavior := &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleDown: &autoscalingv2.HPAScalingRules{StabilizationWindowSeconds: &stabilizationWindow},
+	}
+
+	newScaledObject := func() *v1alpha1.ScaledObject {
+		return &v1alpha1.ScaledObject{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test",
+				Namespace:   "default",
+				UID:         "scaledobject-uid",
+				Annotations: map[string]string{kedacontrollerutil.AdoptHPAAnnotation: "app"},
+				Finalizers:  []string{scaledObjectFinalizer},
+			},
+			Spec: v1alpha1.ScaledObjectSpec{
+				ScaleTargetRef: &v1alpha1.ScaleTarget{Name: "app"},
+			},
+		}
+	}
+
+	newUserHPA := func() *autoscalingv2.HorizontalPodAutoscaler {
+		minReplicas := int32(2)
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "app",
+				Namespace:   "default",
+				Labels:      map[string]string{"team": "payments"},
+				Annotations: map[string]string{"owner": "payments"},
+			},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"},
+				MinReplicas:    &minReplicas,
+				MaxReplicas:    10,
+				Metrics:        []autoscalingv2.MetricSpec{cpuMetric},
+				Behavior:       behavior,
+			},
+		}
+	}
+
+	newReconciler := func(objects ...client.Object) *ScaledObjectReconciler {
+		return &ScaledObjectReconciler{
+			Client:                   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Scheme:                   scheme,
+			Recorder:                 record.NewFakeRecorder(10),
+			scaleHandler:             scaleHandler,
+			scaledObjectsGenerations: &sync.Map{},
+		}
+	}
+
+	expectMetricSpecs := func() {
+		scalersCache := cache.ScalersCache{
+			Scalers: []cache.ScalerBuilder{{
+				Scaler: scaler,
+				Factory: func() (scalers.Scaler, error) {
+					return scaler, nil
+				},
+			}},
+			Logger: logr.Discard(),
+		}
+		scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]autoscalingv2.MetricSpec{{
+			Type:     autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{Metric: autoscalingv2.MetricIdentifier{Name: "s0-queue"}},
+		}}).AnyTimes()
+		scaleHandler.EXPECT().GetScalersCache(gomock.Any(), gomock.Any()).Return(&scalersCache, nil).AnyTimes()
+	}
+
+	getHPA := func(reconciler *ScaledObjectReconciler) *autoscalingv2.HorizontalPodAutoscaler {
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		Expect(reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "app", Namespace: "default"}, hpa)).To(Succeed())
+		return hpa
+	}
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		ctrl = gomock.NewController(GinkgoT())
+		scaleHandler = mock_scaling.NewMockScaleHandler(ctrl)
+		scaler = mock_scalers.NewMockScaler(ctrl)
+		gvkr = &v1alpha1.GroupVersionKindResource{Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments"}
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	It("uses name of the adopted HPA", func() {
+		scaledObject := newScaledObject()
+		Expect(getHPAName(scaledObject)).To(Equal("app"))
+
+		delete(scaledObject.Annotations, kedacontrollerutil.AdoptHPAAnnotation)
+		Expect(getHPAName(scaledObject)).To(Equal("keda-hpa-test"))
+	})
+
+	It("adopts existing HPA and merges its resource metrics and behavior", func() {
+		expectMetricSpecs()
+		scaledObject := newScaledObject()
+		reconciler := newReconciler(scaledObject, newUserHPA())
+
+		created, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeFalse())
+
+		hpa := getHPA(reconciler)
+		Expect(metav1.IsControlledBy(hpa, scaledObject)).To(BeTrue())
+		Expect(hpa.Annotations).To(HaveKey(originalHPAAnnotation))
+		Expect(hpa.Labels).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "keda-operator"))
+		Expect(hpa.Spec.Metrics).To(HaveLen(2))
+		Expect(hpa.Spec.Metrics[0].External.Metric.Name).To(Equal("s0-queue"))
+		Expect(hpa.Spec.Metrics[1]).To(Equal(cpuMetric))
+		Expect(hpa.Spec.Behavior).To(Equal(behavior))
+		Expect(*hpa.Spec.MinReplicas).To(Equal(defaultHPAMinReplicas))
+
+		// the following reconciliation keeps the merged spec
+		Expect(reconciler.updateHPAIfNeeded(context.Background(), logr.Discard(), scaledObject, hpa, gvkr)).To(Succeed())
+		Expect(getHPA(reconciler).Spec.Metrics).To(HaveLen(2))
+	})
+
+	It("refuses to adopt HPA controlled by another object", func() {
+		expectMetricSpecs()
+		scaledObject := newScaledObject()
+		userHPA := newUserHPA()
+		isController := true
+		userHPA.OwnerReferences = []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "Autoscaler", Name: "other", UID: "other-uid", Controller: &isController}}
+		reconciler := newReconciler(scaledObject, userHPA)
+
+		_, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).To(MatchError("HPA app is already controlled by Autoscaler other"))
+	})
+
+	It("refuses to adopt HPA of another scale target", func() {
+		expectMetricSpecs()
+		scaledObject := newScaledObject()
+		userHPA := newUserHPA()
+		userHPA.Spec.ScaleTargetRef.Name = "other"
+		reconciler := newReconciler(scaledObject, userHPA)
+
+		_, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).To(MatchError("HPA app scales Deployment other, not the scaleTarget of ScaledObject"))
+	})
+
+	It("disables scaling of adopted HPA instead of deleting it", func() {
+		expectMetricSpecs()
+		scaledObject := newScaledObject()
+		reconciler := newReconciler(scaledObject, newUserHPA())
+
+		_, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reconciler.deleteHPAForScaledObject(context.Background(), logr.Discard(), scaledObject)).To(Succeed())
+
+		// the HPA isn't handed back to its original owner while ScaledObject is paused or in dry-run mode
+		hpa := getHPA(reconciler)
+		Expect(metav1.IsControlledBy(hpa, scaledObject)).To(BeTrue())
+		Expect(isHPAScalingDisabled(hpa)).To(BeTrue())
+		Expect(hpa.Spec.Behavior.ScaleDown.StabilizationWindowSeconds).To(Equal(&stabilizationWindow))
+
+		_, err = reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		hpa = getHPA(reconciler)
+		Expect(isHPAScalingDisabled(hpa)).To(BeFalse())
+		Expect(hpa.Spec.Behavior).To(Equal(behavior))
+	})
+
+	It("restores adopted HPA when ScaledObject is finalized", func() {
+		expectMetricSpecs()
+		scaleHandler.EXPECT().DeleteScalableObject(gomock.Any(), gomock.Any()).Return(nil)
+		scaledObject := newScaledObject()
+		reconciler := newReconciler(scaledObject, newUserHPA())
+
+		_, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reconciler.finalizeScaledObject(context.Background(), logr.Discard(), scaledObject)).To(Succeed())
+
+		hpa := getHPA(reconciler)
+		Expect(hpa.OwnerReferences).To(BeEmpty())
+		Expect(hpa.Spec).To(Equal(newUserHPA().Spec))
+		Expect(scaledObject.Finalizers).To(BeEmpty())
+	})
+
+	It("releases adopted HPA once the annotation is removed", func() {
+		expectMetricSpecs()
+		scaledObject := newScaledObject()
+		reconciler := newReconciler(scaledObject, newUserHPA())
+
+		_, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+
+		scaledObject.Annotations = map[string]string{}
+		Expect(reconciler.Client.Update(context.Background(), scaledObject)).To(Succeed())
+		created, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeTrue())
+
+		hpa := getHPA(reconciler)
+		Expect(hpa.OwnerReferences).To(BeEmpty())
+		Expect(hpa.Spec).To(Equal(newUserHPA().Spec))
+
+		hpa = &autoscalingv2.HorizontalPodAutoscaler{}
+		Expect(reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-test", Namespace: "default"}, hpa)).To(Succeed())
+		Expect(metav1.IsControlledBy(hpa, scaledObject)).To(BeTrue())
+	})
+
+	It("deletes HPA created for ScaledObject once another HPA is adopted", func() {
+		expectMetricSpecs()
+		scaledObject := newScaledObject()
+		delete(scaledObject.Annotations, kedacontrollerutil.AdoptHPAAnnotation)
+		reconciler := newReconciler(scaledObject, newUserHPA())
+
+		created, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeTrue())
+
+		scaledObject.Annotations = map[string]string{kedacontrollerutil.AdoptHPAAnnotation: "app"}
+		Expect(reconciler.Client.Update(context.Background(), scaledObject)).To(Succeed())
+		_, err = reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-test", Namespace: "default"}, &autoscalingv2.HorizontalPodAutoscaler{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+		Expect(metav1.IsControlledBy(getHPA(reconciler), scaledObject)).To(BeTrue())
+	})
+
+	It("deletes HPA created for ScaledObject", func() {
+		expectMetricSpecs()
+		scaledObject := newScaledObject()
+		scaledObject.Annotations[kedacontrollerutil.AdoptHPAAnnotation] = "missing"
+		reconciler := newReconciler(scaledObject)
+
+		created, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeTrue())
+		Expect(reconciler.deleteHPAForScaledObject(context.Background(), logr.Discard(), scaledObject)).To(Succeed())
+
+		err = reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "missing", Namespace: "default"}, &autoscalingv2.HorizontalPodAutoscaler{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
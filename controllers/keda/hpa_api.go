@@ -0,0 +1,130 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"encoding/json"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
+)
+
+// HPAs are handled as autoscaling/v2 objects, on clusters which don't serve autoscaling/v2 yet
+// they are converted to autoscaling/v2beta2 when they are read from or written to the cluster.
+// HPAs created through autoscaling/v2beta2 are read through autoscaling/v2 once it is served,
+// so they are updated in place and don't have to be re-created.
+
+// useAutoscalingV2beta2 checks whether HPAs have to be handled through autoscaling/v2beta2
+func (r *ScaledObjectReconciler) useAutoscalingV2beta2() bool {
+	return r.hpaAPIVersion == kedautil.AutoscalingV2beta2
+}
+
+// newHPAObject returns an empty HPA of the autoscaling API version served by the cluster, to be watched by the controller
+func (r *ScaledObjectReconciler) newHPAObject() client.Object {
+	if r.useAutoscalingV2beta2() {
+		return &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	}
+	return &autoscalingv2.HorizontalPodAutoscaler{}
+}
+
+// getHPA reads the HPA from the cluster
+func (r *ScaledObjectReconciler) getHPA(ctx context.Context, key types.NamespacedName, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	if !r.useAutoscalingV2beta2() {
+		return r.Client.Get(ctx, key, hpa)
+	}
+
+	legacyHPA := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	if err := r.Client.Get(ctx, key, legacyHPA); err != nil {
+		return err
+	}
+	return convertHPAFromV2beta2(legacyHPA, hpa)
+}
+
+// createHPA creates the HPA in the cluster
+func (r *ScaledObjectReconciler) createHPA(ctx context.Context, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	return r.writeHPA(hpa, func(obj client.Object) error {
+		return r.Client.Create(ctx, obj)
+	})
+}
+
+// updateHPA updates the HPA in the cluster
+func (r *ScaledObjectReconciler) updateHPA(ctx context.Context, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	return r.writeHPA(hpa, func(obj client.Object) error {
+		return r.Client.Update(ctx, obj)
+	})
+}
+
+// deleteHPA deletes the HPA from the cluster
+func (r *ScaledObjectReconciler) deleteHPA(ctx context.Context, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	return r.writeHPA(hpa, func(obj client.Object) error {
+		return r.Client.Delete(ctx, obj)
+	})
+}
+
+// writeHPA executes the write operation with the HPA converted to the autoscaling API version served by the cluster,
+// the HPA is refreshed with the response of the cluster afterwards
+func (r *ScaledObjectReconciler) writeHPA(hpa *autoscalingv2.HorizontalPodAutoscaler, write func(client.Object) error) error {
+	if !r.useAutoscalingV2beta2() {
+		return write(hpa)
+	}
+
+	legacyHPA, err := convertHPAToV2beta2(hpa)
+	if err != nil {
+		return err
+	}
+	if err := write(legacyHPA); err != nil {
+		return err
+	}
+	return convertHPAFromV2beta2(legacyHPA, hpa)
+}
+
+// convertHPAToV2beta2 converts autoscaling/v2 HPA to autoscaling/v2beta2, both versions share the same schema
+func convertHPAToV2beta2(hpa *autoscalingv2.HorizontalPodAutoscaler) (*autoscalingv2beta2.HorizontalPodAutoscaler, error) {
+	data, err := json.Marshal(hpa)
+	if err != nil {
+		return nil, err
+	}
+	legacyHPA := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	if err := json.Unmarshal(data, legacyHPA); err != nil {
+		return nil, err
+	}
+	if legacyHPA.Kind != "" {
+		legacyHPA.APIVersion = kedautil.AutoscalingV2beta2
+	}
+	return legacyHPA, nil
+}
+
+// convertHPAFromV2beta2 converts autoscaling/v2beta2 HPA to autoscaling/v2, both versions share the same schema
+func convertHPAFromV2beta2(legacyHPA *autoscalingv2beta2.HorizontalPodAutoscaler, hpa *autoscalingv2.HorizontalPodAutoscaler) error {
+	data, err := json.Marshal(legacyHPA)
+	if err != nil {
+		return err
+	}
+	*hpa = autoscalingv2.HorizontalPodAutoscaler{}
+	if err := json.Unmarshal(data, hpa); err != nil {
+		return err
+	}
+	if hpa.Kind != "" {
+		hpa.APIVersion = kedautil.AutoscalingV2
+	}
+	return nil
+}
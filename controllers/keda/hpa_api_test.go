@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
+	"github.com/kedacore/keda/v2/pkg/mock/mock_scaling"
+	"github.com/kedacore/keda/v2/pkg/scalers"
+	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
+)
+
+var _ = Describe("hpa api", func() {
+	var (
+		scheme       *runtime.Scheme
+		scaleHandler *mock_scaling.MockScaleHandler
+		scaler       *mock_scalers.MockScaler
+		ctrl         *gomock.Controller
+		gvkr         *v1alpha1.GroupVersionKindResource
+		scaledObject *v1alpha1.ScaledObject
+	)
+
+	averageUtilization := int32(60)
+	containerMetric := autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ContainerResourceMetricSourceType,
+		ContainerResource: &autoscalingv2.ContainerResourceMetricSource{
+			Name:      corev1.ResourceCPU,
+			Container: "app",
+			Target:    autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &averageUtilization},
+		},
+	}
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		ctrl = gomock.NewController(GinkgoT())
+		scaleHandler = mock_scaling.NewMockScaleHandler(ctrl)
+		scaler = mock_scalers.NewMockScaler(ctrl)
+		gvkr = &v1alpha1.GroupVersionKindResource{Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments"}
+		scaledObject = &v1alpha1.ScaledObject{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "scaledobject-uid"},
+			Spec: v1alpha1.ScaledObjectSpec{
+				ScaleTargetRef: &v1alpha1.ScaleTarget{Name: "app"},
+				Advanced: &v1alpha1.AdvancedConfig{
+					HorizontalPodAutoscalerConfig: &v1alpha1.HorizontalPodAutoscalerConfig{
+						Metrics: []autoscalingv2.MetricSpec{containerMetric},
+					},
+				},
+			},
+		}
+
+		scalersCache := cache.ScalersCache{
+			Scalers: []cache.ScalerBuilder{{
+				Scaler: scaler,
+				Factory: func() (scalers.Scaler, error) {
+					return scaler, nil
+				},
+			}},
+			Logger: logr.Discard(),
+		}
+		scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]autoscalingv2.MetricSpec{{
+			Type:     autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{Metric: autoscalingv2.MetricIdentifier{Name: "s0-queue"}},
+		}}).AnyTimes()
+		scaleHandler.EXPECT().GetScalersCache(gomock.Any(), gomock.Any()).Return(&scalersCache, nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		ctrl.Finish()
+	})
+
+	newReconciler := func(hpaAPIVersion string) *ScaledObjectReconciler {
+		return &ScaledObjectReconciler{
+			Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(scaledObject).Build(),
+			Scheme:        scheme,
+			scaleHandler:  scaleHandler,
+			hpaAPIVersion: hpaAPIVersion,
+		}
+	}
+
+	It("creates autoscaling/v2 HPA with metrics from horizontalPodAutoscalerConfig", func() {
+		reconciler := newReconciler(kedautil.AutoscalingV2)
+
+		created, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeTrue())
+
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		Expect(reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-test", Namespace: "default"}, hpa)).To(Succeed())
+		Expect(hpa.Spec.Metrics).To(HaveLen(2))
+		Expect(hpa.Spec.Metrics[0]).To(Equal(containerMetric))
+		Expect(hpa.Spec.Metrics[1].External.Metric.Name).To(Equal("s0-queue"))
+	})
+
+	It("handles autoscaling/v2beta2 HPA on clusters not serving autoscaling/v2", func() {
+		reconciler := newReconciler(kedautil.AutoscalingV2beta2)
+		Expect(reconciler.newHPAObject()).To(BeAssignableToTypeOf(&autoscalingv2beta2.HorizontalPodAutoscaler{}))
+
+		created, err := reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeTrue())
+
+		legacyHPA := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+		Expect(reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-test", Namespace: "default"}, legacyHPA)).To(Succeed())
+		Expect(legacyHPA.Spec.Metrics).To(HaveLen(2))
+		Expect(legacyHPA.Spec.Metrics[0].ContainerResource.Container).To(Equal("app"))
+		Expect(legacyHPA.Spec.MaxReplicas).To(Equal(defaultHPAMaxReplicas))
+
+		// the existing HPA is updated in place
+		maxReplicaCount := int32(20)
+		scaledObject.Spec.MaxReplicaCount = &maxReplicaCount
+		Expect(reconciler.Client.Update(context.Background(), scaledObject)).To(Succeed())
+		created, err = reconciler.ensureHPAForScaledObjectExists(context.Background(), logr.Discard(), scaledObject, gvkr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created).To(BeFalse())
+
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		Expect(reconciler.getHPA(context.Background(), types.NamespacedName{Name: "keda-hpa-test", Namespace: "default"}, hpa)).To(Succeed())
+		Expect(hpa.Spec.MaxReplicas).To(Equal(maxReplicaCount))
+		Expect(metav1.IsControlledBy(hpa, scaledObject)).To(BeTrue())
+
+		Expect(reconciler.deleteHPAForScaledObject(context.Background(), logr.Discard(), scaledObject)).To(Succeed())
+		err = reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-test", Namespace: "default"}, legacyHPA)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects External metrics in horizontalPodAutoscalerConfig", func() {
+		Expect(checkHPAConfigMetricsAreValid(scaledObject)).To(Succeed())
+
+		scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig.Metrics = append(scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig.Metrics, autoscalingv2.MetricSpec{
+			Type:     autoscalingv2.ExternalMetricSourceType,
+			External: &autoscalingv2.ExternalMetricSource{Metric: autoscalingv2.MetricIdentifier{Name: "queue"}},
+		})
+		Expect(checkHPAConfigMetricsAreValid(scaledObject)).To(MatchError("horizontalPodAutoscalerConfig.metrics[1] is External metric, which has to be defined as a trigger"))
+	})
+})
@@ -39,6 +39,9 @@ import (
 	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
 	"github.com/kedacore/keda/v2/pkg/scaling"
+	"github.com/kedacore/keda/v2/pkg/scaling/executor"
+	"github.com/kedacore/keda/v2/pkg/scaling/prediction"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
 )
 
 // +kubebuilder:rbac:groups=keda.sh,resources=scaledjobs;scaledjobs/finalizers;scaledjobs/status,verbs="*"
@@ -50,19 +53,34 @@ type ScaledJobReconciler struct {
 	Scheme            *runtime.Scheme
 	GlobalHTTPTimeout time.Duration
 	Recorder          record.EventRecorder
+	// ScaleLoopScheduler bounds the concurrency and rate of the scale loops, nil means unbounded
+	ScaleLoopScheduler *scheduler.Scheduler
+	// QueryBatchWindow specifies for how long are the metric queries of the scalers sharing a backend
+	// collected before they are executed together, 0 means that the queries aren't batched
+	QueryBatchWindow time.Duration
+	// PredictionStore keeps the histories of the triggers with prediction, nil means that the prediction is disabled
+	PredictionStore *prediction.Store
+	// Shard selects the ScaledJobs handled by this operator replica, nil means all of them
+	Shard *scheduler.Shard
 
 	scaleHandler scaling.ScaleHandler
 }
 
 // SetupWithManager initializes the ScaledJobReconciler instance and starts a new controller managed by the passed Manager instance.
 func (r *ScaledJobReconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
-	r.scaleHandler = scaling.NewScaleHandler(mgr.GetClient(), nil, mgr.GetScheme(), r.GlobalHTTPTimeout, mgr.GetEventRecorderFor("scale-handler"))
+	r.scaleHandler = scaling.NewScaleHandler(mgr.GetClient(), nil, mgr.GetScheme(), r.GlobalHTTPTimeout, mgr.GetEventRecorderFor("scale-handler"), scaling.ScaleHandlerOptions{
+		ScaleLoopScheduler: r.ScaleLoopScheduler,
+		QueryBatchWindow:   r.QueryBatchWindow,
+		PredictionStore:    r.PredictionStore,
+	})
 
 	return ctrl.NewControllerManagedBy(mgr).
 		WithOptions(options).
 		// Ignore updates to ScaledJob Status (in this case metadata.Generation does not change)
 		// so reconcile loop is not started on Status updates
-		For(&kedav1alpha1.ScaledJob{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&kedav1alpha1.ScaledJob{}, builder.WithPredicates(
+			predicate.Or(kedacontrollerutil.PausedPredicate{}, predicate.GenerationChangedPredicate{}),
+		)).
 		Complete(r)
 }
 
@@ -85,14 +103,23 @@ func (r *ScaledJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
-	reqLogger.Info("Reconciling ScaledJob")
-
 	// Check if the ScaledJob instance is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
+	// Any replica of KEDA Operator finalizes it, so the scale loop of a previous owner is stopped as well
 	if scaledJob.GetDeletionTimestamp() != nil {
+		reqLogger.Info("Reconciling ScaledJob")
 		return ctrl.Result{}, r.finalizeScaledJob(ctx, reqLogger, scaledJob)
 	}
 
+	// the ScaledJob is handled by another replica of KEDA Operator, the scale loop is stopped in case it was owned before
+	identifier := (&kedav1alpha1.WithTriggers{TypeMeta: metav1.TypeMeta{Kind: "ScaledJob"}, ObjectMeta: scaledJob.ObjectMeta}).GenerateIdenitifier()
+	if !r.Shard.Owns(identifier) {
+		reqLogger.V(1).Info("ScaledJob is not owned by this shard, skipping")
+		return ctrl.Result{}, r.stopScaleLoop(ctx, reqLogger, scaledJob)
+	}
+
+	reqLogger.Info("Reconciling ScaledJob")
+
 	// ensure finalizer is set on this CR
 	if err := r.ensureFinalizer(ctx, reqLogger, scaledJob); err != nil {
 		return ctrl.Result{}, err
@@ -100,7 +127,7 @@ func (r *ScaledJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	// ensure Status Conditions are initialized
 	if !scaledJob.Status.Conditions.AreInitialized() {
-		conditions := kedav1alpha1.GetInitializedConditions()
+		conditions := scaledJob.Status.Conditions.GetInitializedMissing()
 		if err := kedacontrollerutil.SetStatusConditions(ctx, r.Client, reqLogger, scaledJob, conditions); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -113,8 +140,21 @@ func (r *ScaledJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		reqLogger.Error(err, "scaledJob.spec.jobTargetRef not found")
 		return ctrl.Result{}, err
 	}
+
+	// the autoscaling is paused, stop the scale loop so no new Jobs are created
+	if executor.IsPaused(scaledJob) {
+		return ctrl.Result{}, r.pauseScaledJob(ctx, reqLogger, scaledJob)
+	}
+
 	msg, err := r.reconcileScaledJob(ctx, reqLogger, scaledJob)
 	conditions := scaledJob.Status.Conditions.DeepCopy()
+	wasPaused := conditions.GetPausedCondition()
+	if wasPaused.IsTrue() {
+		r.Recorder.Event(scaledJob, corev1.EventTypeNormal, eventreason.ScaledJobUnpaused, "ScaledJob autoscaling was resumed")
+	}
+	if !wasPaused.IsFalse() {
+		conditions.SetPausedCondition(metav1.ConditionFalse, kedav1alpha1.ConditionUnpausedReason, kedav1alpha1.ConditionUnpausedMessage)
+	}
 	if err != nil {
 		reqLogger.Error(err, msg)
 		conditions.SetReadyCondition(metav1.ConditionFalse, "ScaledJobCheckFailed", msg)
@@ -135,15 +175,35 @@ func (r *ScaledJobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	return ctrl.Result{}, err
 }
 
+// pauseScaledJob stops the scale loop of the paused ScaledJob, Jobs that are already running are left untouched
+func (r *ScaledJobReconciler) pauseScaledJob(ctx context.Context, logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob) error {
+	if err := r.stopScaleLoop(ctx, logger, scaledJob); err != nil {
+		return err
+	}
+
+	conditions := scaledJob.Status.Conditions.DeepCopy()
+	if pausedCondition := conditions.GetPausedCondition(); pausedCondition.IsTrue() {
+		return nil
+	}
+	logger.Info("ScaledJob is paused")
+	r.Recorder.Event(scaledJob, corev1.EventTypeNormal, eventreason.ScaledJobPaused, "ScaledJob autoscaling is paused")
+	conditions.SetPausedCondition(metav1.ConditionTrue, kedav1alpha1.ScaledJobConditionPausedReason, kedav1alpha1.ScaledJobConditionPausedMessage)
+	return kedacontrollerutil.SetStatusConditions(ctx, r.Client, logger, scaledJob, &conditions)
+}
+
 // reconcileScaledJob implements reconciler logic for K8s Jobs based ScaledJob
 func (r *ScaledJobReconciler) reconcileScaledJob(ctx context.Context, logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob) (string, error) {
-	msg, err := r.deletePreviousVersionScaleJobs(ctx, logger, scaledJob)
-	if err != nil {
-		return msg, err
+	// Jobs created before the pause are kept when the ScaledJob is resumed,
+	// no Jobs are created in dry-run mode, so the running ones are kept as well
+	if pausedCondition := scaledJob.Status.Conditions.GetPausedCondition(); !pausedCondition.IsTrue() && !scaledJob.Spec.DryRun {
+		msg, err := r.deletePreviousVersionScaleJobs(ctx, logger, scaledJob)
+		if err != nil {
+			return msg, err
+		}
 	}
 
 	// Check ScaledJob is Ready or not
-	_, err = r.scaleHandler.GetScalersCache(ctx, scaledJob)
+	_, err := r.scaleHandler.GetScalersCache(ctx, scaledJob)
 	if err != nil {
 		logger.Error(err, "Error getting scalers")
 		return "Failed to ensure ScaledJob is correctly created", err
@@ -157,6 +217,15 @@ func (r *ScaledJobReconciler) reconcileScaledJob(ctx context.Context, logger log
 		}
 	}
 
+	warning, err := executor.ValidateScalingStrategy(scaledJob)
+	if err != nil {
+		return "ScaledJob doesn't have correct scalingStrategy specification", err
+	}
+	if warning != "" {
+		logger.Info("Warning: " + warning)
+		r.Recorder.Event(scaledJob, corev1.EventTypeWarning, eventreason.ScaledJobScalingStrategyFallback, warning)
+	}
+
 	// scaledJob was created or modified - let's start a new ScaleLoop
 	err = r.requestScaleLoop(ctx, logger, scaledJob)
 	if err != nil {
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/scaling/executor"
+)
+
+// +kubebuilder:webhook:path=/validate-keda-sh-v1alpha1-scaledjob,mutating=false,failurePolicy=ignore,sideEffects=None,groups=keda.sh,resources=scaledjobs,verbs=create;update,versions=v1alpha1,name=vscaledjob.keda.sh,admissionReviewVersions=v1
+
+// ScaledJobValidator is the validating admission webhook of ScaledJobs
+type ScaledJobValidator struct {
+	Client            client.Client
+	GlobalHTTPTimeout time.Duration
+
+	decoder *admission.Decoder
+	logger  logr.Logger
+}
+
+// SetupWebhookWithManager registers the webhook in the webhook server of the manager
+func (v *ScaledJobValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.logger = mgr.GetLogger().WithName("scaledjob-webhook")
+	mgr.GetWebhookServer().Register("/validate-keda-sh-v1alpha1-scaledjob", &webhook.Admission{Handler: v})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (v *ScaledJobValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// Handle validates the created or updated ScaledJob
+func (v *ScaledJobValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	scaledJob := &kedav1alpha1.ScaledJob{}
+	if err := v.decoder.Decode(req, scaledJob); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// the updates of metadata, eg. finalizers managed by KEDA Operator, are allowed
+	if req.Operation == admissionv1.Update {
+		oldScaledJob := &kedav1alpha1.ScaledJob{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldScaledJob); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if equality.Semantic.DeepEqual(oldScaledJob.Spec, scaledJob.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	warnings, err := v.validate(ctx, scaledJob)
+	if err != nil {
+		v.logger.V(1).Info("Rejecting ScaledJob", "scaledJob.Namespace", scaledJob.Namespace, "scaledJob.Name", scaledJob.Name, "reason", err.Error())
+		return admission.Denied(err.Error()).WithWarnings(warnings...)
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// validate returns an error if the ScaledJob isn't valid, the warnings report what couldn't be validated
+func (v *ScaledJobValidator) validate(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob) ([]string, error) {
+	if scaledJob.Spec.JobTargetRef == nil {
+		return nil, fmt.Errorf("ScaledJob.spec.jobTargetRef is missing")
+	}
+	if scaledJob.Spec.MaxReplicaCount != nil && *scaledJob.Spec.MaxReplicaCount < 0 {
+		return nil, fmt.Errorf("MaxReplicaCount=%d must not be negative", *scaledJob.Spec.MaxReplicaCount)
+	}
+
+	var warnings []string
+	warning, err := executor.ValidateScalingStrategy(scaledJob)
+	if err != nil {
+		return nil, err
+	}
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	for i, trigger := range scaledJob.Spec.Triggers {
+		if trigger.Type == "cpu" || trigger.Type == "memory" {
+			// jobs are created for the pending events, there aren't any long running pods to measure the usage of
+			warnings = append(warnings, fmt.Sprintf("trigger %d of type %s is ignored, ScaledJob is scaled by the event triggers only", i, trigger.Type))
+		}
+	}
+	triggerWarnings, err := validateTriggers(ctx, v.Client, v.logger, &kedav1alpha1.WithTriggers{
+		TypeMeta:   metav1.TypeMeta{Kind: "ScaledJob"},
+		ObjectMeta: scaledJob.ObjectMeta,
+		Spec:       kedav1alpha1.WithTriggersSpec{Triggers: scaledJob.Spec.Triggers},
+	}, &scaledJob.Spec.JobTargetRef.Template, scaledJob.Spec.EnvSourceContainerName, v.GlobalHTTPTimeout)
+	return append(warnings, triggerWarnings...), err
+}
@@ -24,7 +24,7 @@ import (
 
 	"github.com/go-logr/logr"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
-	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -49,6 +49,12 @@ import (
 	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
 	"github.com/kedacore/keda/v2/pkg/scaling"
+	"github.com/kedacore/keda/v2/pkg/scaling/executor"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
+	"github.com/kedacore/keda/v2/pkg/scaling/polling"
+	"github.com/kedacore/keda/v2/pkg/scaling/prediction"
+	"github.com/kedacore/keda/v2/pkg/scaling/schedule"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
 	kedautil "github.com/kedacore/keda/v2/pkg/util"
 )
 
@@ -59,6 +65,7 @@ import (
 // +kubebuilder:rbac:groups="*",resources="*/scale",verbs="*"
 // +kubebuilder:rbac:groups="",resources="serviceaccounts",verbs=list;watch
 // +kubebuilder:rbac:groups="*",resources="*",verbs=get
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
 // +kubebuilder:rbac:groups="apps",resources=deployments;statefulsets,verbs=list;watch
 // +kubebuilder:rbac:groups="coordination.k8s.io",resources=leases,verbs="*"
 
@@ -68,12 +75,26 @@ type ScaledObjectReconciler struct {
 	Scheme            *runtime.Scheme
 	GlobalHTTPTimeout time.Duration
 	Recorder          record.EventRecorder
+	// MetricsFreshnessWindow specifies for how long are metric values recorded by the scale loop
+	// served to the KEDA Metrics Server, 0 means that the scalers are always queried
+	MetricsFreshnessWindow time.Duration
+	// ScaleLoopScheduler bounds the concurrency and rate of the scale loops, nil means unbounded
+	ScaleLoopScheduler *scheduler.Scheduler
+	// QueryBatchWindow specifies for how long are the metric queries of the scalers sharing a backend
+	// collected before they are executed together, 0 means that the queries aren't batched
+	QueryBatchWindow time.Duration
+	// PredictionStore keeps the histories of the triggers with prediction, nil means that the prediction is disabled
+	PredictionStore *prediction.Store
+	// Shard selects the ScaledObjects handled by this operator replica, nil means all of them
+	Shard *scheduler.Shard
 
 	scaleClient              scale.ScalesGetter
 	restMapper               meta.RESTMapper
 	scaledObjectsGenerations *sync.Map
 	scaleHandler             scaling.ScaleHandler
 	kubeVersion              kedautil.K8sVersion
+	// hpaAPIVersion is the highest autoscaling API version served by the cluster, HPAs are written through it
+	hpaAPIVersion string
 }
 
 // A cache mapping "resource.group" to true or false if we know if this resource is scalable.
@@ -107,6 +128,14 @@ func (r *ScaledObjectReconciler) SetupWithManager(mgr ctrl.Manager, options cont
 		setupLog.Error(err, "Not able to get Kubernetes version")
 	}
 
+	// Find out the autoscaling API used for HPAs
+	r.hpaAPIVersion, err = kedautil.GetHighestAutoscalingAPIVersion(clientset)
+	if err != nil {
+		setupLog.Error(err, "Not able to find out autoscaling API version")
+		return err
+	}
+	setupLog.Info("Using " + r.hpaAPIVersion + " API for HPAs")
+
 	// Create Scale Client
 	scaleClient := initScaleClient(mgr, clientset)
 	r.scaleClient = scaleClient
@@ -114,7 +143,12 @@ func (r *ScaledObjectReconciler) SetupWithManager(mgr ctrl.Manager, options cont
 	// Init the rest of ScaledObjectReconciler
 	r.restMapper = mgr.GetRESTMapper()
 	r.scaledObjectsGenerations = &sync.Map{}
-	r.scaleHandler = scaling.NewScaleHandler(mgr.GetClient(), r.scaleClient, mgr.GetScheme(), r.GlobalHTTPTimeout, r.Recorder)
+	r.scaleHandler = scaling.NewScaleHandler(mgr.GetClient(), r.scaleClient, mgr.GetScheme(), r.GlobalHTTPTimeout, r.Recorder, scaling.ScaleHandlerOptions{
+		MetricsFreshnessWindow: r.MetricsFreshnessWindow,
+		ScaleLoopScheduler:     r.ScaleLoopScheduler,
+		QueryBatchWindow:       r.QueryBatchWindow,
+		PredictionStore:        r.PredictionStore,
+	})
 
 	// Start controller
 	return ctrl.NewControllerManagedBy(mgr).
@@ -123,12 +157,24 @@ func (r *ScaledObjectReconciler) SetupWithManager(mgr ctrl.Manager, options cont
 		// (in this case metadata.Generation does not change)
 		// so reconcile loop is not started on Status updates
 		For(&kedav1alpha1.ScaledObject{}, builder.WithPredicates(
-			predicate.Or(kedacontrollerutil.PausedReplicasPredicate{}, predicate.GenerationChangedPredicate{}),
+			predicate.Or(kedacontrollerutil.PausedReplicasPredicate{}, kedacontrollerutil.PausedPredicate{}, predicate.GenerationChangedPredicate{}),
 		)).
-		Owns(&autoscalingv2beta2.HorizontalPodAutoscaler{}).
+		Owns(r.newHPAObject()).
 		Complete(r)
 }
 
+// GetScaleHandler returns the ScaleHandler used by the ScaledObjectReconciler,
+// it is available once the reconciler is set up with the Manager
+func (r *ScaledObjectReconciler) GetScaleHandler() scaling.ScaleHandler {
+	return r.scaleHandler
+}
+
+// GetScaleClient returns the client for the /scale subresource used by the ScaledObjectReconciler,
+// it is available once the reconciler is set up with the Manager
+func (r *ScaledObjectReconciler) GetScaleClient() scale.ScalesGetter {
+	return r.scaleClient
+}
+
 func initScaleClient(mgr manager.Manager, clientset *discovery.DiscoveryClient) scale.ScalesGetter {
 	scaleKindResolver := scale.NewDiscoveryScaleKindResolver(clientset)
 	return scale.New(
@@ -157,14 +203,23 @@ func (r *ScaledObjectReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	reqLogger.Info("Reconciling ScaledObject")
-
 	// Check if the ScaledObject instance is marked to be deleted, which is
 	// indicated by the deletion timestamp being set.
+	// Any replica of KEDA Operator finalizes it, so the scale loop of a previous owner is stopped as well
 	if scaledObject.GetDeletionTimestamp() != nil {
+		reqLogger.Info("Reconciling ScaledObject")
 		return ctrl.Result{}, r.finalizeScaledObject(ctx, reqLogger, scaledObject)
 	}
 
+	// the ScaledObject is handled by another replica of KEDA Operator, the scale loop is stopped in case it was owned before
+	identifier := (&kedav1alpha1.WithTriggers{TypeMeta: metav1.TypeMeta{Kind: "ScaledObject"}, ObjectMeta: scaledObject.ObjectMeta}).GenerateIdenitifier()
+	if !r.Shard.Owns(identifier) {
+		reqLogger.V(1).Info("ScaledObject is not owned by this shard, skipping")
+		return ctrl.Result{}, r.stopScaleLoop(ctx, reqLogger, scaledObject)
+	}
+
+	reqLogger.Info("Reconciling ScaledObject")
+
 	// ensure finalizer is set on this CR
 	if err := r.ensureFinalizer(ctx, reqLogger, scaledObject); err != nil {
 		return ctrl.Result{}, err
@@ -172,15 +227,27 @@ func (r *ScaledObjectReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 	// ensure Status Conditions are initialized
 	if !scaledObject.Status.Conditions.AreInitialized() {
-		conditions := kedav1alpha1.GetInitializedConditions()
+		conditions := scaledObject.Status.Conditions.GetInitializedMissing()
 		if err := kedacontrollerutil.SetStatusConditions(ctx, r.Client, reqLogger, scaledObject, conditions); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
+	// the autoscaling is paused, stop the scaling and keep the current replica count untouched
+	if executor.IsPaused(scaledObject) {
+		return ctrl.Result{}, r.pauseScaledObject(ctx, reqLogger, scaledObject)
+	}
+
 	// reconcile ScaledObject and set status appropriately
 	msg, err := r.reconcileScaledObject(ctx, reqLogger, scaledObject)
 	conditions := scaledObject.Status.Conditions.DeepCopy()
+	wasPaused := conditions.GetPausedCondition()
+	if wasPaused.IsTrue() {
+		r.Recorder.Event(scaledObject, corev1.EventTypeNormal, eventreason.ScaledObjectUnpaused, "ScaledObject autoscaling was resumed")
+	}
+	if !wasPaused.IsFalse() {
+		conditions.SetPausedCondition(metav1.ConditionFalse, kedav1alpha1.ConditionUnpausedReason, kedav1alpha1.ConditionUnpausedMessage)
+	}
 	if err != nil {
 		reqLogger.Error(err, msg)
 		conditions.SetReadyCondition(metav1.ConditionFalse, "ScaledObjectCheckFailed", msg)
@@ -199,7 +266,30 @@ func (r *ScaledObjectReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, err
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return getRequeueResult(scaledObject), nil
+}
+
+// pauseScaledObject stops the scale loop and deletes the HPA of the paused ScaledObject, scaling of an adopted HPA
+// is disabled instead, so the scale target is left with the current replica count until the pause is removed
+func (r *ScaledObjectReconciler) pauseScaledObject(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) error {
+	if err := r.stopScaleLoop(ctx, logger, scaledObject); err != nil {
+		return err
+	}
+	if err := r.deleteHPAForScaledObject(ctx, logger, scaledObject); err != nil {
+		return err
+	}
+
+	conditions := scaledObject.Status.Conditions.DeepCopy()
+	if pausedCondition := conditions.GetPausedCondition(); pausedCondition.IsTrue() {
+		return nil
+	}
+	logger.Info("ScaledObject is paused")
+	r.Recorder.Event(scaledObject, corev1.EventTypeNormal, eventreason.ScaledObjectPaused, "ScaledObject autoscaling is paused")
+	conditions.SetPausedCondition(metav1.ConditionTrue, kedav1alpha1.ScaledObjectConditionPausedReason, kedav1alpha1.ScaledObjectConditionPausedMessage)
+	return kedacontrollerutil.SetStatusConditions(ctx, r.Client, logger, scaledObject, &conditions)
 }
 
 // reconcileScaledObject implements reconciler logic for ScaledObject
@@ -222,11 +312,50 @@ func (r *ScaledObjectReconciler) reconcileScaledObject(ctx context.Context, logg
 		return "ScaledObject doesn't have correct scaleTargetRef specification", err
 	}
 
-	err = r.checkReplicaCountBoundsAreValid(scaledObject)
+	err = checkReplicaCountBoundsAreValid(scaledObject)
 	if err != nil {
 		return "ScaledObject doesn't have correct Idle/Min/Max Replica Counts specification", err
 	}
 
+	err = checkHPAConfigMetricsAreValid(scaledObject)
+	if err != nil {
+		return "ScaledObject doesn't have correct horizontalPodAutoscalerConfig specification", err
+	}
+
+	err = checkResourceTriggersActivation(scaledObject)
+	if err != nil {
+		return "ScaledObject doesn't have correct cpu/memory triggers specification", err
+	}
+
+	err = r.checkResourceTriggerContainers(ctx, logger, scaledObject)
+	if err != nil {
+		return "ScaledObject doesn't have correct containerName in cpu/memory triggers", err
+	}
+
+	err = modifiers.ValidateScalingModifiers(scaledObject)
+	if err != nil {
+		return "ScaledObject doesn't have correct scalingModifiers specification", err
+	}
+
+	err = schedule.ValidateScheduledWindows(scaledObject)
+	if err != nil {
+		return "ScaledObject doesn't have correct scheduledWindows specification", err
+	}
+
+	err = polling.ValidateAdaptivePolling(scaledObject)
+	if err != nil {
+		return "ScaledObject doesn't have correct adaptivePolling specification", err
+	}
+
+	err = r.updateActiveScheduledWindow(ctx, logger, scaledObject)
+	if err != nil {
+		return "Failed to update the active scheduled window of ScaledObject", err
+	}
+
+	if scaledObject.Spec.DryRun {
+		return r.reconcileDryRunScaledObject(ctx, logger, scaledObject)
+	}
+
 	// Create a new HPA or update existing one according to ScaledObject
 	newHPACreated, err := r.ensureHPAForScaledObjectExists(ctx, logger, scaledObject, &gvkr)
 	if err != nil {
@@ -253,6 +382,54 @@ func (r *ScaledObjectReconciler) reconcileScaledObject(ctx context.Context, logg
 	return kedav1alpha1.ScaledObjectConditionReadySuccessMessage, nil
 }
 
+// reconcileDryRunScaledObject makes sure there isn't any HPA scaling the target of ScaledObject in dry-run mode,
+// the scale loop only records the scaling decisions and leaves the scale target untouched
+func (r *ScaledObjectReconciler) reconcileDryRunScaledObject(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) (string, error) {
+	if err := r.deleteHPAForScaledObject(ctx, logger, scaledObject); err != nil {
+		return "Failed to delete HPA of ScaledObject in dry-run mode", err
+	}
+
+	scaleObjectSpecChanged, err := r.scaledObjectGenerationChanged(logger, scaledObject)
+	if err != nil {
+		return "Failed to check whether ScaledObject's Generation was changed", err
+	}
+	if scaleObjectSpecChanged {
+		if err := r.requestScaleLoop(ctx, logger, scaledObject); err != nil {
+			return "Failed to start a new scale loop with scaling logic", err
+		}
+		logger.Info("Initializing Scaling logic according to ScaledObject Specification in dry-run mode")
+	}
+	return kedav1alpha1.ScaledObjectConditionReadySuccessMessage, nil
+}
+
+// updateActiveScheduledWindow records the name of the currently open scheduled window in the status of ScaledObject
+func (r *ScaledObjectReconciler) updateActiveScheduledWindow(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) error {
+	activeWindow := ""
+	if window := schedule.GetActiveWindow(scaledObject, time.Now()); window != nil {
+		activeWindow = window.Name
+	}
+	if scaledObject.Status.ActiveScheduledWindow == activeWindow {
+		return nil
+	}
+
+	logger.Info("Active scheduled window changed", "from", scaledObject.Status.ActiveScheduledWindow, "to", activeWindow)
+	status := scaledObject.Status.DeepCopy()
+	status.ActiveScheduledWindow = activeWindow
+	return kedacontrollerutil.UpdateScaledObjectStatus(ctx, r.Client, logger, scaledObject, status)
+}
+
+// getRequeueResult returns the result that makes the controller reconcile ScaledObject again
+// when any of its scheduled windows opens or closes, so the HPA bounds are updated
+func getRequeueResult(scaledObject *kedav1alpha1.ScaledObject) ctrl.Result {
+	now := time.Now()
+	next, found := schedule.GetNextTransition(scaledObject, now)
+	if !found {
+		return ctrl.Result{}
+	}
+	// add a small delay, so the window is surely opened or closed at the time of the reconciliation
+	return ctrl.Result{RequeueAfter: next.Sub(now) + time.Second}
+}
+
 // ensureScaledObjectLabel ensures that scaledobject.keda.sh/name=<scaledObject.Name> label exist in the ScaledObject
 // This is how the MetricsAdapter will know which ScaledObject a metric is for when the HPA queries it.
 func (r *ScaledObjectReconciler) ensureScaledObjectLabel(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject) error {
@@ -340,7 +517,7 @@ func (r *ScaledObjectReconciler) checkTargetResourceIsScalable(ctx context.Conte
 
 // checkReplicaCountBoundsAreValid checks that Idle/Min/Max ReplicaCount defined in ScaledObject are correctly specified
 // ie. that Min is not greater then Max or Idle greater or equal to Min
-func (r *ScaledObjectReconciler) checkReplicaCountBoundsAreValid(scaledObject *kedav1alpha1.ScaledObject) error {
+func checkReplicaCountBoundsAreValid(scaledObject *kedav1alpha1.ScaledObject) error {
 	min := int32(0)
 	if scaledObject.Spec.MinReplicaCount != nil {
 		min = *getHPAMinReplicas(scaledObject)
@@ -358,12 +535,56 @@ func (r *ScaledObjectReconciler) checkReplicaCountBoundsAreValid(scaledObject *k
 	return nil
 }
 
+// checkHPAConfigMetricsAreValid checks that the metrics passed to the HPA in horizontalPodAutoscalerConfig
+// don't collide with the metrics served by KEDA, External metrics have to be defined as triggers
+func checkHPAConfigMetricsAreValid(scaledObject *kedav1alpha1.ScaledObject) error {
+	if scaledObject.Spec.Advanced == nil || scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig == nil {
+		return nil
+	}
+	for i, metric := range scaledObject.Spec.Advanced.HorizontalPodAutoscalerConfig.Metrics {
+		if metric.Type == autoscalingv2.ExternalMetricSourceType || metric.External != nil {
+			return fmt.Errorf("horizontalPodAutoscalerConfig.metrics[%d] is External metric, which has to be defined as a trigger", i)
+		}
+	}
+	return nil
+}
+
+// checkResourceTriggersActivation checks that a ScaledObject whose cpu/memory triggers use activationThreshold to scale
+// to zero has also another trigger, there aren't any pods reporting usage of resources to activate the scale target again
+func checkResourceTriggersActivation(scaledObject *kedav1alpha1.ScaledObject) error {
+	idleReplicaCount := scaledObject.Spec.IdleReplicaCount
+	scalesToZero := idleReplicaCount != nil && *idleReplicaCount == 0 ||
+		idleReplicaCount == nil && (scaledObject.Spec.MinReplicaCount == nil || *scaledObject.Spec.MinReplicaCount == 0)
+	if !scalesToZero {
+		return nil
+	}
+
+	hasActivationThreshold := false
+	for _, trigger := range scaledObject.Spec.Triggers {
+		if trigger.Type != "cpu" && trigger.Type != "memory" {
+			return nil
+		}
+		if trigger.ActivationThreshold != "" || trigger.Metadata["activationThreshold"] != "" {
+			hasActivationThreshold = true
+		}
+	}
+	if hasActivationThreshold {
+		return fmt.Errorf("cpu/memory triggers with activationThreshold can't activate the scale target from zero replicas, at least one trigger of another type is required")
+	}
+	return nil
+}
+
 // ensureHPAForScaledObjectExists ensures that in cluster exist up-to-date HPA for specified ScaledObject, returns true if a new HPA was created
 func (r *ScaledObjectReconciler) ensureHPAForScaledObjectExists(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, gvkr *kedav1alpha1.GroupVersionKindResource) (bool, error) {
+	// the HPA replaced after a change of the adoption annotation is released before the new one is adopted or created
+	if err := r.releaseReplacedHPA(ctx, logger, scaledObject); err != nil {
+		return false, err
+	}
+
 	hpaName := getHPAName(scaledObject)
-	foundHpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+	foundHpa := &autoscalingv2.HorizontalPodAutoscaler{}
 	// Check if HPA for this ScaledObject already exists
-	err := r.Client.Get(ctx, types.NamespacedName{Name: hpaName, Namespace: scaledObject.Namespace}, foundHpa)
+	err := r.getHPA(ctx, types.NamespacedName{Name: hpaName, Namespace: scaledObject.Namespace}, foundHpa)
 	if err != nil && errors.IsNotFound(err) {
 		// HPA wasn't found -> let's create a new one
 		err = r.createAndDeployNewHPA(ctx, logger, scaledObject, gvkr)
@@ -375,12 +596,19 @@ func (r *ScaledObjectReconciler) ensureHPAForScaledObjectExists(ctx context.Cont
 		r.checkMinK8sVersionforHPABehavior(logger, scaledObject)
 
 		// new HPA created successfully -> notify Reconcile function so it could fire a new ScaleLoop
-		return true, nil
+		return true, r.updateHPANameInStatus(ctx, logger, scaledObject)
 	} else if err != nil {
 		logger.Error(err, "Failed to get HPA from cluster")
 		return false, err
 	}
 
+	// HPA was found, but it isn't managed by ScaledObject yet -> let's adopt it, if requested
+	if _, adopt := getAdoptedHPAName(scaledObject); adopt && !metav1.IsControlledBy(foundHpa, scaledObject) {
+		if err := r.adoptHPA(ctx, logger, scaledObject, foundHpa, gvkr); err != nil {
+			return false, err
+		}
+	}
+
 	// HPA was found -> let's check if we need to update it
 	err = r.updateHPAIfNeeded(ctx, logger, scaledObject, foundHpa, gvkr)
 	if err != nil {
@@ -388,7 +616,7 @@ func (r *ScaledObjectReconciler) ensureHPAForScaledObjectExists(ctx context.Cont
 		return false, err
 	}
 
-	return false, nil
+	return false, r.updateHPANameInStatus(ctx, logger, scaledObject)
 }
 
 // requestScaleLoop tries to start ScaleLoop handler for the respective ScaledObject
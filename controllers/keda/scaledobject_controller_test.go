@@ -19,16 +19,23 @@ package keda
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	appsv1 "k8s.io/api/apps/v1"
-	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
@@ -36,6 +43,7 @@ import (
 	"github.com/kedacore/keda/v2/pkg/mock/mock_scaling"
 	"github.com/kedacore/keda/v2/pkg/scalers"
 	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
 )
 
 type GinkgoTestReporter struct{}
@@ -266,7 +274,7 @@ var _ = Describe("ScaledObjectController", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			// Get and confirm the HPA.
-			hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+			hpa := &autoscalingv2.HorizontalPodAutoscaler{}
 			Eventually(func() error {
 				return k8sClient.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-clean-up-test", Namespace: "default"}, hpa)
 			}).ShouldNot(HaveOccurred())
@@ -322,7 +330,7 @@ var _ = Describe("ScaledObjectController", func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			// Get and confirm the HPA.
-			hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+			hpa := &autoscalingv2.HorizontalPodAutoscaler{}
 			Eventually(func() error {
 				return k8sClient.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-cache-regenerate", Namespace: "default"}, hpa)
 			}).ShouldNot(HaveOccurred())
@@ -359,7 +367,7 @@ var _ = Describe("ScaledObjectController", func() {
 			time.Sleep(30 * time.Second)
 
 			// Get and confirm the HPA.
-			hpa2 := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+			hpa2 := &autoscalingv2.HorizontalPodAutoscaler{}
 			Eventually(func() error {
 				return k8sClient.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-cache-regenerate", Namespace: "default"}, hpa2)
 			}).ShouldNot(HaveOccurred())
@@ -407,7 +415,7 @@ var _ = Describe("ScaledObjectController", func() {
 			Ω(err).ToNot(HaveOccurred())
 
 			// Get and confirm the HPA
-			hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+			hpa := &autoscalingv2.HorizontalPodAutoscaler{}
 			Eventually(func() error {
 				return k8sClient.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-" + soName, Namespace: "default"}, hpa)
 			}).ShouldNot(HaveOccurred())
@@ -461,7 +469,7 @@ var _ = Describe("ScaledObjectController", func() {
 			Ω(err).ToNot(HaveOccurred())
 
 			// Get and confirm the HPA
-			hpa := &autoscalingv2beta2.HorizontalPodAutoscaler{}
+			hpa := &autoscalingv2.HorizontalPodAutoscaler{}
 			Eventually(func() error {
 				return k8sClient.Get(context.Background(), types.NamespacedName{Name: "keda-hpa-" + soName, Namespace: "default"}, hpa)
 			}).ShouldNot(HaveOccurred())
@@ -600,6 +608,70 @@ var _ = Describe("ScaledObjectController", func() {
 	})
 })
 
+var _ = Describe("ScaledObject sharding", func() {
+	var (
+		scheme       *runtime.Scheme
+		scaleHandler *mock_scaling.MockScaleHandler
+		shard        *scheduler.Shard
+	)
+
+	newScaledObject := func() *kedav1alpha1.ScaledObject {
+		return &kedav1alpha1.ScaledObject{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Finalizers: []string{scaledObjectFinalizer}},
+			Spec:       kedav1alpha1.ScaledObjectSpec{ScaleTargetRef: &kedav1alpha1.ScaleTarget{Name: "app"}},
+		}
+	}
+
+	newReconciler := func(scaledObject *kedav1alpha1.ScaledObject) *ScaledObjectReconciler {
+		return &ScaledObjectReconciler{
+			Client:                   fake.NewClientBuilder().WithScheme(scheme).WithObjects(scaledObject).Build(),
+			Scheme:                   scheme,
+			Recorder:                 record.NewFakeRecorder(10),
+			scaleHandler:             scaleHandler,
+			scaledObjectsGenerations: &sync.Map{},
+			Shard:                    shard,
+		}
+	}
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(kedav1alpha1.AddToScheme(scheme)).To(Succeed())
+		scaleHandler = mock_scaling.NewMockScaleHandler(gomock.NewController(GinkgoTestReporter{}))
+
+		// the shard not owning the ScaledObject
+		var err error
+		shard, err = scheduler.NewShard(0, 2)
+		Expect(err).ToNot(HaveOccurred())
+		if shard.Owns("scaledobject.default.test") {
+			shard, err = scheduler.NewShard(1, 2)
+			Expect(err).ToNot(HaveOccurred())
+		}
+	})
+
+	It("stops the scale loop of ScaledObject owned by another shard", func() {
+		scaledObject := newScaledObject()
+		scaleHandler.EXPECT().DeleteScalableObject(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+		_, err := newReconciler(scaledObject).Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("finalizes deleted ScaledObject owned by another shard", func() {
+		scaledObject := newScaledObject()
+		now := metav1.Now()
+		scaledObject.DeletionTimestamp = &now
+		scaleHandler.EXPECT().DeleteScalableObject(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		reconciler := newReconciler(scaledObject)
+
+		_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "test", Namespace: "default"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = reconciler.Client.Get(context.Background(), types.NamespacedName{Name: "test", Namespace: "default"}, &kedav1alpha1.ScaledObject{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
 func generateDeployment(name string) *appsv1.Deployment {
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
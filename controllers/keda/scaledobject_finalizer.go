@@ -70,6 +70,11 @@ func (r *ScaledObjectReconciler) finalizeScaledObject(ctx context.Context, logge
 			}
 		}
 
+		// adopted HPA has to be restored and released before the ScaledObject is removed, otherwise it would be garbage collected
+		if _, err := r.restoreAdoptedHPA(ctx, logger, scaledObject); err != nil {
+			return err
+		}
+
 		// Remove scaledObjectFinalizer. Once all finalizers have been
 		// removed, the object will be deleted.
 		scaledObject.SetFinalizers(util.Remove(scaledObject.GetFinalizers(), scaledObjectFinalizer))
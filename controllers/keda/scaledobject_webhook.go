@@ -0,0 +1,243 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
+	"github.com/kedacore/keda/v2/pkg/scaling/polling"
+	"github.com/kedacore/keda/v2/pkg/scaling/resolver"
+	"github.com/kedacore/keda/v2/pkg/scaling/schedule"
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
+)
+
+// +kubebuilder:webhook:path=/validate-keda-sh-v1alpha1-scaledobject,mutating=false,failurePolicy=ignore,sideEffects=None,groups=keda.sh,resources=scaledobjects,verbs=create;update,versions=v1alpha1,name=vscaledobject.keda.sh,admissionReviewVersions=v1
+
+// ScaledObjectValidator is the validating admission webhook of ScaledObjects, it rejects the ScaledObjects
+// which would be rejected by the reconciler, so the invalid specification is reported at apply time
+type ScaledObjectValidator struct {
+	Client            client.Client
+	GlobalHTTPTimeout time.Duration
+
+	restMapper meta.RESTMapper
+	decoder    *admission.Decoder
+	logger     logr.Logger
+}
+
+// SetupWebhookWithManager registers the webhook in the webhook server of the manager
+func (v *ScaledObjectValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	v.restMapper = mgr.GetRESTMapper()
+	v.logger = mgr.GetLogger().WithName("scaledobject-webhook")
+	mgr.GetWebhookServer().Register("/validate-keda-sh-v1alpha1-scaledobject", &webhook.Admission{Handler: v})
+	return nil
+}
+
+// InjectDecoder implements admission.DecoderInjector
+func (v *ScaledObjectValidator) InjectDecoder(decoder *admission.Decoder) error {
+	v.decoder = decoder
+	return nil
+}
+
+// Handle validates the created or updated ScaledObject
+func (v *ScaledObjectValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return admission.Allowed("")
+	}
+
+	scaledObject := &kedav1alpha1.ScaledObject{}
+	if err := v.decoder.Decode(req, scaledObject); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// the updates of metadata, eg. labels and finalizers managed by KEDA Operator, are allowed
+	if req.Operation == admissionv1.Update {
+		oldScaledObject := &kedav1alpha1.ScaledObject{}
+		if err := v.decoder.DecodeRaw(req.OldObject, oldScaledObject); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := checkAdoptedHPAIsNotChanged(oldScaledObject, scaledObject); err != nil {
+			return admission.Denied(err.Error())
+		}
+		if equality.Semantic.DeepEqual(oldScaledObject.Spec, scaledObject.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	warnings, err := v.validate(ctx, scaledObject)
+	if err != nil {
+		v.logger.V(1).Info("Rejecting ScaledObject", "scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name, "reason", err.Error())
+		return admission.Denied(err.Error()).WithWarnings(warnings...)
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// validate returns an error if the ScaledObject isn't valid, the warnings report what couldn't be validated
+func (v *ScaledObjectValidator) validate(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) ([]string, error) {
+	if scaledObject.Spec.ScaleTargetRef == nil || scaledObject.Spec.ScaleTargetRef.Name == "" {
+		return nil, fmt.Errorf("ScaledObject.spec.scaleTargetRef.name is missing")
+	}
+	if _, err := schema.ParseGroupVersion(scaledObject.Spec.ScaleTargetRef.APIVersion); err != nil {
+		return nil, fmt.Errorf("ScaledObject.spec.scaleTargetRef.apiVersion is invalid: %s", err)
+	}
+	if err := checkReplicaCountBoundsAreValid(scaledObject); err != nil {
+		return nil, err
+	}
+	if err := checkHPAConfigMetricsAreValid(scaledObject); err != nil {
+		return nil, err
+	}
+	if err := checkResourceTriggersActivation(scaledObject); err != nil {
+		return nil, err
+	}
+	if err := modifiers.ValidateScalingModifiers(scaledObject); err != nil {
+		return nil, err
+	}
+	if err := schedule.ValidateScheduledWindows(scaledObject); err != nil {
+		return nil, err
+	}
+	if err := polling.ValidateAdaptivePolling(scaledObject); err != nil {
+		return nil, err
+	}
+	if err := v.checkScaleTargetIsNotScaled(ctx, scaledObject); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	podTemplateSpec, containerName, err := v.resolveScaleTargetPodSpec(ctx, scaledObject)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("scale target can't be resolved: %s", err))
+	} else if err := checkResourceTriggerContainersExist(scaledObject.Spec.Triggers, podTemplateSpec); err != nil {
+		return warnings, err
+	}
+	triggerWarnings, err := validateTriggers(ctx, v.Client, v.logger, &kedav1alpha1.WithTriggers{
+		TypeMeta:   metav1.TypeMeta{Kind: "ScaledObject"},
+		ObjectMeta: scaledObject.ObjectMeta,
+		Spec:       kedav1alpha1.WithTriggersSpec{Triggers: scaledObject.Spec.Triggers},
+	}, podTemplateSpec, containerName, v.GlobalHTTPTimeout)
+	return append(warnings, triggerWarnings...), err
+}
+
+// checkAdoptedHPAIsNotChanged returns an error if the adoption annotation of ScaledObject is added, changed or removed,
+// the HPA of ScaledObject would be replaced by another one while the previous one still scales the target
+func checkAdoptedHPAIsNotChanged(oldScaledObject, scaledObject *kedav1alpha1.ScaledObject) error {
+	oldName := oldScaledObject.GetAnnotations()[kedacontrollerutil.AdoptHPAAnnotation]
+	name := scaledObject.GetAnnotations()[kedacontrollerutil.AdoptHPAAnnotation]
+	if oldName != name {
+		return fmt.Errorf("the %s annotation can't be changed, ScaledObject has to be re-created to adopt another HPA", kedacontrollerutil.AdoptHPAAnnotation)
+	}
+	return nil
+}
+
+// checkScaleTargetIsNotScaled returns an error if the scale target of ScaledObject is scaled by another ScaledObject
+// or by a HorizontalPodAutoscaler not managed by KEDA, as these would fight over the replica count of the target
+func (v *ScaledObjectValidator) checkScaleTargetIsNotScaled(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) error {
+	target := scaledObject.Spec.ScaleTargetRef
+
+	scaledObjects := &kedav1alpha1.ScaledObjectList{}
+	if err := v.Client.List(ctx, scaledObjects, client.InNamespace(scaledObject.Namespace)); err != nil {
+		return fmt.Errorf("error listing ScaledObjects: %s", err)
+	}
+	for _, other := range scaledObjects.Items {
+		if other.Name == scaledObject.Name || other.Spec.ScaleTargetRef == nil {
+			continue
+		}
+		if isSameScaleTarget(target.APIVersion, target.Kind, target.Name, other.Spec.ScaleTargetRef.APIVersion, other.Spec.ScaleTargetRef.Kind, other.Spec.ScaleTargetRef.Name) {
+			return fmt.Errorf("the scale target %s is already scaled by ScaledObject %s", target.Name, other.Name)
+		}
+	}
+
+	// autoscaling/v1 is served by all clusters and contains everything needed here
+	hpas := &autoscalingv1.HorizontalPodAutoscalerList{}
+	if err := v.Client.List(ctx, hpas, client.InNamespace(scaledObject.Namespace)); err != nil {
+		return fmt.Errorf("error listing HorizontalPodAutoscalers: %s", err)
+	}
+	for _, hpa := range hpas.Items {
+		if hpa.Name == getHPAName(scaledObject) || isOwnedByScaledObject(hpa.OwnerReferences) {
+			continue
+		}
+		if isSameScaleTarget(target.APIVersion, target.Kind, target.Name, hpa.Spec.ScaleTargetRef.APIVersion, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name) {
+			return fmt.Errorf("the scale target %s is already scaled by HorizontalPodAutoscaler %s", target.Name, hpa.Name)
+		}
+	}
+	return nil
+}
+
+// resolveScaleTargetPodSpec returns the pod template of the scale target, the target of a new ScaledObject
+// isn't in its status yet, so it is resolved from the spec
+func (v *ScaledObjectValidator) resolveScaleTargetPodSpec(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (*corev1.PodTemplateSpec, string, error) {
+	gvkr, err := kedautil.ParseGVKR(v.restMapper, scaledObject.Spec.ScaleTargetRef.APIVersion, scaledObject.Spec.ScaleTargetRef.Kind)
+	if err != nil {
+		return nil, "", err
+	}
+	withTarget := scaledObject.DeepCopy()
+	withTarget.Status.ScaleTargetGVKR = &gvkr
+	return resolver.ResolveScaleTargetPodSpec(ctx, v.Client, v.logger, withTarget)
+}
+
+// isSameScaleTarget compares the scale targets by the group (the version doesn't matter), kind and name,
+// the empty apiVersion and kind default to apps/v1 Deployment
+func isSameScaleTarget(apiVersion, kind, name, otherAPIVersion, otherKind, otherName string) bool {
+	if name != otherName {
+		return false
+	}
+	group := func(apiVersion string) string {
+		if apiVersion == "" {
+			return "apps"
+		}
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return apiVersion
+		}
+		return gv.Group
+	}
+	if kind == "" {
+		kind = "Deployment"
+	}
+	if otherKind == "" {
+		otherKind = "Deployment"
+	}
+	return group(apiVersion) == group(otherAPIVersion) && strings.EqualFold(kind, otherKind)
+}
+
+// isOwnedByScaledObject returns true if the owner references contain a ScaledObject, the HPAs of the other
+// ScaledObjects are reported as the duplicate scale target of the ScaledObjects
+func isOwnedByScaledObject(ownerReferences []metav1.OwnerReference) bool {
+	for _, owner := range ownerReferences {
+		if owner.Kind == "ScaledObject" {
+			return true
+		}
+	}
+	return false
+}
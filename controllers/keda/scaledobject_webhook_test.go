@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
+)
+
+var _ = Describe("ScaledObjectValidator", func() {
+	var scheme *runtime.Scheme
+
+	newValidator := func(objects ...client.Object) *ScaledObjectValidator {
+		validator := &ScaledObjectValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			logger: logr.Discard(),
+		}
+		decoder, err := admission.NewDecoder(scheme)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(validator.InjectDecoder(decoder)).To(Succeed())
+		return validator
+	}
+
+	newScaledObject := func(name string, target string) *v1alpha1.ScaledObject {
+		return &v1alpha1.ScaledObject{
+			TypeMeta:   metav1.TypeMeta{Kind: "ScaledObject", APIVersion: "keda.sh/v1alpha1"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1alpha1.ScaledObjectSpec{
+				ScaleTargetRef: &v1alpha1.ScaleTarget{Name: target},
+				Triggers: []v1alpha1.ScaleTriggers{{
+					Type:     "prometheus",
+					Metadata: map[string]string{"serverAddress": "http://prometheus:9090", "metricName": "requests", "query": "sum(requests)", "threshold": "10"},
+				}},
+			},
+		}
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Env: []corev1.EnvVar{{Name: "RABBITMQ_HOST", Value: "amqp://rabbitmq:5672"}}}}},
+			},
+		},
+	}
+
+	BeforeEach(func() {
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+	})
+
+	It("accepts valid ScaledObject", func() {
+		scaledObject := newScaledObject("test", "app")
+		scaledObject.Spec.Triggers = append(scaledObject.Spec.Triggers, v1alpha1.ScaleTriggers{
+			Type:     "rabbitmq",
+			Metadata: map[string]string{"hostFromEnv": "RABBITMQ_HOST", "queueName": "tasks", "mode": "QueueLength", "value": "5"},
+		})
+
+		warnings, err := newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(BeEmpty())
+	})
+
+	It("rejects invalid replica counts", func() {
+		scaledObject := newScaledObject("test", "app")
+		min, max := int32(5), int32(2)
+		scaledObject.Spec.MinReplicaCount = &min
+		scaledObject.Spec.MaxReplicaCount = &max
+
+		_, err := newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).To(MatchError("MinReplicaCount=5 must be less than MaxReplicaCount=2"))
+	})
+
+	It("rejects container of cpu trigger missing in the scale target", func() {
+		scaledObject := newScaledObject("test", "app")
+		scaledObject.Spec.Triggers = append(scaledObject.Spec.Triggers, v1alpha1.ScaleTriggers{
+			Type:       "cpu",
+			MetricType: autoscalingv2.UtilizationMetricType,
+			Metadata:   map[string]string{"value": "50", "containerName": "sidecar"},
+		})
+
+		_, err := newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).To(MatchError("trigger 1: container sidecar doesn't exist in the pod template of scale target"))
+
+		scaledObject.Spec.Triggers[1].Metadata["containerName"] = "app"
+		_, err = newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects cpu trigger with activationThreshold as the only trigger of ScaledObject scaling to zero", func() {
+		scaledObject := newScaledObject("test", "app")
+		scaledObject.Spec.Triggers = append(scaledObject.Spec.Triggers, v1alpha1.ScaleTriggers{
+			Type:                "cpu",
+			MetricType:          autoscalingv2.UtilizationMetricType,
+			Metadata:            map[string]string{"value": "50"},
+			ActivationThreshold: "10",
+		})
+
+		// the prometheus trigger activates the scale target from zero replicas
+		_, err := newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+
+		scaledObject.Spec.Triggers = scaledObject.Spec.Triggers[1:]
+		_, err = newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).To(MatchError("cpu/memory triggers with activationThreshold can't activate the scale target from zero replicas, at least one trigger of another type is required"))
+
+		minReplicaCount := int32(1)
+		scaledObject.Spec.MinReplicaCount = &minReplicaCount
+		_, err = newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects invalid trigger metadata", func() {
+		scaledObject := newScaledObject("test", "app")
+		delete(scaledObject.Spec.Triggers[0].Metadata, "query")
+
+		_, err := newValidator(deployment.DeepCopy()).validate(context.Background(), scaledObject)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("trigger 0: error parsing prometheus metadata"))
+	})
+
+	It("warns about triggers which can't be validated", func() {
+		scaledObject := newScaledObject("test", "app")
+		scaledObject.Spec.Triggers[0].AuthenticationRef = &v1alpha1.ScaledObjectAuthRef{Name: "missing"}
+
+		warnings, err := newValidator().validate(context.Background(), scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(HaveLen(2))
+		Expect(warnings[1]).To(ContainSubstring("trigger 0 isn't validated, TriggerAuthentication missing can't be read"))
+	})
+
+	It("rejects scale target of another ScaledObject", func() {
+		other := newScaledObject("other", "app")
+
+		_, err := newValidator(deployment.DeepCopy(), other).validate(context.Background(), newScaledObject("test", "app"))
+		Expect(err).To(MatchError("the scale target app is already scaled by ScaledObject other"))
+
+		// the update of the ScaledObject itself isn't a conflict
+		_, err = newValidator(deployment.DeepCopy(), other).validate(context.Background(), newScaledObject("other", "app"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("rejects scale target of HPA not managed by KEDA", func() {
+		hpa := &autoscalingv1.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"},
+				MaxReplicas:    10,
+			},
+		}
+		_, err := newValidator(deployment.DeepCopy(), hpa).validate(context.Background(), newScaledObject("test", "app"))
+		Expect(err).To(MatchError("the scale target app is already scaled by HorizontalPodAutoscaler app"))
+
+		hpa.Name = "keda-hpa-test"
+		_, err = newValidator(deployment.DeepCopy(), hpa).validate(context.Background(), newScaledObject("test", "app"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("allows updates of metadata of invalid ScaledObject", func() {
+		scaledObject := newScaledObject("test", "app")
+		delete(scaledObject.Spec.Triggers[0].Metadata, "query")
+		oldRaw, err := json.Marshal(scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+		scaledObject.Finalizers = nil
+		scaledObject.Labels = map[string]string{"scaledobject.keda.sh/name": "test"}
+		raw, err := json.Marshal(scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+
+		validator := newValidator(deployment.DeepCopy())
+		request := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Update,
+			Object:    runtime.RawExtension{Raw: raw},
+			OldObject: runtime.RawExtension{Raw: oldRaw},
+		}}
+		Expect(validator.Handle(context.Background(), request).Allowed).To(BeTrue())
+
+		request.Operation = admissionv1.Create
+		Expect(validator.Handle(context.Background(), request).Allowed).To(BeFalse())
+	})
+
+	It("rejects changes of the adoption annotation", func() {
+		scaledObject := newScaledObject("test", "app")
+		oldRaw, err := json.Marshal(scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+		scaledObject.Annotations = map[string]string{kedacontrollerutil.AdoptHPAAnnotation: "app"}
+		raw, err := json.Marshal(scaledObject)
+		Expect(err).ToNot(HaveOccurred())
+
+		validator := newValidator(deployment.DeepCopy())
+		request := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Update,
+			Object:    runtime.RawExtension{Raw: raw},
+			OldObject: runtime.RawExtension{Raw: oldRaw},
+		}}
+		response := validator.Handle(context.Background(), request)
+		Expect(response.Allowed).To(BeFalse())
+		Expect(string(response.Result.Reason)).To(ContainSubstring("annotation can't be changed"))
+
+		// the removal is rejected as well
+		request.Object, request.OldObject = request.OldObject, request.Object
+		Expect(validator.Handle(context.Background(), request).Allowed).To(BeFalse())
+
+		// the annotation of a new ScaledObject is accepted
+		request.Operation = admissionv1.Create
+		request.Object = runtime.RawExtension{Raw: raw}
+		Expect(validator.Handle(context.Background(), request).Allowed).To(BeTrue())
+	})
+})
@@ -7,6 +7,14 @@ import (
 
 const PausedReplicasAnnotation = "autoscaling.keda.sh/paused-replicas"
 
+// PausedAnnotation pauses the autoscaling and keeps the current replica count untouched
+const PausedAnnotation = "autoscaling.keda.sh/paused"
+
+// AdoptHPAAnnotation names an existing HPA which is adopted and managed by the ScaledObject instead of creating a new one,
+// the original HPA is restored once the ScaledObject is deleted. Changing it requires re-creating the ScaledObject,
+// the change is rejected by the admission webhook. Without the webhook, the previous HPA is released on the change.
+const AdoptHPAAnnotation = "autoscaling.keda.sh/adopt-hpa"
+
 type PausedReplicasPredicate struct {
 	predicate.Funcs
 }
@@ -28,3 +36,18 @@ func (PausedReplicasPredicate) Update(e event.UpdateEvent) bool {
 	}
 	return false
 }
+
+// PausedPredicate triggers reconciliation when PausedAnnotation is added, changed or removed
+type PausedPredicate struct {
+	predicate.Funcs
+}
+
+func (PausedPredicate) Update(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+
+	newVal, newOk := e.ObjectNew.GetAnnotations()[PausedAnnotation]
+	oldVal, oldOk := e.ObjectOld.GetAnnotations()[PausedAnnotation]
+	return newOk != oldOk || newVal != oldVal
+}
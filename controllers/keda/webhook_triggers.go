@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keda
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/scalers"
+	"github.com/kedacore/keda/v2/pkg/scaling/prediction"
+	"github.com/kedacore/keda/v2/pkg/scaling/resolver"
+)
+
+// validateTriggers parses the metadata of the triggers offline, without connecting to the scaled services. The credentials
+// are resolved from TriggerAuthentications and the environment of the scale target like by the scale handler, the triggers
+// whose credentials can't be resolved at admission (the referenced objects don't exist yet or are in an external secret
+// store) aren't validated and a warning is returned instead, these are validated by the reconciler once it builds the scalers.
+func validateTriggers(ctx context.Context, kubeClient client.Client, logger logr.Logger, withTriggers *kedav1alpha1.WithTriggers,
+	podTemplateSpec *corev1.PodTemplateSpec, containerName string, globalHTTPTimeout time.Duration) ([]string, error) {
+	var warnings []string
+	var resolvedEnv map[string]string
+	var envErr error
+	if podTemplateSpec != nil {
+		resolvedEnv, envErr = resolver.ResolveContainerEnv(ctx, kubeClient, logger, &podTemplateSpec.Spec, containerName, withTriggers.Namespace)
+	} else {
+		envErr = fmt.Errorf("the scale target isn't available")
+	}
+
+	for i, trigger := range withTriggers.Spec.Triggers {
+		triggerName := trigger.Name
+		if triggerName == "" {
+			triggerName = strconv.Itoa(i)
+		}
+
+		if envErr != nil && usesEnv(trigger) {
+			warnings = append(warnings, fmt.Sprintf("trigger %s isn't validated, its environment can't be resolved: %s", triggerName, envErr))
+			continue
+		}
+		if trigger.AuthenticationRef != nil {
+			if warning := checkAuthRefResolvable(ctx, kubeClient, trigger.AuthenticationRef, withTriggers.Namespace); warning != "" {
+				warnings = append(warnings, fmt.Sprintf("trigger %s isn't validated, %s", triggerName, warning))
+				continue
+			}
+		}
+
+		config := &scalers.ScalerConfig{
+			Name:                withTriggers.Name,
+			Namespace:           withTriggers.Namespace,
+			TriggerMetadata:     trigger.Metadata,
+			ResolvedEnv:         resolvedEnv,
+			GlobalHTTPTimeout:   globalHTTPTimeout,
+			ScalerIndex:         i,
+			MetricType:          trigger.MetricType,
+			ActivationThreshold: trigger.ActivationThreshold,
+		}
+		var err error
+		config.AuthParams, config.PodIdentity, err = resolver.ResolveAuthRefAndPodIdentity(ctx, kubeClient, logger, trigger.AuthenticationRef, podTemplateSpec, withTriggers.Namespace)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("trigger %s isn't validated, its authentication can't be resolved: %s", triggerName, err))
+			continue
+		}
+
+		if err := scalers.ValidateTriggerMetadata(trigger.Type, config); err != nil {
+			return warnings, fmt.Errorf("trigger %s: %s", triggerName, err)
+		}
+		if trigger.Prediction != nil {
+			if _, err := prediction.NewConfig(trigger.Prediction); err != nil {
+				return warnings, fmt.Errorf("trigger %s: %s", triggerName, err)
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// usesEnv returns true if the trigger references the environment of the scale target
+func usesEnv(trigger kedav1alpha1.ScaleTriggers) bool {
+	for key := range trigger.Metadata {
+		if strings.HasSuffix(key, "FromEnv") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkAuthRefResolvable returns the reason why the credentials of the TriggerAuthentication can't be resolved
+// at admission, an empty string is returned if they can be
+func checkAuthRefResolvable(ctx context.Context, kubeClient client.Client, authRef *kedav1alpha1.ScaledObjectAuthRef, namespace string) string {
+	kind := authRef.Kind
+	if kind == "" {
+		kind = "TriggerAuthentication"
+	}
+
+	var spec kedav1alpha1.TriggerAuthenticationSpec
+	switch kind {
+	case "TriggerAuthentication":
+		triggerAuth := &kedav1alpha1.TriggerAuthentication{}
+		if err := kubeClient.Get(ctx, types.NamespacedName{Name: authRef.Name, Namespace: namespace}, triggerAuth); err != nil {
+			return fmt.Sprintf("TriggerAuthentication %s can't be read: %s", authRef.Name, err)
+		}
+		spec = triggerAuth.Spec
+	case "ClusterTriggerAuthentication":
+		triggerAuth := &kedav1alpha1.ClusterTriggerAuthentication{}
+		if err := kubeClient.Get(ctx, types.NamespacedName{Name: authRef.Name}, triggerAuth); err != nil {
+			return fmt.Sprintf("ClusterTriggerAuthentication %s can't be read: %s", authRef.Name, err)
+		}
+		spec = triggerAuth.Spec
+	default:
+		return fmt.Sprintf("unknown trigger auth kind %s", kind)
+	}
+
+	if spec.HashiCorpVault != nil || spec.AzureKeyVault != nil {
+		return fmt.Sprintf("%s %s reads the credentials from an external secret store", kind, authRef.Name)
+	}
+	return ""
+}
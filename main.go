@@ -21,19 +21,28 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
+	"sync"
 	"time"
 
 	apimachineryruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	kedacontrollers "github.com/kedacore/keda/v2/controllers/keda"
+	"github.com/kedacore/keda/v2/pkg/metricsservice"
+	kedaprovider "github.com/kedacore/keda/v2/pkg/provider"
+	"github.com/kedacore/keda/v2/pkg/scaling/prediction"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
 	kedautil "github.com/kedacore/keda/v2/pkg/util"
 	"github.com/kedacore/keda/v2/version"
 	//nolint:gci
@@ -49,6 +58,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(kedav1alpha1.AddToScheme(scheme))
+	utilruntime.Must(metricsv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -62,12 +72,47 @@ func getWatchNamespace() (string, error) {
 	return ns, nil
 }
 
+// getShardIndex returns the shard handled by this replica from the environment, the index isn't derived
+// from the pod name, as the pods of Deployment don't have stable ordinals
+func getShardIndex() (int, error) {
+	const ShardIndexEnvVar = "KEDA_SHARD_INDEX"
+	value, found := os.LookupEnv(ShardIndexEnvVar)
+	if !found || value == "" {
+		return 0, fmt.Errorf("--shard-index or %s must be set with --shards", ShardIndexEnvVar)
+	}
+	index, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s=%s must be an integer", ShardIndexEnvVar, value)
+	}
+	return index, nil
+}
+
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var metricsServiceAddr string
+	var metricsServiceCertDir string
+	var metricsFreshnessWindow time.Duration
+	var scaleLoopMaxConcurrency int
+	var scaleLoopRateLimits string
+	var queryBatchWindow time.Duration
+	var shards int
+	var shardIndex int
+	var enableWebhooks bool
+	var webhooksCertDir string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.StringVar(&metricsServiceAddr, "metrics-service-bind-address", "", "The address the gRPC Metrics Service for KEDA Metrics Server binds to, eg. :9666. If empty, the Metrics Service is disabled.")
+	flag.StringVar(&metricsServiceCertDir, "metrics-service-cert-dir", "/certs", "The directory with tls.crt, tls.key and ca.crt used for mTLS by the Metrics Service.")
+	flag.DurationVar(&metricsFreshnessWindow, "metrics-freshness-window", 0, "For how long are metric values recorded by the scale loop served to KEDA Metrics Server instead of querying the scalers again.")
+	flag.IntVar(&scaleLoopMaxConcurrency, "scale-loop-max-concurrency", 0, "The maximum number of scale loops checking their triggers at the same time. If 0, the number is not limited.")
+	flag.StringVar(&scaleLoopRateLimits, "scale-loop-rate-limits", "", "The maximum number of trigger checks per second for each trigger type, eg. prometheus=50,kafka=10.")
+	flag.DurationVar(&queryBatchWindow, "query-batch-window", 0, "For how long are the metric queries of the Prometheus, RabbitMQ, AWS CloudWatch and Kafka scalers sharing a backend collected before they are executed together, eg. 100ms. The window is started by the first query, which waits for the whole window, so batching helps only the objects polled within the same window, see the keda_scaler_query_batch_size metric. If 0, the queries are not batched.")
+	flag.IntVar(&shards, "shards", 1, "The number of KEDA Operator replicas the ScaledObjects and ScaledJobs are distributed across. Can't be combined with leader election.")
+	flag.IntVar(&shardIndex, "shard-index", -1, "The shard handled by this replica, each replica has to handle a distinct shard. If not set, it is taken from the KEDA_SHARD_INDEX environment variable.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Enable the admission webhooks validating ScaledObjects and ScaledJobs, served on port 9443.")
+	flag.StringVar(&webhooksCertDir, "webhooks-cert-dir", "/certs", "The directory with tls.crt and tls.key used by the admission webhooks server.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -84,6 +129,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	if shards > 1 && enableLeaderElection {
+		setupLog.Error(fmt.Errorf("leader election can't be enabled with %d shards", shards), "invalid sharding configuration")
+		os.Exit(1)
+	}
+
+	var shard *scheduler.Shard
+	if shards > 1 {
+		if shardIndex < 0 {
+			shardIndex, err = getShardIndex()
+			if err != nil {
+				setupLog.Error(err, "failed to get shard index")
+				os.Exit(1)
+			}
+		}
+		shard, err = scheduler.NewShard(shardIndex, shards)
+		if err != nil {
+			setupLog.Error(err, "invalid sharding configuration")
+			os.Exit(1)
+		}
+		setupLog.Info("Sharding scale loops", "shards", shards, "shardIndex", shardIndex)
+	}
+
+	rateLimits, err := scheduler.ParseRateLimits(scaleLoopRateLimits)
+	if err != nil {
+		setupLog.Error(err, "invalid scale loop rate limits")
+		os.Exit(1)
+	}
+	scaleLoopScheduler := scheduler.New(scaleLoopMaxConcurrency, rateLimits)
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -92,6 +166,9 @@ func main() {
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "operator.keda.sh",
 		Namespace:              namespace,
+		CertDir:                webhooksCertDir,
+		// resource metrics API doesn't support watch, usage of pods is always read directly
+		ClientDisableCacheFor: []client.Object{&metricsv1beta1.PodMetrics{}},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -120,20 +197,37 @@ func main() {
 	globalHTTPTimeout := time.Duration(globalHTTPTimeoutMS) * time.Millisecond
 	eventRecorder := mgr.GetEventRecorderFor("keda-operator")
 
-	if err = (&kedacontrollers.ScaledObjectReconciler{
-		Client:            mgr.GetClient(),
-		Scheme:            mgr.GetScheme(),
-		GlobalHTTPTimeout: globalHTTPTimeout,
-		Recorder:          eventRecorder,
-	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: scaledObjectMaxReconciles}); err != nil {
+	// the histories of the triggers with prediction are shared by the scale loops of ScaledObjects and ScaledJobs
+	predictionStore := prediction.NewStore(mgr.GetClient())
+	if err := mgr.Add(predictionStore); err != nil {
+		setupLog.Error(err, "unable to set up prediction store")
+		os.Exit(1)
+	}
+
+	scaledObjectReconciler := &kedacontrollers.ScaledObjectReconciler{
+		Client:                 mgr.GetClient(),
+		Scheme:                 mgr.GetScheme(),
+		GlobalHTTPTimeout:      globalHTTPTimeout,
+		Recorder:               eventRecorder,
+		MetricsFreshnessWindow: metricsFreshnessWindow,
+		ScaleLoopScheduler:     scaleLoopScheduler,
+		QueryBatchWindow:       queryBatchWindow,
+		PredictionStore:        predictionStore,
+		Shard:                  shard,
+	}
+	if err = scaledObjectReconciler.SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: scaledObjectMaxReconciles}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ScaledObject")
 		os.Exit(1)
 	}
 	if err = (&kedacontrollers.ScaledJobReconciler{
-		Client:            mgr.GetClient(),
-		Scheme:            mgr.GetScheme(),
-		GlobalHTTPTimeout: globalHTTPTimeout,
-		Recorder:          eventRecorder,
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+		GlobalHTTPTimeout:  globalHTTPTimeout,
+		Recorder:           eventRecorder,
+		ScaleLoopScheduler: scaleLoopScheduler,
+		QueryBatchWindow:   queryBatchWindow,
+		PredictionStore:    predictionStore,
+		Shard:              shard,
 	}).SetupWithManager(mgr, controller.Options{MaxConcurrentReconciles: scaledJobMaxReconciles}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ScaledJob")
 		os.Exit(1)
@@ -154,8 +248,33 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterTriggerAuthentication")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = (&kedacontrollers.ScaledObjectValidator{
+			Client:            mgr.GetClient(),
+			GlobalHTTPTimeout: globalHTTPTimeout,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScaledObject")
+			os.Exit(1)
+		}
+		if err = (&kedacontrollers.ScaledJobValidator{
+			Client:            mgr.GetClient(),
+			GlobalHTTPTimeout: globalHTTPTimeout,
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ScaledJob")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	ctx := ctrl.SetupSignalHandler()
+	if metricsServiceAddr != "" {
+		metricsProvider := kedaprovider.NewProvider(ctx, setupLog, scaledObjectReconciler.GetScaleHandler(), mgr.GetClient(), scaledObjectReconciler.GetScaleClient(), nil, namespace, &[]provider.ExternalMetricInfo{}, &sync.RWMutex{})
+		if err := mgr.Add(metricsservice.NewGrpcServer(metricsProvider, metricsServiceAddr, metricsServiceCertDir, shard)); err != nil {
+			setupLog.Error(err, "unable to set up Metrics Service gRPC server")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -171,7 +290,7 @@ func main() {
 	setupLog.Info(fmt.Sprintf("Go Version: %s", runtime.Version()))
 	setupLog.Info(fmt.Sprintf("Go OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH))
 
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
@@ -35,6 +35,31 @@ const (
 	// ScaledJobDeleted is for event when ScaledJob is deleted
 	ScaledJobDeleted = "ScaledJobDeleted"
 
+	// ScaledObjectPaused is for event when autoscaling of ScaledObject is paused
+	ScaledObjectPaused = "ScaledObjectPaused"
+
+	// ScaledObjectUnpaused is for event when autoscaling of ScaledObject is resumed
+	ScaledObjectUnpaused = "ScaledObjectUnpaused"
+
+	// ScaledObjectContainerMetricsFallback is for event when ContainerResource metrics of ScaledObject aren't supported
+	// by the cluster and Resource metrics of the whole pod are used instead
+	ScaledObjectContainerMetricsFallback = "ScaledObjectContainerMetricsFallback"
+
+	// ScaledJobPaused is for event when autoscaling of ScaledJob is paused
+	ScaledJobPaused = "ScaledJobPaused"
+
+	// ScaledJobUnpaused is for event when autoscaling of ScaledJob is resumed
+	ScaledJobUnpaused = "ScaledJobUnpaused"
+
+	// ScaledJobFallbackActivated is for event when the failure threshold of ScaledJob triggers is exceeded and fallback is used
+	ScaledJobFallbackActivated = "ScaledJobFallbackActivated"
+
+	// ScaledJobFallbackDeactivated is for event when the triggers of ScaledJob recover and fallback isn't used anymore
+	ScaledJobFallbackDeactivated = "ScaledJobFallbackDeactivated"
+
+	// ScaledJobScalingStrategyFallback is for event when the scaling strategy of ScaledJob isn't valid and the default one is used
+	ScaledJobScalingStrategyFallback = "ScaledJobScalingStrategyFallback"
+
 	// KEDAScalersStarted is for event when scalers watch started for ScaledObject or ScaledJob
 	KEDAScalersStarted = "KEDAScalersStarted"
 
@@ -59,6 +84,12 @@ const (
 	// KEDAJobsCreated is for event when jobs for ScaledJob are created
 	KEDAJobsCreated = "KEDAJobsCreated"
 
+	// KEDAScaleTargetDryRun is for event when the scale target of ScaledObject in dry-run mode would be scaled
+	KEDAScaleTargetDryRun = "KEDAScaleTargetDryRun"
+
+	// KEDAJobsDryRun is for event when jobs for ScaledJob in dry-run mode would be created
+	KEDAJobsDryRun = "KEDAJobsDryRun"
+
 	// TriggerAuthenticationDeleted is for event when a TriggerAuthentication is deleted
 	TriggerAuthenticationDeleted = "TriggerAuthenticationDeleted"
 
@@ -0,0 +1,205 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fallback contains the fallback logic shared by the KEDA Metrics Server (HPA path)
+// and the scale loop of KEDA Operator (0->1 activation path and ScaledJobs), so all of them use the same
+// failure counts stored in the Health status and the same fallback replica count.
+package fallback
+
+import (
+	"fmt"
+
+	v2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
+)
+
+// IsFallbackEnabled returns true if fallback is defined in ScaledObject and the metric is of type AverageValue or Value
+func IsFallbackEnabled(scaledObject *kedav1alpha1.ScaledObject, metricSpec v2.MetricSpec) bool {
+	if scaledObject.Spec.Fallback == nil || metricSpec.External == nil {
+		return false
+	}
+
+	switch metricSpec.External.Target.Type {
+	case v2.AverageValueMetricType, v2.ValueMetricType:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidateFallback checks that fallback parameters are correctly specified
+func ValidateFallback(scaledObject *kedav1alpha1.ScaledObject) bool {
+	fallback := scaledObject.Spec.Fallback
+	if fallback == nil || fallback.FailureThreshold < 0 || fallback.Replicas < 0 {
+		return false
+	}
+
+	switch fallback.Behavior {
+	case "", kedav1alpha1.FallbackBehaviorStatic, kedav1alpha1.FallbackBehaviorCurrentReplicas, kedav1alpha1.FallbackBehaviorCurrentReplicasIfHigher:
+		return true
+	default:
+		return false
+	}
+}
+
+// UpdateHealthStatus records the result of getting the metric in the status,
+// the number of failures is reset on success and increased on error
+func UpdateHealthStatus(status *kedav1alpha1.ScaledObjectStatus, metricName string, err error) kedav1alpha1.HealthStatus {
+	if status.Health == nil {
+		status.Health = make(map[string]kedav1alpha1.HealthStatus)
+	}
+	return updateHealth(status.Health, metricName, err)
+}
+
+// UpdateScaledJobHealthStatus is UpdateHealthStatus for ScaledJob
+func UpdateScaledJobHealthStatus(status *kedav1alpha1.ScaledJobStatus, metricName string, err error) kedav1alpha1.HealthStatus {
+	if status.Health == nil {
+		status.Health = make(map[string]kedav1alpha1.HealthStatus)
+	}
+	return updateHealth(status.Health, metricName, err)
+}
+
+func updateHealth(health map[string]kedav1alpha1.HealthStatus, metricName string, err error) kedav1alpha1.HealthStatus {
+	healthStatus, found := health[metricName]
+	if !found || healthStatus.NumberOfFailures == nil {
+		zero := int32(0)
+		healthStatus = kedav1alpha1.HealthStatus{
+			NumberOfFailures: &zero,
+			Status:           kedav1alpha1.HealthStatusHappy,
+		}
+	}
+
+	failures := *healthStatus.NumberOfFailures
+	if err == nil {
+		failures = 0
+		healthStatus.Status = kedav1alpha1.HealthStatusHappy
+	} else {
+		failures++
+		healthStatus.Status = kedav1alpha1.HealthStatusFailing
+	}
+	healthStatus.NumberOfFailures = &failures
+
+	health[metricName] = healthStatus
+	return healthStatus
+}
+
+// IsFailureThresholdExceeded returns true if the health status has more failures than allowed by the fallback
+func IsFailureThresholdExceeded(scaledObject *kedav1alpha1.ScaledObject, healthStatus kedav1alpha1.HealthStatus) bool {
+	return scaledObject.Spec.Fallback != nil && isFailureThresholdExceeded(scaledObject.Spec.Fallback.FailureThreshold, healthStatus)
+}
+
+func isFailureThresholdExceeded(failureThreshold int32, healthStatus kedav1alpha1.HealthStatus) bool {
+	return healthStatus.Status == kedav1alpha1.HealthStatusFailing &&
+		healthStatus.NumberOfFailures != nil &&
+		*healthStatus.NumberOfFailures > failureThreshold
+}
+
+// IsFallbackActive returns true if at least one metric of the ScaledObject is falling back
+func IsFallbackActive(scaledObject *kedav1alpha1.ScaledObject) bool {
+	if !ValidateFallback(scaledObject) {
+		return false
+	}
+	return isAnyFailureThresholdExceeded(scaledObject.Spec.Fallback.FailureThreshold, scaledObject.Status.Health)
+}
+
+// ValidateScaledJobFallback checks that fallback parameters of ScaledJob are correctly specified
+func ValidateScaledJobFallback(scaledJob *kedav1alpha1.ScaledJob) bool {
+	fallback := scaledJob.Spec.Fallback
+	return fallback != nil && fallback.FailureThreshold >= 0 && fallback.JobCount >= 0
+}
+
+// IsScaledJobFallbackActive returns true if at least one metric of the ScaledJob is falling back
+func IsScaledJobFallbackActive(scaledJob *kedav1alpha1.ScaledJob) bool {
+	if !ValidateScaledJobFallback(scaledJob) {
+		return false
+	}
+	return isAnyFailureThresholdExceeded(scaledJob.Spec.Fallback.FailureThreshold, scaledJob.Status.Health)
+}
+
+func isAnyFailureThresholdExceeded(failureThreshold int32, health map[string]kedav1alpha1.HealthStatus) bool {
+	for _, healthStatus := range health {
+		if isFailureThresholdExceeded(failureThreshold, healthStatus) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsCurrentReplicas returns true if the current replica count of the scale target
+// is needed to compute the fallback metric
+func NeedsCurrentReplicas(scaledObject *kedav1alpha1.ScaledObject, metricSpec v2.MetricSpec) bool {
+	behavior := scaledObject.Spec.Fallback.Behavior
+	return (metricSpec.External != nil && metricSpec.External.Target.Type == v2.ValueMetricType) ||
+		behavior == kedav1alpha1.FallbackBehaviorCurrentReplicas ||
+		behavior == kedav1alpha1.FallbackBehaviorCurrentReplicasIfHigher
+}
+
+// GetFallbackReplicas returns the replica count the scale target should have while falling back
+func GetFallbackReplicas(scaledObject *kedav1alpha1.ScaledObject, currentReplicas int32) int32 {
+	fallbackReplicas := scaledObject.Spec.Fallback.Replicas
+	switch scaledObject.Spec.Fallback.Behavior {
+	case kedav1alpha1.FallbackBehaviorCurrentReplicas:
+		return currentReplicas
+	case kedav1alpha1.FallbackBehaviorCurrentReplicasIfHigher:
+		if currentReplicas > fallbackReplicas {
+			return currentReplicas
+		}
+		return fallbackReplicas
+	default:
+		return fallbackReplicas
+	}
+}
+
+// GetFallbackMetric returns the metric value that makes the HPA scale the target to the fallback replica count,
+// for AverageValue the HPA divides the value by the number of replicas, for Value it compares
+// the value with the target and multiplies the current replica count by the ratio
+func GetFallbackMetric(scaledObject *kedav1alpha1.ScaledObject, metricSpec v2.MetricSpec, metricName string, currentReplicas int32) (external_metrics.ExternalMetricValue, error) {
+	if metricSpec.External == nil {
+		return external_metrics.ExternalMetricValue{}, fmt.Errorf("fallback is supported only for external metrics")
+	}
+	replicas := float64(GetFallbackReplicas(scaledObject, currentReplicas))
+
+	var value float64
+	target := metricSpec.External.Target
+	switch target.Type {
+	case v2.AverageValueMetricType:
+		if target.AverageValue == nil {
+			return external_metrics.ExternalMetricValue{}, fmt.Errorf("metric %s doesn't have averageValue target", metricName)
+		}
+		value = target.AverageValue.AsApproximateFloat64() * replicas
+	case v2.ValueMetricType:
+		if target.Value == nil {
+			return external_metrics.ExternalMetricValue{}, fmt.Errorf("metric %s doesn't have value target", metricName)
+		}
+		// the HPA doesn't scale from 0, the value is computed as if there was one replica
+		if currentReplicas < 1 {
+			currentReplicas = 1
+		}
+		value = target.Value.AsApproximateFloat64() * replicas / float64(currentReplicas)
+	default:
+		return external_metrics.ExternalMetricValue{}, fmt.Errorf("fallback isn't supported for metric type %s", target.Type)
+	}
+
+	return external_metrics.ExternalMetricValue{
+		MetricName: metricName,
+		Value:      *modifiers.QuantityFromFloat(value),
+		Timestamp:  metav1.Now(),
+	}, nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fallback
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+func TestGetFallbackReplicas(t *testing.T) {
+	tests := []struct {
+		behavior        kedav1alpha1.FallbackBehavior
+		currentReplicas int32
+		expected        int32
+	}{
+		{behavior: "", currentReplicas: 7, expected: 5},
+		{behavior: kedav1alpha1.FallbackBehaviorStatic, currentReplicas: 7, expected: 5},
+		{behavior: kedav1alpha1.FallbackBehaviorCurrentReplicas, currentReplicas: 3, expected: 3},
+		{behavior: kedav1alpha1.FallbackBehaviorCurrentReplicasIfHigher, currentReplicas: 3, expected: 5},
+		{behavior: kedav1alpha1.FallbackBehaviorCurrentReplicasIfHigher, currentReplicas: 7, expected: 7},
+	}
+
+	for _, test := range tests {
+		so := &kedav1alpha1.ScaledObject{
+			Spec: kedav1alpha1.ScaledObjectSpec{
+				Fallback: &kedav1alpha1.Fallback{FailureThreshold: 3, Replicas: 5, Behavior: test.behavior},
+			},
+		}
+		assert.Equal(t, test.expected, GetFallbackReplicas(so, test.currentReplicas), "behavior %q", test.behavior)
+	}
+}
+
+func TestGetFallbackMetric(t *testing.T) {
+	so := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			Fallback: &kedav1alpha1.Fallback{FailureThreshold: 3, Replicas: 6},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		target          v2.MetricTarget
+		currentReplicas int32
+		expected        int64
+		isError         bool
+	}{
+		{
+			name:     "average value",
+			target:   v2.MetricTarget{Type: v2.AverageValueMetricType, AverageValue: resource.NewQuantity(10, resource.DecimalSI)},
+			expected: 60,
+		},
+		{
+			name:            "value",
+			target:          v2.MetricTarget{Type: v2.ValueMetricType, Value: resource.NewQuantity(10, resource.DecimalSI)},
+			currentReplicas: 3,
+			expected:        20,
+		},
+		{
+			name:            "value scaled to zero",
+			target:          v2.MetricTarget{Type: v2.ValueMetricType, Value: resource.NewQuantity(10, resource.DecimalSI)},
+			currentReplicas: 0,
+			expected:        60,
+		},
+		{
+			name:    "utilization",
+			target:  v2.MetricTarget{Type: v2.UtilizationMetricType},
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		metricSpec := v2.MetricSpec{External: &v2.ExternalMetricSource{Target: test.target}}
+		metric, err := GetFallbackMetric(so, metricSpec, "metric", test.currentReplicas)
+		if test.isError {
+			assert.Error(t, err, test.name)
+			continue
+		}
+		assert.NoError(t, err, test.name)
+		value, _ := metric.Value.AsInt64()
+		assert.Equal(t, test.expected, value, test.name)
+	}
+}
+
+func TestUpdateHealthStatusAndIsFallbackActive(t *testing.T) {
+	so := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			Fallback: &kedav1alpha1.Fallback{FailureThreshold: 1, Replicas: 5},
+		},
+	}
+
+	UpdateHealthStatus(&so.Status, "metric", errors.New("some error"))
+	assert.False(t, IsFallbackActive(so))
+
+	healthStatus := UpdateHealthStatus(&so.Status, "metric", errors.New("some error"))
+	assert.Equal(t, int32(2), *healthStatus.NumberOfFailures)
+	assert.Equal(t, kedav1alpha1.HealthStatusFailing, healthStatus.Status)
+	assert.True(t, IsFallbackActive(so))
+
+	so.Spec.Fallback.Behavior = "unknown"
+	assert.False(t, IsFallbackActive(so))
+	so.Spec.Fallback.Behavior = ""
+
+	healthStatus = UpdateHealthStatus(&so.Status, "metric", nil)
+	assert.Equal(t, int32(0), *healthStatus.NumberOfFailures)
+	assert.Equal(t, kedav1alpha1.HealthStatusHappy, healthStatus.Status)
+	assert.False(t, IsFallbackActive(so))
+}
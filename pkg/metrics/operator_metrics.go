@@ -0,0 +1,77 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Dry-run decisions recorded by RecordDryRunDecision
+const (
+	// DryRunDecisionScaleLoop is the replica count the scale loop of KEDA would scale the target to
+	DryRunDecisionScaleLoop = "scaleLoop"
+	// DryRunDecisionHPA is the replica count the HPA would scale the target to
+	DryRunDecisionHPA = "hpa"
+	// DryRunDecisionJobs is the number of jobs that would be created
+	DryRunDecisionJobs = "jobs"
+)
+
+var (
+	dryRunDesiredReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "keda_operator",
+			Subsystem: "dry_run",
+			Name:      "desired_replicas",
+			Help:      "Replica count or number of jobs KEDA would scale to if the ScaledObject or ScaledJob wasn't in dry-run mode",
+		},
+		[]string{"namespace", "kind", "name", "decision"},
+	)
+	pollingInterval = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "keda_operator",
+			Subsystem: "scale_loop",
+			Name:      "polling_interval_seconds",
+			Help:      "Effective interval the triggers of ScaledObject or ScaledJob are polled at",
+		},
+		[]string{"namespace", "kind", "name"},
+	)
+)
+
+// metrics of KEDA Operator are served by controller-runtime
+func init() {
+	ctrlmetrics.Registry.MustRegister(dryRunDesiredReplicas)
+	ctrlmetrics.Registry.MustRegister(pollingInterval)
+	ctrlmetrics.Registry.MustRegister(queryBatchSize)
+}
+
+// RecordDryRunDecision records the scaling decision made for ScaledObject or ScaledJob in dry-run mode
+func RecordDryRunDecision(namespace string, kind string, name string, decision string, replicas int64) {
+	dryRunDesiredReplicas.With(prometheus.Labels{"namespace": namespace, "kind": kind, "name": name, "decision": decision}).Set(float64(replicas))
+}
+
+// RecordPollingInterval records the effective polling interval of ScaledObject or ScaledJob
+func RecordPollingInterval(namespace string, kind string, name string, interval time.Duration) {
+	pollingInterval.With(prometheus.Labels{"namespace": namespace, "kind": kind, "name": name}).Set(interval.Seconds())
+}
+
+// DeletePollingInterval deletes the polling interval of ScaledObject or ScaledJob once its scale loop stops
+func DeletePollingInterval(namespace string, kind string, name string) {
+	pollingInterval.Delete(prometheus.Labels{"namespace": namespace, "kind": kind, "name": name})
+}
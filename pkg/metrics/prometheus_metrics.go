@@ -63,6 +63,35 @@ var (
 		},
 		[]string{"namespace", "scaledObject"},
 	)
+	scalerMetricsCacheHits = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "keda_metrics_adapter",
+			Subsystem: "scaler",
+			Name:      "metrics_cache_hits",
+			Help:      "Number of metric values served from the metrics cache",
+		},
+		[]string{"namespace", "scaledObject", "metric"},
+	)
+	scalerMetricsCacheMisses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "keda_metrics_adapter",
+			Subsystem: "scaler",
+			Name:      "metrics_cache_misses",
+			Help:      "Number of metric values not found in the metrics cache or expired",
+		},
+		[]string{"namespace", "scaledObject", "metric"},
+	)
+	// queryBatchSize is served by both KEDA Operator and KEDA Metrics Server, the scalers of both batch the queries
+	queryBatchSize = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "keda",
+			Subsystem: "scaler",
+			Name:      "query_batch_size",
+			Help:      "Number of distinct metric queries executed together by a batch of the scalers sharing a backend",
+			Buckets:   []float64{1, 2, 5, 10, 20, 50, 100},
+		},
+		[]string{"scaler"},
+	)
 )
 
 // PrometheusMetricServer the type of MetricsServer
@@ -76,6 +105,9 @@ func init() {
 	registry.MustRegister(scalerMetricsValue)
 	registry.MustRegister(scalerErrors)
 	registry.MustRegister(scaledObjectErrors)
+	registry.MustRegister(scalerMetricsCacheHits)
+	registry.MustRegister(scalerMetricsCacheMisses)
+	registry.MustRegister(queryBatchSize)
 }
 
 // NewServer creates a new http serving instance of prometheus metrics
@@ -135,6 +167,21 @@ func (metricsServer PrometheusMetricServer) RecordScalerObjectError(namespace st
 	}
 }
 
+// RecordScalerMetricsCache counts hits and misses of the metrics cache
+func (metricsServer PrometheusMetricServer) RecordScalerMetricsCache(namespace string, scaledObject string, metric string, hit bool) {
+	labels := prometheus.Labels{"namespace": namespace, "scaledObject": scaledObject, "metric": metric}
+	if hit {
+		scalerMetricsCacheHits.With(labels).Inc()
+		return
+	}
+	scalerMetricsCacheMisses.With(labels).Inc()
+}
+
 func getLabels(namespace string, scaledObject string, scaler string, scalerIndex int, metric string) prometheus.Labels {
 	return prometheus.Labels{"namespace": namespace, "scaledObject": scaledObject, "scaler": scaler, "scalerIndex": strconv.Itoa(scalerIndex), "metric": metric}
 }
+
+// RecordQueryBatchSize records the number of queries executed by a batch of the scalers of the type
+func RecordQueryBatchSize(scaler string, size int) {
+	queryBatchSize.With(prometheus.Labels{"scaler": scaler}).Observe(float64(size))
+}
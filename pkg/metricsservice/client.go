@@ -0,0 +1,129 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricsservice
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	"github.com/kedacore/keda/v2/pkg/metricsservice/api"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
+)
+
+// shardPlaceholder is replaced by the index of the shard in the address of the Metrics Service of sharded KEDA Operator
+const shardPlaceholder = "{shard}"
+
+// GrpcClient is used by the KEDA Metrics Server to obtain metrics from the KEDA Operator, the requests are
+// routed to the replica of sharded KEDA Operator owning the ScaledObject
+type GrpcClient struct {
+	clients     []api.MetricsServiceClient
+	connections []*grpc.ClientConn
+	// shards maps the ScaledObjects to the replicas of KEDA Operator, nil if it isn't sharded
+	shards *scheduler.Shard
+}
+
+// NewGrpcClient creates a client connected to the Metrics Service served by the KEDA Operator on the address,
+// mTLS certificates are loaded from the certDir
+func NewGrpcClient(address string, certDir string) (*GrpcClient, error) {
+	return NewShardedGrpcClient(address, 1, certDir)
+}
+
+// NewShardedGrpcClient creates a client connected to the Metrics Services served by each of the shards of KEDA Operator,
+// the address of each shard is the address with {shard} replaced by the index of the shard
+func NewShardedGrpcClient(address string, shards int, certDir string) (*GrpcClient, error) {
+	client := &GrpcClient{}
+	if shards > 1 {
+		if !strings.Contains(address, shardPlaceholder) {
+			return nil, fmt.Errorf("metrics service address %s must contain %s with %d shards", address, shardPlaceholder, shards)
+		}
+		ring, err := scheduler.NewShard(0, shards)
+		if err != nil {
+			return nil, err
+		}
+		client.shards = ring
+	}
+
+	for i := 0; i < shards; i++ {
+		conn, err := dial(strings.ReplaceAll(address, shardPlaceholder, strconv.Itoa(i)), certDir)
+		if err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+		client.clients = append(client.clients, api.NewMetricsServiceClient(conn))
+		client.connections = append(client.connections, conn)
+	}
+	return client, nil
+}
+
+// dial connects to the Metrics Service on the address with mTLS certificates loaded from the certDir
+func dial(address string, certDir string) (*grpc.ClientConn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metrics service address %s: %s", address, err)
+	}
+
+	creds, err := loadTLSCredentials(certDir, host, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.Dial(address, grpc.WithTransportCredentials(creds))
+}
+
+// GetMetrics returns metrics for the ScaledObject and metric name obtained from the KEDA Operator
+func (c *GrpcClient) GetMetrics(ctx context.Context, scaledObjectName string, scaledObjectNamespace string, metricName string) (*external_metrics.ExternalMetricValueList, error) {
+	shard := c.shards.OwnerOf(getScaledObjectIdentifier(scaledObjectName, scaledObjectNamespace))
+	response, err := c.clients[shard].GetMetrics(ctx, &api.ScaledObjectRef{Name: scaledObjectName, Namespace: scaledObjectNamespace, MetricName: metricName})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &external_metrics.ExternalMetricValueList{}
+	for _, m := range response.GetMetrics() {
+		value, err := resource.ParseQuantity(m.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("error parsing value of metric %s: %s", m.GetMetricName(), err)
+		}
+		metrics.Items = append(metrics.Items, external_metrics.ExternalMetricValue{
+			MetricName:   m.GetMetricName(),
+			MetricLabels: m.GetMetricLabels(),
+			Timestamp:    metav1.NewTime(time.Unix(m.GetTimestamp(), 0)),
+			Value:        value,
+		})
+	}
+	return metrics, nil
+}
+
+// Close closes the connections to the Metrics Services
+func (c *GrpcClient) Close() error {
+	var lastErr error
+	for _, conn := range c.connections {
+		if err := conn.Close(); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
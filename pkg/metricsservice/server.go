@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricsservice
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/metricsservice/api"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
+)
+
+var log = logf.Log.WithName("grpc_server")
+
+// scaledObjectLabel is the label used by the HPA metric selector to identify the ScaledObject
+const scaledObjectLabel = "scaledobject.keda.sh/name"
+
+// GrpcServer serves metrics of ScaledObjects from the KEDA Operator to the KEDA Metrics Server,
+// so the metrics are obtained from the Operator's scalers cache instead of querying the scalers again
+type GrpcServer struct {
+	api.UnimplementedMetricsServiceServer
+
+	server          *grpc.Server
+	address         string
+	certDir         string
+	metricsProvider provider.ExternalMetricsProvider
+	// shard selects the ScaledObjects whose metrics are served, nil means all of them
+	shard *scheduler.Shard
+}
+
+// NewGrpcServer creates a new instance of GrpcServer, metrics are provided by the metricsProvider.
+// Only metrics of ScaledObjects owned by the shard are served, the scalers of the other ones are run by other replicas
+func NewGrpcServer(metricsProvider provider.ExternalMetricsProvider, address string, certDir string, shard *scheduler.Shard) *GrpcServer {
+	return &GrpcServer{
+		address:         address,
+		certDir:         certDir,
+		metricsProvider: metricsProvider,
+		shard:           shard,
+	}
+}
+
+// GetMetrics returns metrics for the requested ScaledObject and metric name
+func (s *GrpcServer) GetMetrics(ctx context.Context, in *api.ScaledObjectRef) (*api.Response, error) {
+	if identifier := getScaledObjectIdentifier(in.Name, in.Namespace); !s.shard.Owns(identifier) {
+		return nil, status.Errorf(codes.FailedPrecondition, "ScaledObject %s/%s is not owned by this shard of KEDA Operator", in.Namespace, in.Name)
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{scaledObjectLabel: in.Name})
+	metrics, err := s.metricsProvider.GetExternalMetric(ctx, in.Namespace, selector, provider.ExternalMetricInfo{Metric: in.MetricName})
+	if err != nil {
+		log.Error(err, "error getting metric values", "scaledObject.Namespace", in.Namespace, "scaledObject.Name", in.Name, "metricName", in.MetricName)
+		return nil, fmt.Errorf("error getting metric values for ScaledObject %s/%s: %s", in.Namespace, in.Name, err)
+	}
+
+	response := &api.Response{}
+	for _, m := range metrics.Items {
+		response.Metrics = append(response.Metrics, &api.MetricValue{
+			MetricName:   m.MetricName,
+			MetricLabels: m.MetricLabels,
+			Timestamp:    m.Timestamp.Unix(),
+			Value:        m.Value.String(),
+		})
+	}
+	return response, nil
+}
+
+// Start starts the gRPC server and blocks until the context is done, it implements manager.Runnable
+func (s *GrpcServer) Start(ctx context.Context) error {
+	creds, err := loadTLSCredentials(s.certDir, "", true)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %s", s.address, err)
+	}
+
+	s.server = grpc.NewServer(grpc.Creds(creds))
+	api.RegisterMetricsServiceServer(s.server, s)
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info("Starting Metrics Service gRPC Server", "address", s.address)
+		errCh <- s.server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		s.server.GracefulStop()
+		return nil
+	}
+}
+
+// NeedLeaderElection is needed to implement LeaderElectionRunnable interface, the metrics are served
+// only by the leader because it is the one running the scale loops that record metric values. Sharded replicas
+// run without leader election, each of them serves the metrics of the ScaledObjects owned by its shard
+func (s *GrpcServer) NeedLeaderElection() bool {
+	return true
+}
+
+// getScaledObjectIdentifier returns the identifier the ScaledObject is sharded by, see WithTriggers.GenerateIdenitifier
+func getScaledObjectIdentifier(name string, namespace string) string {
+	withTriggers := &kedav1alpha1.WithTriggers{
+		TypeMeta:   metav1.TypeMeta{Kind: "ScaledObject"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+	return withTriggers.GenerateIdenitifier()
+}
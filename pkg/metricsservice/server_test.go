@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricsservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"sigs.k8s.io/custom-metrics-apiserver/pkg/provider"
+
+	"github.com/kedacore/keda/v2/pkg/metricsservice/api"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
+)
+
+type fakeMetricsProvider struct {
+	selector string
+}
+
+func (p *fakeMetricsProvider) GetExternalMetric(ctx context.Context, namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	p.selector = metricSelector.String()
+	if info.Metric == "missing" {
+		return nil, fmt.Errorf("no matching metrics found for %s", info.Metric)
+	}
+	return &external_metrics.ExternalMetricValueList{
+		Items: []external_metrics.ExternalMetricValue{
+			{MetricName: info.Metric, Value: *resource.NewMilliQuantity(1500, resource.DecimalSI)},
+		},
+	}, nil
+}
+
+func (p *fakeMetricsProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
+	return nil
+}
+
+func TestGrpcServerAndClient(t *testing.T) {
+	certDir := t.TempDir()
+	writeTestCertificates(t, certDir)
+
+	address := getFreeAddress(t)
+	metricsProvider := &fakeMetricsProvider{}
+	server := NewGrpcServer(metricsProvider, address, certDir, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = server.Start(ctx)
+	}()
+
+	client, err := NewGrpcClient(address, certDir)
+	assert.NoError(t, err)
+	defer client.Close()
+
+	var metrics *external_metrics.ExternalMetricValueList
+	assert.Eventually(t, func() bool {
+		metrics, err = client.GetMetrics(ctx, "test-so", "test-ns", "s0-queue")
+		return err == nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	assert.Equal(t, "scaledobject.keda.sh/name=test-so", metricsProvider.selector)
+	assert.Len(t, metrics.Items, 1)
+	assert.Equal(t, "s0-queue", metrics.Items[0].MetricName)
+	assert.Equal(t, "1500m", metrics.Items[0].Value.String())
+
+	_, err = client.GetMetrics(ctx, "test-so", "test-ns", "missing")
+	assert.Error(t, err)
+}
+
+func TestGrpcServerRefusesObjectsOfOtherShards(t *testing.T) {
+	shards := make([]*scheduler.Shard, 2)
+	for i := range shards {
+		shard, err := scheduler.NewShard(i, len(shards))
+		assert.NoError(t, err)
+		shards[i] = shard
+	}
+	owner := shards[0].OwnerOf(getScaledObjectIdentifier("test-so", "test-ns"))
+
+	metricsProvider := &fakeMetricsProvider{}
+	ref := &api.ScaledObjectRef{Name: "test-so", Namespace: "test-ns", MetricName: "s0-queue"}
+
+	_, err := NewGrpcServer(metricsProvider, "", "", shards[owner]).GetMetrics(context.Background(), ref)
+	assert.NoError(t, err)
+
+	_, err = NewGrpcServer(metricsProvider, "", "", shards[1-owner]).GetMetrics(context.Background(), ref)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestShardedGrpcClientRequiresShardInAddress(t *testing.T) {
+	_, err := NewShardedGrpcClient("keda-operator.keda.svc:9666", 2, t.TempDir())
+	assert.EqualError(t, err, "metrics service address keda-operator.keda.svc:9666 must contain {shard} with 2 shards")
+}
+
+func TestLoadTLSCredentialsMissingFiles(t *testing.T) {
+	_, err := loadTLSCredentials(t.TempDir(), "", true)
+	assert.Error(t, err)
+}
+
+func getFreeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return fmt.Sprintf("localhost:%d", listener.Addr().(*net.TCPAddr).Port)
+}
+
+// writeTestCertificates creates CA and a certificate for localhost signed by the CA,
+// the same certificate is used by both server and client
+func writeTestCertificates(t *testing.T, certDir string) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePEM(t, path.Join(certDir, caFile), "CERTIFICATE", caDER)
+	writePEM(t, path.Join(certDir, certFile), "CERTIFICATE", certDER)
+	writePEM(t, path.Join(certDir, keyFile), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+func writePEM(t *testing.T, file string, blockType string, bytes []byte) {
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: bytes}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
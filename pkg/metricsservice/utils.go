@@ -0,0 +1,65 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricsservice
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path"
+
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	certFile = "tls.crt"
+	keyFile  = "tls.key"
+	caFile   = "ca.crt"
+)
+
+// loadTLSCredentials loads certificate, key and CA from the certDir and returns mTLS credentials,
+// the server requires and verifies client certificates, the client verifies the server certificate
+func loadTLSCredentials(certDir string, serverName string, server bool) (credentials.TransportCredentials, error) {
+	caCert, err := os.ReadFile(path.Join(certDir, caFile))
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA certificate: %s", err)
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to add CA certificate from %s", path.Join(certDir, caFile))
+	}
+
+	cert, err := tls.LoadX509KeyPair(path.Join(certDir, certFile), path.Join(certDir, keyFile))
+	if err != nil {
+		return nil, fmt.Errorf("error loading certificate and key: %s", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if server {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = certPool
+	} else {
+		config.RootCAs = certPool
+		config.ServerName = serverName
+	}
+
+	return credentials.NewTLS(config), nil
+}
@@ -20,130 +20,84 @@ import (
 	"context"
 	"fmt"
 
-	"k8s.io/api/autoscaling/v2beta2"
-	"k8s.io/apimachinery/pkg/api/resource"
+	v2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/metrics/pkg/apis/external_metrics"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/fallback"
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
 )
 
-func isFallbackEnabled(scaledObject *kedav1alpha1.ScaledObject, metricSpec v2beta2.MetricSpec) bool {
+func isFallbackEnabled(scaledObject *kedav1alpha1.ScaledObject, metricSpec v2.MetricSpec) bool {
 	if scaledObject.Spec.Fallback == nil {
 		return false
 	}
 
-	if metricSpec.External.Target.Type != v2beta2.AverageValueMetricType {
-		logger.V(0).Info("Fallback can only be enabled for triggers with metric of type AverageValue")
+	if !fallback.IsFallbackEnabled(scaledObject, metricSpec) {
+		logger.V(0).Info("Fallback can only be enabled for triggers with metric of type AverageValue or Value")
 		return false
 	}
 
 	return true
 }
 
-func (p *KedaProvider) getMetricsWithFallback(ctx context.Context, metrics []external_metrics.ExternalMetricValue, suppressedError error, metricName string, scaledObject *kedav1alpha1.ScaledObject, metricSpec v2beta2.MetricSpec) ([]external_metrics.ExternalMetricValue, error) {
+func (p *KedaProvider) getMetricsWithFallback(ctx context.Context, metrics []external_metrics.ExternalMetricValue, suppressedError error, metricName string, scaledObject *kedav1alpha1.ScaledObject, metricSpec v2.MetricSpec) ([]external_metrics.ExternalMetricValue, error) {
 	status := scaledObject.Status.DeepCopy()
-
-	initHealthStatus(status)
-	healthStatus := getHealthStatus(status, metricName)
+	healthStatus := fallback.UpdateHealthStatus(status, metricName, suppressedError)
+	p.updateStatus(ctx, scaledObject, status)
 
 	if suppressedError == nil {
-		zero := int32(0)
-		healthStatus.NumberOfFailures = &zero
-		healthStatus.Status = kedav1alpha1.HealthStatusHappy
-		status.Health[metricName] = *healthStatus
-
-		p.updateStatus(ctx, scaledObject, status, metricSpec)
 		return metrics, nil
 	}
 
-	healthStatus.Status = kedav1alpha1.HealthStatusFailing
-	*healthStatus.NumberOfFailures++
-	status.Health[metricName] = *healthStatus
-
-	p.updateStatus(ctx, scaledObject, status, metricSpec)
-
 	switch {
 	case !isFallbackEnabled(scaledObject, metricSpec):
 		return nil, suppressedError
-	case !validateFallback(scaledObject):
-		logger.Info("Failed to validate ScaledObject Spec. Please check that parameters are positive integers")
+	case !fallback.ValidateFallback(scaledObject):
+		logger.Info("Failed to validate ScaledObject Spec. Please check that parameters are positive integers and behavior is valid")
 		return nil, suppressedError
-	case *healthStatus.NumberOfFailures > scaledObject.Spec.Fallback.FailureThreshold:
-		return doFallback(scaledObject, metricSpec, metricName, suppressedError), nil
+	case fallback.IsFailureThresholdExceeded(scaledObject, healthStatus):
+		return p.doFallback(ctx, scaledObject, metricSpec, metricName, suppressedError)
 	default:
 		return nil, suppressedError
 	}
 }
 
-func fallbackExistsInScaledObject(scaledObject *kedav1alpha1.ScaledObject, metricSpec v2beta2.MetricSpec) bool {
-	if !isFallbackEnabled(scaledObject, metricSpec) || !validateFallback(scaledObject) {
-		return false
-	}
-
-	for _, element := range scaledObject.Status.Health {
-		if element.Status == kedav1alpha1.HealthStatusFailing && *element.NumberOfFailures > scaledObject.Spec.Fallback.FailureThreshold {
-			return true
+func (p *KedaProvider) doFallback(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, metricSpec v2.MetricSpec, metricName string, suppressedError error) ([]external_metrics.ExternalMetricValue, error) {
+	var currentReplicas int32
+	if fallback.NeedsCurrentReplicas(scaledObject, metricSpec) {
+		var err error
+		currentReplicas, err = kedautil.GetCurrentReplicas(ctx, p.scaleClient, scaledObject)
+		if err != nil {
+			logger.Error(err, "Failed to get current replicas of the scale target, unable to fall back", "scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+			return nil, suppressedError
 		}
 	}
 
-	return false
-}
-
-func validateFallback(scaledObject *kedav1alpha1.ScaledObject) bool {
-	return scaledObject.Spec.Fallback.FailureThreshold >= 0 &&
-		scaledObject.Spec.Fallback.Replicas >= 0
-}
-
-func doFallback(scaledObject *kedav1alpha1.ScaledObject, metricSpec v2beta2.MetricSpec, metricName string, suppressedError error) []external_metrics.ExternalMetricValue {
-	replicas := int64(scaledObject.Spec.Fallback.Replicas)
-	normalisationValue, _ := metricSpec.External.Target.AverageValue.AsInt64()
-	metric := external_metrics.ExternalMetricValue{
-		MetricName: metricName,
-		Value:      *resource.NewQuantity(normalisationValue*replicas, resource.DecimalSI),
-		Timestamp:  metav1.Now(),
+	metric, err := fallback.GetFallbackMetric(scaledObject, metricSpec, metricName, currentReplicas)
+	if err != nil {
+		logger.Error(err, "Failed to compute fallback metric", "scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+		return nil, suppressedError
 	}
-	fallbackMetrics := []external_metrics.ExternalMetricValue{metric}
 
-	logger.Info(fmt.Sprintf("Suppressing error %s, falling back to %d replicas", suppressedError, replicas))
-	return fallbackMetrics
+	logger.Info(fmt.Sprintf("Suppressing error %s, falling back to %d replicas", suppressedError, fallback.GetFallbackReplicas(scaledObject, currentReplicas)))
+	return []external_metrics.ExternalMetricValue{metric}, nil
 }
 
-func (p *KedaProvider) updateStatus(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, status *kedav1alpha1.ScaledObjectStatus, metricSpec v2beta2.MetricSpec) {
+func (p *KedaProvider) updateStatus(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, status *kedav1alpha1.ScaledObjectStatus) {
 	patch := runtimeclient.MergeFrom(scaledObject.DeepCopy())
 
-	if fallbackExistsInScaledObject(scaledObject, metricSpec) {
-		status.Conditions.SetFallbackCondition(metav1.ConditionTrue, "FallbackExists", "At least one trigger is falling back on this scaled object")
+	scaledObject.Status = *status
+	if fallback.IsFallbackActive(scaledObject) {
+		scaledObject.Status.Conditions.SetFallbackCondition(metav1.ConditionTrue, "FallbackExists", "At least one trigger is falling back on this scaled object")
 	} else {
-		status.Conditions.SetFallbackCondition(metav1.ConditionFalse, "NoFallbackFound", "No fallbacks are active on this scaled object")
+		scaledObject.Status.Conditions.SetFallbackCondition(metav1.ConditionFalse, "NoFallbackFound", "No fallbacks are active on this scaled object")
 	}
 
-	scaledObject.Status = *status
 	err := p.client.Status().Patch(ctx, scaledObject, patch)
 	if err != nil {
 		logger.Error(err, "Failed to patch ScaledObjects Status")
 	}
 }
-
-func getHealthStatus(status *kedav1alpha1.ScaledObjectStatus, metricName string) *kedav1alpha1.HealthStatus {
-	// Get health status for a specific metric
-	_, healthStatusExists := status.Health[metricName]
-	if !healthStatusExists {
-		zero := int32(0)
-		healthStatus := kedav1alpha1.HealthStatus{
-			NumberOfFailures: &zero,
-			Status:           kedav1alpha1.HealthStatusHappy,
-		}
-		status.Health[metricName] = healthStatus
-	}
-	healthStatus := status.Health[metricName]
-	return &healthStatus
-}
-
-func initHealthStatus(status *kedav1alpha1.ScaledObjectStatus) {
-	// Init health status if missing
-	if status.Health == nil {
-		status.Health = make(map[string]kedav1alpha1.HealthStatus)
-	}
-}
@@ -27,7 +27,8 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
-	"k8s.io/api/autoscaling/v2beta2"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/metrics/pkg/apis/external_metrics"
@@ -35,6 +36,7 @@ import (
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/kedacore/keda/v2/pkg/mock/mock_client"
+	"github.com/kedacore/keda/v2/pkg/mock/mock_scale"
 	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
 	"github.com/kedacore/keda/v2/pkg/mock/mock_scaling"
 )
@@ -53,6 +55,7 @@ var _ = Describe("fallback", func() {
 	var (
 		scaleHandler      *mock_scaling.MockScaleHandler
 		client            *mock_client.MockClient
+		scaleClient       *mock_scale.MockScalesGetter
 		providerUnderTest *KedaProvider
 		scaler            *mock_scalers.MockScaler
 		ctrl              *gomock.Controller
@@ -62,8 +65,10 @@ var _ = Describe("fallback", func() {
 		ctrl = gomock.NewController(GinkgoT())
 		scaleHandler = mock_scaling.NewMockScaleHandler(ctrl)
 		client = mock_client.NewMockClient(ctrl)
+		scaleClient = mock_scale.NewMockScalesGetter(ctrl)
 		providerUnderTest = &KedaProvider{
 			client:           client,
+			scaleClient:      scaleClient,
 			scaleHandler:     scaleHandler,
 			watchedNamespace: "",
 		}
@@ -208,10 +213,10 @@ var _ = Describe("fallback", func() {
 		)
 
 		qty := resource.NewQuantity(int64(3), resource.DecimalSI)
-		metricsSpec := v2beta2.MetricSpec{
-			External: &v2beta2.ExternalMetricSource{
-				Target: v2beta2.MetricTarget{
-					Type:  v2beta2.UtilizationMetricType,
+		metricsSpec := v2.MetricSpec{
+			External: &v2.ExternalMetricSource{
+				Target: v2.MetricTarget{
+					Type:  v2.UtilizationMetricType,
 					Value: qty,
 				},
 			},
@@ -351,6 +356,58 @@ var _ = Describe("fallback", func() {
 		condition := so.Status.Conditions.GetFallbackCondition()
 		Expect(condition.IsTrue()).Should(BeFalse())
 	})
+
+	It("should return the fallback metric based on current replicas for value metric type", func() {
+		scaler.EXPECT().GetMetrics(gomock.Any(), gomock.Eq(metricName), gomock.Any()).Return(nil, errors.New("Some error"))
+		startingNumberOfFailures := int32(3)
+
+		so := buildScaledObject(
+			&kedav1alpha1.Fallback{
+				FailureThreshold: int32(3),
+				Replicas:         int32(4),
+				Behavior:         kedav1alpha1.FallbackBehaviorCurrentReplicasIfHigher,
+			},
+			&kedav1alpha1.ScaledObjectStatus{
+				ScaleTargetGVKR: &kedav1alpha1.GroupVersionKindResource{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				},
+				Health: map[string]kedav1alpha1.HealthStatus{
+					metricName: {
+						NumberOfFailures: &startingNumberOfFailures,
+						Status:           kedav1alpha1.HealthStatusFailing,
+					},
+				},
+			},
+		)
+		qty := resource.NewQuantity(int64(10), resource.DecimalSI)
+		metricSpec := v2.MetricSpec{
+			External: &v2.ExternalMetricSource{
+				Target: v2.MetricTarget{
+					Type:  v2.ValueMetricType,
+					Value: qty,
+				},
+			},
+		}
+		expectStatusPatch(ctrl, client)
+		scaleInterface := mock_scale.NewMockScaleInterface(ctrl)
+		scaleClient.EXPECT().Scales(gomock.Any()).Return(scaleInterface)
+		scaleInterface.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(&autoscalingv1.Scale{
+			Spec: autoscalingv1.ScaleSpec{
+				Replicas: 2,
+			},
+		}, nil)
+
+		metrics, err := scaler.GetMetrics(context.Background(), metricName, nil)
+		metrics, err = providerUnderTest.getMetricsWithFallback(context.Background(), metrics, err, metricName, so, metricSpec)
+
+		Expect(err).ToNot(HaveOccurred())
+		value, _ := metrics[0].Value.AsInt64()
+		// the HPA computes 2 * 20 / 10 = 4 replicas
+		Expect(value).Should(Equal(int64(20)))
+		Expect(so.Status.Health[metricName]).To(haveFailureAndStatus(4, kedav1alpha1.HealthStatusFailing))
+	})
 })
 
 func haveFailureAndStatus(numberOfFailures int, status kedav1alpha1.HealthStatusType) types.GomegaMatcher {
@@ -436,12 +493,12 @@ func primeGetMetrics(scaler *mock_scalers.MockScaler, value int64) {
 	scaler.EXPECT().GetMetrics(gomock.Any(), gomock.Eq(metricName), gomock.Any()).Return([]external_metrics.ExternalMetricValue{expectedMetric}, nil)
 }
 
-func createMetricSpec(averageValue int) v2beta2.MetricSpec {
+func createMetricSpec(averageValue int) v2.MetricSpec {
 	qty := resource.NewQuantity(int64(averageValue), resource.DecimalSI)
-	return v2beta2.MetricSpec{
-		External: &v2beta2.ExternalMetricSource{
-			Target: v2beta2.MetricTarget{
-				Type:         v2beta2.AverageValueMetricType,
+	return v2.MetricSpec{
+		External: &v2.ExternalMetricSource{
+			Target: v2.MetricTarget{
+				Type:         v2.AverageValueMetricType,
 				AverageValue: qty,
 			},
 		},
@@ -26,6 +26,7 @@ import (
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/scale"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 	"k8s.io/metrics/pkg/apis/external_metrics"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -33,33 +34,45 @@ import (
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	prommetrics "github.com/kedacore/keda/v2/pkg/metrics"
+	"github.com/kedacore/keda/v2/pkg/metricsservice"
+	"github.com/kedacore/keda/v2/pkg/scalers"
 	"github.com/kedacore/keda/v2/pkg/scaling"
+	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
 )
 
 // KedaProvider implements External Metrics Provider
 type KedaProvider struct {
 	client                  client.Client
+	scaleClient             scale.ScalesGetter
 	scaleHandler            scaling.ScaleHandler
 	watchedNamespace        string
 	ctx                     context.Context
 	externalMetricsInfo     *[]provider.ExternalMetricInfo
 	externalMetricsInfoLock *sync.RWMutex
+	grpcClient              *metricsservice.GrpcClient
 }
 
+// compositeScalerName is used in Prometheus metrics for the composite metric of ScalingModifiers
+const compositeScalerName = "composite"
+
 var (
 	logger        logr.Logger
 	metricsServer prommetrics.PrometheusMetricServer
 )
 
-// NewProvider returns an instance of KedaProvider
-func NewProvider(ctx context.Context, adapterLogger logr.Logger, scaleHandler scaling.ScaleHandler, client client.Client, watchedNamespace string, externalMetricsInfo *[]provider.ExternalMetricInfo, externalMetricsInfoLock *sync.RWMutex) provider.MetricsProvider {
+// NewProvider returns an instance of KedaProvider, if grpcClient is specified
+// the metrics are obtained from the KEDA Operator instead of querying the scalers directly
+func NewProvider(ctx context.Context, adapterLogger logr.Logger, scaleHandler scaling.ScaleHandler, client client.Client, scaleClient scale.ScalesGetter, grpcClient *metricsservice.GrpcClient, watchedNamespace string, externalMetricsInfo *[]provider.ExternalMetricInfo, externalMetricsInfoLock *sync.RWMutex) provider.MetricsProvider {
 	provider := &KedaProvider{
 		client:                  client,
+		scaleClient:             scaleClient,
 		scaleHandler:            scaleHandler,
 		watchedNamespace:        watchedNamespace,
 		ctx:                     ctx,
 		externalMetricsInfo:     externalMetricsInfo,
 		externalMetricsInfoLock: externalMetricsInfoLock,
+		grpcClient:              grpcClient,
 	}
 	logger = adapterLogger.WithName("provider")
 	logger.Info("starting")
@@ -95,6 +108,17 @@ func (p *KedaProvider) GetExternalMetric(ctx context.Context, namespace string,
 	}
 
 	scaledObject := &scaledObjects.Items[0]
+
+	// the KEDA Operator is the single source of truth for metric values, if it is configured
+	if p.grpcClient != nil {
+		metrics, err := p.grpcClient.GetMetrics(ctx, scaledObject.Name, scaledObject.Namespace, info.Metric)
+		if err != nil {
+			logger.Error(err, "error getting metric from KEDA Operator", "scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+			return nil, fmt.Errorf("error getting metric %s from KEDA Operator: %s", info.Metric, err)
+		}
+		return metrics, nil
+	}
+
 	var matchingMetrics []external_metrics.ExternalMetricValue
 
 	cache, err := p.scaleHandler.GetScalersCache(ctx, scaledObject)
@@ -103,11 +127,15 @@ func (p *KedaProvider) GetExternalMetric(ctx context.Context, namespace string,
 		return nil, fmt.Errorf("error when getting scalers %s", err)
 	}
 
+	if scaledObject.HasScalingModifiers() && strings.EqualFold(info.Metric, kedav1alpha1.CompositeMetricName) {
+		return p.getCompositeMetric(ctx, namespace, scaledObject, cache)
+	}
+
 	scalerError := false
 
 	for scalerIndex, scaler := range cache.GetScalers() {
 		metricSpecs := scaler.GetMetricSpecForScaling(ctx)
-		scalerName := strings.Replace(fmt.Sprintf("%T", scaler), "*scalers.", "", 1)
+		scalerName := strings.Replace(fmt.Sprintf("%T", scalers.UnwrapScaler(scaler)), "*scalers.", "", 1)
 
 		for _, metricSpec := range metricSpecs {
 			// skip cpu/memory resource scaler
@@ -153,6 +181,38 @@ func (p *KedaProvider) GetExternalMetric(ctx context.Context, namespace string,
 	}, nil
 }
 
+// getCompositeMetric returns the single metric composed from all named triggers by the ScalingModifiers formula
+func (p *KedaProvider) getCompositeMetric(ctx context.Context, namespace string, scaledObject *kedav1alpha1.ScaledObject, scalersCache *cache.ScalersCache) (*external_metrics.ExternalMetricValueList, error) {
+	metricSpec, err := modifiers.GetCompositeMetricSpec(scaledObject)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []external_metrics.ExternalMetricValue
+	metric, err := scalersCache.GetCompositeMetric(ctx, scaledObject)
+	if err == nil {
+		metrics = []external_metrics.ExternalMetricValue{metric}
+	}
+	metrics, err = p.getMetricsWithFallback(ctx, metrics, err, kedav1alpha1.CompositeMetricName, scaledObject, metricSpec)
+	metricsServer.RecordHPAScalerError(namespace, scaledObject.Name, compositeScalerName, -1, kedav1alpha1.CompositeMetricName, err)
+	if err != nil {
+		logger.Error(err, "error getting composite metric", "scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+		if err := p.scaleHandler.ClearScalersCache(ctx, scaledObject); err != nil {
+			logger.Error(err, "error clearing scalers cache")
+		}
+		return nil, err
+	}
+
+	for _, metric := range metrics {
+		metricValue, _ := metric.Value.AsInt64()
+		metricsServer.RecordHPAScalerMetric(namespace, scaledObject.Name, compositeScalerName, -1, metric.MetricName, metricValue)
+	}
+
+	return &external_metrics.ExternalMetricValueList{
+		Items: metrics,
+	}, nil
+}
+
 // ListAllExternalMetrics returns the supported external metrics for this provider
 func (p *KedaProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
 	logger.V(1).Info("KEDA Metrics Server received request for list of all provided external metrics names")
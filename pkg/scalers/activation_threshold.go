@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+// activationThresholdMetadata is the trigger metadata key that can be used instead of ScaleTriggers.ActivationThreshold
+const activationThresholdMetadata = "activationThreshold"
+
+// activationThresholdTriggers are the triggers whose scaler is active if the value of its only metric is greater
+// than 0, the activation threshold of these is honored by activationThresholdScaler. The triggers which compare
+// the threshold on their own (cpu, memory, kafka and rabbitmq) aren't listed, the activation threshold isn't
+// supported by the rest of the triggers, eg. cron or the triggers whose metric is limited by the number of partitions
+var activationThresholdTriggers = map[string]bool{
+	"activemq":               true,
+	"artemis-queue":          true,
+	"aws-dynamodb":           true,
+	"aws-kinesis-stream":     true,
+	"aws-sqs-queue":          true,
+	"azure-app-insights":     true,
+	"azure-blob":             true,
+	"azure-data-explorer":    true,
+	"azure-log-analytics":    true,
+	"azure-monitor":          true,
+	"azure-pipelines":        true,
+	"azure-queue":            true,
+	"azure-servicebus":       true,
+	"cassandra":              true,
+	"datadog":                true,
+	"elasticsearch":          true,
+	"gcp-stackdriver":        true,
+	"gcp-storage":            true,
+	"graphite":               true,
+	"ibmmq":                  true,
+	"influxdb":               true,
+	"kubernetes-workload":    true,
+	"metrics-api":            true,
+	"mongodb":                true,
+	"mssql":                  true,
+	"mysql":                  true,
+	"new-relic":              true,
+	"openstack-metric":       true,
+	"openstack-swift":        true,
+	"postgresql":             true,
+	"prometheus":             true,
+	"redis":                  true,
+	"redis-cluster":          true,
+	"redis-cluster-streams":  true,
+	"redis-sentinel":         true,
+	"redis-sentinel-streams": true,
+	"redis-streams":          true,
+	"selenium-grid":          true,
+	"stan":                   true,
+}
+
+// ownActivationThresholdTriggers are the triggers whose scaler compares the activation threshold in its own IsActive
+var ownActivationThresholdTriggers = map[string]bool{
+	"cpu":      true,
+	"memory":   true,
+	"kafka":    true,
+	"rabbitmq": true,
+}
+
+// activationThresholdScaler decorates the scaler of any of activationThresholdTriggers and replaces its own
+// IsActive decision by comparing the metric value with the configured activation threshold.
+// This way 0->1 activation is decoupled from the scaling target used by the HPA.
+type activationThresholdScaler struct {
+	Scaler
+	threshold float64
+}
+
+// activationThresholdPushScaler is activationThresholdScaler for PushScaler, so the Run method is kept
+type activationThresholdPushScaler struct {
+	activationThresholdScaler
+	pushScaler PushScaler
+}
+
+// GetActivationThreshold returns the activation threshold specified for the trigger,
+// the second return value is false if no threshold is specified
+func GetActivationThreshold(config *ScalerConfig) (float64, bool, error) {
+	value := config.ActivationThreshold
+	if value == "" {
+		value = config.TriggerMetadata[activationThresholdMetadata]
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+
+	threshold, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("error parsing activationThreshold: %s", err)
+	}
+	if threshold < 0 {
+		return 0, false, fmt.Errorf("activationThreshold=%s must be a non-negative number", value)
+	}
+	return threshold, true, nil
+}
+
+// validateActivationThreshold returns an error if the activation threshold defined for the trigger is invalid
+// or isn't supported by the trigger
+func validateActivationThreshold(triggerType string, config *ScalerConfig) error {
+	_, found, err := GetActivationThreshold(config)
+	if err != nil || !found {
+		return err
+	}
+	if !activationThresholdTriggers[triggerType] && !ownActivationThresholdTriggers[triggerType] {
+		return fmt.Errorf("activationThreshold isn't supported by %s trigger", triggerType)
+	}
+	return nil
+}
+
+// WithActivationThreshold wraps the scaler so it honors the activation threshold defined for the trigger,
+// the scaler is returned unchanged if there isn't any threshold defined or the scaler compares it on its own
+func WithActivationThreshold(scaler Scaler, triggerType string, config *ScalerConfig) (Scaler, error) {
+	if err := validateActivationThreshold(triggerType, config); err != nil {
+		return scaler, err
+	}
+	threshold, found, err := GetActivationThreshold(config)
+	if err != nil || !found || !activationThresholdTriggers[triggerType] {
+		return scaler, err
+	}
+
+	s := activationThresholdScaler{
+		Scaler:    scaler,
+		threshold: threshold,
+	}
+	if ps, ok := scaler.(PushScaler); ok {
+		return &activationThresholdPushScaler{activationThresholdScaler: s, pushScaler: ps}, nil
+	}
+	return &s, nil
+}
+
+// IsActive returns true if the metric value is greater than the activation threshold
+func (s *activationThresholdScaler) IsActive(ctx context.Context) (bool, error) {
+	isActive, _, err := s.isActiveWithMetrics(ctx)
+	return isActive, err
+}
+
+// isActiveWithMetrics compares the metric value with the activation threshold and returns the metric values
+// it was decided on, these are nil if the decision is left to the decorated scaler
+func (s *activationThresholdScaler) isActiveWithMetrics(ctx context.Context) (bool, []external_metrics.ExternalMetricValue, error) {
+	metricSpecs := s.Scaler.GetMetricSpecForScaling(ctx)
+	// the decision is left to the scaler if it doesn't expose any external metric
+	if len(metricSpecs) < 1 || metricSpecs[0].External == nil {
+		isActive, err := s.Scaler.IsActive(ctx)
+		return isActive, nil, err
+	}
+
+	metricName := metricSpecs[0].External.Metric.Name
+	metrics, err := s.Scaler.GetMetrics(ctx, metricName, nil)
+	if err != nil {
+		return false, nil, err
+	}
+
+	var value float64
+	for _, m := range metrics {
+		if m.MetricName == metricName {
+			value += m.Value.AsApproximateFloat64()
+		}
+	}
+	return value > s.threshold, metrics, nil
+}
+
+// IsActiveWithMetrics returns the result of IsActive of the scaler together with the values of its first metric,
+// if the activity was decided on them, so the caller doesn't need to query the scaler for the metric again.
+// The returned metrics are nil if the scaler decided on its own, eg. it isn't decorated by WithActivationThreshold
+func IsActiveWithMetrics(ctx context.Context, scaler Scaler) (bool, []external_metrics.ExternalMetricValue, error) {
+	if s, ok := scaler.(interface {
+		isActiveWithMetrics(context.Context) (bool, []external_metrics.ExternalMetricValue, error)
+	}); ok {
+		return s.isActiveWithMetrics(ctx)
+	}
+	isActive, err := scaler.IsActive(ctx)
+	return isActive, nil, err
+}
+
+// Run forwards the push notifications of the wrapped PushScaler
+func (s *activationThresholdPushScaler) Run(ctx context.Context, active chan<- bool) {
+	s.pushScaler.Run(ctx, active)
+}
+
+// Unwrap returns the decorated scaler
+func (s *activationThresholdScaler) Unwrap() Scaler {
+	return s.Scaler
+}
+
+// UnwrapScaler returns the underlying scaler if the scaler is decorated, eg. by WithActivationThreshold
+func UnwrapScaler(scaler Scaler) Scaler {
+	for {
+		wrapper, ok := scaler.(interface{ Unwrap() Scaler })
+		if !ok {
+			return scaler
+		}
+		scaler = wrapper.Unwrap()
+	}
+}
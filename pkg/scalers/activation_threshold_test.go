@@ -0,0 +1,219 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scalers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+type fakeMetricScaler struct {
+	value    int64
+	resource bool
+}
+
+func (s *fakeMetricScaler) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
+	return []external_metrics.ExternalMetricValue{{MetricName: metricName, Value: *resource.NewQuantity(s.value, resource.DecimalSI)}}, nil
+}
+
+func (s *fakeMetricScaler) GetMetricSpecForScaling(ctx context.Context) []v2.MetricSpec {
+	if s.resource {
+		return []v2.MetricSpec{{Type: v2.ResourceMetricSourceType, Resource: &v2.ResourceMetricSource{Name: "cpu"}}}
+	}
+	return []v2.MetricSpec{{Type: v2.ExternalMetricSourceType, External: &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{Name: "s0-fake"},
+		Target: GetMetricTarget(v2.AverageValueMetricType, 10),
+	}}}
+}
+
+// IsActive mimics most of the scalers, ie. the trigger is active for any value greater than 0
+func (s *fakeMetricScaler) IsActive(ctx context.Context) (bool, error) {
+	return s.value > 0, nil
+}
+
+func (s *fakeMetricScaler) Close(context.Context) error {
+	return nil
+}
+
+type fakeMetricPushScaler struct {
+	fakeMetricScaler
+}
+
+func (s *fakeMetricPushScaler) Run(ctx context.Context, active chan<- bool) {
+	active <- true
+	close(active)
+}
+
+type parseActivationThresholdTestData struct {
+	triggerThreshold  string
+	metadataThreshold string
+	expected          float64
+	found             bool
+	isError           bool
+}
+
+var testActivationThresholdParsing = []parseActivationThresholdTestData{
+	// not specified
+	{found: false},
+	// specified on the trigger
+	{triggerThreshold: "50", expected: 50, found: true},
+	// specified in the trigger metadata
+	{metadataThreshold: "2.5", expected: 2.5, found: true},
+	// trigger field takes precedence over the metadata
+	{triggerThreshold: "5", metadataThreshold: "50", expected: 5, found: true},
+	// invalid values
+	{triggerThreshold: "abc", isError: true},
+	{metadataThreshold: "-1", isError: true},
+}
+
+type activationThresholdTriggerTestData struct {
+	triggerType string
+	decorated   bool
+	isError     bool
+}
+
+var testActivationThresholdTriggers = []activationThresholdTriggerTestData{
+	// the activity is decided by the value of the only metric
+	{triggerType: "prometheus", decorated: true},
+	{triggerType: "redis-streams", decorated: true},
+	// the scaler compares the threshold on its own
+	{triggerType: "cpu"},
+	{triggerType: "kafka"},
+	{triggerType: "rabbitmq"},
+	// the activity isn't decided by the value of a single metric
+	{triggerType: "cron", isError: true},
+	{triggerType: "external", isError: true},
+	{triggerType: "azure-eventhub", isError: true},
+	{triggerType: "solace-event-queue", isError: true},
+}
+
+type activationThresholdTestData struct {
+	value     int64
+	threshold string
+	resource  bool
+	isActive  bool
+}
+
+var testActivationThresholds = []activationThresholdTestData{
+	// no threshold keeps the scaler's own decision
+	{value: 1, isActive: true},
+	{value: 0, isActive: false},
+	// the threshold has to be exceeded
+	{value: 10, threshold: "50", isActive: false},
+	{value: 50, threshold: "50", isActive: false},
+	{value: 51, threshold: "50", isActive: true},
+	// zero threshold behaves like the default
+	{value: 1, threshold: "0", isActive: true},
+	// resource metrics keep the scaler's own decision
+	{value: 1, threshold: "50", resource: true, isActive: true},
+}
+
+func TestGetActivationThreshold(t *testing.T) {
+	for _, testData := range testActivationThresholdParsing {
+		config := &ScalerConfig{
+			ActivationThreshold: testData.triggerThreshold,
+			TriggerMetadata:     map[string]string{},
+		}
+		if testData.metadataThreshold != "" {
+			config.TriggerMetadata[activationThresholdMetadata] = testData.metadataThreshold
+		}
+
+		threshold, found, err := GetActivationThreshold(config)
+		if testData.isError {
+			if err == nil {
+				t.Errorf("Expected error for %+v", testData)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected success for %+v, got %s", testData, err)
+		}
+		if found != testData.found || threshold != testData.expected {
+			t.Errorf("Expected threshold %v (found=%v), got %v (found=%v)", testData.expected, testData.found, threshold, found)
+		}
+	}
+}
+
+func TestWithActivationThreshold(t *testing.T) {
+	for _, testData := range testActivationThresholds {
+		config := &ScalerConfig{
+			ActivationThreshold: testData.threshold,
+			TriggerMetadata:     map[string]string{},
+		}
+		scaler, err := WithActivationThreshold(&fakeMetricScaler{value: testData.value, resource: testData.resource}, "prometheus", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		isActive, err := scaler.IsActive(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isActive != testData.isActive {
+			t.Errorf("Expected isActive %v for %+v, got %v", testData.isActive, testData, isActive)
+		}
+		if _, ok := UnwrapScaler(scaler).(*fakeMetricScaler); !ok {
+			t.Errorf("Expected UnwrapScaler to return the decorated scaler, got %T", UnwrapScaler(scaler))
+		}
+	}
+}
+
+func TestWithActivationThresholdKeepsPushScaler(t *testing.T) {
+	config := &ScalerConfig{ActivationThreshold: "50"}
+	scaler, err := WithActivationThreshold(&fakeMetricPushScaler{fakeMetricScaler{value: 10}}, "redis", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pushScaler, ok := scaler.(PushScaler)
+	if !ok {
+		t.Fatalf("Expected PushScaler, got %T", scaler)
+	}
+
+	active := make(chan bool, 1)
+	pushScaler.Run(context.Background(), active)
+	if !<-active {
+		t.Error("Expected push notification to be forwarded")
+	}
+	if isActive, _ := pushScaler.IsActive(context.Background()); isActive {
+		t.Error("Expected push scaler to honor the activation threshold")
+	}
+}
+
+func TestWithActivationThresholdTriggers(t *testing.T) {
+	for _, testData := range testActivationThresholdTriggers {
+		scaler := &fakeMetricScaler{value: 10}
+		wrapped, err := WithActivationThreshold(scaler, testData.triggerType, &ScalerConfig{ActivationThreshold: "50"})
+		if testData.isError {
+			assert.Error(t, err, testData.triggerType)
+			continue
+		}
+		assert.NoError(t, err, testData.triggerType)
+		assert.Equal(t, testData.decorated, wrapped != Scaler(scaler), testData.triggerType)
+
+		// the scaler is never decorated without a threshold
+		wrapped, err = WithActivationThreshold(scaler, testData.triggerType, &ScalerConfig{})
+		assert.NoError(t, err, testData.triggerType)
+		assert.Equal(t, Scaler(scaler), wrapped, testData.triggerType)
+	}
+}
@@ -3,6 +3,7 @@ package scalers
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,7 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
-	"k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -27,12 +28,19 @@ const (
 	defaultMetricStat           = "Average"
 	defaultMetricStatPeriod     = 300
 	defaultMetricEndTimeOffset  = 0
+
+	// maxCloudwatchMetricDataQueries is the maximal number of queries of a GetMetricData call
+	maxCloudwatchMetricDataQueries = 500
 )
 
 type awsCloudwatchScaler struct {
-	metricType v2beta2.MetricTargetType
+	metricType v2.MetricTargetType
 	metadata   *awsCloudwatchMetadata
 	cwClient   cloudwatchiface.CloudWatchAPI
+	// batcher coalesces the queries of the scalers with the same credentials and query window,
+	// nil means the queries aren't batched
+	batcher   *queryBatcher
+	releaseFn func() error
 }
 
 type awsCloudwatchMetadata struct {
@@ -72,11 +80,43 @@ func NewAwsCloudwatchScaler(config *ScalerConfig) (Scaler, error) {
 		return nil, fmt.Errorf("error parsing cloudwatch metadata: %s", err)
 	}
 
-	return &awsCloudwatchScaler{
+	s := &awsCloudwatchScaler{
 		metricType: metricType,
 		metadata:   meta,
 		cwClient:   createCloudwatchClient(meta),
-	}, nil
+	}
+	if config.QueryBatchWindow > 0 {
+		executor := s.newBatchExecutor()
+		s.batcher, s.releaseFn, err = getSharedQueryBatcher("aws-cloudwatch", executor.batcherKey(), config.QueryBatchWindow, config.GlobalHTTPTimeout, executor.getCloudwatchMetricsBatch)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// newBatchExecutor returns the scaler executing the batched queries, it is configured by the credentials
+// and the query window only, so it doesn't depend on the query of the scaler creating the batcher
+func (c *awsCloudwatchScaler) newBatchExecutor() *awsCloudwatchScaler {
+	return &awsCloudwatchScaler{
+		metadata: &awsCloudwatchMetadata{
+			awsRegion:            c.metadata.awsRegion,
+			awsAuthorization:     c.metadata.awsAuthorization,
+			metricStatPeriod:     c.metadata.metricStatPeriod,
+			metricEndTimeOffset:  c.metadata.metricEndTimeOffset,
+			metricCollectionTime: c.metadata.metricCollectionTime,
+		},
+		cwClient: c.cwClient,
+	}
+}
+
+// batcherKey identifies the credentials and the query window, the scalers with the same key can share the batcher
+// executing the queries by the client of any of them
+func (c *awsCloudwatchScaler) batcherKey() string {
+	auth := c.metadata.awsAuthorization
+	return connectionKey("aws-cloudwatch-batch", c.metadata.awsRegion, strconv.FormatBool(auth.podIdentityOwner), auth.awsRoleArn,
+		auth.awsAccessKeyID, auth.awsSecretAccessKey, auth.awsSessionToken, strconv.FormatInt(c.metadata.metricStatPeriod, 10),
+		strconv.FormatInt(c.metadata.metricEndTimeOffset, 10), strconv.FormatInt(c.metadata.metricCollectionTime, 10))
 }
 
 func getIntMetadataValue(metadata map[string]string, key string, required bool, defaultValue int64) (int64, error) {
@@ -270,7 +310,7 @@ func computeQueryWindow(current time.Time, metricPeriodSec, metricEndTimeOffsetS
 }
 
 func (c *awsCloudwatchScaler) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
-	metricValue, err := c.GetCloudwatchMetrics()
+	metricValue, err := c.GetCloudwatchMetrics(ctx)
 
 	if err != nil {
 		cloudwatchLog.Error(err, "Error getting metric value")
@@ -286,7 +326,7 @@ func (c *awsCloudwatchScaler) GetMetrics(ctx context.Context, metricName string,
 	return append([]external_metrics.ExternalMetricValue{}, metric), nil
 }
 
-func (c *awsCloudwatchScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
+func (c *awsCloudwatchScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
 	var metricNameSuffix string
 
 	if c.metadata.expression != "" {
@@ -295,18 +335,18 @@ func (c *awsCloudwatchScaler) GetMetricSpecForScaling(context.Context) []v2beta2
 		metricNameSuffix = c.metadata.dimensionName[0]
 	}
 
-	externalMetric := &v2beta2.ExternalMetricSource{
-		Metric: v2beta2.MetricIdentifier{
+	externalMetric := &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{
 			Name: GenerateMetricNameWithIndex(c.metadata.scalerIndex, kedautil.NormalizeString(fmt.Sprintf("aws-cloudwatch-%s", metricNameSuffix))),
 		},
 		Target: GetMetricTarget(c.metricType, c.metadata.targetMetricValue),
 	}
-	metricSpec := v2beta2.MetricSpec{External: externalMetric, Type: externalMetricType}
-	return []v2beta2.MetricSpec{metricSpec}
+	metricSpec := v2.MetricSpec{External: externalMetric, Type: externalMetricType}
+	return []v2.MetricSpec{metricSpec}
 }
 
 func (c *awsCloudwatchScaler) IsActive(ctx context.Context) (bool, error) {
-	val, err := c.GetCloudwatchMetrics()
+	val, err := c.GetCloudwatchMetrics(ctx)
 
 	if err != nil {
 		return false, err
@@ -316,76 +356,89 @@ func (c *awsCloudwatchScaler) IsActive(ctx context.Context) (bool, error) {
 }
 
 func (c *awsCloudwatchScaler) Close(context.Context) error {
+	if c.releaseFn != nil {
+		return c.releaseFn()
+	}
 	return nil
 }
 
-func (c *awsCloudwatchScaler) GetCloudwatchMetrics() (int64, error) {
-	var input cloudwatch.GetMetricDataInput
+// getMetricDataQuery returns the query of the metric of the scaler
+func (c *awsCloudwatchScaler) getMetricDataQuery() *cloudwatch.MetricDataQuery {
+	if c.metadata.expression != "" {
+		return &cloudwatch.MetricDataQuery{
+			Expression: aws.String(c.metadata.expression),
+			Id:         aws.String("q1"),
+			Period:     aws.Int64(c.metadata.metricStatPeriod),
+			Label:      aws.String(c.metadata.metricsName),
+		}
+	}
 
-	startTime, endTime := computeQueryWindow(time.Now(), c.metadata.metricStatPeriod, c.metadata.metricEndTimeOffset, c.metadata.metricCollectionTime)
+	dimensions := []*cloudwatch.Dimension{}
+	for i := range c.metadata.dimensionName {
+		dimensions = append(dimensions, &cloudwatch.Dimension{
+			Name:  &c.metadata.dimensionName[i],
+			Value: &c.metadata.dimensionValue[i],
+		})
+	}
 
-	if c.metadata.expression != "" {
-		input = cloudwatch.GetMetricDataInput{
-			StartTime: aws.Time(startTime),
-			EndTime:   aws.Time(endTime),
-			ScanBy:    aws.String(cloudwatch.ScanByTimestampDescending),
-			MetricDataQueries: []*cloudwatch.MetricDataQuery{
-				{
-					Expression: aws.String(c.metadata.expression),
-					Id:         aws.String("q1"),
-					Period:     aws.Int64(c.metadata.metricStatPeriod),
-					Label:      aws.String(c.metadata.metricsName),
-				},
+	var metricUnit *string
+	if c.metadata.metricUnit != "" {
+		metricUnit = aws.String(c.metadata.metricUnit)
+	}
+
+	return &cloudwatch.MetricDataQuery{
+		Id: aws.String("c1"),
+		MetricStat: &cloudwatch.MetricStat{
+			Metric: &cloudwatch.Metric{
+				Namespace:  aws.String(c.metadata.namespace),
+				Dimensions: dimensions,
+				MetricName: aws.String(c.metadata.metricsName),
 			},
+			Period: aws.Int64(c.metadata.metricStatPeriod),
+			Stat:   aws.String(c.metadata.metricStat),
+			Unit:   metricUnit,
+		},
+		ReturnData: aws.Bool(true),
+	}
+}
+
+func (c *awsCloudwatchScaler) GetCloudwatchMetrics(ctx context.Context) (int64, error) {
+	query := c.getMetricDataQuery()
+
+	var value *float64
+	if c.batcher != nil {
+		// the query is identified by its content, the id is assigned by the batch
+		result, err := c.batcher.query(ctx, query.String(), query)
+		if err != nil {
+			cloudwatchLog.Error(err, "Failed to get output")
+			return -1, err
 		}
+		value = result.(*float64)
 	} else {
-		dimensions := []*cloudwatch.Dimension{}
-		for i := range c.metadata.dimensionName {
-			dimensions = append(dimensions, &cloudwatch.Dimension{
-				Name:  &c.metadata.dimensionName[i],
-				Value: &c.metadata.dimensionValue[i],
-			})
+		startTime, endTime := computeQueryWindow(time.Now(), c.metadata.metricStatPeriod, c.metadata.metricEndTimeOffset, c.metadata.metricCollectionTime)
+		input := cloudwatch.GetMetricDataInput{
+			StartTime:         aws.Time(startTime),
+			EndTime:           aws.Time(endTime),
+			ScanBy:            aws.String(cloudwatch.ScanByTimestampDescending),
+			MetricDataQueries: []*cloudwatch.MetricDataQuery{query},
 		}
 
-		var metricUnit *string
-		if c.metadata.metricUnit != "" {
-			metricUnit = aws.String(c.metadata.metricUnit)
-		}
+		output, err := c.cwClient.GetMetricData(&input)
 
-		input = cloudwatch.GetMetricDataInput{
-			StartTime: aws.Time(startTime),
-			EndTime:   aws.Time(endTime),
-			ScanBy:    aws.String(cloudwatch.ScanByTimestampDescending),
-			MetricDataQueries: []*cloudwatch.MetricDataQuery{
-				{
-					Id: aws.String("c1"),
-					MetricStat: &cloudwatch.MetricStat{
-						Metric: &cloudwatch.Metric{
-							Namespace:  aws.String(c.metadata.namespace),
-							Dimensions: dimensions,
-							MetricName: aws.String(c.metadata.metricsName),
-						},
-						Period: aws.Int64(c.metadata.metricStatPeriod),
-						Stat:   aws.String(c.metadata.metricStat),
-						Unit:   metricUnit,
-					},
-					ReturnData: aws.Bool(true),
-				},
-			},
+		if err != nil {
+			cloudwatchLog.Error(err, "Failed to get output")
+			return -1, err
 		}
-	}
 
-	output, err := c.cwClient.GetMetricData(&input)
-
-	if err != nil {
-		cloudwatchLog.Error(err, "Failed to get output")
-		return -1, err
+		cloudwatchLog.V(1).Info("Received Metric Data", "data", output)
+		if len(output.MetricDataResults) > 0 && len(output.MetricDataResults[0].Values) > 0 {
+			value = output.MetricDataResults[0].Values[0]
+		}
 	}
 
-	cloudwatchLog.V(1).Info("Received Metric Data", "data", output)
 	var metricValue int64
-	if len(output.MetricDataResults) > 0 && len(output.MetricDataResults[0].Values) > 0 {
-		metricValue = int64(*output.MetricDataResults[0].Values[0])
+	if value != nil {
+		metricValue = int64(*value)
 	} else {
 		cloudwatchLog.Info("empty metric data received, returning minMetricValue")
 		metricValue = c.metadata.minMetricValue
@@ -393,3 +446,52 @@ func (c *awsCloudwatchScaler) GetCloudwatchMetrics() (int64, error) {
 
 	return metricValue, nil
 }
+
+// getCloudwatchMetricsBatch executes the queries of the batch by as few GetMetricData calls as possible, the result
+// of each query is its latest value or nil if there is no value in the query window
+func (c *awsCloudwatchScaler) getCloudwatchMetricsBatch(ctx context.Context, queries map[string]interface{}) map[string]batchResult {
+	startTime, endTime := computeQueryWindow(time.Now(), c.metadata.metricStatPeriod, c.metadata.metricEndTimeOffset, c.metadata.metricCollectionTime)
+
+	keys := make([]string, 0, len(queries))
+	for key := range queries {
+		keys = append(keys, key)
+	}
+
+	results := make(map[string]batchResult, len(queries))
+	for chunkStart := 0; chunkStart < len(keys); chunkStart += maxCloudwatchMetricDataQueries {
+		chunkEnd := chunkStart + maxCloudwatchMetricDataQueries
+		if chunkEnd > len(keys) {
+			chunkEnd = len(keys)
+		}
+		chunk := keys[chunkStart:chunkEnd]
+
+		input := cloudwatch.GetMetricDataInput{
+			StartTime: aws.Time(startTime),
+			EndTime:   aws.Time(endTime),
+			ScanBy:    aws.String(cloudwatch.ScanByTimestampDescending),
+		}
+		keysByID := make(map[string]string, len(chunk))
+		for i, key := range chunk {
+			query := *queries[key].(*cloudwatch.MetricDataQuery)
+			id := fmt.Sprintf("q%d", i)
+			query.Id = aws.String(id)
+			input.MetricDataQueries = append(input.MetricDataQueries, &query)
+			keysByID[id] = key
+		}
+
+		values := map[string]*float64{}
+		err := c.cwClient.GetMetricDataPagesWithContext(ctx, &input, func(output *cloudwatch.GetMetricDataOutput, _ bool) bool {
+			for _, result := range output.MetricDataResults {
+				// the values are sorted from the latest one, the first page has the latest values
+				if _, ok := values[*result.Id]; !ok && len(result.Values) > 0 {
+					values[*result.Id] = result.Values[0]
+				}
+			}
+			return true
+		})
+		for id, key := range keysByID {
+			results[key] = batchResult{value: values[id], err: err}
+		}
+	}
+	return results
+}
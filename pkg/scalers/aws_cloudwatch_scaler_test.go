@@ -3,10 +3,12 @@ package scalers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/stretchr/testify/assert"
@@ -483,7 +485,7 @@ func TestAWSCloudwatchGetMetricSpecForScaling(t *testing.T) {
 		if err != nil {
 			t.Fatal("Could not parse metadata:", err)
 		}
-		mockAWSCloudwatchScaler := awsCloudwatchScaler{"", meta, &mockCloudwatch{}}
+		mockAWSCloudwatchScaler := awsCloudwatchScaler{"", meta, &mockCloudwatch{}, nil, nil}
 
 		metricSpec := mockAWSCloudwatchScaler.GetMetricSpecForScaling(ctx)
 		metricName := metricSpec[0].External.Metric.Name
@@ -496,7 +498,7 @@ func TestAWSCloudwatchGetMetricSpecForScaling(t *testing.T) {
 func TestAWSCloudwatchScalerGetMetrics(t *testing.T) {
 	var selector labels.Selector
 	for _, meta := range awsCloudwatchGetMetricTestData {
-		mockAWSCloudwatchScaler := awsCloudwatchScaler{"", &meta, &mockCloudwatch{}}
+		mockAWSCloudwatchScaler := awsCloudwatchScaler{"", &meta, &mockCloudwatch{}, nil, nil}
 		value, err := mockAWSCloudwatchScaler.GetMetrics(context.Background(), meta.metricsName, selector)
 		switch meta.metricsName {
 		case testAWSCloudwatchErrorMetric:
@@ -551,3 +553,60 @@ func TestComputeQueryWindow(t *testing.T) {
 		assert.Equal(t, testData.expectedEndTime, endTime.UTC().Format(time.RFC3339Nano), "unexpected endTime", "name", testData.name)
 	}
 }
+
+type mockCloudwatchPages struct {
+	cloudwatchiface.CloudWatchAPI
+	calls [][]string
+}
+
+// GetMetricDataPagesWithContext returns the index of the query as its value, the queries are split across two pages
+func (m *mockCloudwatchPages) GetMetricDataPagesWithContext(_ aws.Context, input *cloudwatch.GetMetricDataInput, fn func(*cloudwatch.GetMetricDataOutput, bool) bool, _ ...request.Option) error {
+	var ids []string
+	var page []*cloudwatch.MetricDataResult
+	for i, query := range input.MetricDataQueries {
+		ids = append(ids, *query.Id)
+		if *query.MetricStat.Metric.MetricName == testAWSCloudwatchNoValueMetric {
+			continue
+		}
+		page = append(page, &cloudwatch.MetricDataResult{Id: query.Id, Values: []*float64{aws.Float64(float64(i)), aws.Float64(-1)}})
+	}
+	m.calls = append(m.calls, ids)
+	fn(&cloudwatch.GetMetricDataOutput{MetricDataResults: page[:len(page)/2]}, false)
+	fn(&cloudwatch.GetMetricDataOutput{MetricDataResults: page[len(page)/2:]}, true)
+	return nil
+}
+
+func TestAWSCloudwatchScalerBatchesQueries(t *testing.T) {
+	mock := &mockCloudwatchPages{}
+	scaler := awsCloudwatchScaler{"", &awsCloudwatchGetMetricTestData[0], mock, nil, nil}
+
+	queries := map[string]interface{}{}
+	for i := 0; i < maxCloudwatchMetricDataQueries+1; i++ {
+		meta := awsCloudwatchGetMetricTestData[0]
+		meta.metricsName = fmt.Sprintf("metric-%d", i)
+		if i == 0 {
+			meta.metricsName = testAWSCloudwatchNoValueMetric
+		}
+		query := (&awsCloudwatchScaler{metadata: &meta}).getMetricDataQuery()
+		queries[query.String()] = query
+	}
+
+	results := scaler.getCloudwatchMetricsBatch(context.Background(), queries)
+
+	// the queries are split into the calls of at most 500 queries
+	assert.Len(t, mock.calls, 2)
+	assert.Len(t, mock.calls[0], maxCloudwatchMetricDataQueries)
+	assert.Len(t, mock.calls[1], 1)
+
+	assert.Len(t, results, len(queries))
+	for key, query := range queries {
+		result := results[key]
+		assert.NoError(t, result.err)
+		if *query.(*cloudwatch.MetricDataQuery).MetricStat.Metric.MetricName == testAWSCloudwatchNoValueMetric {
+			assert.Nil(t, result.value)
+			continue
+		}
+		// each query gets the latest value of its own result
+		assert.GreaterOrEqual(t, *result.value.(*float64), float64(0))
+	}
+}
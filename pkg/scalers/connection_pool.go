@@ -0,0 +1,118 @@
+package scalers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// sharedClient is a client shared by the scalers connecting to the same server with the same credentials
+type sharedClient struct {
+	client io.Closer
+	err    error
+	// ready is closed once the client is created (or its creation failed)
+	ready chan struct{}
+	// refCount is the number of scalers using the client, guarded by sharedClientsMutex
+	refCount int
+}
+
+// closedChecker is implemented by the clients which don't recover from a lost connection,
+// such clients are evicted from the pool once they are closed
+type closedChecker interface {
+	IsClosed() bool
+}
+
+// a pool of sharedClient per connection key, see connectionKey
+var sharedClients = map[string]*sharedClient{}
+
+var sharedClientsMutex sync.Mutex
+
+// connectionKey identifies the connection of a scaler by its kind and the values it is built from, including
+// the credentials, so the scalers with different credentials don't share a client and the rotated credentials
+// result in a new client. The values are hashed by SHA-256, so the secrets aren't kept in the keys of the pool
+// and the scalers with different credentials can't end up with the same key.
+func connectionKey(kind string, values ...string) string {
+	h := sha256.New()
+	for _, value := range values {
+		// the length prefix keeps the boundaries of the values
+		fmt.Fprintf(h, "%d:%s", len(value), value)
+	}
+	return kind + "/" + hex.EncodeToString(h.Sum(nil))
+}
+
+// getSharedClient returns the client pooled under the key, the client is created by newClient if it isn't pooled yet.
+// The returned release function must be called once the scaler doesn't use the client anymore, the client is closed
+// when the last scaler using it releases it. The client is created outside of the lock of the pool, so a slow server
+// doesn't block the scalers of the other servers, the scalers requesting the same key in the meantime wait for it.
+func getSharedClient(key string, newClient func() (io.Closer, error)) (io.Closer, func() error, error) {
+	sharedClientsMutex.Lock()
+	shared, ok := sharedClients[key]
+	if ok && isSharedClientClosed(shared) {
+		delete(sharedClients, key)
+		ok = false
+	}
+	if ok {
+		shared.refCount++
+		sharedClientsMutex.Unlock()
+		<-shared.ready
+	} else {
+		shared = &sharedClient{ready: make(chan struct{}), refCount: 1}
+		sharedClients[key] = shared
+		sharedClientsMutex.Unlock()
+
+		shared.client, shared.err = newClient()
+		if shared.err != nil {
+			// newClient may return a typed nil on error, which isn't a nil io.Closer
+			shared.client = nil
+			// the failed client isn't pooled, so the next scaler tries to create it again
+			sharedClientsMutex.Lock()
+			if sharedClients[key] == shared {
+				delete(sharedClients, key)
+			}
+			sharedClientsMutex.Unlock()
+		}
+		close(shared.ready)
+	}
+
+	var once sync.Once
+	var releaseErr error
+	release := func() error {
+		once.Do(func() {
+			sharedClientsMutex.Lock()
+			shared.refCount--
+			if shared.refCount > 0 {
+				sharedClientsMutex.Unlock()
+				return
+			}
+			if sharedClients[key] == shared {
+				delete(sharedClients, key)
+			}
+			sharedClientsMutex.Unlock()
+
+			if shared.client != nil && !isSharedClientClosed(shared) {
+				releaseErr = shared.client.Close()
+			}
+		})
+		return releaseErr
+	}
+
+	if shared.err != nil {
+		_ = release()
+		return nil, nil, shared.err
+	}
+	return shared.client, release, nil
+}
+
+// isSharedClientClosed returns true if the created client lost its connection for good
+func isSharedClientClosed(shared *sharedClient) bool {
+	select {
+	case <-shared.ready:
+		checker, ok := shared.client.(closedChecker)
+		return ok && checker.IsClosed()
+	default:
+		// the client is being created
+		return false
+	}
+}
@@ -0,0 +1,148 @@
+package scalers
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSharedClient struct {
+	closed int
+}
+
+func (c *fakeSharedClient) Close() error {
+	c.closed++
+	return nil
+}
+
+func (c *fakeSharedClient) IsClosed() bool {
+	return c.closed > 0
+}
+
+// newFakeSharedClient returns the function creating the clients and the list of the created clients
+func newFakeSharedClient() (func() (io.Closer, error), *[]*fakeSharedClient) {
+	var mutex sync.Mutex
+	created := []*fakeSharedClient{}
+	return func() (io.Closer, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		client := &fakeSharedClient{}
+		created = append(created, client)
+		return client, nil
+	}, &created
+}
+
+func TestConnectionKey(t *testing.T) {
+	assert.Equal(t, connectionKey("kafka", "broker:9092", "password"), connectionKey("kafka", "broker:9092", "password"))
+	assert.NotEqual(t, connectionKey("kafka", "broker:9092", "password"), connectionKey("kafka", "broker:9092", "rotated"))
+	assert.NotEqual(t, connectionKey("kafka", "broker:9092"), connectionKey("redis", "broker:9092"))
+	// the boundaries of the values are kept
+	assert.NotEqual(t, connectionKey("kafka", "ab", "c"), connectionKey("kafka", "a", "bc"))
+	assert.NotContains(t, connectionKey("kafka", "broker:9092", "password"), "password")
+}
+
+func TestSharedClientIsClosedByLastUser(t *testing.T) {
+	newClient, created := newFakeSharedClient()
+	key := connectionKey("test", t.Name())
+
+	client1, release1, err := getSharedClient(key, newClient)
+	assert.NoError(t, err)
+	client2, release2, err := getSharedClient(key, newClient)
+	assert.NoError(t, err)
+	assert.Same(t, client1, client2)
+	assert.Len(t, *created, 1)
+
+	assert.NoError(t, release1())
+	// releasing twice doesn't release the client of the other user
+	assert.NoError(t, release1())
+	assert.Equal(t, 0, (*created)[0].closed)
+
+	assert.NoError(t, release2())
+	assert.Equal(t, 1, (*created)[0].closed)
+
+	// the closed client isn't pooled anymore
+	client3, release3, err := getSharedClient(key, newClient)
+	assert.NoError(t, err)
+	assert.NotSame(t, client1, client3)
+	assert.NoError(t, release3())
+}
+
+func TestSharedClientPerKey(t *testing.T) {
+	newClient, created := newFakeSharedClient()
+
+	_, release1, err := getSharedClient(connectionKey("test", t.Name(), "password"), newClient)
+	assert.NoError(t, err)
+	_, release2, err := getSharedClient(connectionKey("test", t.Name(), "rotated"), newClient)
+	assert.NoError(t, err)
+	assert.Len(t, *created, 2)
+
+	assert.NoError(t, release1())
+	assert.Equal(t, 1, (*created)[0].closed)
+	assert.Equal(t, 0, (*created)[1].closed)
+	assert.NoError(t, release2())
+}
+
+func TestSharedClientFailureIsNotPooled(t *testing.T) {
+	key := connectionKey("test", t.Name())
+
+	_, _, err := getSharedClient(key, func() (io.Closer, error) {
+		var client *fakeSharedClient
+		return client, fmt.Errorf("connection refused")
+	})
+	assert.ErrorContains(t, err, "connection refused")
+
+	newClient, created := newFakeSharedClient()
+	_, release, err := getSharedClient(key, newClient)
+	assert.NoError(t, err)
+	assert.Len(t, *created, 1)
+	assert.NoError(t, release())
+}
+
+func TestSharedClientClosedConnectionIsEvicted(t *testing.T) {
+	newClient, created := newFakeSharedClient()
+	key := connectionKey("test", t.Name())
+
+	client1, release1, err := getSharedClient(key, newClient)
+	assert.NoError(t, err)
+	// the connection is lost for good
+	assert.NoError(t, client1.Close())
+
+	client2, release2, err := getSharedClient(key, newClient)
+	assert.NoError(t, err)
+	assert.NotSame(t, client1, client2)
+
+	// the evicted client isn't closed again and the new one is kept
+	assert.NoError(t, release1())
+	assert.Equal(t, 1, (*created)[0].closed)
+	assert.Equal(t, 0, (*created)[1].closed)
+	assert.NoError(t, release2())
+	assert.Equal(t, 1, (*created)[1].closed)
+}
+
+func TestSharedClientIsCreatedOnce(t *testing.T) {
+	newClient, created := newFakeSharedClient()
+	key := connectionKey("test", t.Name())
+
+	var wg sync.WaitGroup
+	releases := make(chan func() error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, release, err := getSharedClient(key, newClient)
+			assert.NoError(t, err)
+			releases <- release
+		}()
+	}
+	wg.Wait()
+	close(releases)
+	assert.Len(t, *created, 1)
+
+	for release := range releases {
+		assert.NoError(t, release())
+	}
+	assert.Equal(t, 1, (*created)[0].closed)
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 
-	"k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/metrics/pkg/apis/external_metrics"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	kedautil "github.com/kedacore/keda/v2/pkg/util"
@@ -17,18 +19,27 @@ import (
 type cpuMemoryScaler struct {
 	metadata     *cpuMemoryMetadata
 	resourceName v1.ResourceName
+	kubeClient   client.Client
 }
 
 type cpuMemoryMetadata struct {
-	Type               v2beta2.MetricTargetType
+	Type               v2.MetricTargetType
 	AverageValue       *resource.Quantity
 	AverageUtilization *int32
+	ContainerName      string
+
+	// ActivationThreshold is compared with the usage of the pods, in percent of the requests for Utilization
+	// and in cores or bytes for AverageValue, the scaler is always active if it isn't specified
+	ActivationThreshold    float64
+	HasActivationThreshold bool
+	Namespace              string
+	PodSelector            labels.Selector
 }
 
 var cpuMemoryLog = logf.Log.WithName("cpu_memory_scaler")
 
 // NewCPUMemoryScaler creates a new cpuMemoryScaler
-func NewCPUMemoryScaler(resourceName v1.ResourceName, config *ScalerConfig) (Scaler, error) {
+func NewCPUMemoryScaler(resourceName v1.ResourceName, kubeClient client.Client, config *ScalerConfig) (Scaler, error) {
 	meta, parseErr := parseResourceMetadata(config)
 	if parseErr != nil {
 		return nil, fmt.Errorf("error parsing %s metadata: %s", resourceName, parseErr)
@@ -37,6 +48,7 @@ func NewCPUMemoryScaler(resourceName v1.ResourceName, config *ScalerConfig) (Sca
 	return &cpuMemoryScaler{
 		metadata:     meta,
 		resourceName: resourceName,
+		kubeClient:   kubeClient,
 	}, nil
 }
 
@@ -50,7 +62,7 @@ func parseResourceMetadata(config *ScalerConfig) (*cpuMemoryMetadata, error) {
 		return nil, fmt.Errorf("only one of trigger.metadata.type or trigger.metricType should be defined")
 	case ok && value != "":
 		cpuMemoryLog.V(0).Info("trigger.metadata.type is deprecated in favor of trigger.metricType")
-		meta.Type = v2beta2.MetricTargetType(value)
+		meta.Type = v2.MetricTargetType(value)
 	case config.MetricType != "":
 		meta.Type = config.MetricType
 	default:
@@ -61,10 +73,10 @@ func parseResourceMetadata(config *ScalerConfig) (*cpuMemoryMetadata, error) {
 		return nil, fmt.Errorf("no value given")
 	}
 	switch meta.Type {
-	case v2beta2.AverageValueMetricType:
+	case v2.AverageValueMetricType:
 		averageValueQuantity := resource.MustParse(value)
 		meta.AverageValue = &averageValueQuantity
-	case v2beta2.UtilizationMetricType:
+	case v2.UtilizationMetricType:
 		valueNum, err := kedautil.ParseNumeric(value, 32, false)
 		if err != nil {
 			return nil, err
@@ -79,12 +91,100 @@ func parseResourceMetadata(config *ScalerConfig) (*cpuMemoryMetadata, error) {
 	default:
 		return nil, fmt.Errorf("unsupported metric type, allowed values are 'Utilization' or 'AverageValue'")
 	}
+
+	if value, ok = config.TriggerMetadata["containerName"]; ok && value != "" {
+		meta.ContainerName = value
+	}
+
+	threshold, found, err := GetActivationThreshold(config)
+	if err != nil {
+		return nil, err
+	}
+	meta.ActivationThreshold = threshold
+	meta.HasActivationThreshold = found
+	meta.Namespace = config.Namespace
+	meta.PodSelector = config.PodSelector
 	return meta, nil
 }
 
-// IsActive always return true for cpu/memory scaler
+// IsActive returns true if the usage of the scale target pods exceeds the activation threshold, the usage is compared
+// on each poll and the scale target goes idle once it stays below the threshold for the cooldownPeriod.
+// The scaler is always active if the threshold isn't specified
 func (s *cpuMemoryScaler) IsActive(ctx context.Context) (bool, error) {
-	return true, nil
+	if !s.metadata.HasActivationThreshold || s.metadata.PodSelector == nil || s.kubeClient == nil {
+		return true, nil
+	}
+
+	usage, found, err := s.getUsage(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		// usage of newly started pods isn't collected yet, don't let the scale target go idle meanwhile
+		cpuMemoryLog.V(1).Info("Usage of running pods isn't available yet, keeping scaler active", "resource", s.resourceName, "namespace", s.metadata.Namespace)
+		return true, nil
+	}
+	return usage > s.metadata.ActivationThreshold, nil
+}
+
+// getUsage returns the usage of the resource by the running pods of scale target, in percent of the pods requests
+// for Utilization and as average value per pod for AverageValue. The usage is zero if there aren't any running pods,
+// the second return value is false if the usage of the running pods isn't available
+func (s *cpuMemoryScaler) getUsage(ctx context.Context) (float64, bool, error) {
+	pods := &v1.PodList{}
+	if err := s.kubeClient.List(ctx, pods, client.InNamespace(s.metadata.Namespace), client.MatchingLabelsSelector{Selector: s.metadata.PodSelector}); err != nil {
+		return 0, false, err
+	}
+	runningPods := make(map[string]*v1.Pod)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == v1.PodRunning {
+			runningPods[pod.Name] = pod
+		}
+	}
+	if len(runningPods) == 0 {
+		return 0, true, nil
+	}
+
+	podMetrics := &metricsv1beta1.PodMetricsList{}
+	if err := s.kubeClient.List(ctx, podMetrics, client.InNamespace(s.metadata.Namespace), client.MatchingLabelsSelector{Selector: s.metadata.PodSelector}); err != nil {
+		return 0, false, fmt.Errorf("error getting %s usage of pods: %s", s.resourceName, err)
+	}
+
+	var usage, requests float64
+	podCount := 0
+	for _, metrics := range podMetrics.Items {
+		pod, found := runningPods[metrics.Name]
+		if !found {
+			continue
+		}
+		for _, container := range metrics.Containers {
+			if s.metadata.ContainerName == "" || container.Name == s.metadata.ContainerName {
+				if quantity, found := container.Usage[s.resourceName]; found {
+					usage += quantity.AsApproximateFloat64()
+				}
+			}
+		}
+		for _, container := range pod.Spec.Containers {
+			if s.metadata.ContainerName == "" || container.Name == s.metadata.ContainerName {
+				if quantity, found := container.Resources.Requests[s.resourceName]; found {
+					requests += quantity.AsApproximateFloat64()
+				}
+			}
+		}
+		podCount++
+	}
+	if podCount == 0 {
+		return 0, false, nil
+	}
+
+	if s.metadata.Type == v2.UtilizationMetricType {
+		if requests == 0 {
+			return 0, false, fmt.Errorf("missing %s request of the running pods", s.resourceName)
+		}
+		return usage * 100 / requests, true, nil
+	}
+	return usage / float64(podCount), true, nil
 }
 
 // Close no need for cpuMemory scaler
@@ -92,18 +192,31 @@ func (s *cpuMemoryScaler) Close(context.Context) error {
 	return nil
 }
 
-// GetMetricSpecForScaling returns the metric spec for the HPA
-func (s *cpuMemoryScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
-	cpuMemoryMetric := &v2beta2.ResourceMetricSource{
-		Name: s.resourceName,
-		Target: v2beta2.MetricTarget{
-			Type:               s.metadata.Type,
-			AverageUtilization: s.metadata.AverageUtilization,
-			AverageValue:       s.metadata.AverageValue,
-		},
-	}
-	metricSpec := v2beta2.MetricSpec{Resource: cpuMemoryMetric, Type: v2beta2.ResourceMetricSourceType}
-	return []v2beta2.MetricSpec{metricSpec}
+// GetMetricSpecForScaling returns the metric spec for the HPA, the metric of a single container
+// is used if the containerName is specified
+func (s *cpuMemoryScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
+	target := v2.MetricTarget{
+		Type:               s.metadata.Type,
+		AverageUtilization: s.metadata.AverageUtilization,
+		AverageValue:       s.metadata.AverageValue,
+	}
+
+	if s.metadata.ContainerName != "" {
+		containerCPUMemoryMetric := &v2.ContainerResourceMetricSource{
+			Name:      s.resourceName,
+			Container: s.metadata.ContainerName,
+			Target:    target,
+		}
+		metricSpec := v2.MetricSpec{ContainerResource: containerCPUMemoryMetric, Type: v2.ContainerResourceMetricSourceType}
+		return []v2.MetricSpec{metricSpec}
+	}
+
+	cpuMemoryMetric := &v2.ResourceMetricSource{
+		Name:   s.resourceName,
+		Target: target,
+	}
+	metricSpec := v2.MetricSpec{Resource: cpuMemoryMetric, Type: v2.ResourceMetricSourceType}
+	return []v2.MetricSpec{metricSpec}
 }
 
 // GetMetrics no need for cpu/memory scaler
@@ -2,15 +2,24 @@ package scalers
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 type parseCPUMemoryMetadataTestData struct {
-	metricType v2beta2.MetricTargetType
+	metricType v2.MetricTargetType
 	metadata   map[string]string
 	isError    bool
 }
@@ -25,13 +34,14 @@ var testCPUMemoryMetadata = []parseCPUMemoryMetadataTestData{
 	{"", map[string]string{}, true},
 	{"", validCPUMemoryMetadata, false},
 	{"", map[string]string{"type": "Utilization", "value": "50"}, false},
-	{v2beta2.UtilizationMetricType, map[string]string{"value": "50"}, false},
+	{v2.UtilizationMetricType, map[string]string{"value": "50"}, false},
 	{"", map[string]string{"type": "AverageValue", "value": "50"}, false},
-	{v2beta2.AverageValueMetricType, map[string]string{"value": "50"}, false},
+	{v2.AverageValueMetricType, map[string]string{"value": "50"}, false},
 	{"", map[string]string{"type": "Value", "value": "50"}, true},
-	{v2beta2.ValueMetricType, map[string]string{"value": "50"}, true},
+	{v2.ValueMetricType, map[string]string{"value": "50"}, true},
 	{"", map[string]string{"type": "AverageValue"}, true},
 	{"", map[string]string{"type": "xxx", "value": "50"}, true},
+	{v2.UtilizationMetricType, map[string]string{"value": "50", "containerName": "app"}, false},
 }
 
 func TestCPUMemoryParseMetadata(t *testing.T) {
@@ -55,22 +65,123 @@ func TestGetMetricSpecForScaling(t *testing.T) {
 	config := &ScalerConfig{
 		TriggerMetadata: validCPUMemoryMetadata,
 	}
-	scaler, _ := NewCPUMemoryScaler(v1.ResourceCPU, config)
+	scaler, _ := NewCPUMemoryScaler(v1.ResourceCPU, nil, config)
 	metricSpec := scaler.GetMetricSpecForScaling(context.Background())
 
-	assert.Equal(t, metricSpec[0].Type, v2beta2.ResourceMetricSourceType)
+	assert.Equal(t, metricSpec[0].Type, v2.ResourceMetricSourceType)
 	assert.Equal(t, metricSpec[0].Resource.Name, v1.ResourceCPU)
-	assert.Equal(t, metricSpec[0].Resource.Target.Type, v2beta2.UtilizationMetricType)
+	assert.Equal(t, metricSpec[0].Resource.Target.Type, v2.UtilizationMetricType)
 
 	// Using trigger.metricType field for type
 	config = &ScalerConfig{
 		TriggerMetadata: map[string]string{"value": "50"},
-		MetricType:      v2beta2.UtilizationMetricType,
+		MetricType:      v2.UtilizationMetricType,
 	}
-	scaler, _ = NewCPUMemoryScaler(v1.ResourceCPU, config)
+	scaler, _ = NewCPUMemoryScaler(v1.ResourceCPU, nil, config)
 	metricSpec = scaler.GetMetricSpecForScaling(context.Background())
 
-	assert.Equal(t, metricSpec[0].Type, v2beta2.ResourceMetricSourceType)
+	assert.Equal(t, metricSpec[0].Type, v2.ResourceMetricSourceType)
 	assert.Equal(t, metricSpec[0].Resource.Name, v1.ResourceCPU)
-	assert.Equal(t, metricSpec[0].Resource.Target.Type, v2beta2.UtilizationMetricType)
+	assert.Equal(t, metricSpec[0].Resource.Target.Type, v2.UtilizationMetricType)
+}
+
+func TestGetContainerMetricSpecForScaling(t *testing.T) {
+	config := &ScalerConfig{
+		TriggerMetadata: map[string]string{"value": "50", "containerName": "app"},
+		MetricType:      v2.UtilizationMetricType,
+	}
+	scaler, _ := NewCPUMemoryScaler(v1.ResourceMemory, nil, config)
+	metricSpec := scaler.GetMetricSpecForScaling(context.Background())
+
+	assert.Equal(t, metricSpec[0].Type, v2.ContainerResourceMetricSourceType)
+	assert.Nil(t, metricSpec[0].Resource)
+	assert.Equal(t, metricSpec[0].ContainerResource.Name, v1.ResourceMemory)
+	assert.Equal(t, metricSpec[0].ContainerResource.Container, "app")
+	assert.Equal(t, metricSpec[0].ContainerResource.Target.Type, v2.UtilizationMetricType)
+}
+
+type cpuMemoryIsActiveTestData struct {
+	name       string
+	metricType v2.MetricTargetType
+	metadata   map[string]string
+	threshold  string
+	usage      []string
+	isActive   bool
+}
+
+var testCPUMemoryIsActiveData = []cpuMemoryIsActiveTestData{
+	{"no activation threshold", v2.UtilizationMetricType, map[string]string{"value": "50"}, "", nil, true},
+	{"no running pods", v2.UtilizationMetricType, map[string]string{"value": "50"}, "10", nil, false},
+	{"utilization below threshold", v2.UtilizationMetricType, map[string]string{"value": "50"}, "20", []string{"10m", "20m"}, false},
+	{"utilization above threshold", v2.UtilizationMetricType, map[string]string{"value": "50"}, "20", []string{"10m", "50m"}, true},
+	{"average value below threshold", v2.AverageValueMetricType, map[string]string{"value": "500m"}, "0.1", []string{"50m", "100m"}, false},
+	{"average value above threshold", v2.AverageValueMetricType, map[string]string{"value": "500m"}, "0.1", []string{"50m", "200m"}, true},
+	{"usage of container", v2.UtilizationMetricType, map[string]string{"value": "50", "containerName": "app"}, "10", []string{"50m"}, true},
+}
+
+func TestCPUMemoryIsActive(t *testing.T) {
+	for _, testData := range testCPUMemoryIsActiveData {
+		t.Run(testData.name, func(t *testing.T) {
+			config := &ScalerConfig{
+				TriggerMetadata:     testData.metadata,
+				MetricType:          testData.metricType,
+				ActivationThreshold: testData.threshold,
+				Namespace:           "default",
+				PodSelector:         labels.SelectorFromSet(map[string]string{"app": "test"}),
+			}
+			scaler, err := NewCPUMemoryScaler(v1.ResourceCPU, createCPUUsageClient(t, testData.usage), config)
+			assert.NoError(t, err)
+
+			isActive, err := scaler.IsActive(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, testData.isActive, isActive)
+		})
+	}
+}
+
+func TestCPUMemoryIsActiveWithoutUsage(t *testing.T) {
+	config := &ScalerConfig{
+		TriggerMetadata:     map[string]string{"value": "50"},
+		MetricType:          v2.UtilizationMetricType,
+		ActivationThreshold: "10",
+		Namespace:           "default",
+		PodSelector:         labels.SelectorFromSet(map[string]string{"app": "test"}),
+	}
+	kubeClient := createCPUUsageClient(t, []string{"10m"})
+	assert.NoError(t, kubeClient.Delete(context.Background(), &metricsv1beta1.PodMetrics{ObjectMeta: metav1.ObjectMeta{Name: "pod-0", Namespace: "default"}}))
+	scaler, err := NewCPUMemoryScaler(v1.ResourceCPU, kubeClient, config)
+	assert.NoError(t, err)
+
+	// usage of the newly started pod isn't collected yet, so the scaler stays active
+	isActive, err := scaler.IsActive(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, isActive)
+}
+
+// createCPUUsageClient returns a client with a running pod for each usage, the pods have two containers
+// requesting 100m cpu in total and only the app container uses cpu
+func createCPUUsageClient(t *testing.T, usage []string) client.Client {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, metricsv1beta1.AddToScheme(scheme))
+
+	var objects []client.Object
+	for i, value := range usage {
+		objectMeta := metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default", Labels: map[string]string{"app": "test"}}
+		objects = append(objects, &v1.Pod{
+			ObjectMeta: objectMeta,
+			Spec: v1.PodSpec{Containers: []v1.Container{
+				{Name: "app", Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("50m")}}},
+				{Name: "sidecar", Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("50m")}}},
+			}},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}, &metricsv1beta1.PodMetrics{
+			ObjectMeta: objectMeta,
+			Containers: []metricsv1beta1.ContainerMetrics{
+				{Name: "app", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse(value)}},
+				{Name: "sidecar", Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse("0")}},
+			},
+		})
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
 }
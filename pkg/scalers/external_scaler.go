@@ -2,16 +2,20 @@ package scalers
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/mitchellh/hashstructure"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
-	v2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -19,12 +23,19 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	pb "github.com/kedacore/keda/v2/pkg/scalers/externalscaler"
+	pbv2 "github.com/kedacore/keda/v2/pkg/scalers/externalscaler/v2"
 )
 
 type externalScaler struct {
-	metricType      v2beta2.MetricTargetType
-	metadata        externalScalerMetadata
-	scaledObjectRef pb.ScaledObjectRef
+	metricType v2.MetricTargetType
+	// explicitMetricType is true if the metric type is specified for the trigger,
+	// it takes precedence over the metric type reported by the scaler
+	explicitMetricType bool
+	metadata           externalScalerMetadata
+	scaledObjectRef    pb.ScaledObjectRef
+	protocol           *externalScalerProtocol
+	// connectionKey is the key of the pooled connection matching the current credentials, guarded by connectionPoolMutex
+	connectionKey string
 }
 
 type externalPushScaler struct {
@@ -34,17 +45,47 @@ type externalPushScaler struct {
 type externalScalerMetadata struct {
 	scalerAddress    string
 	tlsCertFile      string
+	serverName       string
+	caCert           string
+	tlsClientCert    string
+	tlsClientKey     string
+	bearerToken      string
 	originalMetadata map[string]string
 	scalerIndex      int
 }
 
+// externalScalerCredentials are the credentials used by a connection to the external scaler
+type externalScalerCredentials struct {
+	enableTLS     bool
+	serverName    string
+	caCert        string
+	tlsClientCert string
+	tlsClientKey  string
+	bearerToken   string
+}
+
+// connectionGroup is a connection shared by the scalers with the same scaler address, server name and credentials
 type connectionGroup struct {
 	grpcConnection *grpc.ClientConn
-	waitGroup      *sync.WaitGroup
+	// refCount is the number of callers using the connection, guarded by connectionPoolMutex
+	refCount int
+	// scalers are the scalers whose current credentials match the connection, guarded by connectionPoolMutex,
+	// the idle connection is kept open until none of them uses it
+	scalers map[*externalScaler]bool
+}
+
+// cachedFile is the content of a file together with the modification time it was read at
+type cachedFile struct {
+	modTime time.Time
+	content string
 }
 
-// a pool of connectionGroup per metadata hash
-var connectionPool sync.Map
+// a pool of connectionGroup per scaler address, server name and credentials
+var connectionPool = map[string]*connectionGroup{}
+
+// tlsCertFiles caches the tlsCertFiles, so they are read again only once they are modified
+var tlsCertFiles = map[string]cachedFile{}
+var tlsCertFilesMutex sync.Mutex
 
 var externalLog = logf.Log.WithName("external_scaler")
 
@@ -62,13 +103,15 @@ func NewExternalScaler(config *ScalerConfig) (Scaler, error) {
 	}
 
 	return &externalScaler{
-		metricType: metricType,
-		metadata:   meta,
+		metricType:         metricType,
+		explicitMetricType: config.MetricType != "",
+		metadata:           meta,
 		scaledObjectRef: pb.ScaledObjectRef{
 			Name:           config.Name,
 			Namespace:      config.Namespace,
 			ScalerMetadata: meta.originalMetadata,
 		},
+		protocol: &externalScalerProtocol{},
 	}, nil
 }
 
@@ -86,13 +129,15 @@ func NewExternalPushScaler(config *ScalerConfig) (PushScaler, error) {
 
 	return &externalPushScaler{
 		externalScaler{
-			metricType: metricType,
-			metadata:   meta,
+			metricType:         metricType,
+			explicitMetricType: config.MetricType != "",
+			metadata:           meta,
 			scaledObjectRef: pb.ScaledObjectRef{
 				Name:           config.Name,
 				Namespace:      config.Namespace,
 				ScalerMetadata: meta.originalMetadata,
 			},
+			protocol: &externalScalerProtocol{},
 		},
 	}, nil
 }
@@ -113,6 +158,32 @@ func parseExternalScalerMetadata(config *ScalerConfig) (externalScalerMetadata,
 		meta.tlsCertFile = val
 	}
 
+	// overrides the name used for SNI and verification of the server certificate
+	if val, ok := config.TriggerMetadata["serverName"]; ok && val != "" {
+		meta.serverName = val
+	}
+
+	// the CA and the client certificate for mutual TLS are sourced from TriggerAuthentication
+	meta.caCert = config.AuthParams["ca"]
+	meta.tlsClientCert = config.AuthParams["cert"]
+	meta.tlsClientKey = config.AuthParams["key"]
+	if meta.tlsClientCert != "" && meta.tlsClientKey == "" {
+		return meta, fmt.Errorf("key must be provided with cert")
+	}
+	if meta.tlsClientKey != "" && meta.tlsClientCert == "" {
+		return meta, fmt.Errorf("cert must be provided with key")
+	}
+	if meta.caCert != "" && meta.tlsCertFile != "" {
+		return meta, fmt.Errorf("ca and tlsCertFile can't be set both")
+	}
+
+	// the bearer token is sent in the authorization metadata of each call,
+	// it isn't sent over a plaintext connection
+	meta.bearerToken = config.AuthParams["bearerToken"]
+	if meta.bearerToken != "" && meta.caCert == "" && meta.tlsCertFile == "" && meta.tlsClientCert == "" && meta.serverName == "" {
+		return meta, fmt.Errorf("bearerToken requires TLS, ca, tlsCertFile, cert or serverName must be provided")
+	}
+
 	meta.originalMetadata = make(map[string]string)
 
 	// Add elements to metadata
@@ -132,52 +203,79 @@ func parseExternalScalerMetadata(config *ScalerConfig) (externalScalerMetadata,
 
 // IsActive checks if there are any messages in the subscription
 func (s *externalScaler) IsActive(ctx context.Context) (bool, error) {
-	grpcClient, done, err := getClientForConnectionPool(s.metadata)
+	isActive, _, err := s.isActiveWithMetrics(ctx)
+	return isActive, err
+}
+
+// isActiveWithMetrics returns the values of the first metric together with the activity,
+// if a v2 scaler reports an activation target for it, so they aren't queried again by the caller
+func (s *externalScaler) isActiveWithMetrics(ctx context.Context) (bool, []external_metrics.ExternalMetricValue, error) {
+	conn, done, err := getConnectionFromPool(s)
 	if err != nil {
-		return false, err
+		return false, nil, err
 	}
 	defer done()
 
-	response, err := grpcClient.IsActive(ctx, &s.scaledObjectRef)
+	isV2, err := s.protocol.detect(ctx, conn)
+	if err != nil {
+		return false, nil, err
+	}
+	if isV2 {
+		return s.isActiveV2(ctx, pbv2.NewExternalScalerClient(conn))
+	}
+
+	response, err := pb.NewExternalScalerClient(conn).IsActive(ctx, &s.scaledObjectRef)
 	if err != nil {
 		externalLog.Error(err, "error calling IsActive on external scaler")
-		return false, err
+		return false, nil, err
 	}
 
-	return response.Result, nil
+	return response.Result, nil, nil
 }
 
 func (s *externalScaler) Close(context.Context) error {
+	connectionPoolMutex.Lock()
+	defer connectionPoolMutex.Unlock()
+	s.releaseConnection()
 	return nil
 }
 
 // GetMetricSpecForScaling returns the metric spec for the HPA
-func (s *externalScaler) GetMetricSpecForScaling(ctx context.Context) []v2beta2.MetricSpec {
-	var result []v2beta2.MetricSpec
+func (s *externalScaler) GetMetricSpecForScaling(ctx context.Context) []v2.MetricSpec {
+	var result []v2.MetricSpec
 
-	grpcClient, done, err := getClientForConnectionPool(s.metadata)
+	conn, done, err := getConnectionFromPool(s)
 	if err != nil {
 		externalLog.Error(err, "error building grpc connection")
 		return result
 	}
 	defer done()
 
-	response, err := grpcClient.GetMetricSpec(ctx, &s.scaledObjectRef)
+	isV2, err := s.protocol.detect(ctx, conn)
+	if err != nil {
+		externalLog.Error(err, "error detecting external scaler protocol version")
+		return result
+	}
+	if isV2 {
+		return s.getMetricSpecForScalingV2(ctx, pbv2.NewExternalScalerClient(conn))
+	}
+
+	response, err := pb.NewExternalScalerClient(conn).GetMetricSpec(ctx, &s.scaledObjectRef)
 	if err != nil {
 		externalLog.Error(err, "error")
 		return nil
 	}
 
 	for _, spec := range response.MetricSpecs {
-		externalMetric := &v2beta2.ExternalMetricSource{
-			Metric: v2beta2.MetricIdentifier{
+		externalMetric := &v2.ExternalMetricSource{
+			Metric: v2.MetricIdentifier{
 				Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, spec.MetricName),
 			},
 			Target: GetMetricTarget(s.metricType, spec.TargetSize),
 		}
 
 		// Create the metric spec for the HPA
-		metricSpec := v2beta2.MetricSpec{
+		metricSpec := v2.MetricSpec{
 			External: externalMetric,
 			Type:     externalMetricType,
 		}
@@ -191,7 +289,7 @@ func (s *externalScaler) GetMetricSpecForScaling(ctx context.Context) []v2beta2.
 // GetMetrics connects calls the gRPC interface to get the metrics with a specific name
 func (s *externalScaler) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
 	var metrics []external_metrics.ExternalMetricValue
-	grpcClient, done, err := getClientForConnectionPool(s.metadata)
+	conn, done, err := getConnectionFromPool(s)
 	if err != nil {
 		return metrics, err
 	}
@@ -203,12 +301,20 @@ func (s *externalScaler) GetMetrics(ctx context.Context, metricName string, metr
 		return metrics, err
 	}
 
+	isV2, err := s.protocol.detect(ctx, conn)
+	if err != nil {
+		return metrics, err
+	}
+	if isV2 {
+		return s.getMetricsV2(ctx, pbv2.NewExternalScalerClient(conn), metricName, metricNameWithoutIndex)
+	}
+
 	request := &pb.GetMetricsRequest{
 		MetricName:      metricNameWithoutIndex,
 		ScaledObjectRef: &s.scaledObjectRef,
 	}
 
-	response, err := grpcClient.GetMetrics(ctx, request)
+	response, err := pb.NewExternalScalerClient(conn).GetMetrics(ctx, request)
 	if err != nil {
 		externalLog.Error(err, "error")
 		return []external_metrics.ExternalMetricValue{}, err
@@ -232,16 +338,26 @@ func (s *externalPushScaler) Run(ctx context.Context, active chan<- bool) {
 	defer close(active)
 	// It's possible for the connection to get terminated anytime, we need to run this in a retry loop
 	runWithLog := func() {
-		grpcClient, done, err := getClientForConnectionPool(s.metadata)
+		conn, done, err := getConnectionFromPool(&s.externalScaler)
 		if err != nil {
 			externalLog.Error(err, "error running internalRun")
 			return
 		}
-		if err := handleIsActiveStream(ctx, s.scaledObjectRef, grpcClient, active); err != nil {
+		defer done()
+
+		isV2, err := s.protocol.detect(ctx, conn)
+		if err != nil {
 			externalLog.Error(err, "error running internalRun")
 			return
 		}
-		done()
+		if isV2 {
+			err = s.handleIsActiveStreamV2(ctx, pbv2.NewExternalScalerClient(conn), active)
+		} else {
+			err = handleIsActiveStream(ctx, s.scaledObjectRef, pb.NewExternalScalerClient(conn), active)
+		}
+		if err != nil {
+			externalLog.Error(err, "error running internalRun")
+		}
 	}
 
 	// retry on error from runWithLog() starting by 2 sec backing off * 2 with a max of 1 minute
@@ -292,65 +408,173 @@ func handleIsActiveStream(ctx context.Context, scaledObjectRef pb.ScaledObjectRe
 
 var connectionPoolMutex sync.Mutex
 
-// getClientForConnectionPool returns a grpcClient and a done() Func. The done() function must be called once the client is no longer
-// in use to clean up the shared grpc.ClientConn
-func getClientForConnectionPool(metadata externalScalerMetadata) (pb.ExternalScalerClient, func(), error) {
-	connectionPoolMutex.Lock()
-	defer connectionPoolMutex.Unlock()
-
-	buildGRPCConnection := func(metadata externalScalerMetadata) (*grpc.ClientConn, error) {
-		if metadata.tlsCertFile != "" {
-			creds, err := credentials.NewClientTLSFromFile(metadata.tlsCertFile, "")
-			if err != nil {
-				return nil, err
-			}
-			return grpc.Dial(metadata.scalerAddress, grpc.WithTransportCredentials(creds))
+// getCredentials returns the credentials of the connection, tlsCertFile is read again once it is modified,
+// so rotation of the file is picked up as well as rotation of the secrets referenced by TriggerAuthentication
+func (m externalScalerMetadata) getCredentials() (externalScalerCredentials, error) {
+	creds := externalScalerCredentials{
+		serverName:    m.serverName,
+		caCert:        m.caCert,
+		tlsClientCert: m.tlsClientCert,
+		tlsClientKey:  m.tlsClientKey,
+		bearerToken:   m.bearerToken,
+	}
+	if m.tlsCertFile != "" {
+		caCert, err := readTLSCertFile(m.tlsCertFile)
+		if err != nil {
+			return creds, fmt.Errorf("error reading tlsCertFile: %s", err)
 		}
+		creds.caCert = caCert
+	}
+	creds.enableTLS = creds.caCert != "" || creds.tlsClientCert != "" || creds.serverName != ""
+	return creds, nil
+}
 
-		return grpc.Dial(metadata.scalerAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// readTLSCertFile returns the content of the file, it is read only if it was modified since the previous call
+func readTLSCertFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
 	}
 
-	// create a unique key per-metadata. If scaledObjects share the same connection properties
-	// in the metadata, they will share the same grpc.ClientConn
-	key, err := hashstructure.Hash(metadata, nil)
+	tlsCertFilesMutex.Lock()
+	defer tlsCertFilesMutex.Unlock()
+	if cached, ok := tlsCertFiles[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.content, nil
+	}
+	content, err := os.ReadFile(path)
 	if err != nil {
-		return nil, nil, err
+		return "", err
 	}
+	tlsCertFiles[path] = cachedFile{modTime: info.ModTime(), content: string(content)}
+	return string(content), nil
+}
 
-	if i, ok := connectionPool.Load(key); ok {
-		if connGroup, ok := i.(*connectionGroup); ok {
-			connGroup.waitGroup.Add(1)
-			return pb.NewExternalScalerClient(connGroup.grpcConnection), func() {
-				connGroup.waitGroup.Done()
-			}, nil
+// dialOptions returns the options of the connection, the server certificate is verified
+// by the system CAs if no CA is specified
+func (c externalScalerCredentials) dialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if c.enableTLS {
+		config := &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ServerName: c.serverName,
+		}
+		if c.caCert != "" {
+			certPool := x509.NewCertPool()
+			if !certPool.AppendCertsFromPEM([]byte(c.caCert)) {
+				return nil, fmt.Errorf("error parsing CA certificate of external scaler")
+			}
+			config.RootCAs = certPool
+		}
+		if c.tlsClientCert != "" {
+			cert, err := tls.X509KeyPair([]byte(c.tlsClientCert), []byte(c.tlsClientKey))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing client certificate of external scaler: %s", err)
+			}
+			config.Certificates = []tls.Certificate{cert}
 		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	} else {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if c.bearerToken != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerTokenCredentials{token: c.bearerToken}))
 	}
+	return opts, nil
+}
 
-	conn, err := buildGRPCConnection(metadata)
+// hash identifies the credentials, so a new connection is built once any of them changes
+func (c externalScalerCredentials) hash() uint64 {
+	h := fnv.New64a()
+	for _, value := range []string{strconv.FormatBool(c.enableTLS), c.serverName, c.caCert, c.tlsClientCert, c.tlsClientKey, c.bearerToken} {
+		// the length prefix keeps the boundaries of the values
+		fmt.Fprintf(h, "%d:%s", len(value), value)
+	}
+	return h.Sum64()
+}
+
+// bearerTokenCredentials sends the bearer token as gRPC metadata of each call, only over TLS
+type bearerTokenCredentials struct {
+	token string
+}
+
+func (c bearerTokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c bearerTokenCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+// getConnectionFromPool returns a grpc.ClientConn and a done() Func. The done() function must be called once the connection is no longer
+// in use. The connection is shared by the scalers with the same scaler address, server name and credentials and it is kept open
+// while it is idle, so it is reused by the next calls. Once the credentials of the scaler are rotated, a new connection is built
+// and the previous one is closed when none of the scalers uses it and the calls in progress with the previous credentials finish.
+func getConnectionFromPool(scaler *externalScaler) (*grpc.ClientConn, func(), error) {
+	creds, err := scaler.metadata.getCredentials()
 	if err != nil {
 		return nil, nil, err
 	}
+	// the scalers using different credentials don't share the connection
+	key := fmt.Sprintf("%s/%s/%x", scaler.metadata.scalerAddress, scaler.metadata.serverName, creds.hash())
+
+	connectionPoolMutex.Lock()
+	defer connectionPoolMutex.Unlock()
 
-	waitGroup := &sync.WaitGroup{}
-	waitGroup.Add(1)
-	connGroup := connectionGroup{
-		grpcConnection: conn,
-		waitGroup:      waitGroup,
+	if scaler.connectionKey != key {
+		scaler.releaseConnection()
 	}
 
-	connectionPool.Store(key, connGroup)
+	connGroup, ok := connectionPool[key]
+	if !ok {
+		opts, err := creds.dialOptions()
+		if err != nil {
+			return nil, nil, err
+		}
+		conn, err := grpc.Dial(scaler.metadata.scalerAddress, opts...)
+		if err != nil {
+			return nil, nil, err
+		}
+		connGroup = &connectionGroup{
+			grpcConnection: conn,
+			scalers:        map[*externalScaler]bool{},
+		}
+		connectionPool[key] = connGroup
+	}
+	connGroup.scalers[scaler] = true
+	scaler.connectionKey = key
+	connGroup.refCount++
 
-	go func() {
-		// clean up goroutine.
-		// once all waitGroup is done, remove the connection from the pool and Close() grpc.ClientConn
-		connGroup.waitGroup.Wait()
+	done := func() {
 		connectionPoolMutex.Lock()
 		defer connectionPoolMutex.Unlock()
-		connectionPool.Delete(key)
-		connGroup.grpcConnection.Close()
-	}()
+		connGroup.refCount--
+		connGroup.closeIfUnused(key)
+	}
+	return connGroup.grpcConnection, done, nil
+}
 
-	return pb.NewExternalScalerClient(connGroup.grpcConnection), func() {
-		connGroup.waitGroup.Done()
-	}, nil
+// releaseConnection stops the scaler from using the connection matching its previous credentials,
+// connectionPoolMutex must be held by the caller
+func (s *externalScaler) releaseConnection() {
+	if s.connectionKey == "" {
+		return
+	}
+	if connGroup, ok := connectionPool[s.connectionKey]; ok {
+		delete(connGroup.scalers, s)
+		connGroup.closeIfUnused(s.connectionKey)
+	}
+	s.connectionKey = ""
+}
+
+// closeIfUnused removes the connection from the pool and closes it once it isn't used by any scaler or call,
+// connectionPoolMutex must be held by the caller
+func (g *connectionGroup) closeIfUnused(key string) {
+	if g.refCount > 0 || len(g.scalers) > 0 {
+		return
+	}
+	if pooled, ok := connectionPool[key]; ok && pooled == g {
+		delete(connectionPool, key)
+	}
+	g.grpcConnection.Close()
 }
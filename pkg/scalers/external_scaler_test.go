@@ -2,17 +2,33 @@ package scalers
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	v2 "k8s.io/api/autoscaling/v2"
 
 	pb "github.com/kedacore/keda/v2/pkg/scalers/externalscaler"
+	pbv2 "github.com/kedacore/keda/v2/pkg/scalers/externalscaler/v2"
 )
 
 type parseExternalScalerMetadataTestData struct {
@@ -170,3 +186,385 @@ func (e *testExternalScaler) GetMetricSpec(context.Context, *pb.ScaledObjectRef)
 func (e *testExternalScaler) GetMetrics(context.Context, *pb.GetMetricsRequest) (*pb.GetMetricsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetMetrics not implemented")
 }
+
+type testExternalScalerV2 struct {
+	pbv2.UnimplementedExternalScalerServer
+	ready bool
+	// getMetricSpecCalls is the number of GetMetricSpec calls, it is updated atomically
+	getMetricSpecCalls int32
+}
+
+func (e *testExternalScalerV2) GetCapabilities(context.Context, *pbv2.GetCapabilitiesRequest) (*pbv2.GetCapabilitiesResponse, error) {
+	return &pbv2.GetCapabilitiesResponse{
+		Capabilities: []pbv2.Capability{pbv2.Capability_CAPABILITY_HEALTH_CHECK, pbv2.Capability_CAPABILITY_READINESS_CHECK},
+	}, nil
+}
+
+func (e *testExternalScalerV2) CheckHealth(context.Context, *pbv2.CheckHealthRequest) (*pbv2.CheckHealthResponse, error) {
+	return &pbv2.CheckHealthResponse{Status: pbv2.HealthStatus_HEALTH_STATUS_SERVING}, nil
+}
+
+func (e *testExternalScalerV2) CheckReadiness(context.Context, *pbv2.ScaledObjectRef) (*pbv2.CheckHealthResponse, error) {
+	if e.ready {
+		return &pbv2.CheckHealthResponse{Status: pbv2.HealthStatus_HEALTH_STATUS_SERVING}, nil
+	}
+	return &pbv2.CheckHealthResponse{Status: pbv2.HealthStatus_HEALTH_STATUS_NOT_SERVING, Message: "not connected"}, nil
+}
+
+func (e *testExternalScalerV2) GetMetricSpec(_ context.Context, ref *pbv2.ScaledObjectRef) (*pbv2.GetMetricSpecResponse, error) {
+	atomic.AddInt32(&e.getMetricSpecCalls, 1)
+	if ref.ScalerMetadata["invalid"] == "true" {
+		return &pbv2.GetMetricSpecResponse{
+			Error: &pbv2.ScalerError{Code: pbv2.ErrorCode_ERROR_CODE_INVALID_METADATA, Message: "invalid metadata"},
+		}, nil
+	}
+	return &pbv2.GetMetricSpecResponse{
+		MetricSpecs: []*pbv2.MetricSpec{{
+			MetricName:           "queueLength",
+			TargetSize:           "1500m",
+			ActivationTargetSize: "2",
+			MetricType:           pbv2.MetricType_METRIC_TYPE_VALUE,
+		}},
+	}, nil
+}
+
+func (e *testExternalScalerV2) GetMetrics(_ context.Context, request *pbv2.GetMetricsRequest) (*pbv2.GetMetricsResponse, error) {
+	return &pbv2.GetMetricsResponse{
+		MetricValues: []*pbv2.MetricValue{{MetricName: request.MetricName, MetricValue: "2.5"}},
+	}, nil
+}
+
+func createGRPCServerV2(t *testing.T, address string, scaler pbv2.ExternalScalerServer) *grpc.Server {
+	grpcServer := grpc.NewServer()
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pbv2.RegisterExternalScalerServer(grpcServer, scaler)
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Error(err, "error from grpcServer")
+		}
+	}()
+	return grpcServer
+}
+
+func TestExternalScalerV2(t *testing.T) {
+	const address = "127.0.0.1:5070"
+	testScaler := &testExternalScalerV2{ready: true}
+	grpcServer := createGRPCServerV2(t, address, testScaler)
+	defer grpcServer.Stop()
+
+	ctx := context.Background()
+	scaler, err := NewExternalScaler(&ScalerConfig{Name: "app", Namespace: "namespace", TriggerMetadata: map[string]string{"scalerAddress": address}, ResolvedEnv: map[string]string{}})
+	assert.NoError(t, err)
+
+	metricSpecs := scaler.GetMetricSpecForScaling(ctx)
+	assert.Len(t, metricSpecs, 1)
+	assert.Equal(t, "s0-queueLength", metricSpecs[0].External.Metric.Name)
+	assert.Equal(t, v2.ValueMetricType, metricSpecs[0].External.Target.Type)
+	assert.Equal(t, "1500m", metricSpecs[0].External.Target.Value.String())
+	assert.True(t, scaler.(*externalScaler).protocol.v2)
+
+	metrics, err := scaler.GetMetrics(ctx, "s0-queueLength", nil)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "s0-queueLength", metrics[0].MetricName)
+	assert.Equal(t, float64(2.5), metrics[0].Value.AsApproximateFloat64())
+
+	// 2.5 is greater than the activation target 2, the metric specs reported before are reused
+	// and the values of the metric are returned with the activity
+	isActive, metrics, err := IsActiveWithMetrics(ctx, scaler)
+	assert.NoError(t, err)
+	assert.True(t, isActive)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "s0-queueLength", metrics[0].MetricName)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&testScaler.getMetricSpecCalls))
+
+	testScaler.ready = false
+	_, err = scaler.IsActive(ctx)
+	assert.EqualError(t, err, "external scaler isn't serving (HEALTH_STATUS_NOT_SERVING): not connected")
+}
+
+func TestExternalScalerV2MetricTypeOfTrigger(t *testing.T) {
+	const address = "127.0.0.1:5071"
+	grpcServer := createGRPCServerV2(t, address, &testExternalScalerV2{ready: true})
+	defer grpcServer.Stop()
+
+	scaler, err := NewExternalScaler(&ScalerConfig{MetricType: v2.AverageValueMetricType, TriggerMetadata: map[string]string{"scalerAddress": address}, ResolvedEnv: map[string]string{}})
+	assert.NoError(t, err)
+
+	metricSpecs := scaler.GetMetricSpecForScaling(context.Background())
+	assert.Len(t, metricSpecs, 1)
+	assert.Equal(t, v2.AverageValueMetricType, metricSpecs[0].External.Target.Type)
+	assert.Equal(t, "1500m", metricSpecs[0].External.Target.AverageValue.String())
+}
+
+func TestExternalScalerV2Error(t *testing.T) {
+	const address = "127.0.0.1:5072"
+	grpcServer := createGRPCServerV2(t, address, &testExternalScalerV2{ready: true})
+	defer grpcServer.Stop()
+
+	scaler, err := NewExternalScaler(&ScalerConfig{TriggerMetadata: map[string]string{"scalerAddress": address, "invalid": "true"}, ResolvedEnv: map[string]string{}})
+	assert.NoError(t, err)
+
+	_, err = scaler.IsActive(context.Background())
+	assert.EqualError(t, err, "external scaler returned error ERROR_CODE_INVALID_METADATA: invalid metadata")
+}
+
+func TestExternalScalerDetectsV1(t *testing.T) {
+	const address = "127.0.0.1:5073"
+	grpcServer := grpc.NewServer()
+	lis, err := net.Listen("tcp", address)
+	assert.NoError(t, err)
+	pb.RegisterExternalScalerServer(grpcServer, &testExternalScaler{t: t})
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Error(err, "error from grpcServer")
+		}
+	}()
+	defer grpcServer.Stop()
+
+	scaler, err := NewExternalScaler(&ScalerConfig{TriggerMetadata: map[string]string{"scalerAddress": address}, ResolvedEnv: map[string]string{}})
+	assert.NoError(t, err)
+
+	// the v1 test server doesn't implement IsActive
+	_, err = scaler.IsActive(context.Background())
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.True(t, scaler.(*externalScaler).protocol.detected)
+	assert.False(t, scaler.(*externalScaler).protocol.v2)
+}
+
+func TestExternalScalerV2DetectionBackoff(t *testing.T) {
+	const address = "127.0.0.1:5076"
+
+	scaler, err := NewExternalScaler(&ScalerConfig{TriggerMetadata: map[string]string{"scalerAddress": address}, ResolvedEnv: map[string]string{}})
+	assert.NoError(t, err)
+	protocol := scaler.(*externalScaler).protocol
+
+	// the scaler isn't running yet
+	_, err = scaler.IsActive(context.Background())
+	assert.Error(t, err)
+	assert.False(t, protocol.detected)
+	assert.Equal(t, detectionInitialBackoff, protocol.retryBackoff)
+
+	grpcServer := createGRPCServerV2(t, address, &testExternalScalerV2{ready: true})
+	defer grpcServer.Stop()
+
+	// the failure is returned until the backoff elapses
+	_, cachedErr := scaler.IsActive(context.Background())
+	assert.Equal(t, err, cachedErr)
+
+	// the pooled connection reconnects to the scaler with its own backoff
+	var isActive bool
+	assert.Eventually(t, func() bool {
+		protocol.retryAfter = time.Now()
+		isActive, err = scaler.IsActive(context.Background())
+		return err == nil
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.True(t, isActive)
+	assert.True(t, protocol.detected)
+	assert.True(t, protocol.v2)
+}
+
+// getTestCertificates returns PEM encoded CA and a certificate for serverName signed by the CA,
+// the same certificate is used by both server and client
+func getTestCertificates(t *testing.T, serverName string) (string, string, string) {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: serverName},
+		DNSNames:     []string{serverName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
+
+func TestExternalScalerParseAuthMetadata(t *testing.T) {
+	tests := []struct {
+		name       string
+		metadata   map[string]string
+		authParams map[string]string
+		isError    bool
+	}{
+		{"mutual TLS", map[string]string{"scalerAddress": "myservice", "serverName": "myservice.keda"}, map[string]string{"ca": "ca", "cert": "cert", "key": "key", "bearerToken": "token"}, false},
+		{"cert without key", map[string]string{"scalerAddress": "myservice"}, map[string]string{"cert": "cert"}, true},
+		{"key without cert", map[string]string{"scalerAddress": "myservice"}, map[string]string{"key": "key"}, true},
+		{"ca and tlsCertFile", map[string]string{"scalerAddress": "myservice", "tlsCertFile": "/certs/ca.crt"}, map[string]string{"ca": "ca"}, true},
+		{"bearer token without TLS", map[string]string{"scalerAddress": "myservice"}, map[string]string{"bearerToken": "token"}, true},
+	}
+	for _, test := range tests {
+		meta, err := parseExternalScalerMetadata(&ScalerConfig{TriggerMetadata: test.metadata, AuthParams: test.authParams, ResolvedEnv: map[string]string{}})
+		if test.isError {
+			assert.Error(t, err, test.name)
+			continue
+		}
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, "myservice.keda", meta.serverName)
+		assert.Equal(t, "token", meta.bearerToken)
+		// the credentials aren't sent to the scaler
+		assert.Equal(t, test.metadata, meta.originalMetadata)
+	}
+}
+
+func TestExternalScalerMutualTLS(t *testing.T) {
+	const serverName = "external-scaler.keda"
+	ca, cert, key := getTestCertificates(t, serverName)
+	serverCert, err := tls.X509KeyPair([]byte(cert), []byte(key))
+	assert.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(ca))
+
+	// the server requires both the client certificate and the bearer token
+	checkToken := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if len(md.Get("authorization")) != 1 || md.Get("authorization")[0] != "Bearer secret-token" {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{serverCert},
+			ClientCAs:    clientCAs,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			MinVersion:   tls.VersionTLS12,
+		})),
+		grpc.UnaryInterceptor(checkToken),
+	)
+	lis, err := net.Listen("tcp", "127.0.0.1:5074")
+	assert.NoError(t, err)
+	pbv2.RegisterExternalScalerServer(grpcServer, &testExternalScalerV2{ready: true})
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			t.Error(err, "error from grpcServer")
+		}
+	}()
+	defer grpcServer.Stop()
+
+	authParams := map[string]string{"ca": ca, "cert": cert, "key": key, "bearerToken": "secret-token"}
+	scaler, err := NewExternalScaler(&ScalerConfig{
+		TriggerMetadata: map[string]string{"scalerAddress": "127.0.0.1:5074", "serverName": serverName},
+		AuthParams:      authParams,
+		ResolvedEnv:     map[string]string{},
+	})
+	assert.NoError(t, err)
+	metrics, err := scaler.GetMetrics(context.Background(), "s0-queueLength", nil)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+
+	// the token is rotated, but the server still expects the previous one
+	authParams["bearerToken"] = "rotated-token"
+	scaler, err = NewExternalScaler(&ScalerConfig{
+		TriggerMetadata: map[string]string{"scalerAddress": "127.0.0.1:5074", "serverName": serverName},
+		AuthParams:      authParams,
+		ResolvedEnv:     map[string]string{},
+	})
+	assert.NoError(t, err)
+	_, err = scaler.GetMetrics(context.Background(), "s0-queueLength", nil)
+	assert.ErrorContains(t, err, "invalid token")
+}
+
+func TestExternalScalerConnectionPoolPerCredentials(t *testing.T) {
+	meta := externalScalerMetadata{scalerAddress: "127.0.0.1:5075", serverName: "external-scaler.keda", bearerToken: "token"}
+	scaler := &externalScaler{metadata: meta}
+	sharingScaler := &externalScaler{metadata: meta}
+
+	conn, done, err := getConnectionFromPool(scaler)
+	assert.NoError(t, err)
+	sharedConn, sharedDone, err := getConnectionFromPool(sharingScaler)
+	assert.NoError(t, err)
+	assert.Same(t, conn, sharedConn)
+
+	// the idle connection is kept open for the next calls
+	done()
+	sharedDone()
+	assert.NotEqual(t, connectivity.Shutdown, conn.GetState())
+	conn, done, err = getConnectionFromPool(scaler)
+	assert.NoError(t, err)
+	assert.Same(t, sharedConn, conn)
+
+	// the scalers with other credentials, eg. rotated ones, don't take over the connection
+	scaler.metadata.bearerToken = "rotated-token"
+	otherConn, otherDone, err := getConnectionFromPool(scaler)
+	assert.NoError(t, err)
+	assert.NotSame(t, conn, otherConn)
+
+	// the previous connection is closed once the call in progress finishes and no scaler uses it
+	done()
+	assert.NotEqual(t, connectivity.Shutdown, conn.GetState())
+	sharingScaler.metadata.bearerToken = "rotated-token"
+	rotatedConn, rotatedDone, err := getConnectionFromPool(sharingScaler)
+	assert.NoError(t, err)
+	assert.Same(t, otherConn, rotatedConn)
+	assert.Equal(t, connectivity.Shutdown, conn.GetState())
+
+	otherDone()
+	rotatedDone()
+	assert.NotEqual(t, connectivity.Shutdown, otherConn.GetState())
+	assert.NoError(t, scaler.Close(context.Background()))
+	assert.NotEqual(t, connectivity.Shutdown, otherConn.GetState())
+	assert.NoError(t, sharingScaler.Close(context.Background()))
+	assert.Equal(t, connectivity.Shutdown, otherConn.GetState())
+
+	connectionPoolMutex.Lock()
+	defer connectionPoolMutex.Unlock()
+	for key := range connectionPool {
+		assert.False(t, strings.HasPrefix(key, "127.0.0.1:5075/"), key)
+	}
+}
+
+func TestReadTLSCertFileCachesUntilModified(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.crt")
+	assert.NoError(t, os.WriteFile(path, []byte("first"), 0600))
+	modTime := time.Now().Add(-time.Minute)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+
+	content, err := readTLSCertFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", content)
+
+	// the content isn't read again while the modification time is the same
+	assert.NoError(t, os.WriteFile(path, []byte("second"), 0600))
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	content, err = readTLSCertFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", content)
+
+	modTime = time.Now()
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	content, err = readTLSCertFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", content)
+}
@@ -0,0 +1,344 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	pbv2 "github.com/kedacore/keda/v2/pkg/scalers/externalscaler/v2"
+	"github.com/kedacore/keda/v2/version"
+)
+
+// externalScalerCapabilities are the optional features of the v2 protocol supported by KEDA
+var externalScalerCapabilities = []pbv2.Capability{
+	pbv2.Capability_CAPABILITY_STREAM_IS_ACTIVE,
+	pbv2.Capability_CAPABILITY_HEALTH_CHECK,
+	pbv2.Capability_CAPABILITY_READINESS_CHECK,
+}
+
+// externalScalerProtocol is the version of the protocol spoken by the external scaler,
+// it is detected on the first call and kept for the lifetime of the scaler
+type externalScalerProtocol struct {
+	lock         sync.Mutex
+	detected     bool
+	v2           bool
+	capabilities map[pbv2.Capability]bool
+	// detectionErr is the error of the last failed detection, it is returned without
+	// calling the scaler again until retryAfter
+	detectionErr error
+	retryAfter   time.Time
+	retryBackoff time.Duration
+	// metricSpecs are the metric specs last reported by a v2 scaler, they are used
+	// to evaluate the activation targets without calling GetMetricSpec on each poll
+	metricSpecs []*pbv2.MetricSpec
+}
+
+const (
+	detectionInitialBackoff = 2 * time.Second
+	detectionMaxBackoff     = time.Minute
+)
+
+// detect returns true if the server implements the v2 protocol. GetCapabilities is called
+// and the server is considered to implement only the v1 protocol if the v2 service is unknown to it.
+// The calls to the server aren't made under the lock, so a slow scaler doesn't block the other callers,
+// and a failed detection is retried only after a backoff.
+func (p *externalScalerProtocol) detect(ctx context.Context, conn grpc.ClientConnInterface) (bool, error) {
+	p.lock.Lock()
+	if p.detected {
+		isV2 := p.v2
+		p.lock.Unlock()
+		return isV2, nil
+	}
+	if p.detectionErr != nil && time.Now().Before(p.retryAfter) {
+		err := p.detectionErr
+		p.lock.Unlock()
+		return false, err
+	}
+	p.lock.Unlock()
+
+	isV2, capabilities, err := detectExternalScalerProtocol(ctx, conn)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err != nil {
+		if p.retryBackoff == 0 {
+			p.retryBackoff = detectionInitialBackoff
+		} else if p.retryBackoff *= 2; p.retryBackoff > detectionMaxBackoff {
+			p.retryBackoff = detectionMaxBackoff
+		}
+		p.detectionErr = err
+		p.retryAfter = time.Now().Add(p.retryBackoff)
+		return false, err
+	}
+
+	p.detected = true
+	p.v2 = isV2
+	p.capabilities = capabilities
+	p.detectionErr = nil
+	p.retryBackoff = 0
+	return isV2, nil
+}
+
+// detectExternalScalerProtocol calls the server to find out the protocol version and its capabilities
+func detectExternalScalerProtocol(ctx context.Context, conn grpc.ClientConnInterface) (bool, map[pbv2.Capability]bool, error) {
+	grpcClient := pbv2.NewExternalScalerClient(conn)
+	response, err := grpcClient.GetCapabilities(ctx, &pbv2.GetCapabilitiesRequest{
+		KedaVersion:  version.Version,
+		Capabilities: externalScalerCapabilities,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("error getting capabilities of external scaler: %s", err)
+	}
+
+	capabilities := make(map[pbv2.Capability]bool, len(response.Capabilities))
+	for _, capability := range response.Capabilities {
+		capabilities[capability] = true
+	}
+
+	// the scaler isn't used until it reports it is able to serve requests
+	if capabilities[pbv2.Capability_CAPABILITY_HEALTH_CHECK] {
+		health, err := grpcClient.CheckHealth(ctx, &pbv2.CheckHealthRequest{})
+		if err != nil {
+			return false, nil, fmt.Errorf("error checking health of external scaler: %s", err)
+		}
+		if err := checkHealthResponse(health); err != nil {
+			return false, nil, err
+		}
+	}
+
+	return true, capabilities, nil
+}
+
+func (p *externalScalerProtocol) supports(capability pbv2.Capability) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.capabilities[capability]
+}
+
+// getMetricSpecsV2 returns the metric specs reported by the scaler, GetMetricSpec is called only
+// if the specs aren't known yet or refresh is true
+func (s *externalScaler) getMetricSpecsV2(ctx context.Context, grpcClient pbv2.ExternalScalerClient, refresh bool) ([]*pbv2.MetricSpec, error) {
+	s.protocol.lock.Lock()
+	metricSpecs := s.protocol.metricSpecs
+	s.protocol.lock.Unlock()
+	if metricSpecs != nil && !refresh {
+		return metricSpecs, nil
+	}
+
+	response, err := grpcClient.GetMetricSpec(ctx, s.scaledObjectRefV2())
+	if err != nil {
+		return nil, err
+	}
+	if err := scalerErrorToError(response.Error); err != nil {
+		return nil, err
+	}
+
+	s.protocol.lock.Lock()
+	s.protocol.metricSpecs = response.MetricSpecs
+	s.protocol.lock.Unlock()
+	return response.MetricSpecs, nil
+}
+
+// scaledObjectRefV2 returns the reference to the scaled object for the v2 protocol
+func (s *externalScaler) scaledObjectRefV2() *pbv2.ScaledObjectRef {
+	return &pbv2.ScaledObjectRef{
+		Name:           s.scaledObjectRef.Name,
+		Namespace:      s.scaledObjectRef.Namespace,
+		ScalerMetadata: s.scaledObjectRef.ScalerMetadata,
+	}
+}
+
+// isActiveV2 evaluates the activation targets reported by the scaler, IsActive of the scaler is called
+// only if there aren't any. The metric specs of the scaler are cached, so usually a single call is made
+// to the scaler besides the readiness check, if it is supported. An error is returned if the scaler isn't ready.
+// The values of the first metric are returned if the activity was evaluated on them.
+func (s *externalScaler) isActiveV2(ctx context.Context, grpcClient pbv2.ExternalScalerClient) (bool, []external_metrics.ExternalMetricValue, error) {
+	scaledObjectRef := s.scaledObjectRefV2()
+
+	if s.protocol.supports(pbv2.Capability_CAPABILITY_READINESS_CHECK) {
+		readiness, err := grpcClient.CheckReadiness(ctx, scaledObjectRef)
+		if err != nil {
+			return false, nil, fmt.Errorf("error checking readiness of external scaler: %s", err)
+		}
+		if err := checkHealthResponse(readiness); err != nil {
+			return false, nil, err
+		}
+	}
+
+	metricSpecs, err := s.getMetricSpecsV2(ctx, grpcClient, false)
+	if err != nil {
+		externalLog.Error(err, "error calling GetMetricSpec on external scaler")
+		return false, nil, err
+	}
+
+	hasActivationTarget := false
+	var firstMetrics []external_metrics.ExternalMetricValue
+	for i, spec := range metricSpecs {
+		if spec.ActivationTargetSize == "" {
+			continue
+		}
+		hasActivationTarget = true
+
+		activationTarget, err := resource.ParseQuantity(spec.ActivationTargetSize)
+		if err != nil {
+			return false, nil, fmt.Errorf("error parsing activationTargetSize of metric %s: %s", spec.MetricName, err)
+		}
+		metrics, err := s.getMetricsV2(ctx, grpcClient, GenerateMetricNameWithIndex(s.metadata.scalerIndex, spec.MetricName), spec.MetricName)
+		if err != nil {
+			return false, nil, err
+		}
+		if i == 0 {
+			firstMetrics = metrics
+		}
+		for _, metric := range metrics {
+			if metric.Value.Cmp(activationTarget) > 0 {
+				return true, firstMetrics, nil
+			}
+		}
+	}
+	if hasActivationTarget {
+		return false, firstMetrics, nil
+	}
+
+	response, err := grpcClient.IsActive(ctx, scaledObjectRef)
+	if err != nil {
+		externalLog.Error(err, "error calling IsActive on external scaler")
+		return false, nil, err
+	}
+	if err := scalerErrorToError(response.Error); err != nil {
+		return false, nil, err
+	}
+	return response.Result, nil, nil
+}
+
+// getMetricSpecForScalingV2 returns the metric spec for the HPA, the targets are decimal quantities
+// and the metric type reported by the scaler is used unless it is specified for the trigger
+func (s *externalScaler) getMetricSpecForScalingV2(ctx context.Context, grpcClient pbv2.ExternalScalerClient) []v2.MetricSpec {
+	var result []v2.MetricSpec
+
+	metricSpecs, err := s.getMetricSpecsV2(ctx, grpcClient, true)
+	if err != nil {
+		externalLog.Error(err, "error calling GetMetricSpec on external scaler")
+		return nil
+	}
+
+	for _, spec := range metricSpecs {
+		targetSize, err := resource.ParseQuantity(spec.TargetSize)
+		if err != nil {
+			externalLog.Error(err, "error parsing targetSize of metric", "metricName", spec.MetricName)
+			return nil
+		}
+
+		metricType := s.metricType
+		if !s.explicitMetricType {
+			switch spec.MetricType {
+			case pbv2.MetricType_METRIC_TYPE_AVERAGE_VALUE:
+				metricType = v2.AverageValueMetricType
+			case pbv2.MetricType_METRIC_TYPE_VALUE:
+				metricType = v2.ValueMetricType
+			}
+		}
+
+		target := v2.MetricTarget{Type: metricType}
+		if metricType == v2.AverageValueMetricType {
+			target.AverageValue = &targetSize
+		} else {
+			target.Value = &targetSize
+		}
+
+		result = append(result, v2.MetricSpec{
+			External: &v2.ExternalMetricSource{
+				Metric: v2.MetricIdentifier{
+					Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, spec.MetricName),
+				},
+				Target: target,
+			},
+			Type: externalMetricType,
+		})
+	}
+
+	return result
+}
+
+// getMetricsV2 returns the decimal values of the metric, metricNameWithoutIndex is the name used by the scaler
+func (s *externalScaler) getMetricsV2(ctx context.Context, grpcClient pbv2.ExternalScalerClient, metricName string, metricNameWithoutIndex string) ([]external_metrics.ExternalMetricValue, error) {
+	response, err := grpcClient.GetMetrics(ctx, &pbv2.GetMetricsRequest{
+		MetricName:      metricNameWithoutIndex,
+		ScaledObjectRef: s.scaledObjectRefV2(),
+	})
+	if err != nil {
+		externalLog.Error(err, "error calling GetMetrics on external scaler")
+		return []external_metrics.ExternalMetricValue{}, err
+	}
+	if err := scalerErrorToError(response.Error); err != nil {
+		return []external_metrics.ExternalMetricValue{}, err
+	}
+
+	metrics := make([]external_metrics.ExternalMetricValue, 0, len(response.MetricValues))
+	for _, metricResult := range response.MetricValues {
+		value, err := resource.ParseQuantity(metricResult.MetricValue)
+		if err != nil {
+			return []external_metrics.ExternalMetricValue{}, fmt.Errorf("error parsing value of metric %s: %s", metricResult.MetricName, err)
+		}
+		metrics = append(metrics, external_metrics.ExternalMetricValue{
+			MetricName: metricName,
+			Value:      value,
+			Timestamp:  metav1.Now(),
+		})
+	}
+
+	return metrics, nil
+}
+
+// handleIsActiveStreamV2 is handleIsActiveStream for the v2 protocol, the errors reported by the scaler
+// in the stream are logged and don't terminate it
+func (s *externalPushScaler) handleIsActiveStreamV2(ctx context.Context, grpcClient pbv2.ExternalScalerClient, active chan<- bool) error {
+	if !s.protocol.supports(pbv2.Capability_CAPABILITY_STREAM_IS_ACTIVE) {
+		return fmt.Errorf("external scaler doesn't support StreamIsActive")
+	}
+
+	stream, err := grpcClient.StreamIsActive(ctx, s.scaledObjectRefV2())
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if err := scalerErrorToError(resp.Error); err != nil {
+			externalLog.Error(err, "error received from StreamIsActive of external scaler")
+			continue
+		}
+
+		active <- resp.Result
+	}
+}
+
+// scalerErrorToError converts the structured error returned by the scaler, nil is returned if there isn't any
+func scalerErrorToError(scalerError *pbv2.ScalerError) error {
+	if scalerError == nil || (scalerError.Code == pbv2.ErrorCode_ERROR_CODE_UNSPECIFIED && scalerError.Message == "") {
+		return nil
+	}
+	return fmt.Errorf("external scaler returned error %s: %s", scalerError.Code, scalerError.Message)
+}
+
+func checkHealthResponse(response *pbv2.CheckHealthResponse) error {
+	if response.Status == pbv2.HealthStatus_HEALTH_STATUS_SERVING {
+		return nil
+	}
+	return fmt.Errorf("external scaler isn't serving (%s): %s", response.Status, response.Message)
+}
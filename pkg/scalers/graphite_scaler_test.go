@@ -2,10 +2,12 @@ package scalers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -190,3 +192,42 @@ func TestGrapScalerExecuteGrapQuery(t *testing.T) {
 		})
 	}
 }
+
+type graphiteActivationThresholdTestData struct {
+	value               int64
+	activationThreshold string
+	isActive            bool
+}
+
+var testGraphiteActivationThresholds = []graphiteActivationThresholdTestData{
+	// no activation threshold, active for any value greater than 0
+	{value: 0, isActive: false},
+	{value: 1, isActive: true},
+	// activation threshold has to be exceeded
+	{value: 10, activationThreshold: "10", isActive: false},
+	{value: 11, activationThreshold: "10", isActive: true},
+	// decimal activation threshold
+	{value: 2, activationThreshold: "1.5", isActive: true},
+}
+
+func TestGraphiteScalerActivationThreshold(t *testing.T) {
+	for _, testData := range testGraphiteActivationThresholds {
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = fmt.Fprintf(writer, `[{"target":"sumSeries(metric)","tags":{"name":"metric","aggregatedBy":"sum"},"datapoints":[[%d,10000000]]}]`, testData.value)
+		}))
+		config := &ScalerConfig{
+			TriggerMetadata:   map[string]string{"serverAddress": server.URL, "metricName": "request-count", "threshold": "100", "query": "stats.counters.http.hello-world.request.count.count", "queryTime": "-30Seconds", "activationThreshold": testData.activationThreshold},
+			GlobalHTTPTimeout: time.Second,
+		}
+
+		scaler, err := NewGraphiteScaler(config)
+		assert.NoError(t, err)
+		scaler, err = WithActivationThreshold(scaler, "graphite", config)
+		assert.NoError(t, err)
+
+		isActive, err := scaler.IsActive(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testData.isActive, isActive, "value %d, activationThreshold %q", testData.value, testData.activationThreshold)
+		server.Close()
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/Shopify/sarama"
-	v2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -20,10 +22,82 @@ import (
 )
 
 type kafkaScaler struct {
-	metricType v2beta2.MetricTargetType
+	metricType v2.MetricTargetType
 	metadata   kafkaMetadata
 	client     sarama.Client
 	admin      sarama.ClusterAdmin
+	// offsetsBatcher coalesces the consumer offset queries of the scalers of the same group,
+	// nil means the queries aren't batched
+	offsetsBatcher *queryBatcher
+	releaseBatchFn func() error
+	// releaseFn releases the clients shared with the other scalers of the same cluster and credentials
+	releaseFn func() error
+	// consumeFn consumes the new messages of the topic, it returns the channel of the messages
+	// and the function closing the consumer
+	consumeFn func(context.Context) (<-chan *sarama.ConsumerMessage, func() error, error)
+}
+
+// kafkaPushScaler is kafkaScaler notified of the new messages of the topic
+type kafkaPushScaler struct {
+	*kafkaScaler
+}
+
+// kafkaClients are the client and admin shared by the scalers of the same cluster and credentials
+type kafkaClients struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+}
+
+// consumerOffsetsQuery is the query of the consumer offsets of the partitions of the group
+type consumerOffsetsQuery struct {
+	group           string
+	topicPartitions map[string][]int32
+}
+
+// listConsumerGroupOffsets executes the queries of the batch by a single ListConsumerGroupOffsets call per group,
+// the partitions of the queries of the same group are merged and each query gets the offsets of all of them
+func (c *kafkaClients) listConsumerGroupOffsets(_ context.Context, queries map[string]interface{}) map[string]batchResult {
+	partitionsByGroup := map[string]map[string][]int32{}
+	for _, q := range queries {
+		query := q.(*consumerOffsetsQuery)
+		partitions, ok := partitionsByGroup[query.group]
+		if !ok {
+			partitions = map[string][]int32{}
+			partitionsByGroup[query.group] = partitions
+		}
+		for topic, topicPartitions := range query.topicPartitions {
+			// the partitions of the same topic are described by the same cluster, the list of the scaler
+			// which described the topic last may include the partitions added in the meantime
+			if len(topicPartitions) > len(partitions[topic]) {
+				partitions[topic] = topicPartitions
+			}
+		}
+	}
+
+	offsetsByGroup := make(map[string]batchResult, len(partitionsByGroup))
+	for group, partitions := range partitionsByGroup {
+		offsets, err := c.admin.ListConsumerGroupOffsets(group, partitions)
+		if err != nil {
+			err = fmt.Errorf("error listing consumer group offsets: %s", err)
+		}
+		offsetsByGroup[group] = batchResult{value: offsets, err: err}
+	}
+
+	results := make(map[string]batchResult, len(queries))
+	for key, q := range queries {
+		results[key] = offsetsByGroup[q.(*consumerOffsetsQuery).group]
+	}
+	return results
+}
+
+// Close closes the admin and the underlying client
+func (c *kafkaClients) Close() error {
+	return c.admin.Close()
+}
+
+// IsClosed returns true if the client is closed, eg. by a failed call of the admin
+func (c *kafkaClients) IsClosed() bool {
+	return c.client.Closed()
 }
 
 type kafkaMetadata struct {
@@ -39,6 +113,13 @@ type kafkaMetadata struct {
 	// occur or scale to 0 (true). See discussion in https://github.com/kedacore/keda/issues/2612
 	scaleToZeroOnInvalidOffset bool
 
+	// messageNotifications enables the push notifications of the new messages of the topic,
+	// every message of the topic is fetched, so it is meant for topics with a low rate of messages
+	messageNotifications bool
+
+	// activationThreshold is compared with the total lag of the partitions, not limited by the number of partitions
+	activationThreshold float64
+
 	// SASL
 	saslType kafkaSaslType
 	username string
@@ -92,17 +173,31 @@ func NewKafkaScaler(config *ScalerConfig) (Scaler, error) {
 		return nil, fmt.Errorf("error parsing kafka metadata: %s", err)
 	}
 
-	client, admin, err := getKafkaClients(kafkaMetadata)
+	clients, release, err := getSharedKafkaClients(kafkaMetadata)
 	if err != nil {
 		return nil, err
 	}
 
-	return &kafkaScaler{
-		client:     client,
-		admin:      admin,
+	s := &kafkaScaler{
+		client:     clients.client,
+		admin:      clients.admin,
+		releaseFn:  release,
 		metricType: metricType,
 		metadata:   kafkaMetadata,
-	}, nil
+	}
+	if config.QueryBatchWindow > 0 {
+		// the executor uses the clients shared under the same key, the calls are bounded by the timeouts of the client
+		s.offsetsBatcher, s.releaseBatchFn, err = getSharedQueryBatcher("kafka", kafkaClientsKey(kafkaMetadata), config.QueryBatchWindow, 0, clients.listConsumerGroupOffsets)
+		if err != nil {
+			_ = release()
+			return nil, err
+		}
+	}
+	s.consumeFn = s.consumeNewMessages
+	if kafkaMetadata.messageNotifications {
+		return &kafkaPushScaler{kafkaScaler: s}, nil
+	}
+	return s, nil
 }
 
 func parseKafkaAuthParams(config *ScalerConfig, meta *kafkaMetadata) error {
@@ -225,6 +320,18 @@ func parseKafkaMetadata(config *ScalerConfig) (kafkaMetadata, error) {
 		meta.scaleToZeroOnInvalidOffset = t
 	}
 
+	if val, ok := config.TriggerMetadata["messageNotifications"]; ok {
+		t, err := strconv.ParseBool(val)
+		if err != nil {
+			return meta, fmt.Errorf("error parsing messageNotifications: %s", err)
+		}
+		// the messages of all topics of the consumer group can't be consumed without joining it
+		if t && meta.topic == "" {
+			return meta, errors.New("messageNotifications requires topic")
+		}
+		meta.messageNotifications = t
+	}
+
 	meta.version = sarama.V1_0_0_0
 	if val, ok := config.TriggerMetadata["version"]; ok {
 		val = strings.TrimSpace(val)
@@ -234,6 +341,13 @@ func parseKafkaMetadata(config *ScalerConfig) (kafkaMetadata, error) {
 		}
 		meta.version = version
 	}
+
+	threshold, _, err := GetActivationThreshold(config)
+	if err != nil {
+		return meta, err
+	}
+	meta.activationThreshold = threshold
+
 	meta.scalerIndex = config.ScalerIndex
 	return meta, nil
 }
@@ -245,11 +359,12 @@ func (s *kafkaScaler) IsActive(ctx context.Context) (bool, error) {
 		return false, err
 	}
 
-	consumerOffsets, producerOffsets, err := s.getConsumerAndProducerOffsets(topicPartitions)
+	consumerOffsets, producerOffsets, err := s.getConsumerAndProducerOffsets(ctx, topicPartitions)
 	if err != nil {
 		return false, err
 	}
 
+	totalLag := int64(0)
 	for topic, partitionsOffsets := range producerOffsets {
 		for partitionID := range partitionsOffsets {
 			lag, err := s.getLagForPartition(topic, partitionID, consumerOffsets, producerOffsets)
@@ -258,8 +373,9 @@ func (s *kafkaScaler) IsActive(ctx context.Context) (bool, error) {
 			}
 			kafkaLog.V(1).Info(fmt.Sprintf("Group %s has a lag of %d for topic %s and partition %d\n", s.metadata.group, lag, topic, partitionID))
 
-			// Return as soon as a lag was detected for any partitionID
-			if lag > 0 {
+			// Return as soon as the lag exceeds the activation threshold
+			totalLag += lag
+			if float64(totalLag) > s.metadata.activationThreshold {
 				return true, nil
 			}
 		}
@@ -267,6 +383,28 @@ func (s *kafkaScaler) IsActive(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
+// getSharedKafkaClients returns the clients shared by the scalers connecting to the same brokers with the same credentials,
+// the release function must be called once the scaler doesn't use them anymore
+func getSharedKafkaClients(metadata kafkaMetadata) (*kafkaClients, func() error, error) {
+	clients, release, err := getSharedClient(kafkaClientsKey(metadata), func() (io.Closer, error) {
+		client, admin, err := getKafkaClients(metadata)
+		if err != nil {
+			return nil, err
+		}
+		return &kafkaClients{client: client, admin: admin}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return clients.(*kafkaClients), release, nil
+}
+
+// kafkaClientsKey identifies the cluster and the credentials the clients connect with
+func kafkaClientsKey(metadata kafkaMetadata) string {
+	return connectionKey("kafka", strings.Join(metadata.bootstrapServers, ","), metadata.version.String(), string(metadata.saslType),
+		metadata.username, metadata.password, strconv.FormatBool(metadata.enableTLS), metadata.cert, metadata.key, metadata.ca)
+}
+
 func getKafkaClients(metadata kafkaMetadata) (sarama.Client, sarama.ClusterAdmin, error) {
 	config := sarama.NewConfig()
 	config.Version = metadata.version
@@ -353,7 +491,16 @@ func (s *kafkaScaler) getTopicPartitions() (map[string][]int32, error) {
 	return topicPartitions, nil
 }
 
-func (s *kafkaScaler) getConsumerOffsets(topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+func (s *kafkaScaler) getConsumerOffsets(ctx context.Context, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	if s.offsetsBatcher != nil {
+		offsets, err := s.offsetsBatcher.query(ctx, consumerOffsetsQueryKey(s.metadata.group, topicPartitions),
+			&consumerOffsetsQuery{group: s.metadata.group, topicPartitions: topicPartitions})
+		if err != nil {
+			return nil, err
+		}
+		return offsets.(*sarama.OffsetFetchResponse), nil
+	}
+
 	offsets, err := s.admin.ListConsumerGroupOffsets(s.metadata.group, topicPartitions)
 	if err != nil {
 		return nil, fmt.Errorf("error listing consumer group offsets: %s", err)
@@ -361,6 +508,17 @@ func (s *kafkaScaler) getConsumerOffsets(topicPartitions map[string][]int32) (*s
 	return offsets, nil
 }
 
+// consumerOffsetsQueryKey identifies the query by the group and the topics, the queries of the same topics
+// of the group are the same query
+func consumerOffsetsQueryKey(group string, topicPartitions map[string][]int32) string {
+	topics := make([]string, 0, len(topicPartitions))
+	for topic := range topicPartitions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return group + "/" + strings.Join(topics, ",")
+}
+
 func (s *kafkaScaler) getLagForPartition(topic string, partitionID int32, offsets *sarama.OffsetFetchResponse, topicPartitionOffsets map[string]map[int32]int64) (int64, error) {
 	block := offsets.GetBlock(topic, partitionID)
 	if block == nil {
@@ -391,18 +549,109 @@ func (s *kafkaScaler) getLagForPartition(topic string, partitionID int32, offset
 	return latestOffset - consumerOffset, nil
 }
 
-// Close closes the kafka admin and client
-func (s *kafkaScaler) Close(context.Context) error {
-	// underlying client will also be closed on admin's Close() call
-	err := s.admin.Close()
+// consumeNewMessages consumes the messages produced to the partitions of the topic from now on. The consumer doesn't
+// join the consumer group, so it doesn't cause a rebalance of the group and doesn't commit any offsets.
+func (s *kafkaScaler) consumeNewMessages(ctx context.Context) (<-chan *sarama.ConsumerMessage, func() error, error) {
+	partitions, err := s.client.Partitions(s.metadata.topic)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting partitions of topic %s: %s", s.metadata.topic, err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(s.client)
 	if err != nil {
-		return err
+		return nil, nil, fmt.Errorf("error creating kafka consumer: %s", err)
 	}
 
-	return nil
+	var partitionConsumers []sarama.PartitionConsumer
+	closeFn := func() error {
+		var errs []string
+		for _, partitionConsumer := range partitionConsumers {
+			if err := partitionConsumer.Close(); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		// the shared client isn't closed by the consumer
+		if err := consumer.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("error closing kafka consumer: %s", strings.Join(errs, ", "))
+		}
+		return nil
+	}
+
+	messages := make(chan *sarama.ConsumerMessage)
+	var wg sync.WaitGroup
+	for _, partition := range partitions {
+		partitionConsumer, err := consumer.ConsumePartition(s.metadata.topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			_ = closeFn()
+			return nil, nil, fmt.Errorf("error consuming partition %d of topic %s: %s", partition, s.metadata.topic, err)
+		}
+		partitionConsumers = append(partitionConsumers, partitionConsumer)
+
+		wg.Add(1)
+		go func(partitionMessages <-chan *sarama.ConsumerMessage) {
+			defer wg.Done()
+			// the messages are drained until the partition consumer is closed
+			for message := range partitionMessages {
+				select {
+				case messages <- message:
+				case <-ctx.Done():
+				}
+			}
+		}(partitionConsumer.Messages())
+	}
+	go func() {
+		wg.Wait()
+		close(messages)
+	}()
+
+	return messages, closeFn, nil
+}
+
+// Run consumes the new messages of the topic and signals each of them, the partitions
+// added to the topic afterwards are checked only on pollingInterval
+func (s *kafkaPushScaler) Run(ctx context.Context, active chan<- bool) {
+	defer close(active)
+
+	messages, closeFn, err := s.consumeFn(ctx)
+	if err != nil {
+		kafkaLog.Error(err, "error consuming new messages of kafka topic", "topic", s.metadata.topic)
+		return
+	}
+	defer func() {
+		if err := closeFn(); err != nil {
+			kafkaLog.Error(err, "error closing kafka consumer")
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-messages:
+			if !ok {
+				return
+			}
+			select {
+			case active <- true:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Close closes the kafka admin and client
+func (s *kafkaScaler) Close(context.Context) error {
+	if s.releaseBatchFn != nil {
+		_ = s.releaseBatchFn()
+	}
+	// the shared admin and underlying client are closed once the last scaler using them is closed
+	return s.releaseFn()
 }
 
-func (s *kafkaScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
+func (s *kafkaScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
 	var metricName string
 	if s.metadata.topic != "" {
 		metricName = fmt.Sprintf("kafka-%s", s.metadata.topic)
@@ -410,14 +659,14 @@ func (s *kafkaScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricS
 		metricName = fmt.Sprintf("kafka-%s-topics", s.metadata.group)
 	}
 
-	externalMetric := &v2beta2.ExternalMetricSource{
-		Metric: v2beta2.MetricIdentifier{
+	externalMetric := &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{
 			Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, kedautil.NormalizeString(metricName)),
 		},
 		Target: GetMetricTarget(s.metricType, s.metadata.lagThreshold),
 	}
-	metricSpec := v2beta2.MetricSpec{External: externalMetric, Type: kafkaMetricType}
-	return []v2beta2.MetricSpec{metricSpec}
+	metricSpec := v2.MetricSpec{External: externalMetric, Type: kafkaMetricType}
+	return []v2.MetricSpec{metricSpec}
 }
 
 type consumerOffsetResult struct {
@@ -430,10 +679,10 @@ type producerOffsetResult struct {
 	err             error
 }
 
-func (s *kafkaScaler) getConsumerAndProducerOffsets(topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, map[string]map[int32]int64, error) {
+func (s *kafkaScaler) getConsumerAndProducerOffsets(ctx context.Context, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, map[string]map[int32]int64, error) {
 	consumerChan := make(chan consumerOffsetResult, 1)
 	go func() {
-		consumerOffsets, err := s.getConsumerOffsets(topicPartitions)
+		consumerOffsets, err := s.getConsumerOffsets(ctx, topicPartitions)
 		consumerChan <- consumerOffsetResult{consumerOffsets, err}
 	}()
 
@@ -463,7 +712,7 @@ func (s *kafkaScaler) GetMetrics(ctx context.Context, metricName string, metricS
 		return []external_metrics.ExternalMetricValue{}, err
 	}
 
-	consumerOffsets, producerOffsets, err := s.getConsumerAndProducerOffsets(topicPartitions)
+	consumerOffsets, producerOffsets, err := s.getConsumerAndProducerOffsets(ctx, topicPartitions)
 	if err != nil {
 		return []external_metrics.ExternalMetricValue{}, err
 	}
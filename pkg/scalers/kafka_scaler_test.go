@@ -2,8 +2,12 @@ package scalers
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
 )
 
 type parseKafkaMetadataTestData struct {
@@ -211,7 +215,7 @@ func TestKafkaGetMetricSpecForScaling(t *testing.T) {
 		if err != nil {
 			t.Fatal("Could not parse metadata:", err)
 		}
-		mockKafkaScaler := kafkaScaler{"", meta, nil, nil}
+		mockKafkaScaler := kafkaScaler{"", meta, nil, nil, nil, nil, nil, nil}
 
 		metricSpec := mockKafkaScaler.GetMetricSpecForScaling(context.Background())
 		metricName := metricSpec[0].External.Metric.Name
@@ -220,3 +224,149 @@ func TestKafkaGetMetricSpecForScaling(t *testing.T) {
 		}
 	}
 }
+
+type mockKafkaClusterAdmin struct {
+	sarama.ClusterAdmin
+	calls map[string]map[string][]int32
+}
+
+func (m *mockKafkaClusterAdmin) ListConsumerGroupOffsets(group string, topicPartitions map[string][]int32) (*sarama.OffsetFetchResponse, error) {
+	m.calls[group] = topicPartitions
+	if group == "broken" {
+		return nil, errors.New("coordinator not available")
+	}
+	response := &sarama.OffsetFetchResponse{}
+	for topic, partitions := range topicPartitions {
+		for _, partition := range partitions {
+			response.AddBlock(topic, partition, &sarama.OffsetFetchResponseBlock{Offset: int64(partition)})
+		}
+	}
+	return response, nil
+}
+
+func TestKafkaConsumerOffsetsAreBatchedPerGroup(t *testing.T) {
+	admin := &mockKafkaClusterAdmin{calls: map[string]map[string][]int32{}}
+	clients := &kafkaClients{admin: admin}
+
+	queries := map[string]interface{}{}
+	for _, query := range []*consumerOffsetsQuery{
+		{group: "orders", topicPartitions: map[string][]int32{"created": {0, 1}}},
+		{group: "orders", topicPartitions: map[string][]int32{"created": {0, 1, 2}, "paid": {0}}},
+		{group: "payments", topicPartitions: map[string][]int32{"paid": {0}}},
+		{group: "broken", topicPartitions: map[string][]int32{"paid": {0}}},
+	} {
+		queries[consumerOffsetsQueryKey(query.group, query.topicPartitions)] = query
+	}
+
+	results := clients.listConsumerGroupOffsets(context.Background(), queries)
+
+	// a single call per group with the partitions of all its queries
+	assert.Equal(t, map[string]map[string][]int32{
+		"orders":   {"created": {0, 1, 2}, "paid": {0}},
+		"payments": {"paid": {0}},
+		"broken":   {"paid": {0}},
+	}, admin.calls)
+
+	offsets := results["orders/created"].value.(*sarama.OffsetFetchResponse)
+	assert.Equal(t, int64(2), offsets.GetBlock("created", 2).Offset)
+	assert.Same(t, offsets, results["orders/created,paid"].value)
+	assert.NoError(t, results["payments/paid"].err)
+	assert.ErrorContains(t, results["broken/paid"].err, "coordinator not available")
+}
+
+type kafkaActivationThresholdTestData struct {
+	lags                []int64
+	activationThreshold string
+	isActive            bool
+}
+
+var testKafkaActivationThresholds = []kafkaActivationThresholdTestData{
+	// no activation threshold, active for any lag
+	{lags: []int64{0, 0}, isActive: false},
+	{lags: []int64{0, 1}, isActive: true},
+	// activation threshold has to be exceeded by the total lag of the partitions
+	{lags: []int64{5, 5}, activationThreshold: "10", isActive: false},
+	{lags: []int64{5, 6}, activationThreshold: "10", isActive: true},
+	// the lag isn't limited by the number of partitions, unlike the metric
+	{lags: []int64{30, 30}, activationThreshold: "50", isActive: true},
+}
+
+func TestKafkaScalerActivationThreshold(t *testing.T) {
+	for _, testData := range testKafkaActivationThresholds {
+		broker := sarama.NewMockBroker(t, 1)
+		metadataResponse := sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID())
+		offsetFetchResponse := sarama.NewMockOffsetFetchResponse(t)
+		offsetResponse := sarama.NewMockOffsetResponse(t).SetVersion(1)
+		for partition, lag := range testData.lags {
+			metadataResponse.SetLeader("orders", int32(partition), broker.BrokerID())
+			offsetFetchResponse.SetOffset("payments", "orders", int32(partition), 100, "", sarama.ErrNoError)
+			offsetResponse.SetOffset("orders", int32(partition), sarama.OffsetNewest, 100+lag)
+		}
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": metadataResponse,
+			"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+				SetCoordinator(sarama.CoordinatorGroup, "payments", broker),
+			"OffsetFetchRequest": offsetFetchResponse,
+			"OffsetRequest":      offsetResponse,
+		})
+
+		config := &ScalerConfig{
+			TriggerMetadata:     map[string]string{"bootstrapServers": broker.Addr(), "consumerGroup": "payments", "topic": "orders", "lagThreshold": "10"},
+			AuthParams:          map[string]string{},
+			ActivationThreshold: testData.activationThreshold,
+		}
+		scaler, err := NewKafkaScaler(config)
+		assert.NoError(t, err)
+
+		isActive, err := scaler.IsActive(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testData.isActive, isActive, "lags %v, activationThreshold %q", testData.lags, testData.activationThreshold)
+
+		scaler.Close(context.Background())
+		broker.Close()
+	}
+}
+
+func TestKafkaMessageNotifications(t *testing.T) {
+	config := &ScalerConfig{TriggerMetadata: map[string]string{"bootstrapServers": "foobar:9092", "consumerGroup": "my-group", "messageNotifications": "true"}, AuthParams: map[string]string{}}
+	_, err := parseKafkaMetadata(config)
+	assert.EqualError(t, err, "messageNotifications requires topic")
+
+	config.TriggerMetadata["messageNotifications"] = "yes"
+	_, err = parseKafkaMetadata(config)
+	assert.Error(t, err)
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetController(broker.BrokerID()).
+			SetLeader("orders", 0, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("orders", 0, sarama.OffsetOldest, 0).
+			SetOffset("orders", 0, sarama.OffsetNewest, 100),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetVersion(4).
+			SetMessage("orders", 0, 100, sarama.StringEncoder("order")),
+	})
+
+	scaler, err := NewKafkaScaler(&ScalerConfig{TriggerMetadata: map[string]string{"bootstrapServers": broker.Addr(), "consumerGroup": "payments", "topic": "orders", "messageNotifications": "true"}, AuthParams: map[string]string{}})
+	assert.NoError(t, err)
+	defer scaler.Close(context.Background())
+	pushScaler, ok := scaler.(PushScaler)
+	assert.True(t, ok)
+
+	// the message produced to the topic is signaled
+	ctx, cancel := context.WithCancel(context.Background())
+	active := make(chan bool)
+	go pushScaler.Run(ctx, active)
+	assert.True(t, <-active)
+
+	cancel()
+	for range active {
+	}
+}
@@ -0,0 +1,138 @@
+package scalers
+
+import (
+	"fmt"
+)
+
+// ValidateTriggerMetadata parses the metadata of the trigger the same way as its scaler does, but without connecting
+// to the scaled service, so the invalid metadata can be rejected before the scaler is built. The triggers which
+// can't be validated offline (azure-pipelines resolves the agent pool by Azure DevOps API) are accepted.
+func ValidateTriggerMetadata(triggerType string, config *ScalerConfig) error {
+	if triggerType != "cpu" && triggerType != "memory" {
+		if _, err := GetMetricTargetType(config); err != nil {
+			return fmt.Errorf("error getting scaler metric type: %s", err)
+		}
+	}
+	if err := validateActivationThreshold(triggerType, config); err != nil {
+		return err
+	}
+
+	var err error
+	switch triggerType {
+	case "activemq":
+		_, err = parseActiveMQMetadata(config)
+	case "artemis-queue":
+		_, err = parseArtemisMetadata(config)
+	case "aws-cloudwatch":
+		_, err = parseAwsCloudwatchMetadata(config)
+	case "aws-dynamodb":
+		_, err = parseAwsDynamoDBMetadata(config)
+	case "aws-kinesis-stream":
+		_, err = parseAwsKinesisStreamMetadata(config)
+	case "aws-sqs-queue":
+		_, err = parseAwsSqsQueueMetadata(config)
+	case "azure-app-insights":
+		_, err = parseAzureAppInsightsMetadata(config)
+	case "azure-blob":
+		_, _, err = parseAzureBlobMetadata(config)
+	case "azure-data-explorer":
+		_, err = parseAzureDataExplorerMetadata(config)
+	case "azure-eventhub":
+		_, err = parseAzureEventHubMetadata(config)
+	case "azure-log-analytics":
+		_, err = parseAzureLogAnalyticsMetadata(config)
+	case "azure-monitor":
+		_, err = parseAzureMonitorMetadata(config)
+	case "azure-pipelines":
+		return nil
+	case "azure-queue":
+		_, _, err = parseAzureQueueMetadata(config)
+	case "azure-servicebus":
+		_, err = parseAzureServiceBusMetadata(config)
+	case "cassandra":
+		_, err = ParseCassandraMetadata(config)
+	case "cpu", "memory":
+		_, err = parseResourceMetadata(config)
+	case "cron":
+		_, err = parseCronMetadata(config)
+	case "datadog":
+		_, err = parseDatadogMetadata(config)
+	case "elasticsearch":
+		_, err = parseElasticsearchMetadata(config)
+	case "external", "external-push":
+		_, err = parseExternalScalerMetadata(config)
+	case "gcp-pubsub":
+		_, err = parsePubSubMetadata(config)
+	case "gcp-stackdriver":
+		_, err = parseStackdriverMetadata(config)
+	case "gcp-storage":
+		_, err = parseGcsMetadata(config)
+	case "graphite":
+		_, err = parseGraphiteMetadata(config)
+	case "huawei-cloudeye":
+		_, err = parseHuaweiCloudeyeMetadata(config)
+	case "ibmmq":
+		_, err = parseIBMMQMetadata(config)
+	case "influxdb":
+		_, err = parseInfluxDBMetadata(config)
+	case "kafka":
+		_, err = parseKafkaMetadata(config)
+	case "kubernetes-workload":
+		_, err = parseWorkloadMetadata(config)
+	case "liiklus":
+		_, err = parseLiiklusMetadata(config)
+	case "metrics-api":
+		_, err = parseMetricsAPIMetadata(config)
+	case "mongodb":
+		_, _, err = parseMongoDBMetadata(config)
+	case "mssql":
+		_, err = parseMSSQLMetadata(config)
+	case "mysql":
+		_, err = parseMySQLMetadata(config)
+	case "new-relic":
+		_, err = parseNewRelicMetadata(config)
+	case "openstack-metric":
+		if _, err = parseOpenstackMetricMetadata(config); err == nil {
+			_, err = parseOpenstackMetricAuthenticationMetadata(config)
+		}
+	case "openstack-swift":
+		if _, err = parseOpenstackSwiftMetadata(config); err == nil {
+			_, err = parseOpenstackSwiftAuthenticationMetadata(config)
+		}
+	case "postgresql":
+		_, err = parsePostgreSQLMetadata(config)
+	case "predictkube":
+		_, err = parsePredictKubeMetadata(config)
+	case "prometheus":
+		_, err = parsePrometheusMetadata(config)
+	case "rabbitmq":
+		_, err = parseRabbitMQMetadata(config)
+	case "redis":
+		_, err = parseRedisMetadata(config, parseRedisAddress)
+	case "redis-cluster":
+		var meta *redisMetadata
+		if meta, err = parseRedisMetadata(config, parseRedisClusterAddress); err == nil && meta.keyspaceNotifications {
+			err = fmt.Errorf("keyspaceNotifications isn't supported by redis cluster")
+		}
+	case "redis-cluster-streams":
+		_, err = parseRedisStreamsMetadata(config, parseRedisClusterAddress)
+	case "redis-sentinel":
+		_, err = parseRedisMetadata(config, parseRedisSentinelAddress)
+	case "redis-sentinel-streams":
+		_, err = parseRedisStreamsMetadata(config, parseRedisSentinelAddress)
+	case "redis-streams":
+		_, err = parseRedisStreamsMetadata(config, parseRedisAddress)
+	case "selenium-grid":
+		_, err = parseSeleniumGridScalerMetadata(config)
+	case "solace-event-queue":
+		_, err = parseSolaceMetadata(config)
+	case "stan":
+		_, err = parseStanMetadata(config)
+	default:
+		return fmt.Errorf("no scaler found for type: %s", triggerType)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing %s metadata: %s", triggerType, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package scalers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+)
+
+type validateTriggerMetadataTestData struct {
+	name        string
+	triggerType string
+	metadata    map[string]string
+	metricType  v2.MetricTargetType
+	isError     bool
+}
+
+var validateTriggerMetadataTestDataset = []validateTriggerMetadataTestData{
+	{name: "valid", triggerType: "prometheus", metadata: map[string]string{"serverAddress": "http://localhost:9090", "metricName": "http_requests_total", "threshold": "100", "query": "up"}},
+	{name: "missing query", triggerType: "prometheus", metadata: map[string]string{"serverAddress": "http://localhost:9090", "metricName": "http_requests_total", "threshold": "100"}, isError: true},
+	{name: "invalid activation threshold", triggerType: "prometheus", metadata: map[string]string{"serverAddress": "http://localhost:9090", "metricName": "http_requests_total", "threshold": "100", "query": "up", "activationThreshold": "x"}, isError: true},
+	{name: "activation threshold of cron", triggerType: "cron", metadata: map[string]string{"timezone": "Etc/UTC", "start": "0 8 * * *", "end": "0 18 * * *", "desiredReplicas": "3", "activationThreshold": "1"}, isError: true},
+	{name: "activation threshold of kafka", triggerType: "kafka", metadata: map[string]string{"bootstrapServers": "kafka:9092", "consumerGroup": "jobs", "activationThreshold": "50"}},
+	{name: "utilization of external metric", triggerType: "prometheus", metadata: map[string]string{"serverAddress": "http://localhost:9090", "metricName": "http_requests_total", "threshold": "100", "query": "up"}, metricType: v2.UtilizationMetricType, isError: true},
+	{name: "utilization of cpu", triggerType: "cpu", metadata: map[string]string{"value": "50"}, metricType: v2.UtilizationMetricType},
+	{name: "redis cluster keyspace notifications", triggerType: "redis-cluster", metadata: map[string]string{"addresses": "redis:6379", "listName": "jobs", "keyspaceNotifications": "true"}, isError: true},
+	{name: "rabbitmq http consumer events", triggerType: "rabbitmq", metadata: map[string]string{"host": "http://rabbitmq:15672", "queueName": "jobs", "consumerEvents": "true"}, isError: true},
+	{name: "kafka message notifications without topic", triggerType: "kafka", metadata: map[string]string{"bootstrapServers": "kafka:9092", "consumerGroup": "jobs", "messageNotifications": "true"}, isError: true},
+	{name: "unknown type", triggerType: "unknown", metadata: map[string]string{}, isError: true},
+}
+
+func TestValidateTriggerMetadata(t *testing.T) {
+	for _, testData := range validateTriggerMetadataTestDataset {
+		config := &ScalerConfig{
+			TriggerMetadata: testData.metadata,
+			AuthParams:      map[string]string{},
+			ResolvedEnv:     map[string]string{},
+			MetricType:      testData.metricType,
+		}
+		err := ValidateTriggerMetadata(testData.triggerType, config)
+		if testData.isError {
+			assert.Error(t, err, testData.name)
+		} else {
+			assert.NoError(t, err, testData.name)
+		}
+	}
+}
@@ -243,3 +243,43 @@ func TestGetMetricValueErrorMessage(t *testing.T) {
 
 	assert.Equal(t, err.Error(), "/api/v1/: api returned 418")
 }
+
+type metricsAPIActivationThresholdTestData struct {
+	value               string
+	activationThreshold string
+	isActive            bool
+}
+
+var testMetricsAPIActivationThresholds = []metricsAPIActivationThresholdTestData{
+	// no activation threshold, active for any value greater than 0
+	{value: "0", isActive: false},
+	{value: "1", isActive: true},
+	// activation threshold has to be exceeded
+	{value: "10", activationThreshold: "10", isActive: false},
+	{value: "11", activationThreshold: "10", isActive: true},
+	// decimal activation threshold
+	{value: "2", activationThreshold: "1.5", isActive: true},
+}
+
+func TestMetricsAPIScalerActivationThreshold(t *testing.T) {
+	for _, testData := range testMetricsAPIActivationThresholds {
+		apiStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"components":[{"id": "82328e93e", "tasks": %s}]}`, testData.value)
+		}))
+		config := &ScalerConfig{
+			TriggerMetadata:     map[string]string{"url": apiStub.URL, "valueLocation": "components.0.tasks", "targetValue": "100"},
+			ActivationThreshold: testData.activationThreshold,
+			GlobalHTTPTimeout:   time.Second,
+		}
+
+		scaler, err := NewMetricsAPIScaler(config)
+		assert.NoError(t, err)
+		scaler, err = WithActivationThreshold(scaler, "metrics-api", config)
+		assert.NoError(t, err)
+
+		isActive, err := scaler.IsActive(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testData.isActive, isActive, "value %s, activationThreshold %q", testData.value, testData.activationThreshold)
+		apiStub.Close()
+	}
+}
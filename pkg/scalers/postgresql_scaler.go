@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io"
 	"strconv"
+	"time"
 
 	// PostreSQL drive required for this scaler
-	_ "github.com/lib/pq"
-	"k8s.io/api/autoscaling/v2beta2"
+	"github.com/lib/pq"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -19,9 +21,19 @@ import (
 )
 
 type postgreSQLScaler struct {
-	metricType v2beta2.MetricTargetType
+	metricType v2.MetricTargetType
 	metadata   *postgreSQLMetadata
 	connection *sql.DB
+	// releaseFn releases the connection pool shared with the other scalers of the same connection string
+	releaseFn func() error
+	// listenFn listens on the notification channel, it returns the channel of the notifications
+	// and the function closing the listener
+	listenFn func(context.Context) (<-chan *pq.Notification, func() error, error)
+}
+
+// postgreSQLPushScaler is postgreSQLScaler notified of the changes by LISTEN/NOTIFY
+type postgreSQLPushScaler struct {
+	*postgreSQLScaler
 }
 
 type postgreSQLMetadata struct {
@@ -30,6 +42,9 @@ type postgreSQLMetadata struct {
 	query            string
 	metricName       string
 	scalerIndex      int
+	// notifyChannel is the channel listened on for notifications of the changes, eg. sent
+	// by a trigger of the table with NOTIFY, the notifications are disabled if it's empty
+	notifyChannel string
 }
 
 var postgreSQLLog = logf.Log.WithName("postgreSQL_scaler")
@@ -46,15 +61,21 @@ func NewPostgreSQLScaler(config *ScalerConfig) (Scaler, error) {
 		return nil, fmt.Errorf("error parsing postgreSQL metadata: %s", err)
 	}
 
-	conn, err := getConnection(meta)
+	conn, release, err := getSharedConnection(meta)
 	if err != nil {
 		return nil, fmt.Errorf("error establishing postgreSQL connection: %s", err)
 	}
-	return &postgreSQLScaler{
+	s := &postgreSQLScaler{
 		metricType: metricType,
 		metadata:   meta,
 		connection: conn,
-	}, nil
+		releaseFn:  release,
+		listenFn:   getListener(meta),
+	}
+	if meta.notifyChannel != "" {
+		return &postgreSQLPushScaler{postgreSQLScaler: s}, nil
+	}
+	return s, nil
 }
 
 func parsePostgreSQLMetadata(config *ScalerConfig) (*postgreSQLMetadata, error) {
@@ -130,10 +151,23 @@ func parsePostgreSQLMetadata(config *ScalerConfig) (*postgreSQLMetadata, error)
 	} else {
 		meta.metricName = kedautil.NormalizeString("postgresql")
 	}
+	meta.notifyChannel = config.TriggerMetadata["notifyChannel"]
 	meta.scalerIndex = config.ScalerIndex
 	return &meta, nil
 }
 
+// getSharedConnection returns the connection pool shared by the scalers with the same connection string,
+// the release function must be called once the scaler doesn't use it anymore
+func getSharedConnection(meta *postgreSQLMetadata) (*sql.DB, func() error, error) {
+	db, release, err := getSharedClient(connectionKey("postgresql", meta.connection), func() (io.Closer, error) {
+		return getConnection(meta)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.(*sql.DB), release, nil
+}
+
 func getConnection(meta *postgreSQLMetadata) (*sql.DB, error) {
 	db, err := sql.Open("postgres", meta.connection)
 	if err != nil {
@@ -148,9 +182,27 @@ func getConnection(meta *postgreSQLMetadata) (*sql.DB, error) {
 	return db, nil
 }
 
+// getListener returns the function listening on the notification channel, the listener
+// uses its own connection and reconnects if it is lost
+func getListener(meta *postgreSQLMetadata) func(context.Context) (<-chan *pq.Notification, func() error, error) {
+	return func(context.Context) (<-chan *pq.Notification, func() error, error) {
+		listener := pq.NewListener(meta.connection, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				postgreSQLLog.Error(err, "error of postgreSQL listener", "notifyChannel", meta.notifyChannel)
+			}
+		})
+		if err := listener.Listen(meta.notifyChannel); err != nil {
+			listener.Close()
+			return nil, nil, err
+		}
+		return listener.Notify, listener.Close, nil
+	}
+}
+
 // Close disposes of postgres connections
 func (s *postgreSQLScaler) Close(context.Context) error {
-	err := s.connection.Close()
+	// the shared connection pool is closed once the last scaler using it is closed
+	err := s.releaseFn()
 	if err != nil {
 		postgreSQLLog.Error(err, "Error closing postgreSQL connection")
 		return err
@@ -179,17 +231,17 @@ func (s *postgreSQLScaler) getActiveNumber(ctx context.Context) (int64, error) {
 }
 
 // GetMetricSpecForScaling returns the MetricSpec for the Horizontal Pod Autoscaler
-func (s *postgreSQLScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
-	externalMetric := &v2beta2.ExternalMetricSource{
-		Metric: v2beta2.MetricIdentifier{
+func (s *postgreSQLScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
+	externalMetric := &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{
 			Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, s.metadata.metricName),
 		},
 		Target: GetMetricTarget(s.metricType, s.metadata.targetQueryValue),
 	}
-	metricSpec := v2beta2.MetricSpec{
+	metricSpec := v2.MetricSpec{
 		External: externalMetric, Type: externalMetricType,
 	}
-	return []v2beta2.MetricSpec{metricSpec}
+	return []v2.MetricSpec{metricSpec}
 }
 
 // GetMetrics returns value for a supported metric and an error if there is a problem getting the metric
@@ -207,3 +259,36 @@ func (s *postgreSQLScaler) GetMetrics(ctx context.Context, metricName string, me
 
 	return append([]external_metrics.ExternalMetricValue{}, metric), nil
 }
+
+// Run listens on the notification channel and signals each notification. The listener reconnects if the connection
+// is lost, the reconnection is signaled as well, as the notifications sent in the meantime are lost.
+func (s *postgreSQLPushScaler) Run(ctx context.Context, active chan<- bool) {
+	defer close(active)
+
+	notifications, closeFn, err := s.listenFn(ctx)
+	if err != nil {
+		postgreSQLLog.Error(err, "error listening on postgreSQL notification channel", "notifyChannel", s.metadata.notifyChannel)
+		return
+	}
+	defer func() {
+		if err := closeFn(); err != nil {
+			postgreSQLLog.Error(err, "error closing postgreSQL listener")
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-notifications:
+			if !ok {
+				return
+			}
+			select {
+			case active <- true:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
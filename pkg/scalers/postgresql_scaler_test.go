@@ -3,6 +3,8 @@ package scalers
 import (
 	"context"
 	"testing"
+
+	"github.com/lib/pq"
 )
 
 type parsePostgreSQLMetadataTestData struct {
@@ -43,7 +45,7 @@ func TestPosgresSQLGetMetricSpecForScaling(t *testing.T) {
 		if err != nil {
 			t.Fatal("Could not parse metadata:", err)
 		}
-		mockPostgresSQLScaler := postgreSQLScaler{"", meta, nil}
+		mockPostgresSQLScaler := postgreSQLScaler{"", meta, nil, nil, nil}
 
 		metricSpec := mockPostgresSQLScaler.GetMetricSpecForScaling(context.Background())
 		metricName := metricSpec[0].External.Metric.Name
@@ -136,3 +138,42 @@ func TestParsePosgresSQLMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestPosgresSQLPushScalerSignalsNotifications(t *testing.T) {
+	meta, err := parsePostgreSQLMetadata(&ScalerConfig{TriggerMetadata: map[string]string{"query": "test_query", "targetQueryValue": "5", "notifyChannel": "jobs"}, AuthParams: map[string]string{"connection": "postgresql://localhost:5432"}})
+	if err != nil {
+		t.Fatal("Could not parse metadata:", err)
+	}
+	if meta.notifyChannel != "jobs" {
+		t.Errorf("Wrong notifyChannel, expected 'jobs' and get '%s'", meta.notifyChannel)
+	}
+
+	notifications := make(chan *pq.Notification, 2)
+	closed := false
+	scaler := &postgreSQLPushScaler{postgreSQLScaler: &postgreSQLScaler{metadata: meta, listenFn: func(context.Context) (<-chan *pq.Notification, func() error, error) {
+		return notifications, func() error {
+			closed = true
+			return nil
+		}, nil
+	}}}
+
+	// a notification and a reconnection of the listener are signaled
+	notifications <- &pq.Notification{Channel: "jobs"}
+	notifications <- nil
+	ctx, cancel := context.WithCancel(context.Background())
+	active := make(chan bool)
+	go scaler.Run(ctx, active)
+	for i := 0; i < 2; i++ {
+		if !<-active {
+			t.Error("Expected activation to be signaled")
+		}
+	}
+
+	cancel()
+	if _, ok := <-active; ok {
+		t.Error("Expected active channel to be closed")
+	}
+	if !closed {
+		t.Error("Expected listener to be closed")
+	}
+}
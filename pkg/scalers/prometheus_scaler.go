@@ -8,9 +8,10 @@ import (
 	"net/http"
 	url_pkg "net/url"
 	"strconv"
+	"sync"
 	"time"
 
-	v2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -32,9 +33,12 @@ const (
 )
 
 type prometheusScaler struct {
-	metricType v2beta2.MetricTargetType
+	metricType v2.MetricTargetType
 	metadata   *prometheusMetadata
 	httpClient *http.Client
+	// batcher coalesces the queries of the scalers of the same server, nil means the queries aren't batched
+	batcher   *queryBatcher
+	releaseFn func() error
 }
 
 type prometheusMetadata struct {
@@ -87,11 +91,76 @@ func NewPrometheusScaler(config *ScalerConfig) (Scaler, error) {
 		}
 	}
 
-	return &prometheusScaler{
+	s := &prometheusScaler{
 		metricType: metricType,
 		metadata:   meta,
 		httpClient: httpClient,
-	}, nil
+	}
+	if config.QueryBatchWindow > 0 {
+		executor := s.newBatchExecutor()
+		s.batcher, s.releaseFn, err = getSharedQueryBatcher("prometheus", executor.batcherKey(config.GlobalHTTPTimeout), config.QueryBatchWindow, config.GlobalHTTPTimeout, executor.executePromQueries)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// newBatchExecutor returns the scaler executing the batched queries, it is configured by the settings
+// of the server and the client only, so it doesn't depend on the query of the scaler creating the batcher
+func (s *prometheusScaler) newBatchExecutor() *prometheusScaler {
+	return &prometheusScaler{
+		metadata: &prometheusMetadata{
+			serverAddress:  s.metadata.serverAddress,
+			namespace:      s.metadata.namespace,
+			cortexOrgID:    s.metadata.cortexOrgID,
+			prometheusAuth: s.metadata.prometheusAuth,
+		},
+		httpClient: s.httpClient,
+	}
+}
+
+// batcherKey identifies the server and the settings of the client, the scalers with the same key
+// can share the batcher executing the queries by the client of any of them
+func (s *prometheusScaler) batcherKey(timeout time.Duration) string {
+	values := []string{s.metadata.serverAddress, s.metadata.namespace, s.metadata.cortexOrgID, timeout.String()}
+	if auth := s.metadata.prometheusAuth; auth != nil {
+		values = append(values, strconv.FormatBool(auth.EnableBearerAuth), auth.BearerToken, strconv.FormatBool(auth.EnableBasicAuth),
+			auth.Username, auth.Password, strconv.FormatBool(auth.EnableTLS), auth.Cert, auth.Key, auth.CA)
+	}
+	return connectionKey("prometheus-batch", values...)
+}
+
+// executePromQueries executes each distinct query of the batch once, the queries are executed concurrently
+// as Prometheus doesn't evaluate several queries by a single call
+func (s *prometheusScaler) executePromQueries(ctx context.Context, queries map[string]interface{}) map[string]batchResult {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]batchResult, len(queries))
+	for query := range queries {
+		wg.Add(1)
+		go func(query string) {
+			defer wg.Done()
+			value, err := s.executePromQuery(ctx, query)
+			mutex.Lock()
+			results[query] = batchResult{value: value, err: err}
+			mutex.Unlock()
+		}(query)
+	}
+	wg.Wait()
+	return results
+}
+
+// getQueryValue executes the query of the scaler along with the same and other queries of the scalers of the same server
+func (s *prometheusScaler) getQueryValue(ctx context.Context) (float64, error) {
+	if s.batcher == nil {
+		return s.ExecutePromQuery(ctx)
+	}
+	value, err := s.batcher.query(ctx, s.metadata.query, nil)
+	if err != nil {
+		return -1, err
+	}
+	return value.(float64), nil
 }
 
 func parsePrometheusMetadata(config *ScalerConfig) (meta *prometheusMetadata, err error) {
@@ -146,7 +215,7 @@ func parsePrometheusMetadata(config *ScalerConfig) (meta *prometheusMetadata, er
 }
 
 func (s *prometheusScaler) IsActive(ctx context.Context) (bool, error) {
-	val, err := s.ExecutePromQuery(ctx)
+	val, err := s.getQueryValue(ctx)
 	if err != nil {
 		prometheusLog.Error(err, "error executing prometheus query")
 		return false, err
@@ -156,26 +225,33 @@ func (s *prometheusScaler) IsActive(ctx context.Context) (bool, error) {
 }
 
 func (s *prometheusScaler) Close(context.Context) error {
+	if s.releaseFn != nil {
+		return s.releaseFn()
+	}
 	return nil
 }
 
-func (s *prometheusScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
+func (s *prometheusScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
 	metricName := kedautil.NormalizeString(fmt.Sprintf("prometheus-%s", s.metadata.metricName))
-	externalMetric := &v2beta2.ExternalMetricSource{
-		Metric: v2beta2.MetricIdentifier{
+	externalMetric := &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{
 			Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, metricName),
 		},
 		Target: GetMetricTarget(s.metricType, s.metadata.threshold),
 	}
-	metricSpec := v2beta2.MetricSpec{
+	metricSpec := v2.MetricSpec{
 		External: externalMetric, Type: externalMetricType,
 	}
-	return []v2beta2.MetricSpec{metricSpec}
+	return []v2.MetricSpec{metricSpec}
 }
 
 func (s *prometheusScaler) ExecutePromQuery(ctx context.Context) (float64, error) {
+	return s.executePromQuery(ctx, s.metadata.query)
+}
+
+func (s *prometheusScaler) executePromQuery(ctx context.Context, query string) (float64, error) {
 	t := time.Now().UTC().Format(time.RFC3339)
-	queryEscaped := url_pkg.QueryEscape(s.metadata.query)
+	queryEscaped := url_pkg.QueryEscape(query)
 	url := fmt.Sprintf("%s/api/v1/query?query=%s&time=%s", s.metadata.serverAddress, queryEscaped, t)
 
 	// set 'namespace' parameter for namespaced Prometheus requests (eg. for Thanos Querier)
@@ -225,14 +301,14 @@ func (s *prometheusScaler) ExecutePromQuery(ctx context.Context) (float64, error
 	if len(result.Data.Result) == 0 {
 		return 0, nil
 	} else if len(result.Data.Result) > 1 {
-		return -1, fmt.Errorf("prometheus query %s returned multiple elements", s.metadata.query)
+		return -1, fmt.Errorf("prometheus query %s returned multiple elements", query)
 	}
 
 	valueLen := len(result.Data.Result[0].Value)
 	if valueLen == 0 {
 		return 0, nil
 	} else if valueLen < 2 {
-		return -1, fmt.Errorf("prometheus query %s didn't return enough values", s.metadata.query)
+		return -1, fmt.Errorf("prometheus query %s didn't return enough values", query)
 	}
 
 	val := result.Data.Result[0].Value[1]
@@ -249,7 +325,7 @@ func (s *prometheusScaler) ExecutePromQuery(ctx context.Context) (float64, error
 }
 
 func (s *prometheusScaler) GetMetrics(ctx context.Context, metricName string, _ labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
-	val, err := s.ExecutePromQuery(ctx)
+	val, err := s.getQueryValue(ctx)
 	if err != nil {
 		prometheusLog.Error(err, "error executing prometheus query")
 		return []external_metrics.ExternalMetricValue{}, err
@@ -2,10 +2,13 @@ package scalers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -239,3 +242,100 @@ func TestPrometheusScalerCortexHeader(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func TestPrometheusScalersShareQueries(t *testing.T) {
+	var mutex sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mutex.Lock()
+		requests[request.URL.Query().Get("query")]++
+		mutex.Unlock()
+		_, _ = writer.Write([]byte(`{"data":{"result":[{"value": ["1", "2"]}]}}`))
+	}))
+	defer server.Close()
+
+	var scalers []Scaler
+	for _, query := range []string{"up", "up", "down"} {
+		scaler, err := NewPrometheusScaler(&ScalerConfig{
+			TriggerMetadata:   map[string]string{"serverAddress": server.URL, "metricName": "up", "threshold": "1", "query": query},
+			GlobalHTTPTimeout: time.Second,
+			QueryBatchWindow:  testQueryBatchWindow,
+		})
+		assert.NoError(t, err)
+		scalers = append(scalers, scaler)
+	}
+
+	var wg sync.WaitGroup
+	for _, scaler := range scalers {
+		wg.Add(1)
+		go func(scaler Scaler) {
+			defer wg.Done()
+			metrics, err := scaler.GetMetrics(context.Background(), "up", nil)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(2), metrics[0].Value.Value())
+		}(scaler)
+	}
+	wg.Wait()
+
+	// the same query of the scalers of the same server is executed once
+	assert.Equal(t, map[string]int{"up": 1, "down": 1}, requests)
+
+	// the batch doesn't depend on the scaler which created the batcher
+	assert.NoError(t, scalers[0].Close(context.Background()))
+	_, err := scalers[2].GetMetrics(context.Background(), "up", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"up": 1, "down": 2}, requests)
+
+	for _, scaler := range scalers[1:] {
+		assert.NoError(t, scaler.Close(context.Background()))
+	}
+
+	// the queries aren't batched by default
+	scaler, err := NewPrometheusScaler(&ScalerConfig{
+		TriggerMetadata:   map[string]string{"serverAddress": server.URL, "metricName": "up", "threshold": "1", "query": "up"},
+		GlobalHTTPTimeout: time.Second,
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, scaler.(*prometheusScaler).batcher)
+}
+
+type prometheusActivationThresholdTestData struct {
+	value               int64
+	activationThreshold string
+	isActive            bool
+}
+
+var testPrometheusActivationThresholds = []prometheusActivationThresholdTestData{
+	// no activation threshold, active for any value greater than 0
+	{value: 0, isActive: false},
+	{value: 1, isActive: true},
+	// activation threshold has to be exceeded
+	{value: 9, activationThreshold: "10", isActive: false},
+	{value: 10, activationThreshold: "10", isActive: false},
+	{value: 11, activationThreshold: "10", isActive: true},
+	// activation threshold above the target
+	{value: 150, activationThreshold: "200", isActive: false},
+}
+
+func TestPrometheusScalerActivationThreshold(t *testing.T) {
+	for _, testData := range testPrometheusActivationThresholds {
+		server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			_, _ = fmt.Fprintf(writer, `{"data":{"result":[{"value": ["1", "%d"]}]}}`, testData.value)
+		}))
+		config := &ScalerConfig{
+			TriggerMetadata:     map[string]string{"serverAddress": server.URL, "metricName": "http_requests_total", "threshold": "100", "query": "up"},
+			ActivationThreshold: testData.activationThreshold,
+			GlobalHTTPTimeout:   time.Second,
+		}
+
+		scaler, err := NewPrometheusScaler(config)
+		assert.NoError(t, err)
+		scaler, err = WithActivationThreshold(scaler, "prometheus", config)
+		assert.NoError(t, err)
+
+		isActive, err := scaler.IsActive(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testData.isActive, isActive, "value %d, activationThreshold %q", testData.value, testData.activationThreshold)
+		server.Close()
+	}
+}
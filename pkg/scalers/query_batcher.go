@@ -0,0 +1,132 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/kedacore/keda/v2/pkg/metrics"
+)
+
+// batchResult is the result of a single query of the batch
+type batchResult struct {
+	value interface{}
+	err   error
+}
+
+// batchExecutor executes the queries of the batch, keyed by the identity of the query, by as few calls
+// of the backend as possible and returns the result of each of them. A failed call fails the results
+// of all the queries it carried.
+type batchExecutor func(ctx context.Context, queries map[string]interface{}) map[string]batchResult
+
+// queryBatch are the queries collected within a batch window
+type queryBatch struct {
+	queries map[string]interface{}
+	results map[string]batchResult
+	// done is closed once the results are available
+	done chan struct{}
+}
+
+// queryBatcher coalesces the queries of the scalers sharing a backend. The queries issued within the batch window are
+// executed together by a single batchExecutor call and the results are distributed back to the scalers. The queries
+// with the same key are executed once, so the scalers with the same query get the same result.
+// The window is started by the first query, so it waits the full window, and only the scalers polled within
+// the same window share the batch.
+type queryBatcher struct {
+	// scalerType labels the batch size metric
+	scalerType string
+	executeFn  batchExecutor
+	// window is for how long the queries are collected before they are executed together,
+	// it is the latency added to the queries, see ScalerConfig.QueryBatchWindow
+	window time.Duration
+	// timeout bounds the execution of the batch (0 means unbounded), the batch doesn't use the context
+	// of any of the scalers, so a scaler giving up doesn't fail the queries of the others
+	timeout time.Duration
+
+	mutex   sync.Mutex
+	pending *queryBatch
+}
+
+func newQueryBatcher(scalerType string, window time.Duration, timeout time.Duration, executeFn batchExecutor) *queryBatcher {
+	return &queryBatcher{
+		scalerType: scalerType,
+		executeFn:  executeFn,
+		window:     window,
+		timeout:    timeout,
+	}
+}
+
+// query adds the query to the pending batch and waits for its result
+func (b *queryBatcher) query(ctx context.Context, key string, query interface{}) (interface{}, error) {
+	b.mutex.Lock()
+	batch := b.pending
+	if batch == nil {
+		batch = &queryBatch{
+			queries: map[string]interface{}{},
+			done:    make(chan struct{}),
+		}
+		b.pending = batch
+		time.AfterFunc(b.window, b.flush)
+	}
+	if _, ok := batch.queries[key]; !ok {
+		batch.queries[key] = query
+	}
+	b.mutex.Unlock()
+
+	select {
+	case <-batch.done:
+		result, ok := batch.results[key]
+		if !ok {
+			return nil, fmt.Errorf("no result of the batched query %s", key)
+		}
+		return result.value, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush executes the pending batch, the next query starts a new one
+func (b *queryBatcher) flush() {
+	b.mutex.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mutex.Unlock()
+
+	metrics.RecordQueryBatchSize(b.scalerType, len(batch.queries))
+	ctx, cancel := context.WithCancel(context.Background())
+	if b.timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), b.timeout)
+	}
+	defer cancel()
+	batch.results = b.executeFn(ctx, batch.queries)
+	close(batch.done)
+}
+
+// Close allows the batcher to be shared by getSharedClient, the pending batch is still executed
+func (b *queryBatcher) Close() error {
+	return nil
+}
+
+// failBatch returns the error as the result of all the queries
+func failBatch(queries map[string]interface{}, err error) map[string]batchResult {
+	results := make(map[string]batchResult, len(queries))
+	for key := range queries {
+		results[key] = batchResult{err: err}
+	}
+	return results
+}
+
+// getSharedQueryBatcher returns the batcher shared by the scalers of the backend identified by the key, see connectionKey,
+// the release function must be called once the scaler doesn't use it anymore. The key has to identify all the settings
+// the executor depends on, as the executor of the scaler creating the batcher executes the queries of all of them.
+func getSharedQueryBatcher(scalerType string, key string, window time.Duration, timeout time.Duration, executeFn batchExecutor) (*queryBatcher, func() error, error) {
+	batcher, release, err := getSharedClient(key+"/"+window.String(), func() (io.Closer, error) {
+		return newQueryBatcher(scalerType, window, timeout, executeFn), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return batcher.(*queryBatcher), release, nil
+}
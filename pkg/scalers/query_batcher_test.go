@@ -0,0 +1,122 @@
+package scalers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testQueryBatchWindow = 100 * time.Millisecond
+
+type recordingBatchExecutor struct {
+	mutex   sync.Mutex
+	batches []map[string]interface{}
+}
+
+func (e *recordingBatchExecutor) execute(_ context.Context, queries map[string]interface{}) map[string]batchResult {
+	e.mutex.Lock()
+	e.batches = append(e.batches, queries)
+	e.mutex.Unlock()
+
+	results := map[string]batchResult{}
+	for key, query := range queries {
+		if query == "fail" {
+			results[key] = batchResult{err: fmt.Errorf("query %s failed", key)}
+			continue
+		}
+		results[key] = batchResult{value: "result of " + key}
+	}
+	return results
+}
+
+func TestQueryBatcherCoalescesQueries(t *testing.T) {
+	executor := &recordingBatchExecutor{}
+	batcher := newQueryBatcher("test", testQueryBatchWindow, time.Second, executor.execute)
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "a", "c"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			result, err := batcher.query(context.Background(), key, key)
+			assert.NoError(t, err)
+			assert.Equal(t, "result of "+key, result)
+		}(key)
+	}
+	wg.Wait()
+
+	// the queries issued within the window are executed by a single call, the same query once
+	assert.Len(t, executor.batches, 1)
+	assert.Equal(t, map[string]interface{}{"a": "a", "b": "b", "c": "c"}, executor.batches[0])
+
+	// the next query starts a new batch
+	_, err := batcher.query(context.Background(), "a", "a")
+	assert.NoError(t, err)
+	assert.Len(t, executor.batches, 2)
+}
+
+func TestQueryBatcherDistributesErrors(t *testing.T) {
+	executor := &recordingBatchExecutor{}
+	batcher := newQueryBatcher("test", testQueryBatchWindow, time.Second, executor.execute)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := batcher.query(context.Background(), "broken", "fail")
+		assert.ErrorContains(t, err, "query broken failed")
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := batcher.query(context.Background(), "ok", "ok")
+		assert.NoError(t, err)
+	}()
+	wg.Wait()
+
+	_, err := newQueryBatcher("test", testQueryBatchWindow, time.Second, func(_ context.Context, queries map[string]interface{}) map[string]batchResult {
+		return failBatch(queries, fmt.Errorf("backend unavailable"))
+	}).query(context.Background(), "a", "a")
+	assert.ErrorContains(t, err, "backend unavailable")
+
+	_, err = newQueryBatcher("test", testQueryBatchWindow, time.Second, func(context.Context, map[string]interface{}) map[string]batchResult {
+		return nil
+	}).query(context.Background(), "a", "a")
+	assert.ErrorContains(t, err, "no result of the batched query a")
+}
+
+func TestQueryBatcherQueryIsCanceled(t *testing.T) {
+	release := make(chan struct{})
+	batcher := newQueryBatcher("test", testQueryBatchWindow, time.Second, func(_ context.Context, queries map[string]interface{}) map[string]batchResult {
+		<-release
+		return failBatch(queries, nil)
+	})
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testQueryBatchWindow/2)
+	defer cancel()
+	_, err := batcher.query(ctx, "a", "a")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSharedQueryBatcherPerWindow(t *testing.T) {
+	executor := &recordingBatchExecutor{}
+	batcher, release, err := getSharedQueryBatcher("test", "test-batch/backend", testQueryBatchWindow, time.Second, executor.execute)
+	assert.NoError(t, err)
+	defer func() { _ = release() }()
+
+	same, releaseSame, err := getSharedQueryBatcher("test", "test-batch/backend", testQueryBatchWindow, time.Second, executor.execute)
+	assert.NoError(t, err)
+	defer func() { _ = releaseSame() }()
+	assert.Same(t, batcher, same)
+
+	// the scalers configured with another window don't share the batch
+	other, releaseOther, err := getSharedQueryBatcher("test", "test-batch/backend", 2*testQueryBatchWindow, time.Second, executor.execute)
+	assert.NoError(t, err)
+	defer func() { _ = releaseOther() }()
+	assert.NotSame(t, batcher, other)
+	assert.Equal(t, 2*testQueryBatchWindow, other.window)
+}
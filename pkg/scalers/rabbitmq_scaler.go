@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -12,7 +13,7 @@ import (
 	"time"
 
 	"github.com/streadway/amqp"
-	v2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -37,6 +38,8 @@ const (
 	defaultRabbitMQQueueLength   = 20
 	rabbitMetricType             = "External"
 	rabbitRootVhostPath          = "/%2F"
+	// rabbitEventExchange is the exchange the events are published to by the rabbitmq_event_exchange plugin
+	rabbitEventExchange = "amq.rabbitmq.event"
 )
 
 const (
@@ -54,11 +57,24 @@ const (
 )
 
 type rabbitMQScaler struct {
-	metricType v2beta2.MetricTargetType
+	metricType v2.MetricTargetType
 	metadata   *rabbitMQMetadata
 	connection *amqp.Connection
 	channel    *amqp.Channel
 	httpClient *http.Client
+	// releaseFn releases the connection shared with the other scalers of the same host
+	releaseFn func() error
+	// batcher coalesces the queue lookups of the scalers of the same vhost, nil means the lookups aren't batched
+	batcher        *queryBatcher
+	releaseBatchFn func() error
+	// subscribeFn subscribes to the events of the consumers, it returns the channel
+	// of the events and the function closing the subscription
+	subscribeFn func(context.Context) (<-chan amqp.Delivery, func() error, error)
+}
+
+// rabbitMQPushScaler is rabbitMQScaler notified of the changes of the consumers of the queue
+type rabbitMQPushScaler struct {
+	*rabbitMQScaler
 }
 
 type rabbitMQMetadata struct {
@@ -74,6 +90,11 @@ type rabbitMQMetadata struct {
 	metricName  string        // custom metric name for trigger
 	timeout     time.Duration // custom http timeout for a specific trigger
 	scalerIndex int           // scaler index
+	// consumerEvents enables the push notifications of the consumers added to or removed from the queue,
+	// the rabbitmq_event_exchange plugin has to publish the events to the vhost of the queue
+	consumerEvents bool
+	// activationThreshold is compared with the queue length or the publish rate, depending on the mode
+	activationThreshold float64
 }
 
 type queueInfo struct {
@@ -115,6 +136,21 @@ func NewRabbitMQScaler(config *ScalerConfig) (Scaler, error) {
 	s.metadata = meta
 	s.httpClient = kedautil.CreateHTTPClient(meta.timeout, false)
 
+	// the queues matched by regex are listed by the scaler itself, the queues of all vhosts can't be told apart by name
+	if config.QueryBatchWindow > 0 && meta.protocol == httpProtocol && !meta.useRegex {
+		managementURL, vhost, err := s.getManagementAPI()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing rabbitmq host: %s", err)
+		}
+		if vhost != "" {
+			key := connectionKey("rabbitmq-batch", managementURL, vhost, meta.timeout.String())
+			s.batcher, s.releaseBatchFn, err = getSharedQueryBatcher("rabbitmq", key, config.QueryBatchWindow, meta.timeout, getQueuesInfoViaHTTP(s.httpClient, managementURL, vhost))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	if meta.protocol == amqpProtocol {
 		// Override vhost if requested.
 		host := meta.host
@@ -127,14 +163,19 @@ func NewRabbitMQScaler(config *ScalerConfig) (Scaler, error) {
 			host = hostURI.String()
 		}
 
-		conn, ch, err := getConnectionAndChannel(host)
+		conn, ch, release, err := getSharedConnectionAndChannel(host)
 		if err != nil {
 			return nil, fmt.Errorf("error establishing rabbitmq connection: %s", err)
 		}
 		s.connection = conn
 		s.channel = ch
+		s.releaseFn = release
+		s.subscribeFn = s.subscribeToConsumerEvents
 	}
 
+	if meta.consumerEvents {
+		return &rabbitMQPushScaler{rabbitMQScaler: s}, nil
+	}
 	return s, nil
 }
 
@@ -230,6 +271,18 @@ func parseRabbitMQMetadata(config *ScalerConfig) (*rabbitMQMetadata, error) {
 		return nil, fmt.Errorf("configure only useRegex with http protocol")
 	}
 
+	// Resolve consumerEvents
+	if val, ok := config.TriggerMetadata["consumerEvents"]; ok {
+		consumerEvents, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("consumerEvents has invalid value")
+		}
+		if consumerEvents && meta.protocol != amqpProtocol {
+			return nil, fmt.Errorf("configure only consumerEvents with amqp protocol")
+		}
+		meta.consumerEvents = consumerEvents
+	}
+
 	_, err := parseTrigger(&meta, config)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse trigger: %s", err)
@@ -259,6 +312,12 @@ func parseRabbitMQMetadata(config *ScalerConfig) (*rabbitMQMetadata, error) {
 		meta.timeout = config.GlobalHTTPTimeout
 	}
 
+	threshold, _, err := GetActivationThreshold(config)
+	if err != nil {
+		return nil, err
+	}
+	meta.activationThreshold = threshold
+
 	meta.scalerIndex = config.ScalerIndex
 
 	return &meta, nil
@@ -324,24 +383,103 @@ func parseTrigger(meta *rabbitMQMetadata, config *ScalerConfig) (*rabbitMQMetada
 	return meta, nil
 }
 
-func getConnectionAndChannel(host string) (*amqp.Connection, *amqp.Channel, error) {
-	conn, err := amqp.Dial(host)
+// getSharedConnectionAndChannel returns the connection shared by the scalers of the same host and a channel of the scaler,
+// the channel isn't shared because it is closed by the server on an error, eg. when the queue doesn't exist.
+// The release function must be called once the scaler doesn't use the connection anymore.
+func getSharedConnectionAndChannel(host string) (*amqp.Connection, *amqp.Channel, func() error, error) {
+	conn, release, err := getSharedClient(connectionKey("rabbitmq", host), func() (io.Closer, error) {
+		return amqp.Dial(host)
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	channel, err := conn.(*amqp.Connection).Channel()
+	if err != nil {
+		_ = release()
+		return nil, nil, nil, err
+	}
+
+	return conn.(*amqp.Connection), channel, release, nil
+}
+
+// subscribeToConsumerEvents binds an exclusive queue to the event exchange and consumes the events of the consumers,
+// a channel of its own is used, as the channel of the scaler is closed by the server on an error of the queue inspection
+func (s *rabbitMQScaler) subscribeToConsumerEvents(context.Context) (<-chan amqp.Delivery, func() error, error) {
+	ch, err := s.connection.Channel()
 	if err != nil {
 		return nil, nil, err
 	}
 
-	channel, err := conn.Channel()
+	queue, err := ch.QueueDeclare("", false, true, true, false, nil)
 	if err != nil {
+		_ = ch.Close()
 		return nil, nil, err
 	}
+	for _, routingKey := range []string{"consumer.created", "consumer.deleted"} {
+		if err := ch.QueueBind(queue.Name, routingKey, rabbitEventExchange, false, nil); err != nil {
+			// the channel is closed by the server already if the exchange doesn't exist
+			_ = ch.Close()
+			return nil, nil, err
+		}
+	}
 
-	return conn, channel, nil
+	events, err := ch.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		_ = ch.Close()
+		return nil, nil, err
+	}
+	return events, ch.Close, nil
+}
+
+// Run signals the consumers added to or removed from the queue, eg. a consumer is gone while the messages
+// keep coming, the events of the consumers of the other queues are ignored
+func (s *rabbitMQPushScaler) Run(ctx context.Context, active chan<- bool) {
+	defer close(active)
+
+	events, closeFn, err := s.subscribeFn(ctx)
+	if err != nil {
+		rabbitmqLog.Error(s.anonimizeRabbitMQError(err), "error subscribing to rabbitmq consumer events", "queueName", s.metadata.queueName)
+		return
+	}
+	defer func() {
+		if err := closeFn(); err != nil && err != amqp.ErrClosed {
+			rabbitmqLog.Error(err, "error closing subscription to rabbitmq consumer events")
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if queue, _ := event.Headers["queue"].(string); queue != s.metadata.queueName {
+				continue
+			}
+			rabbitmqLog.V(1).Info("Received consumer event", "queueName", s.metadata.queueName, "event", event.RoutingKey)
+			select {
+			case active <- true:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
 }
 
 // Close disposes of RabbitMQ connections
 func (s *rabbitMQScaler) Close(context.Context) error {
+	if s.releaseBatchFn != nil {
+		_ = s.releaseBatchFn()
+	}
 	if s.connection != nil {
-		err := s.connection.Close()
+		// the channel may have been closed by the server already, eg. on an error of the queue inspection
+		if err := s.channel.Close(); err != nil && err != amqp.ErrClosed {
+			rabbitmqLog.Error(err, "Error closing rabbitmq channel")
+		}
+		err := s.releaseFn()
 		if err != nil {
 			rabbitmqLog.Error(err, "Error closing rabbitmq connection")
 			return err
@@ -352,20 +490,21 @@ func (s *rabbitMQScaler) Close(context.Context) error {
 
 // IsActive returns true if there are pending messages to be processed
 func (s *rabbitMQScaler) IsActive(ctx context.Context) (bool, error) {
-	messages, publishRate, err := s.getQueueStatus()
+	messages, publishRate, err := s.getQueueStatus(ctx)
 	if err != nil {
 		return false, s.anonimizeRabbitMQError(err)
 	}
 
 	if s.metadata.mode == rabbitModeQueueLength {
-		return messages > 0, nil
+		return float64(messages) > s.metadata.activationThreshold, nil
 	}
-	return publishRate > 0 || messages > 0, nil
+	// the messages left in the queue are consumed even if the publish rate doesn't exceed the activation threshold
+	return publishRate > s.metadata.activationThreshold || messages > 0, nil
 }
 
-func (s *rabbitMQScaler) getQueueStatus() (int64, float64, error) {
+func (s *rabbitMQScaler) getQueueStatus(ctx context.Context) (int64, float64, error) {
 	if s.metadata.protocol == httpProtocol {
-		info, err := s.getQueueInfoViaHTTP()
+		info, err := s.getQueueInfoViaHTTP(ctx)
 		if err != nil {
 			return -1, -1, err
 		}
@@ -412,11 +551,13 @@ func getJSON(s *rabbitMQScaler, url string) (queueInfo, error) {
 	return result, fmt.Errorf("error requesting rabbitMQ API status: %s, response: %s, from: %s", r.Status, body, url)
 }
 
-func (s *rabbitMQScaler) getQueueInfoViaHTTP() (*queueInfo, error) {
+// getManagementAPI returns the URL of the management API and the escaped path of the vhost of the queues,
+// the path is empty for the queues of all vhosts
+func (s *rabbitMQScaler) getManagementAPI() (string, string, error) {
 	parsedURL, err := url.Parse(s.metadata.host)
 
 	if err != nil {
-		return nil, err
+		return "", "", err
 	}
 
 	// Extract vhost from URL's path.
@@ -446,11 +587,28 @@ func (s *rabbitMQScaler) getQueueInfoViaHTTP() (*queueInfo, error) {
 	// Clear URL path to get the correct host.
 	parsedURL.Path = ""
 
+	return parsedURL.String(), vhost, nil
+}
+
+func (s *rabbitMQScaler) getQueueInfoViaHTTP(ctx context.Context) (*queueInfo, error) {
+	if s.batcher != nil {
+		info, err := s.batcher.query(ctx, s.metadata.queueName, nil)
+		if err != nil {
+			return nil, err
+		}
+		return info.(*queueInfo), nil
+	}
+
+	managementURL, vhost, err := s.getManagementAPI()
+	if err != nil {
+		return nil, err
+	}
+
 	var getQueueInfoManagementURI string
 	if s.metadata.useRegex {
-		getQueueInfoManagementURI = fmt.Sprintf("%s/api/queues%s?page=1&use_regex=true&pagination=false&name=%s&page_size=%d", parsedURL.String(), vhost, url.QueryEscape(s.metadata.queueName), s.metadata.pageSize)
+		getQueueInfoManagementURI = fmt.Sprintf("%s/api/queues%s?page=1&use_regex=true&pagination=false&name=%s&page_size=%d", managementURL, vhost, url.QueryEscape(s.metadata.queueName), s.metadata.pageSize)
 	} else {
-		getQueueInfoManagementURI = fmt.Sprintf("%s/api/queues%s/%s", parsedURL.String(), vhost, url.QueryEscape(s.metadata.queueName))
+		getQueueInfoManagementURI = fmt.Sprintf("%s/api/queues%s/%s", managementURL, vhost, url.QueryEscape(s.metadata.queueName))
 	}
 
 	var info queueInfo
@@ -463,24 +621,78 @@ func (s *rabbitMQScaler) getQueueInfoViaHTTP() (*queueInfo, error) {
 	return &info, nil
 }
 
+// getQueuesInfoViaHTTP returns the executor of the batched lookups of the queues of the vhost, a single queue is looked up
+// by its name, several queues are looked up by a single listing of the queues of the vhost. The client is configured
+// by the timeout only and the credentials are part of managementURL, so these identify the executor.
+func getQueuesInfoViaHTTP(httpClient *http.Client, managementURL, vhost string) batchExecutor {
+	return func(ctx context.Context, queues map[string]interface{}) map[string]batchResult {
+		results := make(map[string]batchResult, len(queues))
+		if len(queues) == 1 {
+			for queueName := range queues {
+				var info queueInfo
+				err := getJSONWithContext(ctx, httpClient, fmt.Sprintf("%s/api/queues%s/%s", managementURL, vhost, url.QueryEscape(queueName)), &info)
+				results[queueName] = batchResult{value: &info, err: err}
+			}
+			return results
+		}
+
+		var infos []queueInfo
+		listURL := fmt.Sprintf("%s/api/queues%s?columns=name,messages,messages_unacknowledged,message_stats.publish_details.rate", managementURL, vhost)
+		if err := getJSONWithContext(ctx, httpClient, listURL, &infos); err != nil {
+			return failBatch(queues, err)
+		}
+		for i := range infos {
+			if _, ok := queues[infos[i].Name]; ok {
+				results[infos[i].Name] = batchResult{value: &infos[i]}
+			}
+		}
+		for queueName := range queues {
+			if _, ok := results[queueName]; !ok {
+				results[queueName] = batchResult{err: fmt.Errorf("queue %s not found in vhost", queueName)}
+			}
+		}
+		return results
+	}
+}
+
+// getJSONWithContext decodes the response of the management API into the result
+func getJSONWithContext(ctx context.Context, httpClient *http.Client, url string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	r, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode == 200 {
+		return json.NewDecoder(r.Body).Decode(result)
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	return fmt.Errorf("error requesting rabbitMQ API status: %s, response: %s, from: %s", r.Status, body, url)
+}
+
 // GetMetricSpecForScaling returns the MetricSpec for the Horizontal Pod Autoscaler
-func (s *rabbitMQScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
-	externalMetric := &v2beta2.ExternalMetricSource{
-		Metric: v2beta2.MetricIdentifier{
+func (s *rabbitMQScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
+	externalMetric := &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{
 			Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, s.metadata.metricName),
 		},
 		Target: GetMetricTarget(s.metricType, s.metadata.value),
 	}
-	metricSpec := v2beta2.MetricSpec{
+	metricSpec := v2.MetricSpec{
 		External: externalMetric, Type: rabbitMetricType,
 	}
 
-	return []v2beta2.MetricSpec{metricSpec}
+	return []v2.MetricSpec{metricSpec}
 }
 
 // GetMetrics returns value for a supported metric and an error if there is a problem getting the metric
 func (s *rabbitMQScaler) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
-	messages, publishRate, err := s.getQueueStatus()
+	messages, publishRate, err := s.getQueueStatus(ctx)
 	if err != nil {
 		return []external_metrics.ExternalMetricValue{}, s.anonimizeRabbitMQError(err)
 	}
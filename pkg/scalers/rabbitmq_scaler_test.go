@@ -6,9 +6,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/streadway/amqp"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -111,6 +113,12 @@ var testRabbitMQMetadata = []parseRabbitMQMetadataTestData{
 	{map[string]string{"mode": "MessageRate", "value": "1000", "queueName": "sample", "host": "http://", "useRegex": "true", "pageSize": "-1"}, true, map[string]string{}},
 	// invalid pageSize
 	{map[string]string{"mode": "MessageRate", "value": "1000", "queueName": "sample", "host": "http://", "useRegex": "true", "pageSize": "a"}, true, map[string]string{}},
+	// amqp consumerEvents
+	{map[string]string{"mode": "QueueLength", "value": "1000", "queueName": "sample", "host": "amqp://", "consumerEvents": "true"}, false, map[string]string{}},
+	// http consumerEvents
+	{map[string]string{"mode": "QueueLength", "value": "1000", "queueName": "sample", "host": "http://", "consumerEvents": "true"}, true, map[string]string{}},
+	// invalid consumerEvents
+	{map[string]string{"mode": "QueueLength", "value": "1000", "queueName": "sample", "host": "amqp://", "consumerEvents": "a"}, true, map[string]string{}},
 }
 
 var rabbitMQMetricIdentifiers = []rabbitMQMetricIdentifier{
@@ -590,3 +598,133 @@ func TestRegexQueueMissingError(t *testing.T) {
 		}
 	}
 }
+
+func TestRabbitMQScalersListQueuesOfVhostOnce(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []string
+	apiStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests = append(requests, r.URL.Path)
+		mutex.Unlock()
+		_, _ = w.Write([]byte(`[{"name": "orders", "messages": 4}, {"name": "payments", "messages": 0}, {"name": "other", "messages": 100}]`))
+	}))
+	defer apiStub.Close()
+
+	var scalers []Scaler
+	for _, queueName := range []string{"orders", "payments", "missing"} {
+		s, err := NewRabbitMQScaler(&ScalerConfig{
+			TriggerMetadata:  map[string]string{"queueName": queueName, "host": apiStub.URL + "/myhost", "protocol": "http"},
+			QueryBatchWindow: testQueryBatchWindow,
+		})
+		assert.NoError(t, err)
+		scalers = append(scalers, s)
+	}
+
+	var wg sync.WaitGroup
+	active := make([]bool, len(scalers))
+	errs := make([]error, len(scalers))
+	for i, s := range scalers {
+		wg.Add(1)
+		go func(i int, s Scaler) {
+			defer wg.Done()
+			active[i], errs[i] = s.IsActive(context.Background())
+		}(i, s)
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"/api/queues/myhost"}, requests)
+	assert.NoError(t, errs[0])
+	assert.True(t, active[0])
+	assert.NoError(t, errs[1])
+	assert.False(t, active[1])
+	assert.ErrorContains(t, errs[2], "queue missing not found in vhost")
+
+	for _, s := range scalers {
+		assert.NoError(t, s.Close(context.Background()))
+	}
+}
+
+type rabbitMQActivationThresholdTestData struct {
+	mode                string
+	messages            int
+	publishRate         float64
+	activationThreshold string
+	isActive            bool
+}
+
+var testRabbitMQActivationThresholds = []rabbitMQActivationThresholdTestData{
+	// no activation threshold, active for any message
+	{mode: rabbitModeQueueLength, messages: 0, isActive: false},
+	{mode: rabbitModeQueueLength, messages: 1, isActive: true},
+	// activation threshold has to be exceeded by the queue length
+	{mode: rabbitModeQueueLength, messages: 50, activationThreshold: "50", isActive: false},
+	{mode: rabbitModeQueueLength, messages: 51, activationThreshold: "50", isActive: true},
+	// activation threshold has to be exceeded by the publish rate
+	{mode: rabbitModeMessageRate, publishRate: 5, activationThreshold: "10", isActive: false},
+	{mode: rabbitModeMessageRate, publishRate: 10.5, activationThreshold: "10", isActive: true},
+	// the messages left in the queue are consumed regardless of the publish rate
+	{mode: rabbitModeMessageRate, messages: 1, activationThreshold: "10", isActive: true},
+}
+
+func TestRabbitMQScalerActivationThreshold(t *testing.T) {
+	for _, testData := range testRabbitMQActivationThresholds {
+		apiStub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"messages": %d, "messages_unacknowledged": 0, "message_stats": {"publish_details": {"rate": %v}}, "name": "orders"}`, testData.messages, testData.publishRate)
+		}))
+		config := &ScalerConfig{
+			TriggerMetadata:     map[string]string{"host": apiStub.URL + "/myhost", "queueName": "orders", "protocol": "http", "mode": testData.mode, "value": "100"},
+			ActivationThreshold: testData.activationThreshold,
+			GlobalHTTPTimeout:   time.Second,
+		}
+
+		scaler, err := NewRabbitMQScaler(config)
+		assert.NoError(t, err)
+
+		isActive, err := scaler.IsActive(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testData.isActive, isActive, "%+v", testData)
+		apiStub.Close()
+	}
+}
+
+func TestRabbitMQPushScalerRunOnConsumerEvents(t *testing.T) {
+	events := make(chan amqp.Delivery, 2)
+	closed := false
+	s := &rabbitMQPushScaler{
+		rabbitMQScaler: &rabbitMQScaler{
+			metadata: &rabbitMQMetadata{queueName: "orders", consumerEvents: true},
+			subscribeFn: func(context.Context) (<-chan amqp.Delivery, func() error, error) {
+				return events, func() error {
+					closed = true
+					return nil
+				}, nil
+			},
+		},
+	}
+
+	// the events of the consumers of the other queues are ignored
+	events <- amqp.Delivery{RoutingKey: "consumer.created", Headers: amqp.Table{"queue": "payments"}}
+	events <- amqp.Delivery{RoutingKey: "consumer.deleted", Headers: amqp.Table{"queue": "orders"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	active := make(chan bool)
+	go s.Run(ctx, active)
+
+	select {
+	case isActive := <-active:
+		assert.True(t, isActive)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a signal on the consumer event")
+	}
+
+	select {
+	case <-active:
+		t.Fatal("expected no signal on the consumer event of another queue")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	_, ok := <-active
+	assert.False(t, ok)
+	assert.True(t, closed)
+}
@@ -4,11 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 
 	"github.com/go-redis/redis/v8"
-	v2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -27,10 +28,18 @@ const (
 type redisAddressParser func(metadata, resolvedEnv, authParams map[string]string) (redisConnectionInfo, error)
 
 type redisScaler struct {
-	metricType      v2beta2.MetricTargetType
+	metricType      v2.MetricTargetType
 	metadata        *redisMetadata
 	closeFn         func() error
 	getListLengthFn func(context.Context) (int64, error)
+	// subscribeFn subscribes to the keyspace notifications of the list, it returns the channel
+	// of the notifications and the function closing the subscription
+	subscribeFn func(context.Context) (<-chan *redis.Message, func() error)
+}
+
+// redisPushScaler is redisScaler notified of the changes of the list by keyspace notifications
+type redisPushScaler struct {
+	*redisScaler
 }
 
 type redisConnectionInfo struct {
@@ -51,6 +60,9 @@ type redisMetadata struct {
 	databaseIndex    int
 	connectionInfo   redisConnectionInfo
 	scalerIndex      int
+	// keyspaceNotifications enables the push notifications of the changes of the list, keyspace notifications
+	// of the events of its type have to be enabled on the server (eg. notify-keyspace-events Kl for lists)
+	keyspaceNotifications bool
 }
 
 var redisLog = logf.Log.WithName("redis_scaler")
@@ -81,6 +93,10 @@ func NewRedisScaler(ctx context.Context, isClustered, isSentinel bool, config *S
 		if err != nil {
 			return nil, fmt.Errorf("error parsing redis metadata: %s", err)
 		}
+		// keyspace notifications are published only by the node owning the key, not to the whole cluster
+		if meta.keyspaceNotifications {
+			return nil, fmt.Errorf("keyspaceNotifications isn't supported by redis cluster")
+		}
 		return createClusteredRedisScaler(ctx, meta, luaScript, metricType)
 	} else if isSentinel {
 		meta, err := parseRedisMetadata(config, parseRedisSentinelAddress)
@@ -97,14 +113,14 @@ func NewRedisScaler(ctx context.Context, isClustered, isSentinel bool, config *S
 	return createRedisScaler(ctx, meta, luaScript, metricType)
 }
 
-func createClusteredRedisScaler(ctx context.Context, meta *redisMetadata, script string, metricType v2beta2.MetricTargetType) (Scaler, error) {
-	client, err := getRedisClusterClient(ctx, meta.connectionInfo)
+func createClusteredRedisScaler(ctx context.Context, meta *redisMetadata, script string, metricType v2.MetricTargetType) (Scaler, error) {
+	client, release, err := getSharedRedisClusterClient(ctx, meta.connectionInfo)
 	if err != nil {
 		return nil, fmt.Errorf("connection to redis cluster failed: %s", err)
 	}
 
 	closeFn := func() error {
-		if err := client.Close(); err != nil {
+		if err := release(); err != nil {
 			redisLog.Error(err, "error closing redis client")
 			return err
 		}
@@ -128,27 +144,35 @@ func createClusteredRedisScaler(ctx context.Context, meta *redisMetadata, script
 	}, nil
 }
 
-func createSentinelRedisScaler(ctx context.Context, meta *redisMetadata, script string, metricType v2beta2.MetricTargetType) (Scaler, error) {
-	client, err := getRedisSentinelClient(ctx, meta.connectionInfo, meta.databaseIndex)
+func createSentinelRedisScaler(ctx context.Context, meta *redisMetadata, script string, metricType v2.MetricTargetType) (Scaler, error) {
+	client, release, err := getSharedRedisSentinelClient(ctx, meta.connectionInfo, meta.databaseIndex)
 	if err != nil {
 		return nil, fmt.Errorf("connection to redis sentinel failed: %s", err)
 	}
 
-	return createRedisScalerWithClient(client, meta, script, metricType), nil
+	return withKeyspaceNotifications(createRedisScalerWithClient(client, release, meta, script, metricType)), nil
 }
 
-func createRedisScaler(ctx context.Context, meta *redisMetadata, script string, metricType v2beta2.MetricTargetType) (Scaler, error) {
-	client, err := getRedisClient(ctx, meta.connectionInfo, meta.databaseIndex)
+func createRedisScaler(ctx context.Context, meta *redisMetadata, script string, metricType v2.MetricTargetType) (Scaler, error) {
+	client, release, err := getSharedRedisClient(ctx, meta.connectionInfo, meta.databaseIndex)
 	if err != nil {
 		return nil, fmt.Errorf("connection to redis failed: %s", err)
 	}
 
-	return createRedisScalerWithClient(client, meta, script, metricType), nil
+	return withKeyspaceNotifications(createRedisScalerWithClient(client, release, meta, script, metricType)), nil
+}
+
+// withKeyspaceNotifications returns the push scaler if keyspace notifications are enabled for the trigger
+func withKeyspaceNotifications(s *redisScaler) Scaler {
+	if s.metadata.keyspaceNotifications {
+		return &redisPushScaler{redisScaler: s}
+	}
+	return s
 }
 
-func createRedisScalerWithClient(client *redis.Client, meta *redisMetadata, script string, metricType v2beta2.MetricTargetType) Scaler {
+func createRedisScalerWithClient(client *redis.Client, release func() error, meta *redisMetadata, script string, metricType v2.MetricTargetType) *redisScaler {
 	closeFn := func() error {
-		if err := client.Close(); err != nil {
+		if err := release(); err != nil {
 			redisLog.Error(err, "error closing redis client")
 			return err
 		}
@@ -164,11 +188,17 @@ func createRedisScalerWithClient(client *redis.Client, meta *redisMetadata, scri
 		return cmd.Int64()
 	}
 
+	subscribeFn := func(ctx context.Context) (<-chan *redis.Message, func() error) {
+		pubsub := client.Subscribe(ctx, fmt.Sprintf("__keyspace@%d__:%s", meta.databaseIndex, meta.listName))
+		return pubsub.Channel(), pubsub.Close
+	}
+
 	return &redisScaler{
 		metricType:      metricType,
 		metadata:        meta,
 		closeFn:         closeFn,
 		getListLengthFn: listLengthFn,
+		subscribeFn:     subscribeFn,
 	}
 }
 
@@ -208,6 +238,14 @@ func parseRedisMetadata(config *ScalerConfig, parserFn redisAddressParser) (*red
 		}
 		meta.databaseIndex = int(typedValue)
 	}
+
+	if val, ok := config.TriggerMetadata["keyspaceNotifications"]; ok {
+		keyspaceNotifications, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("keyspaceNotifications parsing error %s", err.Error())
+		}
+		meta.keyspaceNotifications = keyspaceNotifications
+	}
 	meta.scalerIndex = config.ScalerIndex
 	return &meta, nil
 }
@@ -229,18 +267,18 @@ func (s *redisScaler) Close(context.Context) error {
 }
 
 // GetMetricSpecForScaling returns the metric spec for the HPA
-func (s *redisScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
+func (s *redisScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
 	metricName := kedautil.NormalizeString(fmt.Sprintf("redis-%s", s.metadata.listName))
-	externalMetric := &v2beta2.ExternalMetricSource{
-		Metric: v2beta2.MetricIdentifier{
+	externalMetric := &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{
 			Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, metricName),
 		},
 		Target: GetMetricTarget(s.metricType, s.metadata.targetListLength),
 	}
-	metricSpec := v2beta2.MetricSpec{
+	metricSpec := v2.MetricSpec{
 		External: externalMetric, Type: externalMetricType,
 	}
-	return []v2beta2.MetricSpec{metricSpec}
+	return []v2.MetricSpec{metricSpec}
 }
 
 // GetMetrics connects to Redis and finds the length of the list
@@ -261,6 +299,36 @@ func (s *redisScaler) GetMetrics(ctx context.Context, metricName string, metricS
 	return append([]external_metrics.ExternalMetricValue{}, metric), nil
 }
 
+// Run subscribes to the keyspace notifications of the list and signals each change of it,
+// the subscription is reestablished by the client if the connection is lost
+func (s *redisPushScaler) Run(ctx context.Context, active chan<- bool) {
+	defer close(active)
+
+	notifications, closeFn := s.subscribeFn(ctx)
+	defer func() {
+		if err := closeFn(); err != nil {
+			redisLog.Error(err, "error closing subscription to keyspace notifications")
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification, ok := <-notifications:
+			if !ok {
+				return
+			}
+			redisLog.V(1).Info("Received keyspace notification", "listName", s.metadata.listName, "event", notification.Payload)
+			select {
+			case active <- true:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
 func parseRedisAddress(metadata, resolvedEnv, authParams map[string]string) (redisConnectionInfo, error) {
 	info := redisConnectionInfo{}
 	switch {
@@ -460,6 +528,46 @@ func parseRedisSentinelAddress(metadata, resolvedEnv, authParams map[string]stri
 	return info, nil
 }
 
+// getSharedRedisClusterClient returns the client shared by the scalers connecting to the same cluster with the same credentials,
+// the release function must be called once the scaler doesn't use it anymore
+func getSharedRedisClusterClient(ctx context.Context, info redisConnectionInfo) (*redis.ClusterClient, func() error, error) {
+	key := connectionKey("redis-cluster", strings.Join(info.addresses, ","), info.username, info.password, strconv.FormatBool(info.enableTLS))
+	client, release, err := getSharedClient(key, func() (io.Closer, error) {
+		return getRedisClusterClient(ctx, info)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.(*redis.ClusterClient), release, nil
+}
+
+// getSharedRedisSentinelClient returns the client shared by the scalers connecting to the same master and database
+// with the same credentials, the release function must be called once the scaler doesn't use it anymore
+func getSharedRedisSentinelClient(ctx context.Context, info redisConnectionInfo, dbIndex int) (*redis.Client, func() error, error) {
+	key := connectionKey("redis-sentinel", strings.Join(info.addresses, ","), info.username, info.password, info.sentinelUsername,
+		info.sentinelPassword, info.sentinelMaster, strconv.FormatBool(info.enableTLS), strconv.Itoa(dbIndex))
+	client, release, err := getSharedClient(key, func() (io.Closer, error) {
+		return getRedisSentinelClient(ctx, info, dbIndex)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.(*redis.Client), release, nil
+}
+
+// getSharedRedisClient returns the client shared by the scalers connecting to the same server and database
+// with the same credentials, the release function must be called once the scaler doesn't use it anymore
+func getSharedRedisClient(ctx context.Context, info redisConnectionInfo, dbIndex int) (*redis.Client, func() error, error) {
+	key := connectionKey("redis", info.addresses[0], info.username, info.password, strconv.FormatBool(info.enableTLS), strconv.Itoa(dbIndex))
+	client, release, err := getSharedClient(key, func() (io.Closer, error) {
+		return getRedisClient(ctx, info, dbIndex)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.(*redis.Client), release, nil
+}
+
 func getRedisClusterClient(ctx context.Context, info redisConnectionInfo) (*redis.ClusterClient, error) {
 	options := &redis.ClusterOptions{
 		Addrs:    info.addresses,
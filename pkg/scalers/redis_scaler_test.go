@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -88,6 +89,7 @@ func TestRedisGetMetricSpecForScaling(t *testing.T) {
 			meta,
 			closeFn,
 			lengthFn,
+			nil,
 		}
 
 		metricSpec := mockRedisScaler.GetMetricSpecForScaling(context.Background())
@@ -713,3 +715,88 @@ func TestParseRedisSentinelMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestRedisParseKeyspaceNotifications(t *testing.T) {
+	meta, err := parseRedisMetadata(&ScalerConfig{TriggerMetadata: map[string]string{"listName": "mylist", "keyspaceNotifications": "true"}, AuthParams: map[string]string{"address": "localhost:6379"}}, parseRedisAddress)
+	assert.NoError(t, err)
+	assert.True(t, meta.keyspaceNotifications)
+
+	_, err = parseRedisMetadata(&ScalerConfig{TriggerMetadata: map[string]string{"listName": "mylist", "keyspaceNotifications": "yes please"}, AuthParams: map[string]string{"address": "localhost:6379"}}, parseRedisAddress)
+	assert.Error(t, err)
+
+	_, err = NewRedisScaler(context.Background(), true, false, &ScalerConfig{TriggerMetadata: map[string]string{"listName": "mylist", "keyspaceNotifications": "true"}, AuthParams: map[string]string{"addresses": "localhost:6379"}})
+	assert.ErrorContains(t, err, "keyspaceNotifications isn't supported by redis cluster")
+}
+
+func TestRedisPushScalerSignalsKeyspaceNotifications(t *testing.T) {
+	notifications := make(chan *redis.Message, 1)
+	closed := false
+	scaler := withKeyspaceNotifications(&redisScaler{
+		metadata: &redisMetadata{listName: "mylist", keyspaceNotifications: true},
+		subscribeFn: func(context.Context) (<-chan *redis.Message, func() error) {
+			return notifications, func() error {
+				closed = true
+				return nil
+			}
+		},
+	})
+	pushScaler, ok := scaler.(PushScaler)
+	assert.True(t, ok)
+
+	notifications <- &redis.Message{Channel: "__keyspace@0__:mylist", Payload: "lpush"}
+	ctx, cancel := context.WithCancel(context.Background())
+	active := make(chan bool)
+	go pushScaler.Run(ctx, active)
+	assert.True(t, <-active)
+
+	cancel()
+	_, ok = <-active
+	assert.False(t, ok)
+	assert.True(t, closed)
+
+	// the scaler is polled only if keyspace notifications aren't enabled
+	_, ok = withKeyspaceNotifications(&redisScaler{metadata: &redisMetadata{listName: "mylist"}}).(PushScaler)
+	assert.False(t, ok)
+}
+
+type redisActivationThresholdTestData struct {
+	triggerType         string
+	listLength          int64
+	activationThreshold string
+	isActive            bool
+}
+
+var testRedisActivationThresholds = []redisActivationThresholdTestData{
+	// no activation threshold, active for any list length greater than 0
+	{triggerType: "redis", listLength: 0, isActive: false},
+	{triggerType: "redis", listLength: 1, isActive: true},
+	// activation threshold has to be exceeded
+	{triggerType: "redis", listLength: 50, activationThreshold: "50", isActive: false},
+	{triggerType: "redis", listLength: 51, activationThreshold: "50", isActive: true},
+	// the same for the cluster and sentinel triggers
+	{triggerType: "redis-cluster", listLength: 50, activationThreshold: "50", isActive: false},
+	{triggerType: "redis-sentinel", listLength: 51, activationThreshold: "50", isActive: true},
+}
+
+func TestRedisScalerActivationThreshold(t *testing.T) {
+	for _, testData := range testRedisActivationThresholds {
+		listLength := testData.listLength
+		config := &ScalerConfig{
+			TriggerMetadata:     map[string]string{"listName": "mylist", "listLength": "100", "address": "localhost:6379"},
+			ActivationThreshold: testData.activationThreshold,
+		}
+		meta, err := parseRedisMetadata(config, parseRedisAddress)
+		assert.NoError(t, err)
+
+		scaler, err := WithActivationThreshold(&redisScaler{
+			metadata:        meta,
+			closeFn:         func() error { return nil },
+			getListLengthFn: func(context.Context) (int64, error) { return listLength, nil },
+		}, testData.triggerType, config)
+		assert.NoError(t, err)
+
+		isActive, err := scaler.IsActive(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, testData.isActive, isActive, "%s list length %d, activationThreshold %q", testData.triggerType, testData.listLength, testData.activationThreshold)
+	}
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"strconv"
 
-	v2beta2 "k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -31,7 +31,7 @@ const (
 )
 
 type redisStreamsScaler struct {
-	metricType               v2beta2.MetricTargetType
+	metricType               v2.MetricTargetType
 	metadata                 *redisStreamsMetadata
 	closeFn                  func() error
 	getPendingEntriesCountFn func(ctx context.Context) (int64, error)
@@ -75,14 +75,14 @@ func NewRedisStreamsScaler(ctx context.Context, isClustered, isSentinel bool, co
 	return createRedisStreamsScaler(ctx, meta, metricType)
 }
 
-func createClusteredRedisStreamsScaler(ctx context.Context, meta *redisStreamsMetadata, metricType v2beta2.MetricTargetType) (Scaler, error) {
-	client, err := getRedisClusterClient(ctx, meta.connectionInfo)
+func createClusteredRedisStreamsScaler(ctx context.Context, meta *redisStreamsMetadata, metricType v2.MetricTargetType) (Scaler, error) {
+	client, release, err := getSharedRedisClusterClient(ctx, meta.connectionInfo)
 	if err != nil {
 		return nil, fmt.Errorf("connection to redis cluster failed: %s", err)
 	}
 
 	closeFn := func() error {
-		if err := client.Close(); err != nil {
+		if err := release(); err != nil {
 			redisStreamsLog.Error(err, "error closing redis client")
 			return err
 		}
@@ -105,14 +105,14 @@ func createClusteredRedisStreamsScaler(ctx context.Context, meta *redisStreamsMe
 	}, nil
 }
 
-func createSentinelRedisStreamsScaler(ctx context.Context, meta *redisStreamsMetadata, metricType v2beta2.MetricTargetType) (Scaler, error) {
-	client, err := getRedisSentinelClient(ctx, meta.connectionInfo, meta.databaseIndex)
+func createSentinelRedisStreamsScaler(ctx context.Context, meta *redisStreamsMetadata, metricType v2.MetricTargetType) (Scaler, error) {
+	client, release, err := getSharedRedisSentinelClient(ctx, meta.connectionInfo, meta.databaseIndex)
 	if err != nil {
 		return nil, fmt.Errorf("connection to redis sentinel failed: %s", err)
 	}
 
 	closeFn := func() error {
-		if err := client.Close(); err != nil {
+		if err := release(); err != nil {
 			redisStreamsLog.Error(err, "error closing redis client")
 			return err
 		}
@@ -135,14 +135,14 @@ func createSentinelRedisStreamsScaler(ctx context.Context, meta *redisStreamsMet
 	}, nil
 }
 
-func createRedisStreamsScaler(ctx context.Context, meta *redisStreamsMetadata, metricType v2beta2.MetricTargetType) (Scaler, error) {
-	client, err := getRedisClient(ctx, meta.connectionInfo, meta.databaseIndex)
+func createRedisStreamsScaler(ctx context.Context, meta *redisStreamsMetadata, metricType v2.MetricTargetType) (Scaler, error) {
+	client, release, err := getSharedRedisClient(ctx, meta.connectionInfo, meta.databaseIndex)
 	if err != nil {
 		return nil, fmt.Errorf("connection to redis failed: %s", err)
 	}
 
 	closeFn := func() error {
-		if err := client.Close(); err != nil {
+		if err := release(); err != nil {
 			redisStreamsLog.Error(err, "error closing redis client")
 			return err
 		}
@@ -230,15 +230,15 @@ func (s *redisStreamsScaler) Close(context.Context) error {
 }
 
 // GetMetricSpecForScaling returns the metric spec for the HPA
-func (s *redisStreamsScaler) GetMetricSpecForScaling(context.Context) []v2beta2.MetricSpec {
-	externalMetric := &v2beta2.ExternalMetricSource{
-		Metric: v2beta2.MetricIdentifier{
+func (s *redisStreamsScaler) GetMetricSpecForScaling(context.Context) []v2.MetricSpec {
+	externalMetric := &v2.ExternalMetricSource{
+		Metric: v2.MetricIdentifier{
 			Name: GenerateMetricNameWithIndex(s.metadata.scalerIndex, kedautil.NormalizeString(fmt.Sprintf("redis-streams-%s", s.metadata.streamName))),
 		},
 		Target: GetMetricTarget(s.metricType, s.metadata.targetPendingEntriesCount),
 	}
-	metricSpec := v2beta2.MetricSpec{External: externalMetric, Type: externalMetricType}
-	return []v2beta2.MetricSpec{metricSpec}
+	metricSpec := v2.MetricSpec{External: externalMetric, Type: externalMetricType}
+	return []v2.MetricSpec{metricSpec}
 }
 
 // GetMetrics fetches the number of pending entries for a consumer group in a stream
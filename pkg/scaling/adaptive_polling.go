@@ -0,0 +1,69 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaling
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/scaling/polling"
+)
+
+// nextPollingInterval returns the interval until the next poll of ScaledObject with adaptive polling, with jitter applied.
+// The metric values obtained by the check of the triggers are compared with the previous poll only while the scale target
+// is idle and the triggers succeed, the active scale target is polled at the minimal interval anyway. The scalers aren't
+// queried again for the values. The interval is recorded in status.
+func (h *scaleHandler) nextPollingInterval(ctx context.Context, interval *polling.Interval, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool, metrics []external_metrics.ExternalMetricValue) time.Duration {
+	var values map[string]float64
+	if !isActive && !isError {
+		values = getMetricValues(metrics)
+	}
+
+	next := interval.Next(isActive, values)
+	seconds := int32(next / time.Second)
+	h.updatePollingIntervalStatus(ctx, scaledObject, &seconds)
+	return polling.WithJitter(next)
+}
+
+// getMetricValues returns the values of the metrics keyed by the metric name, the values of the same metric are summed
+// the same as the HPA sums the values of the external metric
+func getMetricValues(metrics []external_metrics.ExternalMetricValue) map[string]float64 {
+	values := make(map[string]float64)
+	for _, metric := range metrics {
+		values[metric.MetricName] += metric.Value.AsApproximateFloat64()
+	}
+	return values
+}
+
+// updatePollingIntervalStatus records the effective polling interval in seconds in status of ScaledObject,
+// the interval is removed from status if it is nil, eg. once adaptive polling is disabled
+func (h *scaleHandler) updatePollingIntervalStatus(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, seconds *int32) {
+	previous := scaledObject.Status.PollingInterval
+	if (previous == nil && seconds == nil) || (previous != nil && seconds != nil && *previous == *seconds) {
+		return
+	}
+
+	patch := client.MergeFrom(scaledObject.DeepCopy())
+	scaledObject.Status.PollingInterval = seconds
+	if err := h.client.Status().Patch(ctx, scaledObject, patch); err != nil {
+		h.logger.Error(err, "Failed to patch ScaledObjects Status", "scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+	}
+}
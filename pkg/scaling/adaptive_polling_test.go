@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaling
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
+	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+	"github.com/kedacore/keda/v2/pkg/scaling/polling"
+)
+
+func TestNextPollingIntervalIsRecordedInStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	scheme := runtime.NewScheme()
+	assert.NoError(t, kedav1alpha1.AddToScheme(scheme))
+
+	scaledObject := &kedav1alpha1.ScaledObject{
+		TypeMeta: metav1.TypeMeta{Kind: "ScaledObject", APIVersion: "keda.sh/v1alpha1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef:  &kedav1alpha1.ScaleTarget{Name: "test"},
+			AdaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 5, MaxPollingInterval: 100},
+		},
+	}
+	metricSpecs := []v2.MetricSpec{createMetricSpec(2)}
+	metricSpecs[0].External.Metric.Name = "s0-queueLength"
+
+	queueLength := int64(10)
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return(metricSpecs).AnyTimes()
+	scaler.EXPECT().IsActive(gomock.Any()).Return(false, nil).Times(2)
+	// the values recorded by the check are reused, the scaler is queried once per poll
+	scaler.EXPECT().GetMetrics(gomock.Any(), "s0-queueLength", gomock.Any()).DoAndReturn(func(context.Context, string, interface{}) ([]external_metrics.ExternalMetricValue, error) {
+		return []external_metrics.ExternalMetricValue{{
+			MetricName: "s0-queueLength",
+			Value:      *resource.NewQuantity(queueLength, resource.DecimalSI),
+		}}, nil
+	}).Times(2)
+
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(scaledObject).Build()
+	handler := &scaleHandler{
+		client:        kubeClient,
+		logger:        logf.Log.WithName("scalehandler"),
+		scaleExecutor: &recordingScaleExecutor{scales: make(chan bool, 10)},
+		scalerCaches: map[string]*cache.ScalersCache{
+			"scaledobject.test.test": {
+				Scalers:  []cache.ScalerBuilder{{Scaler: scaler, MetricsCacheTTL: time.Minute}},
+				Logger:   logf.Log.WithName("scalehandler"),
+				Recorder: record.NewFakeRecorder(10),
+			},
+		},
+		lock: &sync.RWMutex{},
+	}
+	interval := polling.NewInterval(scaledObject, 30*time.Second)
+
+	getStatus := func() *int32 {
+		current := &kedav1alpha1.ScaledObject{}
+		assert.NoError(t, kubeClient.Get(context.Background(), types.NamespacedName{Name: "test", Namespace: "test"}, current))
+		return current.Status.PollingInterval
+	}
+
+	// the scale target is idle and the queue length is stable, the polling backs off
+	isActive, isError, metrics := handler.checkScalers(context.Background(), scaledObject, &sync.Mutex{})
+	next := handler.nextPollingInterval(context.Background(), interval, scaledObject, isActive, isError, metrics)
+	assert.InDelta(t, 60*time.Second, next, float64(6*time.Second))
+	assert.Equal(t, int32(60), *getStatus())
+
+	// the queue length grows quickly while the scale target is still idle
+	queueLength = 20
+	isActive, isError, metrics = handler.checkScalers(context.Background(), scaledObject, &sync.Mutex{})
+	next = handler.nextPollingInterval(context.Background(), interval, scaledObject, isActive, isError, metrics)
+	assert.InDelta(t, 5*time.Second, next, float64(time.Second/2))
+	assert.Equal(t, int32(5), *getStatus())
+
+	// the effective interval is removed once adaptive polling is disabled
+	handler.updatePollingIntervalStatus(context.Background(), scaledObject, nil)
+	assert.Nil(t, getStatus())
+}
@@ -19,18 +19,25 @@ package cache
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
-	"k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/metrics/pkg/apis/external_metrics"
+	"k8s.io/utils/clock"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
+	prommetrics "github.com/kedacore/keda/v2/pkg/metrics"
 	"github.com/kedacore/keda/v2/pkg/scalers"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
 )
 
 type ScalersCache struct {
@@ -38,11 +45,41 @@ type ScalersCache struct {
 	Scalers    []ScalerBuilder
 	Logger     logr.Logger
 	Recorder   record.EventRecorder
+	// Namespace and Name of the object owning the scalers, used as labels of the metrics cache counters
+	Namespace string
+	Name      string
+	// MetricsFreshnessWindow specifies for how long are metric values recorded in the scale loop
+	// served by GetMetricsForScaler instead of querying the scaler again, 0 disables the recording
+	MetricsFreshnessWindow time.Duration
+
+	metricsRecords     map[string]metricsRecord
+	metricsRecordsLock sync.RWMutex
+	// clock is the source of time of the metrics records, the real clock is used if it's nil
+	clock clock.PassiveClock
+
+	// triggerObservations are the last observed states of the triggers keyed by the scaler id, see GetTriggersStatus
+	triggerObservations      map[int]triggerObservation
+	lastTriggersStatusUpdate time.Time
+	triggerObservationsLock  sync.Mutex
 }
 
+// metricsRecord is the last metric value obtained from a scaler
+type metricsRecord struct {
+	metrics   []external_metrics.ExternalMetricValue
+	timestamp time.Time
+}
+
+var metricsServer prommetrics.PrometheusMetricServer
+
 type ScalerBuilder struct {
 	Scaler  scalers.Scaler
 	Factory func() (scalers.Scaler, error)
+	// MetricsCacheTTL specifies for how long is the metric value fetched from the scaler cached,
+	// it takes precedence over ScalersCache.MetricsFreshnessWindow, 0 means it isn't set
+	MetricsCacheTTL time.Duration
+	// TriggerName and TriggerType identify the trigger of the scaler in status of ScaledObject
+	TriggerName string
+	TriggerType string
 }
 
 func (c *ScalersCache) GetScalers() []scalers.Scaler {
@@ -67,41 +104,162 @@ func (c *ScalersCache) GetMetricsForScaler(ctx context.Context, id int, metricNa
 	if id < 0 || id >= len(c.Scalers) {
 		return nil, fmt.Errorf("scaler with id %d not found. Len = %d", id, len(c.Scalers))
 	}
+	ttl := c.getMetricsCacheTTL(id)
+	if ttl > 0 {
+		m, found := c.getMetricsRecord(metricName, ttl)
+		metricsServer.RecordScalerMetricsCache(c.Namespace, c.Name, metricName, found)
+		if found {
+			return m, nil
+		}
+	}
+
 	m, err := c.Scalers[id].Scaler.GetMetrics(ctx, metricName, metricSelector)
 	if err == nil {
+		c.recordTriggerMetrics(id, metricName, m, nil)
+		if ttl > 0 {
+			c.setMetricsRecord(metricName, m)
+		}
 		return m, nil
 	}
 
 	ns, err := c.refreshScaler(ctx, id)
 	if err != nil {
+		c.recordTriggerMetrics(id, metricName, nil, err)
 		return nil, err
 	}
 
-	return ns.GetMetrics(ctx, metricName, metricSelector)
+	m, err = ns.GetMetrics(ctx, metricName, metricSelector)
+	c.recordTriggerMetrics(id, metricName, m, err)
+	if err == nil && ttl > 0 {
+		c.setMetricsRecord(metricName, m)
+	}
+	return m, err
+}
+
+// getMetricsCacheTTL returns for how long are metric values of the scaler cached, 0 means no caching
+func (c *ScalersCache) getMetricsCacheTTL(id int) time.Duration {
+	if ttl := c.Scalers[id].MetricsCacheTTL; ttl > 0 {
+		return ttl
+	}
+	return c.MetricsFreshnessWindow
+}
+
+// recordScalerMetrics queries metrics of the scaler and records them, so they can be served
+// within the metrics cache TTL without querying the scaler again. activityMetrics are the values
+// of the first metric obtained by the activity check of the scaler, these are recorded as they are.
+// It returns the metrics obtained, the activity metrics only if there isn't any metrics cache TTL.
+func (c *ScalersCache) recordScalerMetrics(ctx context.Context, id int, activityMetrics []external_metrics.ExternalMetricValue) []external_metrics.ExternalMetricValue {
+	if c.getMetricsCacheTTL(id) <= 0 {
+		return activityMetrics
+	}
+
+	var result []external_metrics.ExternalMetricValue
+
+	for i, metricSpec := range c.Scalers[id].Scaler.GetMetricSpecForScaling(ctx) {
+		// skip cpu/memory resource scaler, these are handled directly by the HPA
+		if metricSpec.External == nil {
+			continue
+		}
+		metricName := metricSpec.External.Metric.Name
+		m, err := activityMetrics, error(nil)
+		if i > 0 || activityMetrics == nil {
+			m, err = c.Scalers[id].Scaler.GetMetrics(ctx, metricName, nil)
+		}
+		c.recordTriggerMetrics(id, metricName, m, err)
+		if err != nil {
+			c.Logger.V(1).Info("Error getting scaler metrics for recording, but continue", "Metrics Name", metricName, "Error", err)
+			continue
+		}
+		c.setMetricsRecord(metricName, m)
+		result = append(result, m...)
+	}
+	return result
 }
 
+// getMetricsRecord returns metrics recorded for the metricName, if they are not older than ttl
+func (c *ScalersCache) getMetricsRecord(metricName string, ttl time.Duration) ([]external_metrics.ExternalMetricValue, bool) {
+	c.metricsRecordsLock.RLock()
+	defer c.metricsRecordsLock.RUnlock()
+	record, found := c.metricsRecords[metricName]
+	if !found || c.now().Sub(record.timestamp) > ttl {
+		return nil, false
+	}
+	return record.metrics, true
+}
+
+func (c *ScalersCache) setMetricsRecord(metricName string, metrics []external_metrics.ExternalMetricValue) {
+	c.metricsRecordsLock.Lock()
+	defer c.metricsRecordsLock.Unlock()
+	if c.metricsRecords == nil {
+		c.metricsRecords = make(map[string]metricsRecord)
+	}
+	c.metricsRecords[metricName] = metricsRecord{metrics: metrics, timestamp: c.now()}
+}
+
+func (c *ScalersCache) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// IsScaledObjectActive returns whether ScaledObject is active, whether any of its triggers failed and the metric values
+// obtained by the check, ie. the composite metric of scaling modifiers, the values the activity of triggers was decided on
+// and the metrics recorded for the metrics cache TTL. The check doesn't query the scalers for the metric values otherwise.
 func (c *ScalersCache) IsScaledObjectActive(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (bool, bool, []external_metrics.ExternalMetricValue) {
+	return c.isScaledObjectActive(ctx, scaledObject, nil)
+}
+
+// GetScaledObjectState is IsScaledObjectActive which also returns the result of each trigger keyed by the metric name,
+// a nil error means the trigger succeeded. These are used to track the health of triggers while the HPA doesn't query them.
+func (c *ScalersCache) GetScaledObjectState(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (bool, bool, []external_metrics.ExternalMetricValue, map[string]error) {
+	scalerErrors := make(map[string]error)
+	isActive, isError, metrics := c.isScaledObjectActive(ctx, scaledObject, scalerErrors)
+	return isActive, isError, metrics, scalerErrors
+}
+
+func (c *ScalersCache) isScaledObjectActive(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, scalerErrors map[string]error) (bool, bool, []external_metrics.ExternalMetricValue) {
+	if scaledObject.HasScalingModifiers() {
+		isActive, metrics, err := c.isScaledObjectActiveWithModifiers(ctx, scaledObject)
+		if scalerErrors != nil {
+			scalerErrors[kedav1alpha1.CompositeMetricName] = err
+		}
+		return isActive, err != nil, metrics
+	}
+
 	isActive := false
 	isError := false
+	metrics := []external_metrics.ExternalMetricValue{}
 	// Let's collect status of all scalers, no matter if any scaler raises error or is active
 	for i, s := range c.Scalers {
-		isTriggerActive, err := s.Scaler.IsActive(ctx)
+		isTriggerActive, activityMetrics, err := scalers.IsActiveWithMetrics(ctx, s.Scaler)
 		if err != nil {
 			var ns scalers.Scaler
 			ns, err = c.refreshScaler(ctx, i)
 			if err == nil {
-				isTriggerActive, err = ns.IsActive(ctx)
+				isTriggerActive, activityMetrics, err = scalers.IsActiveWithMetrics(ctx, ns)
 			}
 		}
+		c.recordTriggerCheck(i, isTriggerActive, err)
 
 		logger := c.Logger.WithValues("scaledobject.Name", scaledObject.Name, "scaledObject.Namespace", scaledObject.Namespace,
 			"scaleTarget.Name", scaledObject.Spec.ScaleTargetRef.Name)
 
+		if scalerErrors != nil {
+			if metricSpecs := c.Scalers[i].Scaler.GetMetricSpecForScaling(ctx); len(metricSpecs) > 0 && metricSpecs[0].External != nil {
+				scalerErrors[metricSpecs[0].External.Metric.Name] = err
+			}
+		}
+
 		if err != nil {
 			isError = true
 			logger.Error(err, "Error getting scale decision")
 			c.Recorder.Event(scaledObject, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
-		} else if isTriggerActive {
+			continue
+		}
+
+		metrics = append(metrics, c.recordScalerMetrics(ctx, i, activityMetrics)...)
+		if isTriggerActive {
 			isActive = true
 			if externalMetricsSpec := s.Scaler.GetMetricSpecForScaling(ctx)[0].External; externalMetricsSpec != nil {
 				logger.V(1).Info("Scaler for scaledObject is active", "Metrics Name", externalMetricsSpec.Metric.Name)
@@ -112,33 +270,143 @@ func (c *ScalersCache) IsScaledObjectActive(ctx context.Context, scaledObject *k
 		}
 	}
 
-	return isActive, isError, []external_metrics.ExternalMetricValue{}
+	return isActive, isError, metrics
+}
+
+// isScaledObjectActiveWithModifiers evaluates ScalingModifiers formula and compares the result
+// with the activation target, instead of checking activity of each trigger separately
+func (c *ScalersCache) isScaledObjectActiveWithModifiers(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (bool, []external_metrics.ExternalMetricValue, error) {
+	logger := c.Logger.WithValues("scaledobject.Name", scaledObject.Name, "scaledObject.Namespace", scaledObject.Namespace,
+		"scaleTarget.Name", scaledObject.Spec.ScaleTargetRef.Name)
+
+	metric, err := c.GetCompositeMetric(ctx, scaledObject)
+	if err != nil {
+		logger.Error(err, "Error getting scale decision")
+		c.Recorder.Event(scaledObject, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
+		return false, []external_metrics.ExternalMetricValue{}, err
+	}
+
+	activationTarget := float64(0)
+	if scaledObject.Spec.Advanced.ScalingModifiers.ActivationTarget != "" {
+		activationTarget, err = strconv.ParseFloat(scaledObject.Spec.Advanced.ScalingModifiers.ActivationTarget, 64)
+		if err != nil {
+			err = fmt.Errorf("error parsing scalingModifiers.activationTarget: %s", err)
+			logger.Error(err, "Error getting scale decision")
+			c.Recorder.Event(scaledObject, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
+			return false, []external_metrics.ExternalMetricValue{}, err
+		}
+	}
+
+	value := metric.Value.AsApproximateFloat64()
+	isActive := value > activationTarget
+	if isActive {
+		logger.V(1).Info("Scaling modifiers formula for scaledObject is active", "Formula", scaledObject.Spec.Advanced.ScalingModifiers.Formula, "Value", value)
+	}
+
+	return isActive, []external_metrics.ExternalMetricValue{metric}, nil
+}
+
+// GetCompositeMetric returns the metric composed from all named triggers by the ScalingModifiers formula
+func (c *ScalersCache) GetCompositeMetric(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (external_metrics.ExternalMetricValue, error) {
+	if !scaledObject.HasScalingModifiers() {
+		return external_metrics.ExternalMetricValue{}, fmt.Errorf("ScaledObject %s doesn't define scalingModifiers", scaledObject.Name)
+	}
+
+	formula, err := modifiers.Compile(scaledObject.Spec.Advanced.ScalingModifiers.Formula)
+	if err != nil {
+		return external_metrics.ExternalMetricValue{}, fmt.Errorf("error compiling scalingModifiers.formula: %s", err)
+	}
+
+	values, err := c.getTriggerMetricValues(ctx, scaledObject)
+	if err != nil {
+		return external_metrics.ExternalMetricValue{}, err
+	}
+
+	result, err := formula.Eval(values)
+	if err != nil {
+		return external_metrics.ExternalMetricValue{}, fmt.Errorf("error evaluating scalingModifiers.formula: %s", err)
+	}
+
+	return external_metrics.ExternalMetricValue{
+		MetricName: kedav1alpha1.CompositeMetricName,
+		Value:      *modifiers.QuantityFromFloat(result),
+		Timestamp:  metav1.Now(),
+	}, nil
+}
+
+// getTriggerMetricValues returns the current metric value of each named trigger, keyed by the trigger name
+func (c *ScalersCache) getTriggerMetricValues(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) (map[string]float64, error) {
+	values := make(map[string]float64)
+	for i, s := range c.Scalers {
+		if i >= len(scaledObject.Spec.Triggers) || scaledObject.Spec.Triggers[i].Name == "" {
+			continue
+		}
+
+		metricSpecs := s.Scaler.GetMetricSpecForScaling(ctx)
+		// skip cpu/memory resource scaler, these are handled directly by the HPA
+		if len(metricSpecs) < 1 || metricSpecs[0].External == nil {
+			continue
+		}
+
+		metricName := metricSpecs[0].External.Metric.Name
+		metrics, err := c.GetMetricsForScaler(ctx, i, metricName, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting metrics for trigger %s: %s", scaledObject.Spec.Triggers[i].Name, err)
+		}
+
+		var value float64
+		for _, m := range metrics {
+			if m.MetricName == metricName {
+				value += m.Value.AsApproximateFloat64()
+			}
+		}
+		values[scaledObject.Spec.Triggers[i].Name] = value
+	}
+	return values, nil
 }
 
 func (c *ScalersCache) IsScaledJobActive(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob) (bool, int64, int64) {
+	isActive, queueLength, maxValue, _ := c.isScaledJobActive(ctx, scaledJob, nil)
+	return isActive, queueLength, maxValue
+}
+
+// GetScaledJobState is IsScaledJobActive which also returns the number of jobs required by the metrics without
+// the limit of maxReplicaCount and the result of each trigger keyed by the metric name, a nil error means
+// the trigger succeeded. These are used by the scaling strategies and to track the health of triggers for fallback.
+func (c *ScalersCache) GetScaledJobState(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob) (bool, int64, int64, int64, map[string]error) {
+	scalerErrors := make(map[string]error)
+	isActive, queueLength, maxValue, requiredValue := c.isScaledJobActive(ctx, scaledJob, scalerErrors)
+	return isActive, queueLength, maxValue, requiredValue, scalerErrors
+}
+
+func (c *ScalersCache) isScaledJobActive(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob, scalerErrors map[string]error) (bool, int64, int64, int64) {
 	var queueLength int64
 	var maxValue int64
+	var requiredValue int64
 	isActive := false
 
 	logger := logf.Log.WithName("scalemetrics")
-	scalersMetrics := c.getScaledJobMetrics(ctx, scaledJob)
+	scalersMetrics := c.getScaledJobMetrics(ctx, scaledJob, scalerErrors)
 	switch scaledJob.Spec.ScalingStrategy.MultipleScalersCalculation {
 	case "min":
 		for _, metrics := range scalersMetrics {
 			if (queueLength == 0 || metrics.queueLength < queueLength) && metrics.isActive {
 				queueLength = metrics.queueLength
 				maxValue = metrics.maxValue
+				requiredValue = metrics.requiredValue
 				isActive = metrics.isActive
 			}
 		}
 	case "avg":
 		queueLengthSum := int64(0)
 		maxValueSum := int64(0)
+		requiredValueSum := int64(0)
 		length := 0
 		for _, metrics := range scalersMetrics {
 			if metrics.isActive {
 				queueLengthSum += metrics.queueLength
 				maxValueSum += metrics.maxValue
+				requiredValueSum += metrics.requiredValue
 				isActive = metrics.isActive
 				length++
 			}
@@ -146,12 +414,14 @@ func (c *ScalersCache) IsScaledJobActive(ctx context.Context, scaledJob *kedav1a
 		if length != 0 {
 			queueLength = divideWithCeil(queueLengthSum, int64(length))
 			maxValue = divideWithCeil(maxValueSum, int64(length))
+			requiredValue = divideWithCeil(requiredValueSum, int64(length))
 		}
 	case "sum":
 		for _, metrics := range scalersMetrics {
 			if metrics.isActive {
 				queueLength += metrics.queueLength
 				maxValue += metrics.maxValue
+				requiredValue += metrics.requiredValue
 				isActive = metrics.isActive
 			}
 		}
@@ -160,6 +430,7 @@ func (c *ScalersCache) IsScaledJobActive(ctx context.Context, scaledJob *kedav1a
 			if metrics.queueLength > queueLength && metrics.isActive {
 				queueLength = metrics.queueLength
 				maxValue = metrics.maxValue
+				requiredValue = metrics.requiredValue
 				isActive = metrics.isActive
 			}
 		}
@@ -167,7 +438,7 @@ func (c *ScalersCache) IsScaledJobActive(ctx context.Context, scaledJob *kedav1a
 	maxValue = min(scaledJob.MaxReplicaCount(), maxValue)
 	logger.V(1).WithValues("ScaledJob", scaledJob.Name).Info("Checking if ScaleJob Scalers are active", "isActive", isActive, "maxValue", maxValue, "MultipleScalersCalculation", scaledJob.Spec.ScalingStrategy.MultipleScalersCalculation)
 
-	return isActive, queueLength, maxValue
+	return isActive, queueLength, maxValue, requiredValue
 }
 
 func (c *ScalersCache) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
@@ -202,16 +473,19 @@ func (c *ScalersCache) refreshScaler(ctx context.Context, id int) (scalers.Scale
 	}
 
 	c.Scalers[id] = ScalerBuilder{
-		Scaler:  ns,
-		Factory: sb.Factory,
+		Scaler:          ns,
+		Factory:         sb.Factory,
+		MetricsCacheTTL: sb.MetricsCacheTTL,
+		TriggerName:     sb.TriggerName,
+		TriggerType:     sb.TriggerType,
 	}
 	sb.Scaler.Close(ctx)
 
 	return ns, nil
 }
 
-func (c *ScalersCache) GetMetricSpecForScaling(ctx context.Context) []v2beta2.MetricSpec {
-	var spec []v2beta2.MetricSpec
+func (c *ScalersCache) GetMetricSpecForScaling(ctx context.Context) []v2.MetricSpec {
+	var spec []v2.MetricSpec
 	for _, s := range c.Scalers {
 		spec = append(spec, s.Scaler.GetMetricSpecForScaling(ctx)...)
 	}
@@ -232,16 +506,21 @@ func (c *ScalersCache) Close(ctx context.Context) {
 type scalerMetrics struct {
 	queueLength int64
 	maxValue    int64
-	isActive    bool
+	// requiredValue is maxValue without the limit of maxReplicaCount
+	requiredValue int64
+	isActive      bool
 }
 
-func (c *ScalersCache) getScaledJobMetrics(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob) []scalerMetrics {
+// getScaledJobMetrics returns metrics of all scalers, the failing scalers are skipped
+// and their errors are recorded in scalerErrors, if it isn't nil
+func (c *ScalersCache) getScaledJobMetrics(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob, scalerErrors map[string]error) []scalerMetrics {
 	var scalersMetrics []scalerMetrics
 	for i, s := range c.Scalers {
 		var queueLength int64
 		var targetAverageValue int64
 		isActive := false
 		maxValue := int64(0)
+		requiredValue := int64(0)
 		scalerType := fmt.Sprintf("%T:", s)
 
 		scalerLogger := c.Logger.WithValues("ScaledJob", scaledJob.Name, "Scaler", scalerType)
@@ -249,29 +528,34 @@ func (c *ScalersCache) getScaledJobMetrics(ctx context.Context, scaledJob *kedav
 		metricSpecs := s.Scaler.GetMetricSpecForScaling(ctx)
 
 		// skip scaler that doesn't return any metric specs (usually External scaler with incorrect metadata)
-		// or skip cpu/memory resource scaler
+		// or skip cpu/memory resource scaler, jobs don't have long running pods to measure the usage of
 		if len(metricSpecs) < 1 || metricSpecs[0].External == nil {
 			continue
 		}
 
-		isTriggerActive, err := s.Scaler.IsActive(ctx)
+		isTriggerActive, metrics, err := scalers.IsActiveWithMetrics(ctx, s.Scaler)
 		if err != nil {
 			var ns scalers.Scaler
 			ns, err = c.refreshScaler(ctx, i)
 			if err == nil {
-				isTriggerActive, err = ns.IsActive(ctx)
+				isTriggerActive, metrics, err = scalers.IsActiveWithMetrics(ctx, ns)
 			}
 		}
 
 		if err != nil {
 			scalerLogger.V(1).Info("Error getting scaler.IsActive, but continue", "Error", err)
 			c.Recorder.Event(scaledJob, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
+			recordScalerError(scalerErrors, metricSpecs[0].External.Metric.Name, err)
 			continue
 		}
 
 		targetAverageValue = getTargetAverageValue(metricSpecs)
 
-		metrics, err := s.Scaler.GetMetrics(ctx, metricSpecs[0].External.Metric.Name, nil)
+		// the metrics are queried again only if the scaler didn't return them with the activity
+		if metrics == nil {
+			metrics, err = c.Scalers[i].Scaler.GetMetrics(ctx, metricSpecs[0].External.Metric.Name, nil)
+		}
+		recordScalerError(scalerErrors, metricSpecs[0].External.Metric.Name, err)
 		if err != nil {
 			scalerLogger.V(1).Info("Error getting scaler metrics, but continue", "Error", err)
 			c.Recorder.Event(scaledJob, corev1.EventTypeWarning, eventreason.KEDAScalerFailed, err.Error())
@@ -293,18 +577,26 @@ func (c *ScalersCache) getScaledJobMetrics(ctx context.Context, scaledJob *kedav
 		}
 
 		if targetAverageValue != 0 {
-			maxValue = min(scaledJob.MaxReplicaCount(), divideWithCeil(queueLength, targetAverageValue))
+			requiredValue = divideWithCeil(queueLength, targetAverageValue)
+			maxValue = min(scaledJob.MaxReplicaCount(), requiredValue)
 		}
 		scalersMetrics = append(scalersMetrics, scalerMetrics{
-			queueLength: queueLength,
-			maxValue:    maxValue,
-			isActive:    isActive,
+			queueLength:   queueLength,
+			maxValue:      maxValue,
+			requiredValue: requiredValue,
+			isActive:      isActive,
 		})
 	}
 	return scalersMetrics
 }
 
-func getTargetAverageValue(metricSpecs []v2beta2.MetricSpec) int64 {
+func recordScalerError(scalerErrors map[string]error, metricName string, err error) {
+	if scalerErrors != nil {
+		scalerErrors[metricName] = err
+	}
+}
+
+func getTargetAverageValue(metricSpecs []v2.MetricSpec) int64 {
 	var targetAverageValue int64
 	var metricValue int64
 	var flag bool
@@ -4,14 +4,16 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
-	"k8s.io/api/autoscaling/v2beta2"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/metrics/pkg/apis/external_metrics"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
@@ -20,19 +22,19 @@ import (
 
 func TestTargetAverageValue(t *testing.T) {
 	// count = 0
-	specs := []v2beta2.MetricSpec{}
+	specs := []v2.MetricSpec{}
 	metricName := "s0-messageCount"
 	targetAverageValue := getTargetAverageValue(specs)
 	assert.Equal(t, int64(0), targetAverageValue)
 	// 1 1
-	specs = []v2beta2.MetricSpec{
+	specs = []v2.MetricSpec{
 		createMetricSpec(1, metricName),
 		createMetricSpec(1, metricName),
 	}
 	targetAverageValue = getTargetAverageValue(specs)
 	assert.Equal(t, int64(1), targetAverageValue)
 	// 5 5 3
-	specs = []v2beta2.MetricSpec{
+	specs = []v2.MetricSpec{
 		createMetricSpec(5, metricName),
 		createMetricSpec(5, metricName),
 		createMetricSpec(3, metricName),
@@ -41,7 +43,7 @@ func TestTargetAverageValue(t *testing.T) {
 	assert.Equal(t, int64(4), targetAverageValue)
 
 	// 5 5 4
-	specs = []v2beta2.MetricSpec{
+	specs = []v2.MetricSpec{
 		createMetricSpec(5, metricName),
 		createMetricSpec(5, metricName),
 		createMetricSpec(3, metricName),
@@ -50,14 +52,14 @@ func TestTargetAverageValue(t *testing.T) {
 	assert.Equal(t, int64(4), targetAverageValue)
 }
 
-func createMetricSpec(averageValue int64, metricName string) v2beta2.MetricSpec {
+func createMetricSpec(averageValue int64, metricName string) v2.MetricSpec {
 	qty := resource.NewQuantity(averageValue, resource.DecimalSI)
-	return v2beta2.MetricSpec{
-		External: &v2beta2.ExternalMetricSource{
-			Target: v2beta2.MetricTarget{
+	return v2.MetricSpec{
+		External: &v2.ExternalMetricSource{
+			Target: v2.MetricTarget{
 				AverageValue: qty,
 			},
-			Metric: v2beta2.MetricIdentifier{
+			Metric: v2.MetricIdentifier{
 				Name: metricName,
 			},
 		},
@@ -158,6 +160,221 @@ func TestIsScaledJobActive(t *testing.T) {
 	}
 }
 
+func TestGetScaledJobStateRequiredValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(1)
+	scaledJob := createScaledObject(10, "")
+
+	cache := ScalersCache{
+		Scalers:  []ScalerBuilder{{Scaler: createScaler(ctrl, int64(1000), int64(1), true, "s0-queueLength")}},
+		Logger:   logr.Discard(),
+		Recorder: recorder,
+	}
+
+	// the required jobs aren't limited by maxReplicaCount, unlike maxValue
+	isActive, queueLength, maxValue, requiredValue, scalerErrors := cache.GetScaledJobState(context.TODO(), scaledJob)
+	assert.True(t, isActive)
+	assert.Equal(t, int64(1000), queueLength)
+	assert.Equal(t, int64(10), maxValue)
+	assert.Equal(t, int64(1000), requiredValue)
+	assert.NoError(t, scalerErrors["s0-queueLength"])
+	cache.Close(context.Background())
+}
+
+func TestIsScaledObjectActiveWithModifiers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(10)
+
+	scaledObject := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &kedav1alpha1.ScaleTarget{Name: "test"},
+			Advanced: &kedav1alpha1.AdvancedConfig{
+				ScalingModifiers: &kedav1alpha1.ScalingModifiers{
+					Formula:          "(queue + 2*lag) / 3",
+					Target:           "10",
+					ActivationTarget: "4",
+				},
+			},
+			Triggers: []kedav1alpha1.ScaleTriggers{
+				{Name: "queue", Type: "rabbitmq"},
+				{Name: "lag", Type: "kafka"},
+			},
+		},
+	}
+
+	tests := []struct {
+		queue, lag     int64
+		expectedActive bool
+		expectedValue  int64
+	}{
+		{queue: 3, lag: 6, expectedActive: true, expectedValue: 5},
+		{queue: 3, lag: 3, expectedActive: false, expectedValue: 3},
+	}
+
+	for _, test := range tests {
+		cache := ScalersCache{
+			Scalers: []ScalerBuilder{
+				{Scaler: createFormulaScaler(ctrl, test.queue, "s0-queue")},
+				{Scaler: createFormulaScaler(ctrl, test.lag, "s1-lag")},
+			},
+			Logger:   logr.Discard(),
+			Recorder: recorder,
+		}
+
+		isActive, isError, metrics := cache.IsScaledObjectActive(context.TODO(), scaledObject)
+		assert.Equal(t, test.expectedActive, isActive)
+		assert.Equal(t, false, isError)
+		assert.Equal(t, 1, len(metrics))
+		assert.Equal(t, kedav1alpha1.CompositeMetricName, metrics[0].MetricName)
+		value, _ := metrics[0].Value.AsInt64()
+		assert.Equal(t, test.expectedValue, value)
+	}
+}
+
+func TestGetMetricsForScalerWithFreshnessWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(10)
+	metricName := "s0-queue"
+
+	scaledObject := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &kedav1alpha1.ScaleTarget{Name: "test"},
+		},
+	}
+
+	// metric values recorded in the scale loop are reused, the scaler is queried only once
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().IsActive(gomock.Any()).Return(true, nil)
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2.MetricSpec{createMetricSpec(1, metricName)}).AnyTimes()
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).Return([]external_metrics.ExternalMetricValue{
+		{MetricName: metricName, Value: *resource.NewQuantity(5, resource.DecimalSI)},
+	}, nil).Times(1)
+
+	cache := ScalersCache{
+		Scalers:                []ScalerBuilder{{Scaler: scaler}},
+		Logger:                 logr.Discard(),
+		Recorder:               recorder,
+		MetricsFreshnessWindow: time.Minute,
+	}
+
+	isActive, isError, _ := cache.IsScaledObjectActive(context.TODO(), scaledObject)
+	assert.True(t, isActive)
+	assert.False(t, isError)
+	for i := 0; i < 2; i++ {
+		metrics, err := cache.GetMetricsForScaler(context.TODO(), 0, metricName, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(metrics))
+		value, _ := metrics[0].Value.AsInt64()
+		assert.Equal(t, int64(5), value)
+	}
+
+	// without freshness window the scaler is queried on every request
+	scaler = mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).Return([]external_metrics.ExternalMetricValue{
+		{MetricName: metricName, Value: *resource.NewQuantity(5, resource.DecimalSI)},
+	}, nil).Times(2)
+
+	cache = ScalersCache{
+		Scalers:  []ScalerBuilder{{Scaler: scaler}},
+		Logger:   logr.Discard(),
+		Recorder: recorder,
+	}
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetMetricsForScaler(context.TODO(), 0, metricName, nil)
+		assert.NoError(t, err)
+	}
+}
+
+func TestActivationThresholdScalerIsQueriedOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	recorder := record.NewFakeRecorder(10)
+	metricName := "s0-queue"
+	metrics := []external_metrics.ExternalMetricValue{
+		{MetricName: metricName, Value: *resource.NewQuantity(20, resource.DecimalSI)},
+	}
+
+	// the activity is decided on the metric value, which is reused instead of querying the scaler again
+	newScaler := func() scalers.Scaler {
+		scaler := mock_scalers.NewMockScaler(ctrl)
+		scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2.MetricSpec{createMetricSpec(10, metricName)}).AnyTimes()
+		scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).Return(metrics, nil).Times(1)
+		wrapped, err := scalers.WithActivationThreshold(scaler, "prometheus", &scalers.ScalerConfig{ActivationThreshold: "15"})
+		assert.NoError(t, err)
+		return wrapped
+	}
+
+	cache := ScalersCache{
+		Scalers:                []ScalerBuilder{{Scaler: newScaler()}},
+		Logger:                 logr.Discard(),
+		Recorder:               recorder,
+		MetricsFreshnessWindow: time.Minute,
+	}
+	scaledObject := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &kedav1alpha1.ScaleTarget{Name: "test"},
+		},
+	}
+	isActive, isError, _ := cache.IsScaledObjectActive(context.TODO(), scaledObject)
+	assert.True(t, isActive)
+	assert.False(t, isError)
+	recorded, err := cache.GetMetricsForScaler(context.TODO(), 0, metricName, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, metrics, recorded)
+
+	cache = ScalersCache{
+		Scalers:  []ScalerBuilder{{Scaler: newScaler()}},
+		Logger:   logr.Discard(),
+		Recorder: recorder,
+	}
+	isActive, queueLength, maxValue := cache.IsScaledJobActive(context.TODO(), createScaledObject(10, ""))
+	assert.True(t, isActive)
+	assert.Equal(t, int64(20), queueLength)
+	assert.Equal(t, int64(2), maxValue)
+}
+
+func TestGetMetricsForScalerWithMetricsCacheTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	metricName := "s0-queue"
+
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).Return([]external_metrics.ExternalMetricValue{
+		{MetricName: metricName, Value: *resource.NewQuantity(5, resource.DecimalSI)},
+	}, nil).Times(2)
+
+	clock := clocktesting.NewFakePassiveClock(time.Now())
+	cache := ScalersCache{
+		Scalers:  []ScalerBuilder{{Scaler: scaler, MetricsCacheTTL: 50 * time.Millisecond}},
+		Logger:   logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+		clock:    clock,
+	}
+
+	// the first request misses the cache, the second one is served from the cache
+	for i := 0; i < 2; i++ {
+		metrics, err := cache.GetMetricsForScaler(context.TODO(), 0, metricName, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(metrics))
+	}
+
+	// the cached value expired, the scaler is queried again
+	clock.SetTime(clock.Now().Add(60 * time.Millisecond))
+	_, err := cache.GetMetricsForScaler(context.TODO(), 0, metricName, nil)
+	assert.NoError(t, err)
+}
+
+func createFormulaScaler(ctrl *gomock.Controller, value int64, metricName string) *mock_scalers.MockScaler {
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	metrics := []external_metrics.ExternalMetricValue{
+		{
+			MetricName: metricName,
+			Value:      *resource.NewQuantity(value, resource.DecimalSI),
+		},
+	}
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2.MetricSpec{createMetricSpec(1, metricName)}).AnyTimes()
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).Return(metrics, nil).AnyTimes()
+	return scaler
+}
+
 func newScalerTestData(
 	metricName string,
 	maxReplicaCount int,
@@ -240,7 +457,7 @@ func createScaledObject(maxReplicaCount int32, multipleScalersCalculation string
 
 func createScaler(ctrl *gomock.Controller, queueLength int64, averageValue int64, isActive bool, metricName string) *mock_scalers.MockScaler {
 	scaler := mock_scalers.NewMockScaler(ctrl)
-	metricsSpecs := []v2beta2.MetricSpec{createMetricSpec(averageValue, metricName)}
+	metricsSpecs := []v2.MetricSpec{createMetricSpec(averageValue, metricName)}
 
 	metrics := []external_metrics.ExternalMetricValue{
 		{
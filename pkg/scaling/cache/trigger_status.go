@@ -0,0 +1,188 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+// triggersStatusUpdateInterval is the minimal interval between the updates of status of ScaledObject
+// caused only by new metric values or success times of its triggers
+var triggersStatusUpdateInterval = 30 * time.Second
+
+// triggerObservation is the last observed state of a trigger
+type triggerObservation struct {
+	value           string
+	active          bool
+	lastSuccessTime *metav1.Time
+	lastError       string
+}
+
+// recordTriggerCheck records the result of the activity check of the trigger
+func (c *ScalersCache) recordTriggerCheck(id int, isActive bool, err error) {
+	c.updateTriggerObservation(id, func(observation *triggerObservation) {
+		observation.active = isActive
+		observeTriggerResult(observation, err)
+	})
+}
+
+// recordTriggerMetrics records the result of the metric query of the trigger, the value is the sum
+// of the values of the metric, as the HPA sums the values of the external metric
+func (c *ScalersCache) recordTriggerMetrics(id int, metricName string, metrics []external_metrics.ExternalMetricValue, err error) {
+	c.updateTriggerObservation(id, func(observation *triggerObservation) {
+		if err == nil {
+			value := resource.Quantity{}
+			for _, m := range metrics {
+				if m.MetricName == metricName {
+					value.Add(m.Value)
+				}
+			}
+			observation.value = value.String()
+		}
+		observeTriggerResult(observation, err)
+	})
+}
+
+func observeTriggerResult(observation *triggerObservation, err error) {
+	if err != nil {
+		observation.lastError = err.Error()
+		return
+	}
+	now := metav1.Now()
+	observation.lastSuccessTime = &now
+	observation.lastError = ""
+}
+
+func (c *ScalersCache) updateTriggerObservation(id int, update func(observation *triggerObservation)) {
+	c.triggerObservationsLock.Lock()
+	defer c.triggerObservationsLock.Unlock()
+	if c.triggerObservations == nil {
+		c.triggerObservations = make(map[int]triggerObservation)
+	}
+	observation := c.triggerObservations[id]
+	update(&observation)
+	c.triggerObservations[id] = observation
+}
+
+// GetTriggersStatus returns the last observed state of the triggers of ScaledObject and whether its status should be
+// updated with it. The status is updated right away once the triggers, their activity or errors change, the changes
+// of the metric values and success times are updated at most once per triggersStatusUpdateInterval, so the frequently
+// changing values don't result in a status update on every poll.
+func (c *ScalersCache) GetTriggersStatus(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject) ([]kedav1alpha1.TriggerStatus, bool) {
+	status := make([]kedav1alpha1.TriggerStatus, 0, len(c.Scalers))
+	for i, s := range c.Scalers {
+		triggerStatus := kedav1alpha1.TriggerStatus{
+			Name: s.TriggerName,
+			Type: s.TriggerType,
+		}
+		if triggerStatus.Name == "" {
+			triggerStatus.Name = strconv.Itoa(i)
+		}
+		if metricSpecs := s.Scaler.GetMetricSpecForScaling(ctx); len(metricSpecs) > 0 {
+			triggerStatus.MetricName, triggerStatus.Target = getMetricNameAndTarget(metricSpecs[0])
+		}
+		status = append(status, triggerStatus)
+	}
+
+	c.triggerObservationsLock.Lock()
+	defer c.triggerObservationsLock.Unlock()
+	for i := range status {
+		if observation, found := c.triggerObservations[i]; found {
+			status[i].Value = observation.value
+			status[i].Active = observation.active
+			status[i].LastSuccessTime = observation.lastSuccessTime
+			status[i].LastError = observation.lastError
+		}
+	}
+
+	previous := scaledObject.Status.Triggers
+	if triggersStatusChanged(previous, status) {
+		c.lastTriggersStatusUpdate = time.Now()
+		return status, true
+	}
+	if triggersStatusValuesChanged(previous, status) && time.Since(c.lastTriggersStatusUpdate) >= triggersStatusUpdateInterval {
+		c.lastTriggersStatusUpdate = time.Now()
+		return status, true
+	}
+	return status, false
+}
+
+// getMetricNameAndTarget returns the name of the metric and its target as used by the HPA
+func getMetricNameAndTarget(metricSpec v2.MetricSpec) (string, string) {
+	var metricName string
+	var target v2.MetricTarget
+	switch {
+	case metricSpec.External != nil:
+		metricName = metricSpec.External.Metric.Name
+		target = metricSpec.External.Target
+	case metricSpec.Resource != nil:
+		metricName = string(metricSpec.Resource.Name)
+		target = metricSpec.Resource.Target
+	case metricSpec.ContainerResource != nil:
+		metricName = string(metricSpec.ContainerResource.Name)
+		target = metricSpec.ContainerResource.Target
+	default:
+		return "", ""
+	}
+
+	switch {
+	case target.Value != nil:
+		return metricName, target.Value.String()
+	case target.AverageValue != nil:
+		return metricName, target.AverageValue.String()
+	case target.AverageUtilization != nil:
+		return metricName, strconv.Itoa(int(*target.AverageUtilization))
+	}
+	return metricName, ""
+}
+
+// triggersStatusChanged returns true if the triggers, their activity or errors changed
+func triggersStatusChanged(previous, current []kedav1alpha1.TriggerStatus) bool {
+	if len(previous) != len(current) {
+		return true
+	}
+	for i := range current {
+		p, c := previous[i], current[i]
+		if p.Name != c.Name || p.Type != c.Type || p.MetricName != c.MetricName || p.Target != c.Target ||
+			p.Active != c.Active || p.LastError != c.LastError {
+			return true
+		}
+	}
+	return false
+}
+
+// triggersStatusValuesChanged returns true if the metric values or success times of the triggers changed,
+// the success times are compared in seconds, as they are stored in status
+func triggersStatusValuesChanged(previous, current []kedav1alpha1.TriggerStatus) bool {
+	for i := range current {
+		p, c := previous[i], current[i]
+		if p.Value != c.Value || (p.LastSuccessTime == nil) != (c.LastSuccessTime == nil) ||
+			(c.LastSuccessTime != nil && p.LastSuccessTime.Unix() != c.LastSuccessTime.Unix()) {
+			return true
+		}
+	}
+	return false
+}
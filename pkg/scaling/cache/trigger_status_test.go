@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
+	"github.com/kedacore/keda/v2/pkg/scalers"
+)
+
+func TestGetTriggersStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	metricName := "s0-queue"
+
+	scaledObject := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &kedav1alpha1.ScaleTarget{Name: "test"},
+		},
+	}
+
+	var queueLength int64 = 5
+	var activeErr error
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2.MetricSpec{createMetricSpec(10, metricName)}).AnyTimes()
+	scaler.EXPECT().Close(gomock.Any()).AnyTimes()
+	scaler.EXPECT().IsActive(gomock.Any()).DoAndReturn(func(context.Context) (bool, error) {
+		return activeErr == nil, activeErr
+	}).AnyTimes()
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, nil).DoAndReturn(func(context.Context, string, interface{}) ([]external_metrics.ExternalMetricValue, error) {
+		return []external_metrics.ExternalMetricValue{
+			{MetricName: metricName, Value: *resource.NewQuantity(queueLength, resource.DecimalSI)},
+		}, nil
+	}).AnyTimes()
+
+	cache := ScalersCache{
+		Scalers: []ScalerBuilder{{
+			Scaler:      scaler,
+			TriggerType: "rabbitmq",
+			// the failing scaler is rebuilt by the factory
+			Factory: func() (scalers.Scaler, error) { return scaler, nil },
+		}},
+		Logger:   logr.Discard(),
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	// the triggers not observed yet are reported with their target
+	status, update := cache.GetTriggersStatus(context.TODO(), scaledObject)
+	assert.True(t, update)
+	assert.Equal(t, []kedav1alpha1.TriggerStatus{{Name: "0", Type: "rabbitmq", MetricName: metricName, Target: "10"}}, status)
+	scaledObject.Status.Triggers = status
+
+	// the activity is updated right away
+	cache.IsScaledObjectActive(context.TODO(), scaledObject)
+	_, err := cache.GetMetricsForScaler(context.TODO(), 0, metricName, nil)
+	assert.NoError(t, err)
+	status, update = cache.GetTriggersStatus(context.TODO(), scaledObject)
+	assert.True(t, update)
+	assert.True(t, status[0].Active)
+	assert.Equal(t, "5", status[0].Value)
+	assert.NotNil(t, status[0].LastSuccessTime)
+	assert.Empty(t, status[0].LastError)
+	scaledObject.Status.Triggers = status
+
+	// a new value alone is updated at most once per interval
+	queueLength = 7
+	_, err = cache.GetMetricsForScaler(context.TODO(), 0, metricName, nil)
+	assert.NoError(t, err)
+	status, update = cache.GetTriggersStatus(context.TODO(), scaledObject)
+	assert.False(t, update)
+	assert.Equal(t, "7", status[0].Value)
+
+	cache.lastTriggersStatusUpdate = time.Now().Add(-triggersStatusUpdateInterval)
+	status, update = cache.GetTriggersStatus(context.TODO(), scaledObject)
+	assert.True(t, update)
+	assert.Equal(t, "7", status[0].Value)
+	scaledObject.Status.Triggers = status
+
+	// the error is updated right away and cleared once the trigger succeeds
+	activeErr = fmt.Errorf("queue not found")
+	cache.IsScaledObjectActive(context.TODO(), scaledObject)
+	status, update = cache.GetTriggersStatus(context.TODO(), scaledObject)
+	assert.True(t, update)
+	assert.False(t, status[0].Active)
+	assert.Equal(t, "queue not found", status[0].LastError)
+	scaledObject.Status.Triggers = status
+
+	activeErr = nil
+	cache.IsScaledObjectActive(context.TODO(), scaledObject)
+	status, update = cache.GetTriggersStatus(context.TODO(), scaledObject)
+	assert.True(t, update)
+	assert.True(t, status[0].Active)
+	assert.Empty(t, status[0].LastError)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaling
+
+import (
+	"context"
+	"math"
+	"time"
+
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/eventreason"
+	"github.com/kedacore/keda/v2/pkg/metrics"
+	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
+	"github.com/kedacore/keda/v2/pkg/scaling/schedule"
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
+)
+
+const (
+	// same defaults as used for the HPA created by KEDA Operator
+	dryRunHPAMinReplicas int32 = 1
+	dryRunHPAMaxReplicas int32 = 100
+	// the HPA doesn't scale if the ratio of the metric value and the target is within the tolerance
+	hpaTolerance = 0.1
+)
+
+type hpaMetric struct {
+	spec  v2.MetricSpec
+	value float64
+}
+
+// recordDryRunHPADecision computes the replica count the HPA would scale the target of ScaledObject in dry-run mode to,
+// the external metrics are evaluated against their targets the same way the HPA does it. Resource metrics
+// (cpu/memory) are evaluated by the HPA directly from the pods and so they are not taken into account.
+func (h *scaleHandler) recordDryRunHPADecision(ctx context.Context, scalersCache *cache.ScalersCache, scaledObject *kedav1alpha1.ScaledObject) {
+	logger := h.logger.WithValues("scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+
+	// the replica count the scale loop decided on is used, the target itself isn't scaled in dry-run mode
+	var currentReplicas int32
+	if scaledObject.Status.DryRun != nil && scaledObject.Status.DryRun.DesiredReplicas != nil {
+		currentReplicas = *scaledObject.Status.DryRun.DesiredReplicas
+	} else {
+		var err error
+		currentReplicas, err = kedautil.GetCurrentReplicas(ctx, h.scaleClient, scaledObject)
+		if err != nil {
+			logger.Error(err, "Error getting the current replica count of the scale target")
+			return
+		}
+	}
+
+	hpaMetrics := h.getHPAMetrics(ctx, scalersCache, scaledObject)
+
+	now := time.Now()
+	minReplicas := dryRunHPAMinReplicas
+	if minReplicaCount := schedule.GetMinReplicaCount(scaledObject, now); minReplicaCount != nil && *minReplicaCount > 0 {
+		minReplicas = *minReplicaCount
+	}
+	maxReplicas := dryRunHPAMaxReplicas
+	if maxReplicaCount := schedule.GetMaxReplicaCount(scaledObject, now); maxReplicaCount != nil {
+		maxReplicas = *maxReplicaCount
+	}
+	desiredReplicas := getHPADesiredReplicas(hpaMetrics, currentReplicas, minReplicas, maxReplicas)
+
+	metrics.RecordDryRunDecision(scaledObject.Namespace, "ScaledObject", scaledObject.Name, metrics.DryRunDecisionHPA, int64(desiredReplicas))
+
+	previous := scaledObject.Status.DryRun
+	if previous != nil && previous.HPADesiredReplicas != nil && *previous.HPADesiredReplicas == desiredReplicas {
+		return
+	}
+
+	h.recorder.Eventf(scaledObject, corev1.EventTypeNormal, eventreason.KEDAScaleTargetDryRun, "HPA would scale %s %s/%s from %d to %d",
+		scaledObject.Status.ScaleTargetKind, scaledObject.Namespace, scaledObject.Spec.ScaleTargetRef.Name, currentReplicas, desiredReplicas)
+
+	patch := client.MergeFrom(scaledObject.DeepCopy())
+	if scaledObject.Status.DryRun == nil {
+		scaledObject.Status.DryRun = &kedav1alpha1.DryRunStatus{}
+	}
+	scaledObject.Status.DryRun.HPADesiredReplicas = &desiredReplicas
+	if err := h.client.Status().Patch(ctx, scaledObject, patch); err != nil {
+		logger.Error(err, "Failed to patch ScaledObjects Status")
+	}
+}
+
+// getHPAMetrics returns the values and targets of the external metrics the HPA would query,
+// metrics that can't be obtained are skipped, the same as the HPA ignores them
+func (h *scaleHandler) getHPAMetrics(ctx context.Context, scalersCache *cache.ScalersCache, scaledObject *kedav1alpha1.ScaledObject) []hpaMetric {
+	logger := h.logger.WithValues("scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+
+	if scaledObject.HasScalingModifiers() {
+		metricSpec, err := modifiers.GetCompositeMetricSpec(scaledObject)
+		if err != nil {
+			logger.Error(err, "Error getting the composite metric spec")
+			return nil
+		}
+		metric, err := scalersCache.GetCompositeMetric(ctx, scaledObject)
+		if err != nil {
+			logger.V(1).Info("Error getting the composite metric, skipping it", "Error", err)
+			return nil
+		}
+		return []hpaMetric{{spec: metricSpec, value: metric.Value.AsApproximateFloat64()}}
+	}
+
+	var result []hpaMetric
+	for i, s := range scalersCache.Scalers {
+		for _, metricSpec := range s.Scaler.GetMetricSpecForScaling(ctx) {
+			if metricSpec.External == nil {
+				continue
+			}
+			metricName := metricSpec.External.Metric.Name
+			values, err := scalersCache.GetMetricsForScaler(ctx, i, metricName, nil)
+			if err != nil {
+				logger.V(1).Info("Error getting metric, skipping it", "Metrics Name", metricName, "Error", err)
+				continue
+			}
+			sum := float64(0)
+			for _, value := range values {
+				sum += value.Value.AsApproximateFloat64()
+			}
+			result = append(result, hpaMetric{spec: metricSpec, value: sum})
+		}
+	}
+	return result
+}
+
+// getHPADesiredReplicas returns the replica count the HPA would scale the target to,
+// it is the highest replica count proposed by the metrics bounded by minReplicas and maxReplicas.
+// The HPA doesn't scale targets with zero replicas, these are activated by the scale loop of KEDA.
+func getHPADesiredReplicas(hpaMetrics []hpaMetric, currentReplicas int32, minReplicas int32, maxReplicas int32) int32 {
+	if currentReplicas == 0 {
+		return 0
+	}
+
+	desiredReplicas := int32(0)
+	found := false
+	for _, metric := range hpaMetrics {
+		if proposed, ok := getHPAProposedReplicas(metric, currentReplicas); ok {
+			found = true
+			if proposed > desiredReplicas {
+				desiredReplicas = proposed
+			}
+		}
+	}
+	// without any usable metric the HPA keeps the current replica count
+	if !found {
+		desiredReplicas = currentReplicas
+	}
+
+	if desiredReplicas < minReplicas {
+		desiredReplicas = minReplicas
+	}
+	if desiredReplicas > maxReplicas {
+		desiredReplicas = maxReplicas
+	}
+	return desiredReplicas
+}
+
+// getHPAProposedReplicas evaluates a single external metric the same way the HPA does,
+// false is returned if the target of the metric isn't specified
+func getHPAProposedReplicas(metric hpaMetric, currentReplicas int32) (int32, bool) {
+	target := metric.spec.External.Target
+	switch target.Type {
+	case v2.AverageValueMetricType:
+		if target.AverageValue == nil || target.AverageValue.AsApproximateFloat64() <= 0 {
+			return 0, false
+		}
+		targetValue := target.AverageValue.AsApproximateFloat64()
+		usageRatio := metric.value / (targetValue * float64(currentReplicas))
+		if math.Abs(1.0-usageRatio) <= hpaTolerance {
+			return currentReplicas, true
+		}
+		return int32(math.Ceil(metric.value / targetValue)), true
+	case v2.ValueMetricType:
+		if target.Value == nil || target.Value.AsApproximateFloat64() <= 0 {
+			return 0, false
+		}
+		usageRatio := metric.value / target.Value.AsApproximateFloat64()
+		if math.Abs(1.0-usageRatio) <= hpaTolerance {
+			return currentReplicas, true
+		}
+		return int32(math.Ceil(usageRatio * float64(currentReplicas))), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func getHPAMetric(metricType v2.MetricTargetType, target int64, value float64) hpaMetric {
+	metricTarget := v2.MetricTarget{Type: metricType}
+	if metricType == v2.AverageValueMetricType {
+		metricTarget.AverageValue = resource.NewQuantity(target, resource.DecimalSI)
+	} else {
+		metricTarget.Value = resource.NewQuantity(target, resource.DecimalSI)
+	}
+	return hpaMetric{
+		spec: v2.MetricSpec{
+			Type:     v2.ExternalMetricSourceType,
+			External: &v2.ExternalMetricSource{Target: metricTarget},
+		},
+		value: value,
+	}
+}
+
+func TestGetHPADesiredReplicas(t *testing.T) {
+	tests := []struct {
+		name            string
+		metrics         []hpaMetric
+		currentReplicas int32
+		expected        int32
+	}{
+		{name: "average value", metrics: []hpaMetric{getHPAMetric(v2.AverageValueMetricType, 10, 45)}, currentReplicas: 2, expected: 5},
+		{name: "value", metrics: []hpaMetric{getHPAMetric(v2.ValueMetricType, 10, 30)}, currentReplicas: 2, expected: 6},
+		{name: "within tolerance", metrics: []hpaMetric{getHPAMetric(v2.ValueMetricType, 10, 10.5)}, currentReplicas: 3, expected: 3},
+		{name: "highest of metrics", metrics: []hpaMetric{getHPAMetric(v2.AverageValueMetricType, 10, 20), getHPAMetric(v2.ValueMetricType, 10, 40)}, currentReplicas: 1, expected: 4},
+		{name: "bounded by min", metrics: []hpaMetric{getHPAMetric(v2.AverageValueMetricType, 10, 0)}, currentReplicas: 3, expected: 2},
+		{name: "bounded by max", metrics: []hpaMetric{getHPAMetric(v2.AverageValueMetricType, 1, 100)}, currentReplicas: 3, expected: 10},
+		{name: "no metrics", currentReplicas: 3, expected: 3},
+		{name: "scaled to zero", metrics: []hpaMetric{getHPAMetric(v2.AverageValueMetricType, 10, 45)}, currentReplicas: 0, expected: 0},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, getHPADesiredReplicas(test.metrics, test.currentReplicas, 2, 10), test.name)
+	}
+}
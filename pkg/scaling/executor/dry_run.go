@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/eventreason"
+	"github.com/kedacore/keda/v2/pkg/metrics"
+)
+
+// requestDryRunScale records the replica count the ScaleTarget of ScaledObject in dry-run mode would be scaled to
+// instead of scaling it, the last active time and the active condition are still maintained, so the recorded
+// decisions respect the cooldown period
+func (e *scaleExecutor) requestDryRunScale(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool, currentReplicas int32, pausedCount *int32) {
+	if pausedCount != nil {
+		e.updateScaledObjectDryRunStatus(ctx, logger, scaledObject, isActive, currentReplicas, *pausedCount)
+		return
+	}
+
+	desiredReplicas := getDesiredReplicas(scaledObject, isActive, isError, currentReplicas, time.Now())
+	if isActive {
+		if err := e.updateLastActiveTime(ctx, logger, scaledObject); err != nil {
+			logger.Error(err, "Error updating last active time")
+			return
+		}
+	}
+	e.updateScaledObjectDryRunStatus(ctx, logger, scaledObject, isActive, currentReplicas, desiredReplicas)
+	e.updateActiveCondition(ctx, logger, scaledObject, isActive)
+}
+
+// updateScaledObjectDryRunStatus records the replica count the scale loop would scale the target of ScaledObject to,
+// the status is patched and an event is emitted only if the decision changed since the previous polling interval
+func (e *scaleExecutor) updateScaledObjectDryRunStatus(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, isActive bool, currentReplicas int32, desiredReplicas int32) {
+	metrics.RecordDryRunDecision(scaledObject.Namespace, "ScaledObject", scaledObject.Name, metrics.DryRunDecisionScaleLoop, int64(desiredReplicas))
+
+	previous := scaledObject.Status.DryRun
+	if previous != nil && previous.WouldActivate == isActive &&
+		previous.DesiredReplicas != nil && *previous.DesiredReplicas == desiredReplicas {
+		return
+	}
+
+	if desiredReplicas != currentReplicas {
+		logger.Info("ScaledObject is in dry-run mode, ScaleTarget wasn't updated",
+			"Original Replicas Count", currentReplicas,
+			"Desired Replicas Count", desiredReplicas)
+		e.recorder.Eventf(scaledObject, corev1.EventTypeNormal, eventreason.KEDAScaleTargetDryRun, "Would scale %s %s/%s from %d to %d",
+			scaledObject.Status.ScaleTargetKind, scaledObject.Namespace, scaledObject.Spec.ScaleTargetRef.Name, currentReplicas, desiredReplicas)
+	}
+
+	patch := client.MergeFrom(scaledObject.DeepCopy())
+	scaledObject.Status.DryRun = newDryRunStatus(previous, isActive)
+	scaledObject.Status.DryRun.DesiredReplicas = &desiredReplicas
+	if err := e.client.Status().Patch(ctx, scaledObject, patch); err != nil {
+		logger.Error(err, "Failed to patch ScaledObjects Status")
+	}
+}
+
+// updateScaledJobDryRunStatus records the number of jobs that would be created for ScaledJob,
+// the status is patched only if the decision changed since the previous polling interval
+func (e *scaleExecutor) updateScaledJobDryRunStatus(ctx context.Context, logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob, isActive bool, jobsToCreate int64) {
+	metrics.RecordDryRunDecision(scaledJob.Namespace, "ScaledJob", scaledJob.Name, metrics.DryRunDecisionJobs, jobsToCreate)
+
+	// jobs are never created in dry-run mode, so they would be created in every polling interval
+	if jobsToCreate > 0 {
+		e.recorder.Eventf(scaledJob, corev1.EventTypeNormal, eventreason.KEDAJobsDryRun, "Would create %d jobs", jobsToCreate)
+	}
+
+	previous := scaledJob.Status.DryRun
+	if previous != nil && previous.WouldActivate == isActive &&
+		previous.JobsToCreate != nil && *previous.JobsToCreate == jobsToCreate {
+		return
+	}
+
+	patch := client.MergeFrom(scaledJob.DeepCopy())
+	scaledJob.Status.DryRun = newDryRunStatus(previous, isActive)
+	scaledJob.Status.DryRun.JobsToCreate = &jobsToCreate
+	if err := e.client.Status().Patch(ctx, scaledJob, patch); err != nil {
+		logger.Error(err, "Failed to patch ScaledJobs Status")
+	}
+}
+
+// newDryRunStatus returns a copy of the previous dry-run status with the new decision time
+func newDryRunStatus(previous *kedav1alpha1.DryRunStatus, isActive bool) *kedav1alpha1.DryRunStatus {
+	status := previous.DeepCopy()
+	if status == nil {
+		status = &kedav1alpha1.DryRunStatus{}
+	}
+	now := metav1.Now()
+	status.WouldActivate = isActive
+	status.LastDecisionTime = &now
+	return status
+}
@@ -19,6 +19,8 @@ package executor
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,8 +40,8 @@ const (
 
 // ScaleExecutor contains methods RequestJobScale and RequestScale
 type ScaleExecutor interface {
-	RequestJobScale(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob, isActive bool, scaleTo int64, maxScale int64)
-	RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool)
+	RequestJobScale(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob, isActive bool, scaleTo int64, maxScale int64, requiredScale int64, scalerErrors map[string]error)
+	RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool, scalerErrors map[string]error)
 }
 
 type scaleExecutor struct {
@@ -48,6 +50,17 @@ type scaleExecutor struct {
 	reconcilerScheme *runtime.Scheme
 	logger           logr.Logger
 	recorder         record.EventRecorder
+
+	// jobCreations are the jobs created for each ScaledJob with MaxJobsCreatedPerInterval keyed by namespace/name,
+	// so the jobs removed by the history limit before the end of the interval are still counted
+	jobCreations     map[string][]jobCreation
+	jobCreationsLock sync.Mutex
+}
+
+// jobCreation is a job created by scaleExecutor
+type jobCreation struct {
+	name string
+	time time.Time
 }
 
 // NewScaleExecutor creates a ScaleExecutor object
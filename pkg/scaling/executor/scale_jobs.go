@@ -19,17 +19,19 @@ package executor
 import (
 	"context"
 	"sort"
-	"strconv"
+	"time"
 
 	"github.com/go-logr/logr"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
+	"github.com/kedacore/keda/v2/pkg/fallback"
 	version "github.com/kedacore/keda/v2/version"
 )
 
@@ -38,21 +40,57 @@ const (
 	defaultFailedJobsHistoryLimit     = int32(100)
 )
 
-func (e *scaleExecutor) RequestJobScale(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob, isActive bool, scaleTo int64, maxScale int64) {
+func (e *scaleExecutor) RequestJobScale(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob, isActive bool, scaleTo int64, maxScale int64, requiredScale int64, scalerErrors map[string]error) {
 	logger := e.logger.WithValues("scaledJob.Name", scaledJob.Name, "scaledJob.Namespace", scaledJob.Namespace)
 
-	runningJobCount := e.getRunningJobCount(ctx, scaledJob)
+	// the autoscaling is paused, new Jobs are not created
+	if IsPaused(scaledJob) {
+		logger.V(1).Info("ScaledJob is paused, skipping creation of new Jobs")
+		return
+	}
+
+	jobs := e.getJobs(ctx, scaledJob)
+	runningJobCount := e.getRunningJobCount(jobs)
 	pendingJobCount := e.getPendingJobCount(ctx, scaledJob)
 	logger.Info("Scaling Jobs", "Number of running Jobs", runningJobCount)
 	logger.Info("Scaling Jobs", "Number of pending Jobs ", pendingJobCount)
 
-	effectiveMaxScale := NewScalingStrategy(logger, scaledJob).GetEffectiveMaxScale(maxScale, runningJobCount, pendingJobCount, scaledJob.MaxReplicaCount())
+	scalingState := ScalingState{
+		MaxScale:        maxScale,
+		RequiredScale:   requiredScale,
+		RunningJobCount: runningJobCount,
+		PendingJobCount: pendingJobCount,
+		MaxReplicaCount: scaledJob.MaxReplicaCount(),
+	}
+	if parallelism := scaledJob.Spec.JobTargetRef.Parallelism; parallelism != nil {
+		scalingState.JobParallelism = int64(*parallelism)
+	}
+	if completions := scaledJob.Spec.JobTargetRef.Completions; completions != nil {
+		scalingState.JobCompletions = int64(*completions)
+	}
+	if scaledJob.Spec.ScalingStrategy.MaxJobsCreatedPerInterval != nil {
+		scalingState.CreatedJobCount = e.getCreatedJobCount(scaledJob, jobs, getJobCreationInterval(scaledJob))
+	}
+	effectiveMaxScale := NewScalingStrategy(logger, scaledJob).GetEffectiveMaxScale(scalingState)
 
 	if effectiveMaxScale < 0 {
 		effectiveMaxScale = 0
 	}
 
-	if isActive {
+	if scaledJob.Spec.Fallback != nil && len(scalerErrors) > 0 {
+		e.updateScaledJobHealthStatus(ctx, logger, scaledJob, scalerErrors)
+	}
+
+	var jobsToCreate int64
+	switch {
+	case fallback.IsScaledJobFallbackActive(scaledJob):
+		logger.V(1).Info("Failure threshold of triggers is exceeded, falling back", "Fallback job count", scaledJob.Spec.Fallback.JobCount)
+		// the metrics of failing triggers aren't known, so the scaling strategy can't be applied,
+		// the number of running jobs is still limited by maxReplicaCount
+		if fallbackMaxScale := scaledJob.MaxReplicaCount() - runningJobCount; scaledJob.Spec.Fallback.JobCount > 0 && fallbackMaxScale > 0 {
+			jobsToCreate = e.createJobs(ctx, logger, scaledJob, int64(scaledJob.Spec.Fallback.JobCount), fallbackMaxScale)
+		}
+	case isActive:
 		logger.V(1).Info("At least one scaler is active")
 		now := metav1.Now()
 		scaledJob.Status.LastActiveTime = &now
@@ -60,11 +98,15 @@ func (e *scaleExecutor) RequestJobScale(ctx context.Context, scaledJob *kedav1al
 		if err != nil {
 			logger.Error(err, "Failed to update last active time")
 		}
-		e.createJobs(ctx, logger, scaledJob, scaleTo, effectiveMaxScale)
-	} else {
+		jobsToCreate = e.createJobs(ctx, logger, scaledJob, scaleTo, effectiveMaxScale)
+	default:
 		logger.V(1).Info("No change in activity")
 	}
 
+	if scaledJob.Spec.DryRun {
+		e.updateScaledJobDryRunStatus(ctx, logger, scaledJob, isActive, jobsToCreate)
+	}
+
 	condition := scaledJob.Status.Conditions.GetActiveCondition()
 	if condition.IsUnknown() || condition.IsTrue() != isActive {
 		if isActive {
@@ -86,7 +128,46 @@ func (e *scaleExecutor) RequestJobScale(ctx context.Context, scaledJob *kedav1al
 	}
 }
 
-func (e *scaleExecutor) createJobs(ctx context.Context, logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob, scaleTo int64, maxScale int64) {
+// updateScaledJobHealthStatus records the result of each trigger in ScaledJob.Status.Health
+// and updates the fallback condition, an event is emitted when the fallback is activated or deactivated.
+// The status is patched only if it has changed, so healthy triggers don't cause a patch on every polling interval
+func (e *scaleExecutor) updateScaledJobHealthStatus(ctx context.Context, logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob, scalerErrors map[string]error) {
+	wasFallbackActive := fallback.IsScaledJobFallbackActive(scaledJob)
+
+	original := scaledJob.DeepCopy()
+	for metricName, err := range scalerErrors {
+		// a successful trigger without recorded failures is already healthy
+		if _, found := scaledJob.Status.Health[metricName]; err == nil && !found {
+			continue
+		}
+		fallback.UpdateScaledJobHealthStatus(&scaledJob.Status, metricName, err)
+	}
+
+	isFallbackActive := fallback.IsScaledJobFallbackActive(scaledJob)
+	if isFallbackActive {
+		scaledJob.Status.Conditions.SetFallbackCondition(metav1.ConditionTrue, "FallbackExists", "At least one trigger is falling back on this scaled job")
+	} else {
+		scaledJob.Status.Conditions.SetFallbackCondition(metav1.ConditionFalse, "NoFallbackFound", "No fallbacks are active on this scaled job")
+	}
+
+	if equality.Semantic.DeepEqual(original.Status, scaledJob.Status) {
+		return
+	}
+	if err := e.client.Status().Patch(ctx, scaledJob, client.MergeFrom(original)); err != nil {
+		logger.Error(err, "Failed to patch ScaledJobs Status")
+	}
+
+	switch {
+	case isFallbackActive && !wasFallbackActive:
+		e.recorder.Eventf(scaledJob, corev1.EventTypeWarning, eventreason.ScaledJobFallbackActivated, "Failure threshold of triggers exceeded, falling back to %d jobs", scaledJob.Spec.Fallback.JobCount)
+	case !isFallbackActive && wasFallbackActive:
+		e.recorder.Event(scaledJob, corev1.EventTypeNormal, eventreason.ScaledJobFallbackDeactivated, "Triggers recovered, fallback is not used anymore")
+	}
+}
+
+// createJobs creates up to maxScale jobs and returns their number,
+// in dry-run mode the number is returned without creating the jobs
+func (e *scaleExecutor) createJobs(ctx context.Context, logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob, scaleTo int64, maxScale int64) int64 {
 	scaledJob.Spec.JobTargetRef.Template.GenerateName = scaledJob.GetName() + "-"
 	if scaledJob.Spec.JobTargetRef.Template.Labels == nil {
 		scaledJob.Spec.JobTargetRef.Template.Labels = map[string]string{}
@@ -98,6 +179,11 @@ func (e *scaleExecutor) createJobs(ctx context.Context, logger logr.Logger, scal
 	if scaleTo > maxScale {
 		scaleTo = maxScale
 	}
+
+	if scaledJob.Spec.DryRun {
+		logger.Info("ScaledJob is in dry-run mode, skipping creation of jobs", "Number of jobs", scaleTo)
+		return scaleTo
+	}
 	logger.Info("Creating jobs", "Number of jobs", scaleTo)
 
 	labels := map[string]string{
@@ -137,10 +223,15 @@ func (e *scaleExecutor) createJobs(ctx context.Context, logger logr.Logger, scal
 		err = e.client.Create(ctx, job)
 		if err != nil {
 			logger.Error(err, "Failed to create a new Job")
+			continue
+		}
+		if scaledJob.Spec.ScalingStrategy.MaxJobsCreatedPerInterval != nil {
+			e.recordJobCreation(scaledJob, job.Name)
 		}
 	}
 	logger.Info("Created jobs", "Number of jobs", scaleTo)
 	e.recorder.Eventf(scaledJob, corev1.EventTypeNormal, eventreason.KEDAJobsCreated, "Created %d jobs", scaleTo)
+	return scaleTo
 }
 
 func (e *scaleExecutor) isJobFinished(j *batchv1.Job) bool {
@@ -152,9 +243,8 @@ func (e *scaleExecutor) isJobFinished(j *batchv1.Job) bool {
 	return false
 }
 
-func (e *scaleExecutor) getRunningJobCount(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob) int64 {
-	var runningJobs int64
-
+// getJobs returns the jobs of ScaledJob, none if they can't be listed
+func (e *scaleExecutor) getJobs(ctx context.Context, scaledJob *kedav1alpha1.ScaledJob) []batchv1.Job {
 	opts := []client.ListOption{
 		client.InNamespace(scaledJob.GetNamespace()),
 		client.MatchingLabels(map[string]string{"scaledjob.keda.sh/name": scaledJob.GetName()}),
@@ -164,10 +254,15 @@ func (e *scaleExecutor) getRunningJobCount(ctx context.Context, scaledJob *kedav
 	err := e.client.List(ctx, jobs, opts...)
 
 	if err != nil {
-		return 0
+		return nil
 	}
+	return jobs.Items
+}
 
-	for _, job := range jobs.Items {
+func (e *scaleExecutor) getRunningJobCount(jobs []batchv1.Job) int64 {
+	var runningJobs int64
+
+	for _, job := range jobs {
 		job := job
 		if !e.isJobFinished(&job) {
 			runningJobs++
@@ -177,6 +272,55 @@ func (e *scaleExecutor) getRunningJobCount(ctx context.Context, scaledJob *kedav
 	return runningJobs
 }
 
+// getCreatedJobCount returns the number of jobs of ScaledJob created within the interval, the jobs created by this
+// executor are counted even if they are already removed by the history limit, the listed jobs cover the ones
+// created before KEDA Operator was started
+func (e *scaleExecutor) getCreatedJobCount(scaledJob *kedav1alpha1.ScaledJob, jobs []batchv1.Job, interval time.Duration) int64 {
+	since := time.Now().Add(-interval)
+	createdJobs := int64(0)
+	listedJobs := make(map[string]bool)
+	for _, job := range jobs {
+		if job.CreationTimestamp.Time.After(since) {
+			listedJobs[job.Name] = true
+			createdJobs++
+		}
+	}
+
+	e.jobCreationsLock.Lock()
+	defer e.jobCreationsLock.Unlock()
+
+	key := scaledJob.Namespace + "/" + scaledJob.Name
+	var recent []jobCreation
+	for _, creation := range e.jobCreations[key] {
+		if !creation.time.After(since) {
+			continue
+		}
+		recent = append(recent, creation)
+		if creation.name == "" || !listedJobs[creation.name] {
+			createdJobs++
+		}
+	}
+	if len(recent) == 0 {
+		delete(e.jobCreations, key)
+	} else {
+		e.jobCreations[key] = recent
+	}
+
+	return createdJobs
+}
+
+// recordJobCreation records the job created for ScaledJob to be counted by getCreatedJobCount
+func (e *scaleExecutor) recordJobCreation(scaledJob *kedav1alpha1.ScaledJob, name string) {
+	e.jobCreationsLock.Lock()
+	defer e.jobCreationsLock.Unlock()
+
+	if e.jobCreations == nil {
+		e.jobCreations = make(map[string][]jobCreation)
+	}
+	key := scaledJob.Namespace + "/" + scaledJob.Name
+	e.jobCreations[key] = append(e.jobCreations[key], jobCreation{name: name, time: time.Now()})
+}
+
 func (e *scaleExecutor) isAnyPodRunningOrCompleted(ctx context.Context, j *batchv1.Job) bool {
 	opts := []client.ListOption{
 		client.InNamespace(j.GetNamespace()),
@@ -350,67 +494,3 @@ func (e *scaleExecutor) getFinishedJobConditionType(j *batchv1.Job) batchv1.JobC
 	}
 	return ""
 }
-
-// NewScalingStrategy returns ScalingStrategy instance
-func NewScalingStrategy(logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob) ScalingStrategy {
-	switch scaledJob.Spec.ScalingStrategy.Strategy {
-	case "custom":
-		logger.V(1).Info("Selecting Scale Strategy", "specified", scaledJob.Spec.ScalingStrategy.Strategy, "selected:", "custom", "customScalingQueueLength", scaledJob.Spec.ScalingStrategy.CustomScalingQueueLengthDeduction, "customScallingRunningJobPercentage", scaledJob.Spec.ScalingStrategy.CustomScalingRunningJobPercentage)
-		var err error
-		if percentage, err := strconv.ParseFloat(scaledJob.Spec.ScalingStrategy.CustomScalingRunningJobPercentage, 64); err == nil {
-			return customScalingStrategy{
-				CustomScalingQueueLengthDeduction: scaledJob.Spec.ScalingStrategy.CustomScalingQueueLengthDeduction,
-				CustomScalingRunningJobPercentage: &percentage,
-			}
-		}
-
-		logger.V(1).Info("Fail to convert CustomScalingRunningJobPercentage into float", "error", err, "CustomScalingRunningJobPercentage", scaledJob.Spec.ScalingStrategy.CustomScalingRunningJobPercentage)
-		logger.V(1).Info("Selecting Scale has been changed", "selected", "default")
-		return defaultScalingStrategy{}
-
-	case "accurate":
-		logger.V(1).Info("Selecting Scale Strategy", "specified", scaledJob.Spec.ScalingStrategy.Strategy, "selected", "accurate")
-		return accurateScalingStrategy{}
-	default:
-		logger.V(1).Info("Selecting Scale Strategy", "specified", scaledJob.Spec.ScalingStrategy.Strategy, "selected", "default")
-		return defaultScalingStrategy{}
-	}
-}
-
-// ScalingStrategy is an interface for switching scaling algorithm
-type ScalingStrategy interface {
-	GetEffectiveMaxScale(maxScale, runningJobCount, pendingJobCount, maxReplicaCount int64) int64
-}
-
-type defaultScalingStrategy struct {
-}
-
-func (s defaultScalingStrategy) GetEffectiveMaxScale(maxScale, runningJobCount, pendingJobCount, maxReplicaCount int64) int64 {
-	return maxScale - runningJobCount
-}
-
-type customScalingStrategy struct {
-	CustomScalingQueueLengthDeduction *int32
-	CustomScalingRunningJobPercentage *float64
-}
-
-func (s customScalingStrategy) GetEffectiveMaxScale(maxScale, runningJobCount, pendingJobCount, maxReplicaCount int64) int64 {
-	return min(maxScale-int64(*s.CustomScalingQueueLengthDeduction)-int64(float64(runningJobCount)*(*s.CustomScalingRunningJobPercentage)), maxReplicaCount)
-}
-
-type accurateScalingStrategy struct {
-}
-
-func (s accurateScalingStrategy) GetEffectiveMaxScale(maxScale, runningJobCount, pendingJobCount, maxReplicaCount int64) int64 {
-	if (maxScale + runningJobCount) > maxReplicaCount {
-		return maxReplicaCount - runningJobCount
-	}
-	return maxScale - pendingJobCount
-}
-
-func min(x, y int64) int64 {
-	if x > y {
-		return y
-	}
-	return x
-}
@@ -28,6 +28,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -66,6 +67,129 @@ func TestCleanUpNormalCase(t *testing.T) {
 	assert.True(t, ok)
 }
 
+func TestPausedScaledJobDoesNotCreateJobs(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scaledJob := getMockScaledJob(2, 2)
+	scaledJob.Annotations = map[string]string{"autoscaling.keda.sh/paused": "true"}
+
+	// no calls on the client are expected, ie. no Jobs are listed or created
+	client := mock_client.NewMockClient(ctrl)
+	scaleExecutor := getMockScaleExecutor(client)
+	scaleExecutor.RequestJobScale(ctx, scaledJob, true, 5, 5, 5, nil)
+}
+
+func TestScaledJobFallbackCreatesFallbackJobs(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	numberOfFailures := int32(2)
+	scaledJob := getMockScaledJob(2, 2)
+	scaledJob.Spec.JobTargetRef = &batchv1.JobSpec{}
+	scaledJob.Spec.Fallback = &kedav1alpha1.ScaledJobFallback{
+		FailureThreshold: 2,
+		JobCount:         3,
+	}
+	scaledJob.Status.Conditions = *kedav1alpha1.GetInitializedConditions()
+	scaledJob.Status.Health = map[string]kedav1alpha1.HealthStatus{
+		"some-metric": {
+			NumberOfFailures: &numberOfFailures,
+			Status:           kedav1alpha1.HealthStatusFailing,
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, kedav1alpha1.AddToScheme(scheme))
+	recorder := record.NewFakeRecorder(10)
+
+	client := mock_client.NewMockClient(ctrl)
+	statusWriter := mock_client.NewMockStatusWriter(ctrl)
+	client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	client.EXPECT().Status().Return(statusWriter).AnyTimes()
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	client.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+
+	scaleExecutor := &scaleExecutor{
+		client:           client,
+		reconcilerScheme: scheme,
+		logger:           logf.Log.WithName("scaleexecutor"),
+		recorder:         recorder,
+	}
+	scaleExecutor.RequestJobScale(ctx, scaledJob, false, 0, 0, 0, map[string]error{"some-metric": fmt.Errorf("some error")})
+
+	assert.Equal(t, int32(3), *scaledJob.Status.Health["some-metric"].NumberOfFailures)
+	condition := scaledJob.Status.Conditions.GetFallbackCondition()
+	assert.True(t, condition.IsTrue())
+	assert.Contains(t, <-recorder.Events, "ScaledJobFallbackActivated")
+}
+
+func TestScaledJobFallbackDoesNotPatchUnchangedHealth(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scaledJob := getMockScaledJob(2, 2)
+	scaledJob.Spec.JobTargetRef = &batchv1.JobSpec{}
+	scaledJob.Spec.Fallback = &kedav1alpha1.ScaledJobFallback{
+		FailureThreshold: 2,
+		JobCount:         3,
+	}
+	scaledJob.Status.Conditions = *kedav1alpha1.GetInitializedConditions()
+	scaledJob.Status.Conditions.SetFallbackCondition(metav1.ConditionFalse, "NoFallbackFound", "No fallbacks are active on this scaled job")
+
+	recorder := record.NewFakeRecorder(10)
+
+	// the triggers are healthy, so the status of the ScaledJob isn't patched
+	client := mock_client.NewMockClient(ctrl)
+	client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	client.EXPECT().Status().Times(0)
+
+	scaleExecutor := &scaleExecutor{
+		client:   client,
+		logger:   logf.Log.WithName("scaleexecutor"),
+		recorder: recorder,
+	}
+	scaleExecutor.updateScaledJobHealthStatus(ctx, scaleExecutor.logger, scaledJob, map[string]error{"some-metric": nil})
+
+	assert.Empty(t, scaledJob.Status.Health)
+	assert.Empty(t, recorder.Events)
+}
+
+func TestScaledJobInDryRunModeDoesNotCreateJobs(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scaledJob := getMockScaledJob(2, 2)
+	scaledJob.Spec.JobTargetRef = &batchv1.JobSpec{}
+	scaledJob.Spec.DryRun = true
+	scaledJob.Status.Conditions = *kedav1alpha1.GetInitializedConditions()
+
+	recorder := record.NewFakeRecorder(10)
+
+	client := mock_client.NewMockClient(ctrl)
+	statusWriter := mock_client.NewMockStatusWriter(ctrl)
+	client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	client.EXPECT().Status().Return(statusWriter).AnyTimes()
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	client.EXPECT().Create(gomock.Any(), gomock.Any()).Times(0)
+
+	scaleExecutor := &scaleExecutor{
+		client:   client,
+		logger:   logf.Log.WithName("scaleexecutor"),
+		recorder: recorder,
+	}
+	scaleExecutor.RequestJobScale(ctx, scaledJob, true, 3, 3, 3, nil)
+
+	assert.NotNil(t, scaledJob.Status.DryRun)
+	assert.True(t, scaledJob.Status.DryRun.WouldActivate)
+	assert.Equal(t, int64(3), *scaledJob.Status.DryRun.JobsToCreate)
+	assert.Equal(t, "Normal KEDAJobsDryRun Would create 3 jobs", <-recorder.Events)
+}
+
 func TestNewNewScalingStrategy(t *testing.T) {
 	logger := logf.Log.WithName("ScaledJobTest")
 	strategy := NewScalingStrategy(logger, getMockScaledJobWithStrategy("custom", "custom", int32(10), "0"))
@@ -83,8 +207,8 @@ func TestDefaultScalingStrategy(t *testing.T) {
 	strategy := NewScalingStrategy(logger, getMockScaledJobWithDefaultStrategy("default"))
 	// maxScale doesn't exceed MaxReplicaCount. You can ignore on this sceanrio
 	// pendingJobCount isn't relevant on this scenario
-	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(3, 2, 0, 5))
-	assert.Equal(t, int64(2), strategy.GetEffectiveMaxScale(2, 0, 0, 5))
+	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 3, RunningJobCount: 2, PendingJobCount: 0, MaxReplicaCount: 5}))
+	assert.Equal(t, int64(2), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 2, RunningJobCount: 0, PendingJobCount: 0, MaxReplicaCount: 5}))
 }
 
 func TestCustomScalingStrategy(t *testing.T) {
@@ -94,13 +218,13 @@ func TestCustomScalingStrategy(t *testing.T) {
 	strategy := NewScalingStrategy(logger, getMockScaledJobWithStrategy("custom", "custom", customScalingQueueLengthDeduction, customScalingRunningJobPercentage))
 	// maxScale doesn't exceed MaxReplicaCount. You can ignore on this sceanrio
 	// pendingJobCount isn't relevant on this scenario
-	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(3, 2, 0, 5))
-	assert.Equal(t, int64(9), strategy.GetEffectiveMaxScale(10, 0, 0, 10))
+	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 3, RunningJobCount: 2, PendingJobCount: 0, MaxReplicaCount: 5}))
+	assert.Equal(t, int64(9), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 10, RunningJobCount: 0, PendingJobCount: 0, MaxReplicaCount: 10}))
 	strategy = NewScalingStrategy(logger, getMockScaledJobWithCustomStrategyWithNilParameter("custom", "custom"))
 
 	// If you don't set the two parameters is the same behavior as DefaultStrategy
-	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(3, 2, 0, 5))
-	assert.Equal(t, int64(2), strategy.GetEffectiveMaxScale(2, 0, 0, 5))
+	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 3, RunningJobCount: 2, PendingJobCount: 0, MaxReplicaCount: 5}))
+	assert.Equal(t, int64(2), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 2, RunningJobCount: 0, PendingJobCount: 0, MaxReplicaCount: 5}))
 
 	// Empty String will be DefaultStrategy
 	customScalingQueueLengthDeduction = int32(1)
@@ -112,25 +236,25 @@ func TestCustomScalingStrategy(t *testing.T) {
 	customScalingQueueLengthDeduction = int32(2)
 	customScalingRunningJobPercentage = "0"
 	strategy = NewScalingStrategy(logger, getMockScaledJobWithStrategy("custom", "custom", customScalingQueueLengthDeduction, customScalingRunningJobPercentage))
-	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(3, 2, 0, 5))
+	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 3, RunningJobCount: 2, PendingJobCount: 0, MaxReplicaCount: 5}))
 
 	// Exceed the MaxReplicaCount
 	customScalingQueueLengthDeduction = int32(-2)
 	customScalingRunningJobPercentage = "0"
 	strategy = NewScalingStrategy(logger, getMockScaledJobWithStrategy("custom", "custom", customScalingQueueLengthDeduction, customScalingRunningJobPercentage))
-	assert.Equal(t, int64(4), strategy.GetEffectiveMaxScale(3, 2, 0, 4))
+	assert.Equal(t, int64(4), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 3, RunningJobCount: 2, PendingJobCount: 0, MaxReplicaCount: 4}))
 }
 
 func TestAccurateScalingStrategy(t *testing.T) {
 	logger := logf.Log.WithName("ScaledJobTest")
 	strategy := NewScalingStrategy(logger, getMockScaledJobWithStrategy("accurate", "accurate", 0, "0"))
 	// maxScale doesn't exceed MaxReplicaCount. You can ignore on this sceanrio
-	assert.Equal(t, int64(3), strategy.GetEffectiveMaxScale(3, 2, 0, 5))
-	assert.Equal(t, int64(3), strategy.GetEffectiveMaxScale(5, 2, 0, 5))
+	assert.Equal(t, int64(3), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 3, RunningJobCount: 2, PendingJobCount: 0, MaxReplicaCount: 5}))
+	assert.Equal(t, int64(3), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 5, RunningJobCount: 2, PendingJobCount: 0, MaxReplicaCount: 5}))
 
 	// Test with 2 pending jobs
-	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(3, 4, 2, 10))
-	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(5, 4, 2, 5))
+	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 3, RunningJobCount: 4, PendingJobCount: 2, MaxReplicaCount: 10}))
+	assert.Equal(t, int64(1), strategy.GetEffectiveMaxScale(ScalingState{MaxScale: 5, RunningJobCount: 4, PendingJobCount: 2, MaxReplicaCount: 5}))
 }
 
 func TestCleanUpMixedCaseWithSortByTime(t *testing.T) {
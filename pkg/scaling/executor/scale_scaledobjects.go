@@ -31,13 +31,21 @@ import (
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	kedacontrollerutil "github.com/kedacore/keda/v2/controllers/keda/util"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
+	"github.com/kedacore/keda/v2/pkg/fallback"
+	"github.com/kedacore/keda/v2/pkg/scaling/schedule"
 )
 
-func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool) {
+func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool, scalerErrors map[string]error) {
 	logger := e.logger.WithValues("scaledobject.Name", scaledObject.Name,
 		"scaledObject.Namespace", scaledObject.Namespace,
 		"scaleTarget.Name", scaledObject.Spec.ScaleTargetRef.Name)
 
+	// the autoscaling is paused, keep the current replica count untouched
+	if IsPaused(scaledObject) {
+		logger.V(1).Info("ScaledObject is paused, skipping scaling")
+		return
+	}
+
 	// Get the current replica count. As a special case, Deployments and StatefulSets fetch directly from the object so they can use the informer cache
 	// to reduce API calls. Everything else uses the scale subresource.
 	var currentScale *autoscalingv1.Scale
@@ -71,6 +79,12 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 		currentReplicas = currentScale.Spec.Replicas
 	}
 
+	// the HPA doesn't query the metrics while the target is scaled to zero, so the health
+	// of the triggers is tracked here to be able to fall back on the 0->1 activation path
+	if currentReplicas == 0 && scaledObject.Spec.Fallback != nil && len(scalerErrors) > 0 {
+		e.updateHealthStatus(ctx, logger, scaledObject, scalerErrors)
+	}
+
 	// if the ScaledObject's triggers aren't in the error state,
 	// but ScaledObject.Status.ReadyCondition is set not set to 'true' -> set it back to 'true'
 	readyCondition := scaledObject.Status.Conditions.GetReadyCondition()
@@ -92,6 +106,12 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 		return
 	}
 
+	// in dry-run mode the ScaleTarget isn't scaled, only the decision is recorded
+	if scaledObject.Spec.DryRun {
+		e.requestDryRunScale(ctx, logger, scaledObject, isActive, isError, currentReplicas, pausedCount)
+		return
+	}
+
 	status := scaledObject.Status.DeepCopy()
 	if pausedCount != nil {
 		// Scale the target to the paused replica count
@@ -116,15 +136,17 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 		return
 	}
 
-	// if scaledObject.Spec.MinReplicaCount is not set, then set the default value (0)
+	// if scaledObject.Spec.MinReplicaCount is not set, then set the default value (0),
+	// the active scheduled window can override it and disable Idle Replicas mode
 	minReplicas := int32(0)
-	if scaledObject.Spec.MinReplicaCount != nil {
-		minReplicas = *scaledObject.Spec.MinReplicaCount
+	if minReplicaCount := schedule.GetMinReplicaCount(scaledObject, time.Now()); minReplicaCount != nil {
+		minReplicas = *minReplicaCount
 	}
+	idleReplicaCount := getIdleReplicaCount(scaledObject)
 
 	if isActive {
 		switch {
-		case scaledObject.Spec.IdleReplicaCount != nil && currentReplicas < minReplicas,
+		case idleReplicaCount != nil && currentReplicas < minReplicas,
 			// triggers are active, Idle Replicas mode is enabled
 			// AND
 			// replica count is less then minimum replica count
@@ -160,17 +182,17 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 	} else {
 		// isActive == false
 		switch {
-		case isError && scaledObject.Spec.Fallback != nil && scaledObject.Spec.Fallback.Replicas != 0:
+		case isError && fallback.IsFallbackActive(scaledObject):
 			// there are no active triggers, but a scaler responded with an error
 			// AND
-			// there is a fallback replicas count defined
+			// the failure threshold of a fallback is exceeded
 
 			// Scale to the fallback replicas count
 			e.doFallbackScaling(ctx, scaledObject, currentScale, logger, currentReplicas)
-		case isError && scaledObject.Spec.Fallback == nil:
+		case isError:
 			// there are no active triggers, but a scaler responded with an error
 			// AND
-			// there is not a fallback replicas count defined
+			// there is not a fallback defined or its failure threshold isn't exceeded yet
 
 			// Set ScaledObject.Status.ReadyCondition to false
 			msg := "Triggers defined in ScaledObject are not working correctly"
@@ -180,7 +202,7 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 					logger.Error(err, "error setting ready condition")
 				}
 			}
-		case scaledObject.Spec.IdleReplicaCount != nil && currentReplicas > *scaledObject.Spec.IdleReplicaCount,
+		case idleReplicaCount != nil && currentReplicas > *idleReplicaCount,
 			// there are no active triggers, Idle Replicas mode is enabled
 			// AND
 			// current replicas count is greater than Idle Replicas count
@@ -192,7 +214,7 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 
 			// Try to scale the deployment down, HPA will handle other scale down operations
 			e.scaleToZeroOrIdle(ctx, logger, scaledObject, currentScale)
-		case currentReplicas < minReplicas && scaledObject.Spec.IdleReplicaCount == nil:
+		case currentReplicas < minReplicas && idleReplicaCount == nil:
 			// there are no active triggers
 			// AND
 			// ScaleTarget replicas count is less than minimum replica count specified in ScaledObject
@@ -200,11 +222,11 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 			// Idle Replicas mode is disabled
 
 			// ScaleTarget replicas count to correct value
-			_, err := e.updateScaleOnScaleTarget(ctx, scaledObject, currentScale, *scaledObject.Spec.MinReplicaCount)
+			_, err := e.updateScaleOnScaleTarget(ctx, scaledObject, currentScale, minReplicas)
 			if err == nil {
 				logger.Info("Successfully set ScaleTarget replicas count to ScaledObject minReplicaCount",
 					"Original Replicas Count", currentReplicas,
-					"New Replicas Count", *scaledObject.Spec.MinReplicaCount)
+					"New Replicas Count", minReplicas)
 			}
 		default:
 			// there are no active triggers
@@ -214,6 +236,11 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 		}
 	}
 
+	e.updateActiveCondition(ctx, logger, scaledObject, isActive)
+}
+
+// updateActiveCondition sets the active condition of ScaledObject if it doesn't match the state of the triggers
+func (e *scaleExecutor) updateActiveCondition(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, isActive bool) {
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
 	if condition.IsUnknown() || condition.IsTrue() != isActive {
 		if isActive {
@@ -231,32 +258,59 @@ func (e *scaleExecutor) RequestScale(ctx context.Context, scaledObject *kedav1al
 }
 
 func (e *scaleExecutor) doFallbackScaling(ctx context.Context, scaledObject *kedav1alpha1.ScaledObject, currentScale *autoscalingv1.Scale, logger logr.Logger, currentReplicas int32) {
-	_, err := e.updateScaleOnScaleTarget(ctx, scaledObject, currentScale, scaledObject.Spec.Fallback.Replicas)
-	if err == nil {
-		logger.Info("Successfully set ScaleTarget replicas count to ScaledObject fallback.replicas",
-			"Original Replicas Count", currentReplicas,
-			"New Replicas Count", scaledObject.Spec.Fallback.Replicas)
+	fallbackReplicas := fallback.GetFallbackReplicas(scaledObject, currentReplicas)
+	if fallbackReplicas != currentReplicas {
+		_, err := e.updateScaleOnScaleTarget(ctx, scaledObject, currentScale, fallbackReplicas)
+		if err == nil {
+			logger.Info("Successfully set ScaleTarget replicas count to ScaledObject fallback replicas",
+				"Original Replicas Count", currentReplicas,
+				"New Replicas Count", fallbackReplicas)
+		}
 	}
 	if e := e.setFallbackCondition(ctx, logger, scaledObject, metav1.ConditionTrue, "FallbackExists", "At least one trigger is falling back on this scaled object"); e != nil {
 		logger.Error(e, "Error setting fallback condition")
 	}
 }
 
-// An object will be scaled down to 0 only if it's passed its cooldown period
-// or if LastActiveTime is nil
-func (e *scaleExecutor) scaleToZeroOrIdle(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, scale *autoscalingv1.Scale) {
-	var cooldownPeriod time.Duration
+// updateHealthStatus records the result of each trigger in ScaledObject.Status.Health and updates the fallback condition,
+// the same failure counts are maintained by KEDA Metrics Server while the HPA queries the metrics
+func (e *scaleExecutor) updateHealthStatus(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, scalerErrors map[string]error) {
+	patch := client.MergeFrom(scaledObject.DeepCopy())
+	for metricName, err := range scalerErrors {
+		fallback.UpdateHealthStatus(&scaledObject.Status, metricName, err)
+	}
 
-	if scaledObject.Spec.CooldownPeriod != nil {
-		cooldownPeriod = time.Second * time.Duration(*scaledObject.Spec.CooldownPeriod)
+	if fallback.IsFallbackActive(scaledObject) {
+		scaledObject.Status.Conditions.SetFallbackCondition(metav1.ConditionTrue, "FallbackExists", "At least one trigger is falling back on this scaled object")
 	} else {
-		cooldownPeriod = time.Second * time.Duration(defaultCooldownPeriod)
+		scaledObject.Status.Conditions.SetFallbackCondition(metav1.ConditionFalse, "NoFallbackFound", "No fallbacks are active on this scaled object")
+	}
+
+	if err := e.client.Status().Patch(ctx, scaledObject, patch); err != nil {
+		logger.Error(err, "Failed to patch ScaledObjects Status")
 	}
+}
 
+// getCooldownPeriod returns the cooldown period of ScaledObject
+func getCooldownPeriod(scaledObject *kedav1alpha1.ScaledObject) time.Duration {
+	if scaledObject.Spec.CooldownPeriod != nil {
+		return time.Second * time.Duration(*scaledObject.Spec.CooldownPeriod)
+	}
+	return time.Second * time.Duration(defaultCooldownPeriod)
+}
+
+// isCooledDown returns true if the triggers weren't active for the cooldown period
+func isCooledDown(scaledObject *kedav1alpha1.ScaledObject, now time.Time) bool {
 	// LastActiveTime can be nil if the ScaleTarget was scaled outside of KEDA.
 	// In this case we will ignore the cooldown period and scale it down
-	if scaledObject.Status.LastActiveTime == nil ||
-		scaledObject.Status.LastActiveTime.Add(cooldownPeriod).Before(time.Now()) {
+	return scaledObject.Status.LastActiveTime == nil ||
+		scaledObject.Status.LastActiveTime.Add(getCooldownPeriod(scaledObject)).Before(now)
+}
+
+// An object will be scaled down to 0 only if it's passed its cooldown period
+// or if LastActiveTime is nil
+func (e *scaleExecutor) scaleToZeroOrIdle(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, scale *autoscalingv1.Scale) {
+	if isCooledDown(scaledObject, time.Now()) {
 		// or last time a trigger was active was > cooldown period, so scale down.
 
 		idleValue, scaleToReplicas := getIdleOrMinimumReplicaCount(scaledObject)
@@ -284,7 +338,7 @@ func (e *scaleExecutor) scaleToZeroOrIdle(ctx context.Context, logger logr.Logge
 	} else {
 		logger.V(1).Info("ScaleTarget cooling down",
 			"LastActiveTime", scaledObject.Status.LastActiveTime,
-			"CoolDownPeriod", cooldownPeriod)
+			"CoolDownPeriod", getCooldownPeriod(scaledObject))
 
 		activeCondition := scaledObject.Status.Conditions.GetActiveCondition()
 		if !activeCondition.IsFalse() || activeCondition.Reason != "ScalerCooldown" {
@@ -296,13 +350,16 @@ func (e *scaleExecutor) scaleToZeroOrIdle(ctx context.Context, logger logr.Logge
 	}
 }
 
-func (e *scaleExecutor) scaleFromZeroOrIdle(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, scale *autoscalingv1.Scale) {
-	var replicas int32
-	if scaledObject.Spec.MinReplicaCount != nil && *scaledObject.Spec.MinReplicaCount > 0 {
-		replicas = *scaledObject.Spec.MinReplicaCount
-	} else {
-		replicas = 1
+// getActivationReplicaCount returns the replica count the ScaleTarget is scaled to once the triggers become active
+func getActivationReplicaCount(scaledObject *kedav1alpha1.ScaledObject, now time.Time) int32 {
+	if minReplicaCount := schedule.GetMinReplicaCount(scaledObject, now); minReplicaCount != nil && *minReplicaCount > 0 {
+		return *minReplicaCount
 	}
+	return 1
+}
+
+func (e *scaleExecutor) scaleFromZeroOrIdle(ctx context.Context, logger logr.Logger, scaledObject *kedav1alpha1.ScaledObject, scale *autoscalingv1.Scale) {
+	replicas := getActivationReplicaCount(scaledObject, time.Now())
 
 	currentReplicas, err := e.updateScaleOnScaleTarget(ctx, scaledObject, scale, replicas)
 
@@ -339,23 +396,78 @@ func (e *scaleExecutor) updateScaleOnScaleTarget(ctx context.Context, scaledObje
 	// Update with requested repliacs.
 	currentReplicas := scale.Spec.Replicas
 	scale.Spec.Replicas = replicas
-
 	_, err := e.scaleClient.Scales(scaledObject.Namespace).Update(ctx, scaledObject.Status.ScaleTargetGVKR.GroupResource(), scale, metav1.UpdateOptions{})
 	return currentReplicas, err
 }
 
+// getDesiredReplicas returns the replica count RequestScale scales the ScaleTarget to for the state of the triggers,
+// it follows the same decisions as the scaling itself
+func getDesiredReplicas(scaledObject *kedav1alpha1.ScaledObject, isActive bool, isError bool, currentReplicas int32, now time.Time) int32 {
+	minReplicas := int32(0)
+	if minReplicaCount := schedule.GetMinReplicaCount(scaledObject, now); minReplicaCount != nil {
+		minReplicas = *minReplicaCount
+	}
+	idleReplicaCount := getIdleReplicaCount(scaledObject)
+
+	if isActive {
+		if (idleReplicaCount != nil && currentReplicas < minReplicas) || currentReplicas == 0 {
+			return getActivationReplicaCount(scaledObject, now)
+		}
+		return currentReplicas
+	}
+
+	switch {
+	case isError && fallback.IsFallbackActive(scaledObject):
+		return fallback.GetFallbackReplicas(scaledObject, currentReplicas)
+	case isError:
+		return currentReplicas
+	case idleReplicaCount != nil && currentReplicas > *idleReplicaCount,
+		currentReplicas > 0 && minReplicas == 0:
+		if isCooledDown(scaledObject, now) {
+			_, replicas := getIdleOrMinimumReplicaCount(scaledObject)
+			return replicas
+		}
+		return currentReplicas
+	case currentReplicas < minReplicas && idleReplicaCount == nil:
+		return minReplicas
+	default:
+		return currentReplicas
+	}
+}
+
 // getIdleOrMinimumReplicaCount returns true if the second value returned is from IdleReplicaCount
 // it returns false if it is from MinReplicaCount followed by the actual value
 func getIdleOrMinimumReplicaCount(scaledObject *kedav1alpha1.ScaledObject) (bool, int32) {
-	if scaledObject.Spec.IdleReplicaCount != nil {
-		return true, *scaledObject.Spec.IdleReplicaCount
+	if idleReplicaCount := getIdleReplicaCount(scaledObject); idleReplicaCount != nil {
+		return true, *idleReplicaCount
 	}
 
-	if scaledObject.Spec.MinReplicaCount == nil {
+	minReplicaCount := schedule.GetMinReplicaCount(scaledObject, time.Now())
+	if minReplicaCount == nil {
 		return false, 0
 	}
 
-	return false, *scaledObject.Spec.MinReplicaCount
+	return false, *minReplicaCount
+}
+
+// getIdleReplicaCount returns IdleReplicaCount of the ScaledObject,
+// nil is returned if the active scheduled window disables scale to zero
+func getIdleReplicaCount(scaledObject *kedav1alpha1.ScaledObject) *int32 {
+	if schedule.IsScaleToZeroDisabled(scaledObject, time.Now()) {
+		return nil
+	}
+	return scaledObject.Spec.IdleReplicaCount
+}
+
+// IsPaused returns true if the autoscaling of the ScaledObject or ScaledJob is paused
+// by the autoscaling.keda.sh/paused annotation, in this case the replica count is kept untouched.
+func IsPaused(object metav1.Object) bool {
+	val, ok := object.GetAnnotations()[kedacontrollerutil.PausedAnnotation]
+	if !ok {
+		return false
+	}
+	paused, err := strconv.ParseBool(val)
+	return err == nil && paused
 }
 
 // GetPausedReplicaCount returns the paused replica count of the ScaledObject.
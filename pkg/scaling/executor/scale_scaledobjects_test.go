@@ -18,7 +18,9 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,8 @@ func TestScaleToFallbackReplicasWhenNotActiveAndIsError(t *testing.T) {
 
 	scaleExecutor := NewScaleExecutor(client, mockScaleClient, nil, recorder)
 
+	numberOfFailures := int32(4)
+
 	scaledObject := v1alpha1.ScaledObject{
 		ObjectMeta: v1.ObjectMeta{
 			Name:      "name",
@@ -61,6 +65,12 @@ func TestScaleToFallbackReplicasWhenNotActiveAndIsError(t *testing.T) {
 				Group: "apps",
 				Kind:  "Deployment",
 			},
+			Health: map[string]v1alpha1.HealthStatus{
+				"some-metric": {
+					NumberOfFailures: &numberOfFailures,
+					Status:           v1alpha1.HealthStatusFailing,
+				},
+			},
 		},
 	}
 
@@ -87,9 +97,79 @@ func TestScaleToFallbackReplicasWhenNotActiveAndIsError(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, true)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, true, nil)
+
+	assert.Equal(t, int32(5), scale.Spec.Replicas)
+	condition := scaledObject.Status.Conditions.GetFallbackCondition()
+	assert.Equal(t, true, condition.IsTrue())
+}
+
+func TestScaleFromZeroToFallbackReplicasWhenFailureThresholdIsExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+	mockScaleClient := mock_scale.NewMockScalesGetter(ctrl)
+	mockScaleInterface := mock_scale.NewMockScaleInterface(ctrl)
+	statusWriter := mock_client.NewMockStatusWriter(ctrl)
+
+	scaleExecutor := NewScaleExecutor(client, mockScaleClient, nil, recorder)
+
+	numberOfFailures := int32(3)
+	scaledObject := v1alpha1.ScaledObject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "name",
+			Namespace: "namespace",
+		},
+		Spec: v1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &v1alpha1.ScaleTarget{
+				Name: "name",
+			},
+			Fallback: &v1alpha1.Fallback{
+				FailureThreshold: 3,
+				Replicas:         5,
+			},
+		},
+		Status: v1alpha1.ScaledObjectStatus{
+			ScaleTargetGVKR: &v1alpha1.GroupVersionKindResource{
+				Group: "apps",
+				Kind:  "Deployment",
+			},
+			Health: map[string]v1alpha1.HealthStatus{
+				"some-metric": {
+					NumberOfFailures: &numberOfFailures,
+					Status:           v1alpha1.HealthStatusFailing,
+				},
+			},
+		},
+	}
+
+	scaledObject.Status.Conditions = *v1alpha1.GetInitializedConditions()
+
+	numberOfReplicas := int32(0)
+
+	client.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(2, appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &numberOfReplicas,
+		},
+	})
+
+	scale := &autoscalingv1.Scale{
+		Spec: autoscalingv1.ScaleSpec{
+			Replicas: numberOfReplicas,
+		},
+	}
+
+	mockScaleClient.EXPECT().Scales(gomock.Any()).Return(mockScaleInterface).Times(2)
+	mockScaleInterface.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(scale, nil)
+	mockScaleInterface.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Eq(scale), gomock.Any())
+
+	client.EXPECT().Status().Times(3).Return(statusWriter)
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, true, map[string]error{"some-metric": errors.New("some error")})
 
 	assert.Equal(t, int32(5), scale.Spec.Replicas)
+	assert.Equal(t, int32(4), *scaledObject.Status.Health["some-metric"].NumberOfFailures)
 	condition := scaledObject.Status.Conditions.GetFallbackCondition()
 	assert.Equal(t, true, condition.IsTrue())
 }
@@ -148,7 +228,7 @@ func TestScaleToMinReplicasWhenNotActive(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -209,7 +289,7 @@ func TestScaleToMinReplicasFromLowerInitialReplicaCount(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -268,7 +348,7 @@ func TestScaleFromMinReplicasWhenActive(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter).Times(3)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
 
 	assert.Equal(t, int32(1), scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -331,7 +411,7 @@ func TestScaleToIdleReplicasWhenNotActive(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, false, false, nil)
 
 	assert.Equal(t, idleReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -392,7 +472,7 @@ func TestScaleFromIdleToMinReplicasWhenActive(t *testing.T) {
 	client.EXPECT().Status().Times(2).Return(statusWriter).Times(3)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
 
 	assert.Equal(t, minReplicas, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
@@ -454,9 +534,149 @@ func TestScaleToPausedReplicasCount(t *testing.T) {
 	client.EXPECT().Status().Return(statusWriter).Times(2)
 	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false)
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
 
 	assert.Equal(t, pausedReplicaCount, scale.Spec.Replicas)
 	condition := scaledObject.Status.Conditions.GetActiveCondition()
 	assert.Equal(t, false, condition.IsTrue())
 }
+
+func TestPausedScaledObjectIsNotScaled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+	mockScaleClient := mock_scale.NewMockScalesGetter(ctrl)
+
+	scaleExecutor := NewScaleExecutor(client, mockScaleClient, nil, recorder)
+
+	scaledObject := v1alpha1.ScaledObject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        "name",
+			Namespace:   "namespace",
+			Annotations: map[string]string{"autoscaling.keda.sh/paused": "true"},
+		},
+		Spec: v1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &v1alpha1.ScaleTarget{
+				Name: "name",
+			},
+		},
+		Status: v1alpha1.ScaledObjectStatus{
+			ScaleTargetGVKR: &v1alpha1.GroupVersionKindResource{
+				Group: "apps",
+				Kind:  "Deployment",
+			},
+		},
+	}
+
+	// no calls on the client are expected, the current replica count is left untouched
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
+}
+
+func TestIsPaused(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    bool
+	}{
+		{annotations: nil, expected: false},
+		{annotations: map[string]string{"autoscaling.keda.sh/paused-replicas": "1"}, expected: false},
+		{annotations: map[string]string{"autoscaling.keda.sh/paused": "true"}, expected: true},
+		{annotations: map[string]string{"autoscaling.keda.sh/paused": "false"}, expected: false},
+		{annotations: map[string]string{"autoscaling.keda.sh/paused": "invalid"}, expected: false},
+	}
+
+	for _, test := range tests {
+		scaledObject := &v1alpha1.ScaledObject{ObjectMeta: v1.ObjectMeta{Annotations: test.annotations}}
+		assert.Equal(t, test.expected, IsPaused(scaledObject), test.annotations)
+	}
+}
+
+func TestScaleFromZeroInDryRunModeRecordsDecisionWithoutUpdatingScaleTarget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := mock_client.NewMockClient(ctrl)
+	recorder := record.NewFakeRecorder(1)
+	mockScaleClient := mock_scale.NewMockScalesGetter(ctrl)
+	statusWriter := mock_client.NewMockStatusWriter(ctrl)
+
+	scaleExecutor := NewScaleExecutor(client, mockScaleClient, nil, recorder)
+
+	scaledObject := v1alpha1.ScaledObject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "name",
+			Namespace: "namespace",
+		},
+		Spec: v1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &v1alpha1.ScaleTarget{
+				Name: "name",
+			},
+			DryRun: true,
+		},
+		Status: v1alpha1.ScaledObjectStatus{
+			ScaleTargetKind: "apps/v1.Deployment",
+			ScaleTargetGVKR: &v1alpha1.GroupVersionKindResource{
+				Group: "apps",
+				Kind:  "Deployment",
+			},
+		},
+	}
+
+	scaledObject.Status.Conditions = *v1alpha1.GetInitializedConditions()
+
+	numberOfReplicas := int32(0)
+
+	// the Deployment is read, but the scale subresource is never updated
+	client.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).SetArg(2, appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &numberOfReplicas,
+		},
+	})
+	mockScaleClient.EXPECT().Scales(gomock.Any()).Times(0)
+
+	client.EXPECT().Status().Times(4).Return(statusWriter)
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Times(4)
+
+	scaleExecutor.RequestScale(context.TODO(), &scaledObject, true, false, nil)
+
+	assert.NotNil(t, scaledObject.Status.DryRun)
+	assert.True(t, scaledObject.Status.DryRun.WouldActivate)
+	assert.Equal(t, int32(1), *scaledObject.Status.DryRun.DesiredReplicas)
+	assert.NotNil(t, scaledObject.Status.DryRun.LastDecisionTime)
+	assert.Equal(t, "Normal KEDAScaleTargetDryRun Would scale apps/v1.Deployment namespace/name from 0 to 1", <-recorder.Events)
+}
+
+func TestGetDesiredReplicas(t *testing.T) {
+	minReplicas := int32(2)
+	idleReplicas := int32(0)
+	cooldownPeriod := int32(300)
+	recentlyActive := v1.NewTime(time.Now().Add(-time.Minute))
+	longAgoActive := v1.NewTime(time.Now().Add(-time.Hour))
+
+	tests := []struct {
+		name            string
+		spec            v1alpha1.ScaledObjectSpec
+		lastActiveTime  *v1.Time
+		isActive        bool
+		isError         bool
+		currentReplicas int32
+		desiredReplicas int32
+	}{
+		{name: "activation from zero", isActive: true, currentReplicas: 0, desiredReplicas: 1},
+		{name: "activation to minReplicaCount", spec: v1alpha1.ScaledObjectSpec{MinReplicaCount: &minReplicas}, isActive: true, currentReplicas: 0, desiredReplicas: 2},
+		{name: "activation from idle", spec: v1alpha1.ScaledObjectSpec{MinReplicaCount: &minReplicas, IdleReplicaCount: &idleReplicas}, isActive: true, currentReplicas: 0, desiredReplicas: 2},
+		{name: "active is left to HPA", isActive: true, currentReplicas: 5, desiredReplicas: 5},
+		{name: "inactive within cooldown", spec: v1alpha1.ScaledObjectSpec{CooldownPeriod: &cooldownPeriod}, lastActiveTime: &recentlyActive, currentReplicas: 3, desiredReplicas: 3},
+		{name: "inactive after cooldown", spec: v1alpha1.ScaledObjectSpec{CooldownPeriod: &cooldownPeriod}, lastActiveTime: &longAgoActive, currentReplicas: 3, desiredReplicas: 0},
+		{name: "inactive to idle", spec: v1alpha1.ScaledObjectSpec{MinReplicaCount: &minReplicas, IdleReplicaCount: &idleReplicas}, currentReplicas: 3, desiredReplicas: 0},
+		{name: "inactive below minReplicaCount", spec: v1alpha1.ScaledObjectSpec{MinReplicaCount: &minReplicas}, currentReplicas: 1, desiredReplicas: 2},
+		{name: "inactive with error", isError: true, currentReplicas: 3, desiredReplicas: 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scaledObject := &v1alpha1.ScaledObject{
+				Spec:   test.spec,
+				Status: v1alpha1.ScaledObjectStatus{LastActiveTime: test.lastActiveTime},
+			}
+			assert.Equal(t, test.desiredReplicas, getDesiredReplicas(scaledObject, test.isActive, test.isError, test.currentReplicas, time.Now()))
+		})
+	}
+}
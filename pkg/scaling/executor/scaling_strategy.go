@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+const defaultScalingStrategyName = "default"
+
+// ScalingState is the state of ScaledJob the scaling strategies decide on
+type ScalingState struct {
+	// MaxScale is the number of jobs required by the metrics of triggers, limited by MaxReplicaCount
+	MaxScale int64
+	// RequiredScale is the number of jobs required by the metrics of triggers, without the limit of MaxReplicaCount
+	RequiredScale int64
+	// RunningJobCount is the number of unfinished jobs, including the pending ones
+	RunningJobCount int64
+	// PendingJobCount is the number of unfinished jobs whose pods aren't running yet
+	PendingJobCount int64
+	MaxReplicaCount int64
+	// JobParallelism and JobCompletions are taken from the job template, they are 0 if they aren't specified
+	JobParallelism int64
+	JobCompletions int64
+	// CreatedJobCount is the number of jobs created within the interval of MaxJobsCreatedPerInterval,
+	// it is counted only if the limit is specified
+	CreatedJobCount int64
+}
+
+// ScalingStrategy is an interface for switching scaling algorithm
+type ScalingStrategy interface {
+	// GetEffectiveMaxScale returns the maximum number of jobs to be created in this polling interval
+	GetEffectiveMaxScale(state ScalingState) int64
+}
+
+// scalingStrategyFactory creates the ScalingStrategy from the spec of ScaledJob, it returns an error if the spec isn't valid for the strategy
+type scalingStrategyFactory func(spec kedav1alpha1.ScalingStrategy) (ScalingStrategy, error)
+
+// scalingStrategies are the strategies selectable by ScalingStrategy.Strategy
+var scalingStrategies = map[string]scalingStrategyFactory{
+	defaultScalingStrategyName: func(kedav1alpha1.ScalingStrategy) (ScalingStrategy, error) { return defaultScalingStrategy{}, nil },
+	"custom":                   newCustomScalingStrategy,
+	"accurate":                 func(kedav1alpha1.ScalingStrategy) (ScalingStrategy, error) { return accurateScalingStrategy{}, nil },
+	"parallelism":              func(kedav1alpha1.ScalingStrategy) (ScalingStrategy, error) { return parallelismScalingStrategy{}, nil },
+	"inflight":                 newInFlightScalingStrategy,
+}
+
+// validatedScalingStrategies are rejected if their spec isn't valid, the other strategies fall back to the default one
+var validatedScalingStrategies = map[string]bool{
+	"parallelism": true,
+	"inflight":    true,
+}
+
+// NewScalingStrategy returns ScalingStrategy instance, the default strategy is used if the specified one isn't valid.
+// The strategy is limited by the number of jobs created within an interval if MaxJobsCreatedPerInterval is specified
+func NewScalingStrategy(logger logr.Logger, scaledJob *kedav1alpha1.ScaledJob) ScalingStrategy {
+	spec := scaledJob.Spec.ScalingStrategy
+	strategy, err := getScalingStrategy(spec)
+	if err != nil {
+		logger.V(1).Info("Invalid Scale Strategy, selecting default", "specified", spec.Strategy, "error", err.Error())
+		strategy = defaultScalingStrategy{}
+	} else {
+		logger.V(1).Info("Selecting Scale Strategy", "specified", spec.Strategy, "selected", fmt.Sprintf("%T", strategy))
+	}
+
+	if spec.MaxJobsCreatedPerInterval != nil {
+		return rateLimitedScalingStrategy{
+			ScalingStrategy: strategy,
+			maxJobs:         int64(*spec.MaxJobsCreatedPerInterval),
+		}
+	}
+	return strategy
+}
+
+// ValidateScalingStrategy returns an error if the parallelism, inflight or rate limiting options of ScalingStrategy
+// aren't valid. An unknown strategy or custom strategy without valid customScalingRunningJobPercentage falls back
+// to the default strategy as it always did, the returned warning reports the fallback
+func ValidateScalingStrategy(scaledJob *kedav1alpha1.ScaledJob) (string, error) {
+	spec := scaledJob.Spec.ScalingStrategy
+	if spec.MaxJobsCreatedPerInterval != nil && *spec.MaxJobsCreatedPerInterval < 1 {
+		return "", fmt.Errorf("scalingStrategy.maxJobsCreatedPerInterval=%d must be greater than 0", *spec.MaxJobsCreatedPerInterval)
+	}
+	if spec.JobCreationIntervalSeconds != nil && *spec.JobCreationIntervalSeconds < 1 {
+		return "", fmt.Errorf("scalingStrategy.jobCreationIntervalSeconds=%d must be greater than 0", *spec.JobCreationIntervalSeconds)
+	}
+	if _, err := getScalingStrategy(spec); err != nil {
+		if validatedScalingStrategies[spec.Strategy] {
+			return "", err
+		}
+		return fmt.Sprintf("%s, the default scalingStrategy is used instead", err), nil
+	}
+	return "", nil
+}
+
+// getScalingStrategy creates the strategy selected by ScalingStrategy.Strategy
+func getScalingStrategy(spec kedav1alpha1.ScalingStrategy) (ScalingStrategy, error) {
+	name := spec.Strategy
+	if name == "" {
+		name = defaultScalingStrategyName
+	}
+	factory, found := scalingStrategies[name]
+	if !found {
+		return nil, fmt.Errorf("unknown scalingStrategy.strategy %s", spec.Strategy)
+	}
+	return factory(spec)
+}
+
+// getJobCreationInterval returns the interval MaxJobsCreatedPerInterval applies to
+func getJobCreationInterval(scaledJob *kedav1alpha1.ScaledJob) time.Duration {
+	if interval := scaledJob.Spec.ScalingStrategy.JobCreationIntervalSeconds; interval != nil {
+		return time.Second * time.Duration(*interval)
+	}
+	withTriggers := kedav1alpha1.WithTriggers{Spec: kedav1alpha1.WithTriggersSpec{PollingInterval: scaledJob.Spec.PollingInterval}}
+	return withTriggers.GetPollingInterval()
+}
+
+type defaultScalingStrategy struct {
+}
+
+func (s defaultScalingStrategy) GetEffectiveMaxScale(state ScalingState) int64 {
+	return state.MaxScale - state.RunningJobCount
+}
+
+type customScalingStrategy struct {
+	CustomScalingQueueLengthDeduction *int32
+	CustomScalingRunningJobPercentage *float64
+}
+
+func newCustomScalingStrategy(spec kedav1alpha1.ScalingStrategy) (ScalingStrategy, error) {
+	percentage, err := strconv.ParseFloat(spec.CustomScalingRunningJobPercentage, 64)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing scalingStrategy.customScalingRunningJobPercentage: %s", err)
+	}
+	deduction := int32(0)
+	if spec.CustomScalingQueueLengthDeduction != nil {
+		deduction = *spec.CustomScalingQueueLengthDeduction
+	}
+	return customScalingStrategy{
+		CustomScalingQueueLengthDeduction: &deduction,
+		CustomScalingRunningJobPercentage: &percentage,
+	}, nil
+}
+
+func (s customScalingStrategy) GetEffectiveMaxScale(state ScalingState) int64 {
+	return min(state.MaxScale-int64(*s.CustomScalingQueueLengthDeduction)-int64(float64(state.RunningJobCount)*(*s.CustomScalingRunningJobPercentage)), state.MaxReplicaCount)
+}
+
+type accurateScalingStrategy struct {
+}
+
+func (s accurateScalingStrategy) GetEffectiveMaxScale(state ScalingState) int64 {
+	if (state.MaxScale + state.RunningJobCount) > state.MaxReplicaCount {
+		return state.MaxReplicaCount - state.RunningJobCount
+	}
+	return state.MaxScale - state.PendingJobCount
+}
+
+// parallelismScalingStrategy creates jobs for the work items that don't fit into the running jobs, a job processes
+// Completions work items, or Parallelism work items at once if it is a work queue job without fixed completions
+type parallelismScalingStrategy struct {
+}
+
+func (s parallelismScalingStrategy) GetEffectiveMaxScale(state ScalingState) int64 {
+	itemsPerJob := state.JobCompletions
+	if itemsPerJob < 1 {
+		itemsPerJob = state.JobParallelism
+	}
+	if itemsPerJob < 1 {
+		itemsPerJob = 1
+	}
+	// MaxScale limited by MaxReplicaCount would under-provision the jobs processing more than one work item each
+	requiredJobs := (state.RequiredScale + itemsPerJob - 1) / itemsPerJob
+	return min(requiredJobs, state.MaxReplicaCount) - state.RunningJobCount
+}
+
+// inFlightScalingStrategy keeps the number of unfinished jobs at InFlightJobs while there is pending work,
+// each job is expected to process work items until there are none left, instead of a job being created per item
+type inFlightScalingStrategy struct {
+	inFlightJobs int64
+}
+
+func newInFlightScalingStrategy(spec kedav1alpha1.ScalingStrategy) (ScalingStrategy, error) {
+	if spec.InFlightJobs == nil || *spec.InFlightJobs < 1 {
+		return nil, fmt.Errorf("scalingStrategy.inFlightJobs must be greater than 0 for inflight strategy")
+	}
+	return inFlightScalingStrategy{inFlightJobs: int64(*spec.InFlightJobs)}, nil
+}
+
+func (s inFlightScalingStrategy) GetEffectiveMaxScale(state ScalingState) int64 {
+	return min(min(s.inFlightJobs, state.MaxScale), state.MaxReplicaCount) - state.RunningJobCount
+}
+
+// rateLimitedScalingStrategy limits any strategy by the number of jobs created within the interval of MaxJobsCreatedPerInterval
+type rateLimitedScalingStrategy struct {
+	ScalingStrategy
+	maxJobs int64
+}
+
+func (s rateLimitedScalingStrategy) GetEffectiveMaxScale(state ScalingState) int64 {
+	return min(s.ScalingStrategy.GetEffectiveMaxScale(state), s.maxJobs-state.CreatedJobCount)
+}
+
+func min(x, y int64) int64 {
+	if x > y {
+		return y
+	}
+	return x
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/mock/mock_client"
+)
+
+type scalingStrategyTestData struct {
+	name              string
+	scalingStrategy   kedav1alpha1.ScalingStrategy
+	state             ScalingState
+	effectiveMaxScale int64
+}
+
+var scalingStrategyTestDataset = []scalingStrategyTestData{
+	{
+		name:              "default",
+		state:             ScalingState{MaxScale: 5, RunningJobCount: 2, MaxReplicaCount: 10},
+		effectiveMaxScale: 3,
+	},
+	{
+		name:              "parallelism without parallelism and completions",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "parallelism"},
+		state:             ScalingState{MaxScale: 5, RequiredScale: 5, RunningJobCount: 2, MaxReplicaCount: 10},
+		effectiveMaxScale: 3,
+	},
+	{
+		name:              "parallelism of work queue jobs",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "parallelism"},
+		state:             ScalingState{MaxScale: 10, RequiredScale: 10, RunningJobCount: 1, MaxReplicaCount: 10, JobParallelism: 3},
+		effectiveMaxScale: 3,
+	},
+	{
+		name:              "parallelism with completions",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "parallelism"},
+		state:             ScalingState{MaxScale: 10, RequiredScale: 10, RunningJobCount: 1, MaxReplicaCount: 10, JobParallelism: 2, JobCompletions: 5},
+		effectiveMaxScale: 1,
+	},
+	{
+		name:              "parallelism limited by maxReplicaCount",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "parallelism"},
+		state:             ScalingState{MaxScale: 5, RequiredScale: 100, RunningJobCount: 1, MaxReplicaCount: 5, JobParallelism: 2},
+		effectiveMaxScale: 4,
+	},
+	{
+		name:              "parallelism with queue above maxReplicaCount times completions",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "parallelism"},
+		state:             ScalingState{MaxScale: 10, RequiredScale: 1000, MaxReplicaCount: 10, JobCompletions: 2},
+		effectiveMaxScale: 10,
+	},
+	{
+		name:              "parallelism with queue between maxReplicaCount and maxReplicaCount times completions",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "parallelism"},
+		state:             ScalingState{MaxScale: 10, RequiredScale: 15, MaxReplicaCount: 10, JobCompletions: 2},
+		effectiveMaxScale: 8,
+	},
+	{
+		name:              "inflight below the desired count",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "inflight", InFlightJobs: pointer.Int32(4)},
+		state:             ScalingState{MaxScale: 50, RunningJobCount: 1, MaxReplicaCount: 10},
+		effectiveMaxScale: 3,
+	},
+	{
+		name:              "inflight at the desired count",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "inflight", InFlightJobs: pointer.Int32(4)},
+		state:             ScalingState{MaxScale: 50, RunningJobCount: 4, MaxReplicaCount: 10},
+		effectiveMaxScale: 0,
+	},
+	{
+		name:              "inflight with less pending work than the desired count",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "inflight", InFlightJobs: pointer.Int32(4)},
+		state:             ScalingState{MaxScale: 2, RunningJobCount: 0, MaxReplicaCount: 10},
+		effectiveMaxScale: 2,
+	},
+	{
+		name:              "inflight limited by maxReplicaCount",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "inflight", InFlightJobs: pointer.Int32(20)},
+		state:             ScalingState{MaxScale: 50, RunningJobCount: 1, MaxReplicaCount: 10},
+		effectiveMaxScale: 9,
+	},
+	{
+		name:              "rate limit below the strategy",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{MaxJobsCreatedPerInterval: pointer.Int32(5)},
+		state:             ScalingState{MaxScale: 10, RunningJobCount: 2, MaxReplicaCount: 10, CreatedJobCount: 3},
+		effectiveMaxScale: 2,
+	},
+	{
+		name:              "rate limit above the strategy",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "accurate", MaxJobsCreatedPerInterval: pointer.Int32(5)},
+		state:             ScalingState{MaxScale: 3, RunningJobCount: 2, PendingJobCount: 1, MaxReplicaCount: 10},
+		effectiveMaxScale: 2,
+	},
+	{
+		name:              "rate limit exhausted",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "parallelism", MaxJobsCreatedPerInterval: pointer.Int32(5)},
+		state:             ScalingState{MaxScale: 10, RequiredScale: 10, MaxReplicaCount: 10, CreatedJobCount: 5},
+		effectiveMaxScale: 0,
+	},
+	{
+		name:              "invalid strategy falls back to default",
+		scalingStrategy:   kedav1alpha1.ScalingStrategy{Strategy: "inflight"},
+		state:             ScalingState{MaxScale: 5, RunningJobCount: 2, MaxReplicaCount: 10},
+		effectiveMaxScale: 3,
+	},
+}
+
+func TestScalingStrategies(t *testing.T) {
+	logger := logf.Log.WithName("ScaledJobTest")
+	for _, testData := range scalingStrategyTestDataset {
+		t.Run(testData.name, func(t *testing.T) {
+			scaledJob := &kedav1alpha1.ScaledJob{Spec: kedav1alpha1.ScaledJobSpec{ScalingStrategy: testData.scalingStrategy}}
+			strategy := NewScalingStrategy(logger, scaledJob)
+			assert.Equal(t, testData.effectiveMaxScale, strategy.GetEffectiveMaxScale(testData.state))
+		})
+	}
+}
+
+type validateScalingStrategyTestData struct {
+	name            string
+	scalingStrategy kedav1alpha1.ScalingStrategy
+	warning         string
+	err             string
+}
+
+var validateScalingStrategyTestDataset = []validateScalingStrategyTestData{
+	{name: "default", scalingStrategy: kedav1alpha1.ScalingStrategy{}},
+	{name: "custom", scalingStrategy: kedav1alpha1.ScalingStrategy{Strategy: "custom", CustomScalingRunningJobPercentage: "0.5"}},
+	{name: "parallelism", scalingStrategy: kedav1alpha1.ScalingStrategy{Strategy: "parallelism"}},
+	{name: "inflight", scalingStrategy: kedav1alpha1.ScalingStrategy{Strategy: "inflight", InFlightJobs: pointer.Int32(2)}},
+	{name: "rate limit", scalingStrategy: kedav1alpha1.ScalingStrategy{MaxJobsCreatedPerInterval: pointer.Int32(2), JobCreationIntervalSeconds: pointer.Int32(60)}},
+	{
+		name:            "unknown strategy",
+		scalingStrategy: kedav1alpha1.ScalingStrategy{Strategy: "eager"},
+		warning:         "unknown scalingStrategy.strategy eager, the default scalingStrategy is used instead",
+	},
+	{
+		name:            "custom without running job percentage",
+		scalingStrategy: kedav1alpha1.ScalingStrategy{Strategy: "custom"},
+		warning:         "error parsing scalingStrategy.customScalingRunningJobPercentage: strconv.ParseFloat: parsing \"\": invalid syntax, the default scalingStrategy is used instead",
+	},
+	{
+		name:            "unknown strategy with zero maxJobsCreatedPerInterval",
+		scalingStrategy: kedav1alpha1.ScalingStrategy{Strategy: "eager", MaxJobsCreatedPerInterval: pointer.Int32(0)},
+		err:             "scalingStrategy.maxJobsCreatedPerInterval=0 must be greater than 0",
+	},
+	{
+		name:            "inflight without inFlightJobs",
+		scalingStrategy: kedav1alpha1.ScalingStrategy{Strategy: "inflight"},
+		err:             "scalingStrategy.inFlightJobs must be greater than 0 for inflight strategy",
+	},
+	{
+		name:            "zero maxJobsCreatedPerInterval",
+		scalingStrategy: kedav1alpha1.ScalingStrategy{MaxJobsCreatedPerInterval: pointer.Int32(0)},
+		err:             "scalingStrategy.maxJobsCreatedPerInterval=0 must be greater than 0",
+	},
+	{
+		name:            "negative jobCreationIntervalSeconds",
+		scalingStrategy: kedav1alpha1.ScalingStrategy{MaxJobsCreatedPerInterval: pointer.Int32(2), JobCreationIntervalSeconds: pointer.Int32(-1)},
+		err:             "scalingStrategy.jobCreationIntervalSeconds=-1 must be greater than 0",
+	},
+}
+
+func TestValidateScalingStrategy(t *testing.T) {
+	for _, testData := range validateScalingStrategyTestDataset {
+		t.Run(testData.name, func(t *testing.T) {
+			warning, err := ValidateScalingStrategy(&kedav1alpha1.ScaledJob{Spec: kedav1alpha1.ScaledJobSpec{ScalingStrategy: testData.scalingStrategy}})
+			assert.Equal(t, testData.warning, warning)
+			if testData.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, testData.err)
+			}
+		})
+	}
+}
+
+func TestGetJobCreationInterval(t *testing.T) {
+	scaledJob := &kedav1alpha1.ScaledJob{}
+	assert.Equal(t, 30*time.Second, getJobCreationInterval(scaledJob))
+
+	scaledJob.Spec.PollingInterval = pointer.Int32(10)
+	assert.Equal(t, 10*time.Second, getJobCreationInterval(scaledJob))
+
+	scaledJob.Spec.ScalingStrategy.JobCreationIntervalSeconds = pointer.Int32(300)
+	assert.Equal(t, 300*time.Second, getJobCreationInterval(scaledJob))
+}
+
+func TestRateLimitedScaledJobCreatesJobsUpToLimit(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scaledJob := getMockScaledJob(2, 2)
+	scaledJob.Spec.JobTargetRef = &batchv1.JobSpec{}
+	scaledJob.Spec.ScalingStrategy.MaxJobsCreatedPerInterval = pointer.Int32(3)
+	scaledJob.Status.Conditions = *kedav1alpha1.GetInitializedConditions()
+
+	// two jobs were created within the polling interval, the older one doesn't count towards the limit
+	jobs := []batchv1.Job{
+		newFinishedJob("recent-0", time.Now().Add(-10*time.Second)),
+		newFinishedJob("recent-1", time.Now().Add(-20*time.Second)),
+		newFinishedJob("old", time.Now().Add(-time.Hour)),
+	}
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, kedav1alpha1.AddToScheme(scheme))
+	assert.NoError(t, batchv1.AddToScheme(scheme))
+
+	client := mock_client.NewMockClient(ctrl)
+	statusWriter := mock_client.NewMockStatusWriter(ctrl)
+	client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, list runtimeclient.ObjectList, _ ...runtimeclient.ListOption) error {
+			if jobList, ok := list.(*batchv1.JobList); ok {
+				jobList.Items = jobs
+			}
+			return nil
+		}).AnyTimes()
+	client.EXPECT().Status().Return(statusWriter).AnyTimes()
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	client.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+	// the old job exceeds the history limit of getMockScaledJob
+	client.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	scaleExecutor := &scaleExecutor{
+		client:           client,
+		reconcilerScheme: scheme,
+		logger:           logf.Log.WithName("scaleexecutor"),
+		recorder:         record.NewFakeRecorder(10),
+	}
+	scaleExecutor.RequestJobScale(ctx, scaledJob, true, 5, 5, 5, nil)
+}
+
+func TestRateLimitCountsJobsRemovedByHistoryLimit(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scaledJob := getMockScaledJob(0, 0)
+	scaledJob.Spec.JobTargetRef = &batchv1.JobSpec{}
+	scaledJob.Spec.ScalingStrategy.MaxJobsCreatedPerInterval = pointer.Int32(3)
+	scaledJob.Status.Conditions = *kedav1alpha1.GetInitializedConditions()
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, kedav1alpha1.AddToScheme(scheme))
+	assert.NoError(t, batchv1.AddToScheme(scheme))
+
+	// the created jobs finish and are removed by the history limit right away, so none of them is listed
+	client := mock_client.NewMockClient(ctrl)
+	statusWriter := mock_client.NewMockStatusWriter(ctrl)
+	client.EXPECT().List(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	client.EXPECT().Status().Return(statusWriter).AnyTimes()
+	statusWriter.EXPECT().Patch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	createdJobs := 0
+	client.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, obj runtimeclient.Object, _ ...runtimeclient.CreateOption) error {
+			createdJobs++
+			obj.SetName(fmt.Sprintf("job-%d", createdJobs))
+			return nil
+		}).Times(3)
+
+	scaleExecutor := &scaleExecutor{
+		client:           client,
+		reconcilerScheme: scheme,
+		logger:           logf.Log.WithName("scaleexecutor"),
+		recorder:         record.NewFakeRecorder(10),
+	}
+	scaleExecutor.RequestJobScale(ctx, scaledJob, true, 5, 5, 5, nil)
+	scaleExecutor.RequestJobScale(ctx, scaledJob, true, 5, 5, 5, nil)
+	assert.Equal(t, 3, createdJobs)
+}
+
+func TestGetCreatedJobCount(t *testing.T) {
+	scaledJob := getMockScaledJob(0, 0)
+	scaleExecutor := &scaleExecutor{}
+	scaleExecutor.recordJobCreation(scaledJob, "recorded-and-listed")
+	scaleExecutor.recordJobCreation(scaledJob, "recorded")
+
+	// the jobs both recorded and listed are counted once
+	jobs := []batchv1.Job{
+		newFinishedJob("recorded-and-listed", time.Now()),
+		newFinishedJob("listed", time.Now().Add(-10*time.Second)),
+		newFinishedJob("old", time.Now().Add(-time.Hour)),
+	}
+	assert.Equal(t, int64(3), scaleExecutor.getCreatedJobCount(scaledJob, jobs, time.Minute))
+
+	// the recorded jobs are forgotten once they are out of the interval
+	assert.Equal(t, int64(0), scaleExecutor.getCreatedJobCount(scaledJob, nil, 0))
+	assert.Empty(t, scaleExecutor.jobCreations)
+}
+
+func newFinishedJob(name string, creationTime time.Time) batchv1.Job {
+	return batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(creationTime),
+		},
+		Status: batchv1.JobStatus{
+			CompletionTime: &metav1.Time{Time: creationTime.Add(time.Second)},
+			Conditions:     []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}},
+		},
+	}
+}
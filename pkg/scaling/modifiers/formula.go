@@ -0,0 +1,450 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modifiers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Formula is a compiled scaling modifiers expression, it combines metric values
+// of named triggers into a single value, eg. `(queue + 2*lag) / 3`.
+//
+// Supported syntax:
+//   - numbers and trigger names (identifiers may contain letters, digits and '_')
+//   - arithmetic operators: + - * / %
+//   - comparison operators: == != < <= > >=
+//   - logical operators: && || !
+//   - conditional operator: cond ? a : b
+//   - functions: min, max, abs, ceil, floor, round
+//
+// Boolean results are represented as 1 (true) and 0 (false).
+type Formula struct {
+	source    string
+	root      node
+	variables []string
+}
+
+type node func(vars map[string]float64) (float64, error)
+
+// Compile parses the formula and returns it in a form that can be evaluated repeatedly
+func Compile(formula string) (*Formula, error) {
+	p := &parser{input: formula, variables: map[string]bool{}}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("formula is empty")
+	}
+
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected token %q at position %d", p.peek().value, p.peek().pos)
+	}
+
+	variables := make([]string, 0, len(p.variables))
+	for v := range p.variables {
+		variables = append(variables, v)
+	}
+	sort.Strings(variables)
+
+	return &Formula{source: formula, root: root, variables: variables}, nil
+}
+
+// String returns the source of the formula
+func (f *Formula) String() string {
+	return f.source
+}
+
+// Variables returns sorted list of trigger names referenced by the formula
+func (f *Formula) Variables() []string {
+	return f.variables
+}
+
+// Eval evaluates the formula with values of the referenced triggers
+func (f *Formula) Eval(vars map[string]float64) (float64, error) {
+	result, err := f.root(vars)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, fmt.Errorf("formula %q evaluated to invalid number %v", f.source, result)
+	}
+	return result, nil
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type parser struct {
+	input     string
+	tokens    []token
+	current   int
+	variables map[string]bool
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "%", "!", "(", ")", ",", "?", ":"}
+
+func (p *parser) tokenize() error {
+	runes := []rune(p.input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenNumber, value: string(runes[start:i]), pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenIdent, value: string(runes[start:i]), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					p.tokens = append(p.tokens, token{kind: tokenOperator, value: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *parser) done() bool {
+	return p.current >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{kind: tokenOperator, value: "", pos: len(p.input)}
+	}
+	return p.tokens[p.current]
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.value == op {
+			p.current++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return fmt.Errorf("expected %q at position %d", op, p.peek().pos)
+	}
+	return nil
+}
+
+func (p *parser) parseExpression() (node, error) {
+	cond, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return cond, nil
+	}
+	whenTrue, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	whenFalse, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	return func(vars map[string]float64) (float64, error) {
+		c, err := cond(vars)
+		if err != nil {
+			return 0, err
+		}
+		if c != 0 {
+			return whenTrue(vars)
+		}
+		return whenFalse(vars)
+	}, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *parser) parseComparison() (node, error) {
+	return p.parseBinary(p.parseAdditive, "==", "!=", "<=", ">=", "<", ">")
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *parser) parseBinary(next func() (node, error), ops ...string) (node, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binary(op, left, right)
+	}
+}
+
+func binary(op string, left, right node) node {
+	return func(vars map[string]float64) (float64, error) {
+		l, err := left(vars)
+		if err != nil {
+			return 0, err
+		}
+		// short-circuit logical operators
+		switch op {
+		case "&&":
+			if l == 0 {
+				return 0, nil
+			}
+		case "||":
+			if l != 0 {
+				return 1, nil
+			}
+		}
+		r, err := right(vars)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case "+":
+			return l + r, nil
+		case "-":
+			return l - r, nil
+		case "*":
+			return l * r, nil
+		case "/":
+			if r == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return l / r, nil
+		case "%":
+			if r == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return math.Mod(l, r), nil
+		case "==":
+			return boolToFloat(l == r), nil
+		case "!=":
+			return boolToFloat(l != r), nil
+		case "<":
+			return boolToFloat(l < r), nil
+		case "<=":
+			return boolToFloat(l <= r), nil
+		case ">":
+			return boolToFloat(l > r), nil
+		case ">=":
+			return boolToFloat(l >= r), nil
+		default: // && and || once the left side didn't short-circuit
+			return boolToFloat(r != 0), nil
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	op, ok := p.accept("-", "!")
+	if !ok {
+		return p.parsePrimary()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return func(vars map[string]float64) (float64, error) {
+		v, err := operand(vars)
+		if err != nil {
+			return 0, err
+		}
+		if op == "-" {
+			return -v, nil
+		}
+		return boolToFloat(v == 0), nil
+	}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if _, ok := p.accept("("); ok {
+		n, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	}
+
+	t := p.peek()
+	switch {
+	case p.done():
+		return nil, fmt.Errorf("unexpected end of formula")
+	case t.kind == tokenNumber:
+		p.current++
+		value, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.value, t.pos)
+		}
+		return func(map[string]float64) (float64, error) { return value, nil }, nil
+	case t.kind == tokenIdent:
+		p.current++
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t)
+		}
+		name := t.value
+		p.variables[name] = true
+		return func(vars map[string]float64) (float64, error) {
+			value, found := vars[name]
+			if !found {
+				return 0, fmt.Errorf("no value for trigger %q", name)
+			}
+			return value, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unexpected token %q at position %d", t.value, t.pos)
+	}
+}
+
+func (p *parser) parseCall(fn token) (node, error) {
+	var args []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	var impl func(values []float64) float64
+	arity := 1
+	switch fn.value {
+	case "min":
+		arity = -1
+		impl = func(values []float64) float64 {
+			result := values[0]
+			for _, v := range values[1:] {
+				result = math.Min(result, v)
+			}
+			return result
+		}
+	case "max":
+		arity = -1
+		impl = func(values []float64) float64 {
+			result := values[0]
+			for _, v := range values[1:] {
+				result = math.Max(result, v)
+			}
+			return result
+		}
+	case "abs":
+		impl = func(values []float64) float64 { return math.Abs(values[0]) }
+	case "ceil":
+		impl = func(values []float64) float64 { return math.Ceil(values[0]) }
+	case "floor":
+		impl = func(values []float64) float64 { return math.Floor(values[0]) }
+	case "round":
+		impl = func(values []float64) float64 { return math.Round(values[0]) }
+	default:
+		return nil, fmt.Errorf("unknown function %q at position %d", fn.value, fn.pos)
+	}
+
+	if arity == -1 && len(args) == 0 {
+		return nil, fmt.Errorf("function %q requires at least one argument", fn.value)
+	}
+	if arity > 0 && len(args) != arity {
+		return nil, fmt.Errorf("function %q requires %d argument(s), got %d", fn.value, arity, len(args))
+	}
+
+	return func(vars map[string]float64) (float64, error) {
+		values := make([]float64, len(args))
+		for i, arg := range args {
+			v, err := arg(vars)
+			if err != nil {
+				return 0, err
+			}
+			values[i] = v
+		}
+		return impl(values), nil
+	}, nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modifiers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type formulaTestData struct {
+	formula   string
+	vars      map[string]float64
+	expected  float64
+	isError   bool
+	variables []string
+}
+
+var testFormulas = []formulaTestData{
+	{formula: "1 + 2 * 3", expected: 7, variables: []string{}},
+	{formula: "(1 + 2) * 3", expected: 9, variables: []string{}},
+	{formula: "(queue + 2*lag) / 3", vars: map[string]float64{"queue": 3, "lag": 6}, expected: 5, variables: []string{"lag", "queue"}},
+	{formula: "min(a, b)", vars: map[string]float64{"a": 3, "b": 6}, expected: 3, variables: []string{"a", "b"}},
+	{formula: "max(a, b, 10)", vars: map[string]float64{"a": 3, "b": 6}, expected: 10, variables: []string{"a", "b"}},
+	{formula: "a > 10 ? a : b", vars: map[string]float64{"a": 3, "b": 6}, expected: 6, variables: []string{"a", "b"}},
+	{formula: "a > 10 && b > 1 ? 1 : 0", vars: map[string]float64{"a": 30, "b": 6}, expected: 1, variables: []string{"a", "b"}},
+	{formula: "a < 10 || !b", vars: map[string]float64{"a": 30, "b": 0}, expected: 1, variables: []string{"a", "b"}},
+	{formula: "-a + abs(-2) + ceil(1.2) + floor(1.8) + round(1.5)", vars: map[string]float64{"a": 1}, expected: 6, variables: []string{"a"}},
+	{formula: "a % 4", vars: map[string]float64{"a": 10}, expected: 2, variables: []string{"a"}},
+	{formula: "queue_length / 2.5", vars: map[string]float64{"queue_length": 10}, expected: 4, variables: []string{"queue_length"}},
+	// errors
+	{formula: "a / b", vars: map[string]float64{"a": 10, "b": 0}, isError: true},
+	{formula: "a + missing", vars: map[string]float64{"a": 10}, isError: true},
+}
+
+var testInvalidFormulas = []string{
+	"",
+	"1 +",
+	"(a + b",
+	"a b",
+	"unknown(a)",
+	"abs(a, b)",
+	"min()",
+	"a ? b",
+	"a $ b",
+	"1.2.3",
+}
+
+func TestFormulaEval(t *testing.T) {
+	for _, testData := range testFormulas {
+		formula, err := Compile(testData.formula)
+		if err != nil {
+			t.Errorf("Formula %q should compile, got %s", testData.formula, err)
+			continue
+		}
+		result, err := formula.Eval(testData.vars)
+		if testData.isError {
+			assert.Error(t, err, testData.formula)
+			continue
+		}
+		assert.NoError(t, err, testData.formula)
+		assert.Equal(t, testData.expected, result, testData.formula)
+		assert.Equal(t, testData.variables, formula.Variables(), testData.formula)
+	}
+}
+
+func TestFormulaCompileErrors(t *testing.T) {
+	for _, formula := range testInvalidFormulas {
+		_, err := Compile(formula)
+		assert.Error(t, err, formula)
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modifiers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+// resourceTriggerTypes are triggers handled by the HPA directly, they can't be referenced in a formula
+var resourceTriggerTypes = map[string]bool{
+	"cpu":    true,
+	"memory": true,
+}
+
+// ValidateScalingModifiers checks that ScalingModifiers defined in ScaledObject are correctly specified,
+// ie. that the formula compiles, targets are valid numbers and all referenced triggers exist
+func ValidateScalingModifiers(scaledObject *kedav1alpha1.ScaledObject) error {
+	if scaledObject.Spec.Advanced == nil || scaledObject.Spec.Advanced.ScalingModifiers == nil {
+		return nil
+	}
+	sm := scaledObject.Spec.Advanced.ScalingModifiers
+
+	if sm.Formula == "" {
+		return fmt.Errorf("scalingModifiers.formula must be specified")
+	}
+	formula, err := Compile(sm.Formula)
+	if err != nil {
+		return fmt.Errorf("error compiling scalingModifiers.formula: %s", err)
+	}
+
+	target, err := strconv.ParseFloat(sm.Target, 64)
+	if err != nil || target <= 0 {
+		return fmt.Errorf("scalingModifiers.target=%q must be a positive number", sm.Target)
+	}
+	if sm.ActivationTarget != "" {
+		activationTarget, err := strconv.ParseFloat(sm.ActivationTarget, 64)
+		if err != nil || activationTarget < 0 {
+			return fmt.Errorf("scalingModifiers.activationTarget=%q must be a non-negative number", sm.ActivationTarget)
+		}
+	}
+	if _, err := getMetricTargetType(sm.MetricType); err != nil {
+		return err
+	}
+
+	triggers := make(map[string]string, len(scaledObject.Spec.Triggers))
+	for _, trigger := range scaledObject.Spec.Triggers {
+		if trigger.Name == "" {
+			continue
+		}
+		if _, exists := triggers[trigger.Name]; exists {
+			return fmt.Errorf("trigger name %s is defined multiple times, it must be unique when using scalingModifiers", trigger.Name)
+		}
+		triggers[trigger.Name] = trigger.Type
+	}
+
+	for _, variable := range formula.Variables() {
+		triggerType, found := triggers[variable]
+		if !found {
+			return fmt.Errorf("scalingModifiers.formula references trigger %s which is not defined in ScaledObject", variable)
+		}
+		if resourceTriggerTypes[triggerType] {
+			return fmt.Errorf("scalingModifiers.formula can't reference trigger %s of type %s", variable, triggerType)
+		}
+	}
+
+	return nil
+}
+
+// GetCompositeMetricSpec returns MetricSpec of the composite metric that replaces external metrics
+// of all triggers in the HPA when ScalingModifiers are used
+func GetCompositeMetricSpec(scaledObject *kedav1alpha1.ScaledObject) (v2.MetricSpec, error) {
+	if !scaledObject.HasScalingModifiers() {
+		return v2.MetricSpec{}, fmt.Errorf("ScaledObject %s doesn't define scalingModifiers", scaledObject.Name)
+	}
+	sm := scaledObject.Spec.Advanced.ScalingModifiers
+
+	target, err := strconv.ParseFloat(sm.Target, 64)
+	if err != nil {
+		return v2.MetricSpec{}, fmt.Errorf("error parsing scalingModifiers.target: %s", err)
+	}
+	metricType, err := getMetricTargetType(sm.MetricType)
+	if err != nil {
+		return v2.MetricSpec{}, err
+	}
+
+	metricTarget := v2.MetricTarget{Type: metricType}
+	targetQty := QuantityFromFloat(target)
+	if metricType == v2.AverageValueMetricType {
+		metricTarget.AverageValue = targetQty
+	} else {
+		metricTarget.Value = targetQty
+	}
+
+	return v2.MetricSpec{
+		Type: v2.ExternalMetricSourceType,
+		External: &v2.ExternalMetricSource{
+			Metric: v2.MetricIdentifier{
+				Name: kedav1alpha1.CompositeMetricName,
+			},
+			Target: metricTarget,
+		},
+	}, nil
+}
+
+// QuantityFromFloat converts value to Quantity, whole numbers are kept as integers
+// so they are still readable by Quantity.AsInt64()
+func QuantityFromFloat(value float64) *resource.Quantity {
+	if value == math.Trunc(value) {
+		return resource.NewQuantity(int64(value), resource.DecimalSI)
+	}
+	return resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+}
+
+func getMetricTargetType(metricType v2.MetricTargetType) (v2.MetricTargetType, error) {
+	switch metricType {
+	case "":
+		return v2.AverageValueMetricType, nil
+	case v2.AverageValueMetricType, v2.ValueMetricType:
+		return metricType, nil
+	default:
+		return "", fmt.Errorf("scalingModifiers.metricType=%s is unsupported, allowed values are 'Value' or 'AverageValue'", metricType)
+	}
+}
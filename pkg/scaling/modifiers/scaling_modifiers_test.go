@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modifiers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+type validateTestData struct {
+	name      string
+	modifiers *kedav1alpha1.ScalingModifiers
+	isError   bool
+}
+
+var validateTestDataset = []validateTestData{
+	{name: "no modifiers", modifiers: nil},
+	{name: "valid", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue + lag", Target: "10"}},
+	{name: "valid with activation and Value", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "max(queue, lag)", Target: "2.5", ActivationTarget: "1", MetricType: v2.ValueMetricType}},
+	{name: "missing formula", modifiers: &kedav1alpha1.ScalingModifiers{Target: "10"}, isError: true},
+	{name: "invalid formula", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue +", Target: "10"}, isError: true},
+	{name: "missing target", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue"}, isError: true},
+	{name: "negative target", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue", Target: "-1"}, isError: true},
+	{name: "invalid activation target", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue", Target: "1", ActivationTarget: "abc"}, isError: true},
+	{name: "utilization metric type", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue", Target: "1", MetricType: v2.UtilizationMetricType}, isError: true},
+	{name: "unknown trigger", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue + unknown", Target: "1"}, isError: true},
+	{name: "resource trigger", modifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue + cpu_usage", Target: "1"}, isError: true},
+}
+
+func TestValidateScalingModifiers(t *testing.T) {
+	for _, testData := range validateTestDataset {
+		scaledObject := &kedav1alpha1.ScaledObject{
+			Spec: kedav1alpha1.ScaledObjectSpec{
+				Advanced: &kedav1alpha1.AdvancedConfig{ScalingModifiers: testData.modifiers},
+				Triggers: []kedav1alpha1.ScaleTriggers{
+					{Name: "queue", Type: "rabbitmq"},
+					{Name: "lag", Type: "kafka"},
+					{Name: "cpu_usage", Type: "cpu"},
+				},
+			},
+		}
+		err := ValidateScalingModifiers(scaledObject)
+		if testData.isError {
+			assert.Error(t, err, testData.name)
+		} else {
+			assert.NoError(t, err, testData.name)
+		}
+	}
+}
+
+func TestGetCompositeMetricSpec(t *testing.T) {
+	scaledObject := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			Advanced: &kedav1alpha1.AdvancedConfig{ScalingModifiers: &kedav1alpha1.ScalingModifiers{Formula: "queue", Target: "2.5"}},
+		},
+	}
+
+	spec, err := GetCompositeMetricSpec(scaledObject)
+	assert.NoError(t, err)
+	assert.Equal(t, kedav1alpha1.CompositeMetricName, spec.External.Metric.Name)
+	assert.Equal(t, v2.AverageValueMetricType, spec.External.Target.Type)
+	assert.Equal(t, "2500m", spec.External.Target.AverageValue.String())
+
+	scaledObject.Spec.Advanced.ScalingModifiers.MetricType = v2.ValueMetricType
+	spec, err = GetCompositeMetricSpec(scaledObject)
+	assert.NoError(t, err)
+	assert.Nil(t, spec.External.Target.AverageValue)
+	assert.Equal(t, "2500m", spec.External.Target.Value.String())
+}
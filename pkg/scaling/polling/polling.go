@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package polling computes the polling interval of ScaledObject with AdaptivePolling, which speeds up
+// while the scale target is active or the metric values change quickly and backs off while it is idle.
+package polling
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+const (
+	defaultChangeThreshold = 20
+	// backOffFactor is the factor the interval grows by on each poll while the scale target is idle
+	backOffFactor = 2
+	// jitterRatio is the maximal relative deviation of the interval, so the polls of many ScaledObjects
+	// with the same interval are spread over time instead of hitting the metric sources at once
+	jitterRatio = 0.1
+)
+
+// ValidateAdaptivePolling checks that the AdaptivePolling of ScaledObject is correctly specified
+func ValidateAdaptivePolling(scaledObject *kedav1alpha1.ScaledObject) error {
+	adaptivePolling := scaledObject.Spec.AdaptivePolling
+	if adaptivePolling == nil {
+		return nil
+	}
+	if adaptivePolling.MinPollingInterval < 1 {
+		return fmt.Errorf("minPollingInterval=%d must be at least 1", adaptivePolling.MinPollingInterval)
+	}
+	if adaptivePolling.MinPollingInterval > adaptivePolling.MaxPollingInterval {
+		return fmt.Errorf("minPollingInterval=%d must be less than maxPollingInterval=%d", adaptivePolling.MinPollingInterval, adaptivePolling.MaxPollingInterval)
+	}
+	if adaptivePolling.ChangeThreshold != nil && *adaptivePolling.ChangeThreshold < 1 {
+		return fmt.Errorf("changeThreshold=%d must be at least 1", *adaptivePolling.ChangeThreshold)
+	}
+	return nil
+}
+
+// Interval is the adaptive polling interval of a ScaledObject, it keeps the metric values of the previous poll
+type Interval struct {
+	min             time.Duration
+	max             time.Duration
+	changeThreshold float64
+	current         time.Duration
+	previousValues  map[string]float64
+}
+
+// NewInterval returns the adaptive polling interval, starting at pollingInterval within the bounds
+// of AdaptivePolling. Nil is returned if the ScaledObject doesn't use adaptive polling.
+func NewInterval(scaledObject *kedav1alpha1.ScaledObject, pollingInterval time.Duration) *Interval {
+	adaptivePolling := scaledObject.Spec.AdaptivePolling
+	if adaptivePolling == nil {
+		return nil
+	}
+
+	changeThreshold := int32(defaultChangeThreshold)
+	if adaptivePolling.ChangeThreshold != nil {
+		changeThreshold = *adaptivePolling.ChangeThreshold
+	}
+	interval := &Interval{
+		min:             time.Duration(adaptivePolling.MinPollingInterval) * time.Second,
+		max:             time.Duration(adaptivePolling.MaxPollingInterval) * time.Second,
+		changeThreshold: float64(changeThreshold) / 100,
+	}
+	interval.current = interval.clamp(pollingInterval)
+	return interval
+}
+
+// Next returns the interval until the next poll given the result of the current one. The interval drops to the minimum
+// while the scale target is active or any metric value changed by more than the threshold since the previous poll,
+// otherwise it backs off up to the maximum. The values are nil if they couldn't be obtained, eg. because triggers fail.
+func (i *Interval) Next(isActive bool, values map[string]float64) time.Duration {
+	changed := i.isChanging(values)
+	if values != nil {
+		i.previousValues = values
+	}
+
+	if isActive || changed {
+		i.current = i.min
+	} else {
+		i.current = i.clamp(i.current * backOffFactor)
+	}
+	return i.current
+}
+
+// Current returns the interval computed by the last call of Next, without jitter
+func (i *Interval) Current() time.Duration {
+	return i.current
+}
+
+func (i *Interval) isChanging(values map[string]float64) bool {
+	for name, value := range values {
+		previous, found := i.previousValues[name]
+		if !found {
+			continue
+		}
+		// changes of values around zero are relative to 1, so eg. a change from 0 to 1 isn't infinitely quick
+		if math.Abs(value-previous)/math.Max(math.Abs(previous), 1) >= i.changeThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Interval) clamp(interval time.Duration) time.Duration {
+	if interval < i.min {
+		return i.min
+	}
+	if interval > i.max {
+		return i.max
+	}
+	return interval
+}
+
+// WithJitter returns the interval randomly deviated by up to 10%
+func WithJitter(interval time.Duration) time.Duration {
+	return interval + time.Duration((rand.Float64()*2-1)*jitterRatio*float64(interval))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polling
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/pointer"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+func TestValidateAdaptivePolling(t *testing.T) {
+	tests := []struct {
+		name            string
+		adaptivePolling *kedav1alpha1.AdaptivePolling
+		isError         bool
+	}{
+		{name: "disabled", adaptivePolling: nil},
+		{name: "valid", adaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 5, MaxPollingInterval: 300, ChangeThreshold: pointer.Int32(50)}},
+		{name: "no min", adaptivePolling: &kedav1alpha1.AdaptivePolling{MaxPollingInterval: 300}, isError: true},
+		{name: "min above max", adaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 60, MaxPollingInterval: 30}, isError: true},
+		{name: "no change threshold", adaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 5, MaxPollingInterval: 300, ChangeThreshold: pointer.Int32(0)}, isError: true},
+	}
+
+	for _, test := range tests {
+		so := &kedav1alpha1.ScaledObject{Spec: kedav1alpha1.ScaledObjectSpec{AdaptivePolling: test.adaptivePolling}}
+		err := ValidateAdaptivePolling(so)
+		if test.isError {
+			assert.Error(t, err, test.name)
+		} else {
+			assert.NoError(t, err, test.name)
+		}
+	}
+}
+
+func TestNewIntervalStartsAtPollingInterval(t *testing.T) {
+	tests := []struct {
+		name             string
+		adaptivePolling  *kedav1alpha1.AdaptivePolling
+		expectedInterval time.Duration
+	}{
+		{name: "disabled", adaptivePolling: nil},
+		{name: "polling interval", adaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 5, MaxPollingInterval: 300}, expectedInterval: 30 * time.Second},
+		{name: "min above polling interval", adaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 60, MaxPollingInterval: 300}, expectedInterval: 60 * time.Second},
+	}
+
+	for _, test := range tests {
+		so := &kedav1alpha1.ScaledObject{Spec: kedav1alpha1.ScaledObjectSpec{AdaptivePolling: test.adaptivePolling}}
+		interval := NewInterval(so, 30*time.Second)
+		if test.adaptivePolling == nil {
+			assert.Nil(t, interval, test.name)
+		} else {
+			assert.Equal(t, test.expectedInterval, interval.Current(), test.name)
+		}
+	}
+}
+
+func TestIntervalBacksOffWhileIdle(t *testing.T) {
+	so := &kedav1alpha1.ScaledObject{Spec: kedav1alpha1.ScaledObjectSpec{AdaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 5, MaxPollingInterval: 100}}}
+	interval := NewInterval(so, 30*time.Second)
+
+	values := map[string]float64{"s0-queueLength": 10}
+	assert.Equal(t, 60*time.Second, interval.Next(false, values))
+	assert.Equal(t, 100*time.Second, interval.Next(false, values))
+	assert.Equal(t, 100*time.Second, interval.Next(false, values))
+
+	// the triggers fail, the values are unknown
+	assert.Equal(t, 100*time.Second, interval.Next(false, nil))
+
+	// the scale target is activated
+	assert.Equal(t, 5*time.Second, interval.Next(true, values))
+	assert.Equal(t, 5*time.Second, interval.Next(true, values))
+	assert.Equal(t, 10*time.Second, interval.Next(false, values))
+}
+
+func TestIntervalSpeedsUpOnQuickChange(t *testing.T) {
+	so := &kedav1alpha1.ScaledObject{Spec: kedav1alpha1.ScaledObjectSpec{AdaptivePolling: &kedav1alpha1.AdaptivePolling{MinPollingInterval: 5, MaxPollingInterval: 100, ChangeThreshold: pointer.Int32(50)}}}
+	interval := NewInterval(so, 30*time.Second)
+
+	assert.Equal(t, 60*time.Second, interval.Next(false, map[string]float64{"s0-queueLength": 10}))
+	// a change within the threshold
+	assert.Equal(t, 100*time.Second, interval.Next(false, map[string]float64{"s0-queueLength": 14}))
+	// the previous values are kept while the triggers fail
+	assert.Equal(t, 100*time.Second, interval.Next(false, nil))
+	assert.Equal(t, 5*time.Second, interval.Next(false, map[string]float64{"s0-queueLength": 21}))
+
+	// changes around zero are relative to 1
+	assert.Equal(t, 5*time.Second, interval.Next(false, map[string]float64{"s0-queueLength": 0}))
+	assert.Equal(t, 10*time.Second, interval.Next(false, map[string]float64{"s0-queueLength": 0.4}))
+	assert.Equal(t, 5*time.Second, interval.Next(false, map[string]float64{"s0-queueLength": 1}))
+}
+
+func TestWithJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		jittered := WithJitter(100 * time.Second)
+		assert.GreaterOrEqual(t, jittered, 90*time.Second)
+		assert.LessOrEqual(t, jittered, 110*time.Second)
+	}
+}
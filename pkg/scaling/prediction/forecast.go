@@ -0,0 +1,184 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package prediction forecasts metric values of triggers from the history of their values,
+// so the metric fed to the HPA can lead demand by a configured horizon.
+package prediction
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+const (
+	defaultSeason  = 24 * time.Hour
+	defaultSeasons = 7
+
+	// bucketsPerSeason is the resolution of the history, the metric values are averaged in steps of season/bucketsPerSeason
+	bucketsPerSeason = 288
+
+	// smoothing factors of Holt-Winters for the level, trend and seasonal components
+	holtWintersAlpha = 0.3
+	holtWintersBeta  = 0.05
+	holtWintersGamma = 0.3
+)
+
+// Config is the prediction of a trigger with the defaults applied
+type Config struct {
+	Horizon          time.Duration
+	Method           kedav1alpha1.PredictionMethod
+	Season           time.Duration
+	Seasons          int
+	HistoryConfigMap string
+}
+
+// NewConfig validates the prediction specified for a trigger and applies the defaults
+func NewConfig(prediction *kedav1alpha1.TriggerPrediction) (*Config, error) {
+	config := &Config{
+		Horizon:          time.Second * time.Duration(prediction.HorizonSeconds),
+		Method:           prediction.Method,
+		Season:           defaultSeason,
+		Seasons:          defaultSeasons,
+		HistoryConfigMap: prediction.HistoryConfigMap,
+	}
+	if config.Method == "" {
+		config.Method = kedav1alpha1.PredictionMethodSeasonalAverage
+	}
+	if prediction.SeasonSeconds != nil {
+		config.Season = time.Second * time.Duration(*prediction.SeasonSeconds)
+	}
+	if prediction.Seasons != nil {
+		config.Seasons = int(*prediction.Seasons)
+	}
+
+	minSeasons := 1
+	switch config.Method {
+	case kedav1alpha1.PredictionMethodSeasonalAverage:
+	case kedav1alpha1.PredictionMethodHoltWinters:
+		// the initial trend is estimated from the first two seasons
+		minSeasons = 2
+	default:
+		return nil, fmt.Errorf("unknown prediction method %s", config.Method)
+	}
+
+	if config.Season < time.Minute {
+		return nil, fmt.Errorf("prediction seasonSeconds=%d must be at least 60", int64(config.Season.Seconds()))
+	}
+	if config.Horizon <= 0 || config.Horizon >= config.Season {
+		return nil, fmt.Errorf("prediction horizonSeconds=%d must be greater than 0 and less than seasonSeconds", prediction.HorizonSeconds)
+	}
+	if config.Seasons < minSeasons {
+		return nil, fmt.Errorf("prediction seasons=%d must be at least %d for method %s", config.Seasons, minSeasons, config.Method)
+	}
+	return config, nil
+}
+
+// Step returns the duration of a bucket of the history
+func (c *Config) Step() time.Duration {
+	return c.Season / bucketsPerSeason
+}
+
+// Retention returns for how long the values are kept in the history
+func (c *Config) Retention() time.Duration {
+	return c.Season * time.Duration(c.Seasons)
+}
+
+// Forecast returns the metric value forecasted for now+horizon,
+// false is returned if the history isn't long enough yet
+func Forecast(history *History, config *Config, now time.Time) (float64, bool) {
+	switch config.Method {
+	case kedav1alpha1.PredictionMethodHoltWinters:
+		n := config.Seasons * bucketsPerSeason
+		horizonSteps := int(math.Ceil(float64(config.Horizon) / float64(config.Step())))
+		return HoltWinters(history.Series(now, n), bucketsPerSeason, horizonSteps)
+	default:
+		return SeasonalAverage(history, now.Add(config.Horizon), config.Season, config.Seasons)
+	}
+}
+
+// SeasonalAverage returns the average of the values observed at the same time of the previous seasons,
+// false is returned if there isn't any such value
+func SeasonalAverage(history *History, at time.Time, season time.Duration, seasons int) (float64, bool) {
+	sum := float64(0)
+	count := 0
+	for k := 1; k <= seasons; k++ {
+		if value, found := history.Value(at.Add(-season * time.Duration(k))); found {
+			sum += value
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), true
+}
+
+// HoltWinters forecasts the value horizon steps after the last value of the series by additive Holt-Winters
+// (triple exponential smoothing), seasonLength is the number of steps in a season. Missing values (NaN) at the
+// beginning of the series are skipped, the others are filled in from the previous season or the previous value.
+// False is returned if the series doesn't contain at least two seasons.
+func HoltWinters(series []float64, seasonLength int, horizon int) (float64, bool) {
+	start := 0
+	for start < len(series) && math.IsNaN(series[start]) {
+		start++
+	}
+	x := make([]float64, 0, len(series)-start)
+	for i := start; i < len(series); i++ {
+		value := series[i]
+		if math.IsNaN(value) {
+			if len(x) >= seasonLength {
+				value = x[len(x)-seasonLength]
+			} else {
+				value = x[len(x)-1]
+			}
+		}
+		x = append(x, value)
+	}
+	if seasonLength < 1 || len(x) < 2*seasonLength {
+		return 0, false
+	}
+
+	firstMean := mean(x[:seasonLength])
+	level := firstMean
+	trend := (mean(x[seasonLength:2*seasonLength]) - firstMean) / float64(seasonLength)
+	seasonal := make([]float64, seasonLength)
+	for i := 0; i < seasonLength; i++ {
+		seasonal[i] = x[i] - firstMean
+	}
+
+	for t := seasonLength; t < len(x); t++ {
+		s := seasonal[t%seasonLength]
+		lastLevel := level
+		level = holtWintersAlpha*(x[t]-s) + (1-holtWintersAlpha)*(level+trend)
+		trend = holtWintersBeta*(level-lastLevel) + (1-holtWintersBeta)*trend
+		seasonal[t%seasonLength] = holtWintersGamma*(x[t]-level) + (1-holtWintersGamma)*s
+	}
+
+	forecast := level + float64(horizon)*trend + seasonal[(len(x)-1+horizon)%seasonLength]
+	// metric values are never negative
+	return math.Max(forecast, 0), true
+}
+
+func mean(values []float64) float64 {
+	sum := float64(0)
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/pointer"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+// dailyDemand is a synthetic metric with a peak at 12:00 and no demand at night
+func dailyDemand(t time.Time) float64 {
+	hour := float64(t.Hour()) + float64(t.Minute())/60
+	return math.Max(0, 100*math.Sin(math.Pi*(hour-6)/12))
+}
+
+// getSyntheticHistory returns the history with the values of the synthetic metric
+// polled every 30 seconds for the given number of days before now
+func getSyntheticHistory(config *Config, now time.Time, days int, metric func(time.Time) float64) *History {
+	history := NewHistory(config.Step(), config.Retention())
+	for t := now.Add(-24 * time.Hour * time.Duration(days)); !t.After(now); t = t.Add(30 * time.Second) {
+		history.Add(t, metric(t))
+	}
+	return history
+}
+
+func TestNewConfig(t *testing.T) {
+	config, err := NewConfig(&kedav1alpha1.TriggerPrediction{HorizonSeconds: 600})
+	assert.NoError(t, err)
+	assert.Equal(t, kedav1alpha1.PredictionMethodSeasonalAverage, config.Method)
+	assert.Equal(t, 24*time.Hour, config.Season)
+	assert.Equal(t, 5*time.Minute, config.Step())
+	assert.Equal(t, 7*24*time.Hour, config.Retention())
+
+	tests := []struct {
+		name       string
+		prediction kedav1alpha1.TriggerPrediction
+	}{
+		{name: "no horizon", prediction: kedav1alpha1.TriggerPrediction{}},
+		{name: "horizon longer than season", prediction: kedav1alpha1.TriggerPrediction{HorizonSeconds: 3600, SeasonSeconds: pointer.Int32(600)}},
+		{name: "short season", prediction: kedav1alpha1.TriggerPrediction{HorizonSeconds: 10, SeasonSeconds: pointer.Int32(30)}},
+		{name: "unknown method", prediction: kedav1alpha1.TriggerPrediction{HorizonSeconds: 600, Method: "arima"}},
+		{name: "one season for holtWinters", prediction: kedav1alpha1.TriggerPrediction{HorizonSeconds: 600, Method: kedav1alpha1.PredictionMethodHoltWinters, Seasons: pointer.Int32(1)}},
+	}
+	for _, test := range tests {
+		_, err := NewConfig(&test.prediction)
+		assert.Error(t, err, test.name)
+	}
+}
+
+func TestSeasonalAverageLeadsDemand(t *testing.T) {
+	config, err := NewConfig(&kedav1alpha1.TriggerPrediction{HorizonSeconds: 3600})
+	assert.NoError(t, err)
+
+	// 7:00, the demand starts to grow
+	now := time.Date(2022, 6, 8, 7, 0, 0, 0, time.UTC)
+	history := getSyntheticHistory(config, now, 7, dailyDemand)
+
+	forecast, found := Forecast(history, config, now)
+	assert.True(t, found)
+	assert.InDelta(t, dailyDemand(now.Add(time.Hour)), forecast, 2)
+	assert.Greater(t, forecast, dailyDemand(now))
+}
+
+func TestSeasonalAverageWithoutHistory(t *testing.T) {
+	config, err := NewConfig(&kedav1alpha1.TriggerPrediction{HorizonSeconds: 3600})
+	assert.NoError(t, err)
+
+	now := time.Date(2022, 6, 8, 7, 0, 0, 0, time.UTC)
+	// less than a day of history, there aren't any values from the previous seasons
+	history := getSyntheticHistory(config, now.Add(-2*time.Hour), 0, dailyDemand)
+
+	_, found := Forecast(history, config, now)
+	assert.False(t, found)
+}
+
+func TestHoltWintersLeadsDemand(t *testing.T) {
+	config, err := NewConfig(&kedav1alpha1.TriggerPrediction{HorizonSeconds: 3600, Method: kedav1alpha1.PredictionMethodHoltWinters, Seasons: pointer.Int32(3)})
+	assert.NoError(t, err)
+
+	now := time.Date(2022, 6, 8, 7, 0, 0, 0, time.UTC)
+	// the demand grows by 10% every day
+	growingDemand := func(t time.Time) float64 {
+		days := t.Sub(now.Add(-3*24*time.Hour)).Hours() / 24
+		return dailyDemand(t) * (1 + 0.1*days)
+	}
+	history := getSyntheticHistory(config, now, 3, growingDemand)
+
+	forecast, found := Forecast(history, config, now)
+	assert.True(t, found)
+	assert.InDelta(t, growingDemand(now.Add(time.Hour)), forecast, 10)
+	assert.Greater(t, forecast, growingDemand(now))
+}
+
+func TestHoltWintersNeedsTwoSeasons(t *testing.T) {
+	_, found := HoltWinters([]float64{math.NaN(), 1, 2, 3, 4, 5}, 3, 1)
+	assert.False(t, found)
+
+	forecast, found := HoltWinters([]float64{1, 2, 3, 1, math.NaN(), 3}, 3, 1)
+	assert.True(t, found)
+	assert.InDelta(t, 1, forecast, 0.5)
+}
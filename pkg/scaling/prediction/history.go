@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// History is a rolling history of metric values of a trigger, the values are averaged
+// in buckets of a fixed step, so the memory used doesn't depend on the polling interval
+type History struct {
+	step      time.Duration
+	retention time.Duration
+
+	lock    sync.Mutex
+	buckets map[int64]bucket
+	// changed is true if a value was added since the History was persisted
+	changed bool
+}
+
+type bucket struct {
+	sum   float64
+	count int
+}
+
+// NewHistory creates an empty History with buckets of step, values older than retention are dropped
+func NewHistory(step time.Duration, retention time.Duration) *History {
+	return &History{
+		step:      step,
+		retention: retention,
+		buckets:   make(map[int64]bucket),
+	}
+}
+
+func (h *History) index(t time.Time) int64 {
+	return t.UnixNano() / int64(h.step)
+}
+
+// Add records the metric value observed at time t
+func (h *History) Add(t time.Time, value float64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	index := h.index(t)
+	b, found := h.buckets[index]
+	b.sum += value
+	b.count++
+	h.buckets[index] = b
+	h.changed = true
+
+	// old buckets are dropped only when a new one is started, it happens once per step
+	if !found {
+		oldest := h.index(t.Add(-h.retention))
+		for i := range h.buckets {
+			if i < oldest {
+				delete(h.buckets, i)
+			}
+		}
+	}
+}
+
+// Value returns the average of the metric values observed in the step containing time t
+func (h *History) Value(t time.Time) (float64, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	b, found := h.buckets[h.index(t)]
+	if !found || b.count == 0 {
+		return 0, false
+	}
+	return b.sum / float64(b.count), true
+}
+
+// Series returns n values of consecutive steps ending with the step containing time end,
+// math.NaN() is returned for steps without any observed value
+func (h *History) Series(end time.Time, n int) []float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	series := make([]float64, n)
+	last := h.index(end)
+	for i := 0; i < n; i++ {
+		b, found := h.buckets[last-int64(n-1-i)]
+		if !found || b.count == 0 {
+			series[i] = math.NaN()
+			continue
+		}
+		series[i] = b.sum / float64(b.count)
+	}
+	return series
+}
+
+// values returns the average value of each bucket keyed by the start of the bucket in Unix milliseconds
+func (h *History) values() map[int64]float64 {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.valuesLocked()
+}
+
+// takeChangedValues returns the result of values if a value was added since the last call, the History is
+// considered persisted afterwards
+func (h *History) takeChangedValues() (map[int64]float64, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if !h.changed {
+		return nil, false
+	}
+	h.changed = false
+	return h.valuesLocked(), true
+}
+
+// markChanged lets the values be persisted again, eg. after persisting them failed
+func (h *History) markChanged() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.changed = true
+}
+
+func (h *History) valuesLocked() map[int64]float64 {
+
+	result := make(map[int64]float64, len(h.buckets))
+	for i, b := range h.buckets {
+		if b.count > 0 {
+			result[i*int64(h.step)/int64(time.Millisecond)] = b.sum / float64(b.count)
+		}
+	}
+	return result
+}
+
+// restore adds the values returned by values to the History, values older than the retention are skipped
+func (h *History) restore(values map[int64]float64, now time.Time) {
+	oldest := now.Add(-h.retention)
+	for timestamp, value := range values {
+		if t := time.UnixMilli(timestamp); !t.Before(oldest) {
+			h.Add(t, value)
+		}
+	}
+
+	// the restored values don't need to be written back
+	h.lock.Lock()
+	h.changed = false
+	h.lock.Unlock()
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	"github.com/kedacore/keda/v2/pkg/scalers"
+	"github.com/kedacore/keda/v2/pkg/scaling/modifiers"
+)
+
+// predictionScaler decorates any Scaler, it records the metric values in the history
+// and returns the forecasted value instead of the current one if it is higher
+type predictionScaler struct {
+	scalers.Scaler
+	store     *Store
+	config    *Config
+	namespace string
+	owner     string
+}
+
+// predictionPushScaler is predictionScaler for PushScaler, so the Run method is kept
+type predictionPushScaler struct {
+	predictionScaler
+	pushScaler scalers.PushScaler
+}
+
+// WithPrediction wraps the scaler so its metric values lead demand by the horizon of the prediction,
+// the scaler is returned unchanged if the trigger doesn't specify any prediction
+func WithPrediction(scaler scalers.Scaler, trigger kedav1alpha1.ScaleTriggers, withTriggers *kedav1alpha1.WithTriggers, store *Store) (scalers.Scaler, error) {
+	if trigger.Prediction == nil {
+		return scaler, nil
+	}
+	config, err := NewConfig(trigger.Prediction)
+	if err != nil {
+		return scaler, err
+	}
+
+	s := predictionScaler{
+		Scaler:    scaler,
+		store:     store,
+		config:    config,
+		namespace: withTriggers.Namespace,
+		owner:     withTriggers.GenerateIdenitifier(),
+	}
+	if ps, ok := scaler.(scalers.PushScaler); ok {
+		return &predictionPushScaler{predictionScaler: s, pushScaler: ps}, nil
+	}
+	return &s, nil
+}
+
+// GetMetrics records the current metric value and returns the forecasted one if it is higher
+func (s *predictionScaler) GetMetrics(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, error) {
+	metrics, value, forecast, found, err := s.record(ctx, metricName, metricSelector)
+	if err != nil || !found || forecast <= value {
+		return metrics, err
+	}
+	return []external_metrics.ExternalMetricValue{{
+		MetricName: metricName,
+		Value:      *modifiers.QuantityFromFloat(forecast),
+		Timestamp:  metav1.Now(),
+	}}, nil
+}
+
+// IsActive returns true if any demand is forecasted, so the target is activated ahead of it,
+// otherwise the scaler's own decision is used. The metric value is recorded even while
+// the target is scaled to zero and the HPA doesn't query the metrics.
+func (s *predictionScaler) IsActive(ctx context.Context) (bool, error) {
+	metricSpecs := s.Scaler.GetMetricSpecForScaling(ctx)
+	// resource metrics (cpu/memory) are evaluated by the HPA, keep the scaler's own decision
+	if len(metricSpecs) < 1 || metricSpecs[0].External == nil {
+		return s.Scaler.IsActive(ctx)
+	}
+
+	_, _, forecast, found, err := s.record(ctx, metricSpecs[0].External.Metric.Name, nil)
+	if err != nil {
+		return false, err
+	}
+	if found && forecast > 0 {
+		return true, nil
+	}
+	return s.Scaler.IsActive(ctx)
+}
+
+// record gets the current metric value from the scaler and records it in the history,
+// it returns the metrics, the current value and the forecasted value if the history is long enough
+func (s *predictionScaler) record(ctx context.Context, metricName string, metricSelector labels.Selector) ([]external_metrics.ExternalMetricValue, float64, float64, bool, error) {
+	metrics, err := s.Scaler.GetMetrics(ctx, metricName, metricSelector)
+	if err != nil {
+		return metrics, 0, 0, false, err
+	}
+
+	var value float64
+	for _, m := range metrics {
+		if m.MetricName == metricName {
+			value += m.Value.AsApproximateFloat64()
+		}
+	}
+
+	now := time.Now()
+	history := s.store.Get(ctx, s.namespace, s.owner, metricName, s.config)
+	history.Add(now, value)
+
+	forecast, found := Forecast(history, s.config, now)
+	return metrics, value, forecast, found, nil
+}
+
+// Run forwards the push notifications of the wrapped PushScaler
+func (s *predictionPushScaler) Run(ctx context.Context, active chan<- bool) {
+	s.pushScaler.Run(ctx, active)
+}
+
+// Unwrap returns the decorated scaler
+func (s *predictionScaler) Unwrap() scalers.Scaler {
+	return s.Scaler
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
+)
+
+const metricName = "s0-queueLength"
+
+func getWithTriggers() *kedav1alpha1.WithTriggers {
+	return &kedav1alpha1.WithTriggers{
+		TypeMeta:   metav1.TypeMeta{Kind: "ScaledObject"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+	}
+}
+
+func getMockScaler(ctrl *gomock.Controller, value int64) *mock_scalers.MockScaler {
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return([]v2.MetricSpec{{
+		External: &v2.ExternalMetricSource{Metric: v2.MetricIdentifier{Name: metricName}},
+	}}).AnyTimes()
+	scaler.EXPECT().GetMetrics(gomock.Any(), metricName, gomock.Any()).Return([]external_metrics.ExternalMetricValue{{
+		MetricName: metricName,
+		Value:      *resource.NewQuantity(value, resource.DecimalSI),
+	}}, nil).AnyTimes()
+	return scaler
+}
+
+// addPastDemand records the value in the buckets around now+horizon of the previous season
+func addPastDemand(history *History, config *Config, now time.Time, value float64) {
+	at := now.Add(config.Horizon - config.Season)
+	for t := at.Add(-config.Step()); t.Before(at.Add(3 * config.Step())); t = t.Add(config.Step()) {
+		history.Add(t, value)
+	}
+}
+
+func TestPredictionScalerReturnsForecastedDemand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+	trigger := kedav1alpha1.ScaleTriggers{Type: "mock", Prediction: &kedav1alpha1.TriggerPrediction{HorizonSeconds: 600}}
+	withTriggers := getWithTriggers()
+	store := NewStore(nil)
+
+	scaler, err := WithPrediction(getMockScaler(ctrl, 10), trigger, withTriggers, store)
+	assert.NoError(t, err)
+
+	config, _ := NewConfig(trigger.Prediction)
+	addPastDemand(store.Get(ctx, "default", withTriggers.GenerateIdenitifier(), metricName, config), config, time.Now(), 50)
+
+	metrics, err := scaler.GetMetrics(ctx, metricName, nil)
+	assert.NoError(t, err)
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, int64(50), metrics[0].Value.Value())
+}
+
+func TestPredictionScalerKeepsCurrentValueIfHigher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+	trigger := kedav1alpha1.ScaleTriggers{Type: "mock", Prediction: &kedav1alpha1.TriggerPrediction{HorizonSeconds: 600}}
+	withTriggers := getWithTriggers()
+	store := NewStore(nil)
+
+	scaler, err := WithPrediction(getMockScaler(ctrl, 100), trigger, withTriggers, store)
+	assert.NoError(t, err)
+
+	config, _ := NewConfig(trigger.Prediction)
+	addPastDemand(store.Get(ctx, "default", withTriggers.GenerateIdenitifier(), metricName, config), config, time.Now(), 50)
+
+	metrics, err := scaler.GetMetrics(ctx, metricName, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), metrics[0].Value.Value())
+}
+
+func TestPredictionScalerActivatesAheadOfDemand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx := context.Background()
+	trigger := kedav1alpha1.ScaleTriggers{Type: "mock", Prediction: &kedav1alpha1.TriggerPrediction{HorizonSeconds: 600}}
+	withTriggers := getWithTriggers()
+	store := NewStore(nil)
+
+	mockScaler := getMockScaler(ctrl, 0)
+	mockScaler.EXPECT().IsActive(gomock.Any()).Return(false, nil).Times(1)
+	scaler, err := WithPrediction(mockScaler, trigger, withTriggers, store)
+	assert.NoError(t, err)
+
+	// without any history the scaler's own decision is used
+	isActive, err := scaler.IsActive(ctx)
+	assert.NoError(t, err)
+	assert.False(t, isActive)
+
+	config, _ := NewConfig(trigger.Prediction)
+	addPastDemand(store.Get(ctx, "default", withTriggers.GenerateIdenitifier(), metricName, config), config, time.Now(), 50)
+
+	isActive, err = scaler.IsActive(ctx)
+	assert.NoError(t, err)
+	assert.True(t, isActive)
+}
+
+func TestWithoutPredictionReturnsScaler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockScaler := getMockScaler(ctrl, 0)
+
+	scaler, err := WithPrediction(mockScaler, kedav1alpha1.ScaleTriggers{Type: "mock"}, getWithTriggers(), NewStore(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, mockScaler, scaler)
+
+	_, err = WithPrediction(mockScaler, kedav1alpha1.ScaleTriggers{Type: "mock", Prediction: &kedav1alpha1.TriggerPrediction{}}, getWithTriggers(), NewStore(nil))
+	assert.Error(t, err)
+}
+
+func TestStorePersistsHistoryToConfigMap(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	config, err := NewConfig(&kedav1alpha1.TriggerPrediction{HorizonSeconds: 600, HistoryConfigMap: "test-history"})
+	assert.NoError(t, err)
+	owner := getWithTriggers().GenerateIdenitifier()
+	now := time.Now()
+
+	store := NewStore(kubeClient)
+	history := store.Get(ctx, "default", owner, metricName, config)
+	addPastDemand(history, config, now, 50)
+	store.Persist(ctx)
+
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, kubeClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-history"}, configMap))
+	assert.Contains(t, configMap.Data, historyKey(owner, metricName))
+
+	// the history is loaded by a new store, e.g. after restart of the operator
+	restored := NewStore(kubeClient).Get(ctx, "default", owner, metricName, config)
+	value, found := restored.Value(now.Add(config.Horizon - config.Season))
+	assert.True(t, found)
+	assert.Equal(t, float64(50), value)
+}
+
+func TestStorePersistsOnlyChangedHistories(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := &countingClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+	config, err := NewConfig(&kedav1alpha1.TriggerPrediction{HorizonSeconds: 600, HistoryConfigMap: "test-history"})
+	assert.NoError(t, err)
+	owner := getWithTriggers().GenerateIdenitifier()
+
+	store := NewStore(kubeClient)
+	history := store.Get(ctx, "default", owner, metricName, config)
+	addPastDemand(history, config, time.Now(), 50)
+	store.Persist(ctx)
+	assert.Equal(t, 1, kubeClient.writes)
+
+	store.Persist(ctx)
+	assert.Equal(t, 1, kubeClient.writes)
+
+	history.Add(time.Now(), 10)
+	store.Persist(ctx)
+	assert.Equal(t, 2, kubeClient.writes)
+}
+
+func TestStorePersistRetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	configMapKey := client.ObjectKey{Namespace: "default", Name: "test-history"}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapKey.Name, Namespace: configMapKey.Namespace},
+		Data:       map[string]string{},
+	}).Build()
+	// another writer updates the ConfigMap between the read and the first update of the store
+	kubeClient := &conflictingClient{Client: fakeClient, configMapKey: configMapKey, otherKey: "other"}
+	config, err := NewConfig(&kedav1alpha1.TriggerPrediction{HorizonSeconds: 600, HistoryConfigMap: configMapKey.Name})
+	assert.NoError(t, err)
+	owner := getWithTriggers().GenerateIdenitifier()
+
+	store := NewStore(kubeClient)
+	addPastDemand(store.Get(ctx, "default", owner, metricName, config), config, time.Now(), 50)
+	store.Persist(ctx)
+
+	configMap := &corev1.ConfigMap{}
+	assert.NoError(t, fakeClient.Get(ctx, configMapKey, configMap))
+	assert.Contains(t, configMap.Data, "other")
+	assert.Contains(t, configMap.Data, historyKey(owner, metricName))
+}
+
+// countingClient counts the writes of ConfigMaps
+type countingClient struct {
+	client.Client
+	writes int
+}
+
+func (c *countingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.writes++
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *countingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.writes++
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// conflictingClient writes otherKey to the ConfigMap before the first update is done
+type conflictingClient struct {
+	client.Client
+	configMapKey client.ObjectKey
+	otherKey     string
+	updated      bool
+}
+
+func (c *conflictingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if !c.updated {
+		c.updated = true
+		configMap := &corev1.ConfigMap{}
+		if err := c.Client.Get(ctx, c.configMapKey, configMap); err != nil {
+			return err
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[c.otherKey] = "{}"
+		if err := c.Client.Update(ctx, configMap); err != nil {
+			return err
+		}
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prediction
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// persistInterval is how often the changed histories are written to the ConfigMaps
+const persistInterval = 5 * time.Minute
+
+// finalPersistTimeout bounds the write of the histories on shutdown
+const finalPersistTimeout = 10 * time.Second
+
+var invalidConfigMapKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// Store keeps the histories of triggers of all ScaledObjects and ScaledJobs, so they survive
+// rebuilding of the scalers, and persists them to ConfigMaps if it is requested by the trigger.
+// Only KEDA Operator records the histories, it runs the Store as manager.Runnable to persist them.
+type Store struct {
+	client client.Client
+	logger logr.Logger
+
+	lock      sync.Mutex
+	histories map[string]*History
+	// configMaps are the ConfigMaps the histories are persisted to, keyed by the key of the history
+	configMaps map[string]client.ObjectKey
+}
+
+// persistedHistory is the history as it is stored in the ConfigMap
+type persistedHistory struct {
+	// Values are keyed by Unix milliseconds
+	Values map[int64]float64 `json:"values"`
+}
+
+// NewStore creates an empty Store, the client is used to load and persist the histories
+func NewStore(kubeClient client.Client) *Store {
+	return &Store{
+		client:     kubeClient,
+		logger:     logf.Log.WithName("prediction"),
+		histories:  make(map[string]*History),
+		configMaps: make(map[string]client.ObjectKey),
+	}
+}
+
+// Get returns the history of the metric of the object identified by owner (see WithTriggers.GenerateIdenitifier),
+// the history is loaded from the ConfigMap when it is accessed for the first time
+func (s *Store) Get(ctx context.Context, namespace string, owner string, metricName string, config *Config) *History {
+	key := historyKey(owner, metricName)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if history, found := s.histories[key]; found && history.step == config.Step() && history.retention == config.Retention() {
+		return history
+	}
+
+	history := NewHistory(config.Step(), config.Retention())
+	if config.HistoryConfigMap != "" {
+		s.load(ctx, namespace, key, config.HistoryConfigMap, history)
+		s.configMaps[key] = client.ObjectKey{Namespace: namespace, Name: config.HistoryConfigMap}
+	} else {
+		delete(s.configMaps, key)
+	}
+	s.histories[key] = history
+	return history
+}
+
+// Delete drops the histories of all metrics of the object identified by owner, the ConfigMaps are kept
+func (s *Store) Delete(owner string) {
+	prefix := historyKey(owner, "")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key := range s.histories {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.histories, key)
+			delete(s.configMaps, key)
+		}
+	}
+}
+
+// Start persists the changed histories every persistInterval and once more when the context is done,
+// it implements manager.Runnable
+func (s *Store) Start(ctx context.Context) error {
+	ticker := time.NewTicker(persistInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Persist(ctx)
+		case <-ctx.Done():
+			// the context of the manager is already done, the histories are written with a new one
+			persistCtx, cancel := context.WithTimeout(context.Background(), finalPersistTimeout)
+			s.Persist(persistCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+// historyChange is the encoded history to be written to the ConfigMap
+type historyChange struct {
+	key     string
+	data    string
+	history *History
+}
+
+// Persist writes the histories changed since they were persisted last time to their ConfigMaps,
+// the histories persisted to the same ConfigMap are written by a single update
+func (s *Store) Persist(ctx context.Context) {
+	changes := make(map[client.ObjectKey][]historyChange)
+	s.lock.Lock()
+	for key, configMapKey := range s.configMaps {
+		history := s.histories[key]
+		values, changed := history.takeChangedValues()
+		if !changed {
+			continue
+		}
+		data, err := json.Marshal(persistedHistory{Values: values})
+		if err != nil {
+			s.logger.Error(err, "Error encoding history", "key", key)
+			continue
+		}
+		changes[configMapKey] = append(changes[configMapKey], historyChange{key: key, data: string(data), history: history})
+	}
+	s.lock.Unlock()
+
+	for configMapKey, configMapChanges := range changes {
+		if err := s.persistConfigMap(ctx, configMapKey, configMapChanges); err != nil {
+			s.logger.Error(err, "Error persisting histories", "configMap.Namespace", configMapKey.Namespace, "configMap.Name", configMapKey.Name)
+			// the histories are written again in the next interval
+			for _, change := range configMapChanges {
+				change.history.markChanged()
+			}
+		}
+	}
+}
+
+// persistConfigMap sets the keys of the changed histories in the ConfigMap, which is created if it doesn't exist.
+// The update is retried on conflict, eg. with KEDA Operator writing the histories of the other objects
+// to the same ConfigMap, the keys of the other histories are kept
+func (s *Store) persistConfigMap(ctx context.Context, configMapKey client.ObjectKey, changes []historyChange) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap := &corev1.ConfigMap{}
+		err := s.client.Get(ctx, configMapKey, configMap)
+		if errors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      configMapKey.Name,
+					Namespace: configMapKey.Namespace,
+					Labels:    map[string]string{"app.kubernetes.io/managed-by": "keda-operator"},
+				},
+				Data: make(map[string]string, len(changes)),
+			}
+			for _, change := range changes {
+				configMap.Data[change.key] = change.data
+			}
+			return s.client.Create(ctx, configMap)
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string, len(changes))
+		}
+		for _, change := range changes {
+			configMap.Data[change.key] = change.data
+		}
+		return s.client.Update(ctx, configMap)
+	})
+}
+
+func (s *Store) load(ctx context.Context, namespace string, key string, configMapName string, history *History) {
+	logger := s.logger.WithValues("configMap.Namespace", namespace, "configMap.Name", configMapName)
+
+	configMap := &corev1.ConfigMap{}
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: configMapName}, configMap); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error loading history")
+		}
+		return
+	}
+
+	data, found := configMap.Data[key]
+	if !found {
+		return
+	}
+	persisted := persistedHistory{}
+	if err := json.Unmarshal([]byte(data), &persisted); err != nil {
+		logger.Error(err, "Error decoding history", "key", key)
+		return
+	}
+	history.restore(persisted.Values, time.Now())
+}
+
+// historyKey returns the key of the history, it is also used as the key in the ConfigMap
+func historyKey(owner string, metricName string) string {
+	return invalidConfigMapKeyChars.ReplaceAllString(owner+"."+metricName, "-")
+}
@@ -24,19 +24,26 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/metrics/pkg/apis/external_metrics"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	"github.com/kedacore/keda/v2/pkg/eventreason"
+	"github.com/kedacore/keda/v2/pkg/metrics"
 	"github.com/kedacore/keda/v2/pkg/scalers"
 	"github.com/kedacore/keda/v2/pkg/scaling/cache"
 	"github.com/kedacore/keda/v2/pkg/scaling/executor"
+	"github.com/kedacore/keda/v2/pkg/scaling/polling"
+	"github.com/kedacore/keda/v2/pkg/scaling/prediction"
 	"github.com/kedacore/keda/v2/pkg/scaling/resolver"
+	"github.com/kedacore/keda/v2/pkg/scaling/scheduler"
+	kedautil "github.com/kedacore/keda/v2/pkg/util"
 )
 
 // ScaleHandler encapsulates the logic of calling the right scalers for
@@ -49,27 +56,52 @@ type ScaleHandler interface {
 }
 
 type scaleHandler struct {
-	client            client.Client
-	logger            logr.Logger
-	scaleLoopContexts *sync.Map
-	scaleExecutor     executor.ScaleExecutor
-	globalHTTPTimeout time.Duration
-	recorder          record.EventRecorder
-	scalerCaches      map[string]*cache.ScalersCache
-	lock              *sync.RWMutex
+	client                 client.Client
+	scaleClient            scale.ScalesGetter
+	logger                 logr.Logger
+	scaleLoopContexts      *sync.Map
+	scaleExecutor          executor.ScaleExecutor
+	globalHTTPTimeout      time.Duration
+	recorder               record.EventRecorder
+	scalerCaches           map[string]*cache.ScalersCache
+	lock                   *sync.RWMutex
+	metricsFreshnessWindow time.Duration
+	predictionStore        *prediction.Store
+	scaleLoopScheduler     *scheduler.Scheduler
+	queryBatchWindow       time.Duration
+}
+
+// ScaleHandlerOptions are the optional settings of ScaleHandler, the zero value disables all of them
+type ScaleHandlerOptions struct {
+	// MetricsFreshnessWindow specifies for how long are metric values recorded in the scale loop
+	// served to KEDA Metrics Server instead of querying the scalers again, 0 disables the recording
+	MetricsFreshnessWindow time.Duration
+	// ScaleLoopScheduler bounds the concurrency and the rate of the scale loops, nil means they aren't bounded
+	ScaleLoopScheduler *scheduler.Scheduler
+	// QueryBatchWindow specifies for how long are the metric queries of the scalers sharing a backend
+	// collected before they are executed together, 0 disables the batching
+	QueryBatchWindow time.Duration
+	// PredictionStore keeps the histories of the triggers with prediction, nil disables the prediction,
+	// it is set only in KEDA Operator, so the histories are recorded and persisted by a single process
+	PredictionStore *prediction.Store
 }
 
 // NewScaleHandler creates a ScaleHandler object
-func NewScaleHandler(client client.Client, scaleClient scale.ScalesGetter, reconcilerScheme *runtime.Scheme, globalHTTPTimeout time.Duration, recorder record.EventRecorder) ScaleHandler {
+func NewScaleHandler(client client.Client, scaleClient scale.ScalesGetter, reconcilerScheme *runtime.Scheme, globalHTTPTimeout time.Duration, recorder record.EventRecorder, options ScaleHandlerOptions) ScaleHandler {
 	return &scaleHandler{
-		client:            client,
-		logger:            logf.Log.WithName("scalehandler"),
-		scaleLoopContexts: &sync.Map{},
-		scaleExecutor:     executor.NewScaleExecutor(client, scaleClient, reconcilerScheme, recorder),
-		globalHTTPTimeout: globalHTTPTimeout,
-		recorder:          recorder,
-		scalerCaches:      map[string]*cache.ScalersCache{},
-		lock:              &sync.RWMutex{},
+		client:                 client,
+		scaleClient:            scaleClient,
+		logger:                 logf.Log.WithName("scalehandler"),
+		scaleLoopContexts:      &sync.Map{},
+		scaleExecutor:          executor.NewScaleExecutor(client, scaleClient, reconcilerScheme, recorder),
+		globalHTTPTimeout:      globalHTTPTimeout,
+		recorder:               recorder,
+		scalerCaches:           map[string]*cache.ScalersCache{},
+		lock:                   &sync.RWMutex{},
+		metricsFreshnessWindow: options.MetricsFreshnessWindow,
+		predictionStore:        options.PredictionStore,
+		scaleLoopScheduler:     options.ScaleLoopScheduler,
+		queryBatchWindow:       options.QueryBatchWindow,
 	}
 }
 
@@ -134,6 +166,11 @@ func (h *scaleHandler) DeleteScalableObject(ctx context.Context, scalableObject
 		h.logger.V(1).Info("ScaledObject was not found in controller cache", "key", key)
 	}
 
+	// the history of metric values is kept while the scale loop is only stopped, eg. when the object is paused
+	if h.predictionStore != nil && withTriggers.GetDeletionTimestamp() != nil {
+		h.predictionStore.Delete(key)
+	}
+
 	return nil
 }
 
@@ -144,9 +181,27 @@ func (h *scaleHandler) startScaleLoop(ctx context.Context, withTriggers *kedav1a
 	pollingInterval := withTriggers.GetPollingInterval()
 	logger.V(1).Info("Watching with pollingInterval", "PollingInterval", pollingInterval)
 
+	var adaptiveInterval *polling.Interval
+	if scaledObject, ok := scalableObject.(*kedav1alpha1.ScaledObject); ok {
+		adaptiveInterval = polling.NewInterval(scaledObject, pollingInterval)
+	}
+	defer metrics.DeletePollingInterval(withTriggers.Namespace, withTriggers.Kind, withTriggers.Name)
+
 	for {
-		tmr := time.NewTimer(pollingInterval)
-		h.checkScalers(ctx, scalableObject, scalingMutex)
+		checkStarted := time.Now()
+		isActive, isError, metricValues := h.checkScalers(ctx, scalableObject, scalingMutex)
+
+		interval := pollingInterval
+		if scaledObject, ok := scalableObject.(*kedav1alpha1.ScaledObject); ok {
+			if adaptiveInterval != nil {
+				interval = h.nextPollingInterval(ctx, adaptiveInterval, scaledObject, isActive, isError, metricValues)
+			} else {
+				h.updatePollingIntervalStatus(ctx, scaledObject, nil)
+			}
+		}
+		metrics.RecordPollingInterval(withTriggers.Namespace, withTriggers.Kind, withTriggers.Name, interval)
+		// the interval includes the time the check took
+		tmr := time.NewTimer(interval - time.Since(checkStarted))
 
 		select {
 		case <-tmr.C:
@@ -191,16 +246,21 @@ func (h *scaleHandler) GetScalersCache(ctx context.Context, scalableObject inter
 		return nil, err
 	}
 
-	scalers, err := h.buildScalers(ctx, withTriggers, podTemplateSpec, containerName)
+	podSelector := h.getPodSelector(ctx, scalableObject, withTriggers)
+
+	scalers, err := h.buildScalers(ctx, withTriggers, podTemplateSpec, containerName, podSelector)
 	if err != nil {
 		return nil, err
 	}
 
 	h.scalerCaches[key] = &cache.ScalersCache{
-		Generation: withTriggers.Generation,
-		Scalers:    scalers,
-		Logger:     h.logger,
-		Recorder:   h.recorder,
+		Generation:             withTriggers.Generation,
+		Scalers:                scalers,
+		Logger:                 h.logger,
+		Recorder:               h.recorder,
+		Namespace:              withTriggers.Namespace,
+		Name:                   withTriggers.Name,
+		MetricsFreshnessWindow: h.metricsFreshnessWindow,
 	}
 
 	return h.scalerCaches[key], nil
@@ -233,7 +293,17 @@ func (h *scaleHandler) startPushScalers(ctx context.Context, withTriggers *kedav
 		return
 	}
 
-	for _, ps := range cache.GetPushScalers() {
+	pushScalers := cache.GetPushScalers()
+	if len(pushScalers) == 0 {
+		return
+	}
+
+	// push signals are hints, they trigger an immediate check of all the triggers instead of scaling the target right away.
+	// The signals are coalesced, so a burst of them results in a single check.
+	checkCh := make(chan struct{}, 1)
+	go h.checkScalersOnPush(ctx, scalableObject, checkCh, scalingMutex)
+
+	for _, ps := range pushScalers {
 		go func(s scalers.PushScaler) {
 			activeCh := make(chan bool)
 			go s.Run(ctx, activeCh)
@@ -242,28 +312,55 @@ func (h *scaleHandler) startPushScalers(ctx context.Context, withTriggers *kedav
 				select {
 				case <-ctx.Done():
 					return
-				case active := <-activeCh:
-					scalingMutex.Lock()
-					switch obj := scalableObject.(type) {
-					case *kedav1alpha1.ScaledObject:
-						h.scaleExecutor.RequestScale(ctx, obj, active, false)
-					case *kedav1alpha1.ScaledJob:
-						h.logger.Info("Warning: External Push Scaler does not support ScaledJob", "object", scalableObject)
+				case active, ok := <-activeCh:
+					if !ok {
+						// the scaler stopped pushing, the triggers are still checked by the scale loop
+						return
+					}
+					// jobs are never scaled in, the deactivation of a ScaledJob is picked up by the scale loop
+					if _, isScaledJob := scalableObject.(*kedav1alpha1.ScaledJob); isScaledJob && !active {
+						continue
+					}
+					select {
+					case checkCh <- struct{}{}:
+					default:
+						logger.V(1).Info("Check of triggers is already pending, skipping push signal")
 					}
-					scalingMutex.Unlock()
 				}
 			}
 		}(ps)
 	}
 }
 
+// checkScalersOnPush checks the triggers right after a push scaler signals a change, instead of waiting for
+// the next pollingInterval. The state of the object is evaluated from all of its triggers, as it is by the scale loop,
+// so a signal of a single scaler doesn't override the others. The check is serialized with the scale loop by scalingMutex.
+func (h *scaleHandler) checkScalersOnPush(ctx context.Context, scalableObject interface{}, checkCh <-chan struct{}, scalingMutex sync.Locker) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-checkCh:
+			h.checkScalers(ctx, scalableObject, scalingMutex)
+		}
+	}
+}
+
 // checkScalers contains the main logic for the ScaleHandler scaling logic.
-// It'll check each trigger active status then call RequestScale
-func (h *scaleHandler) checkScalers(ctx context.Context, scalableObject interface{}, scalingMutex sync.Locker) {
+// It'll check each trigger active status then call RequestScale. It returns whether
+// the object is active, whether any of its triggers failed and the metric values of ScaledObject obtained by the check.
+func (h *scaleHandler) checkScalers(ctx context.Context, scalableObject interface{}, scalingMutex sync.Locker) (bool, bool, []external_metrics.ExternalMetricValue) {
+	// the check waits for the rate limits of its trigger types and for a free worker
+	release, err := h.scaleLoopScheduler.Acquire(ctx, getTriggerTypes(scalableObject))
+	if err != nil {
+		return false, false, nil
+	}
+	defer release()
+
 	cache, err := h.GetScalersCache(ctx, scalableObject)
 	if err != nil {
 		h.logger.Error(err, "Error getting scalers", "object", scalableObject)
-		return
+		return false, true, nil
 	}
 
 	scalingMutex.Lock()
@@ -273,23 +370,70 @@ func (h *scaleHandler) checkScalers(ctx context.Context, scalableObject interfac
 		err = h.client.Get(ctx, types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}, obj)
 		if err != nil {
 			h.logger.Error(err, "Error getting scaledObject", "object", scalableObject)
-			return
+			return false, true, nil
 		}
-		isActive, isError, _ := cache.IsScaledObjectActive(ctx, obj)
-		h.scaleExecutor.RequestScale(ctx, obj, isActive, isError)
+		if executor.IsPaused(obj) {
+			return false, false, nil
+		}
+		isActive, isError, metricValues, scalerErrors := cache.GetScaledObjectState(ctx, obj)
+		h.scaleExecutor.RequestScale(ctx, obj, isActive, isError, scalerErrors)
+		if obj.Spec.DryRun {
+			h.recordDryRunHPADecision(ctx, cache, obj)
+		}
+		h.updateTriggersStatus(ctx, cache, obj)
+		return isActive, isError, metricValues
 	case *kedav1alpha1.ScaledJob:
 		err = h.client.Get(ctx, types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}, obj)
 		if err != nil {
 			h.logger.Error(err, "Error getting scaledJob", "object", scalableObject)
-			return
+			return false, true, nil
 		}
-		isActive, scaleTo, maxScale := cache.IsScaledJobActive(ctx, obj)
-		h.scaleExecutor.RequestJobScale(ctx, obj, isActive, scaleTo, maxScale)
+		if executor.IsPaused(obj) {
+			return false, false, nil
+		}
+		isActive, scaleTo, maxScale, requiredScale, scalerErrors := cache.GetScaledJobState(ctx, obj)
+		h.scaleExecutor.RequestJobScale(ctx, obj, isActive, scaleTo, maxScale, requiredScale, scalerErrors)
+		isError := false
+		for _, err := range scalerErrors {
+			if err != nil {
+				isError = true
+			}
+		}
+		return isActive, isError, nil
+	}
+	return false, false, nil
+}
+
+// getPodSelector returns the selector of the scale target pods used by cpu and memory triggers, the pods of ScaledObject
+// are selected as reported by the /scale subresource of its target. The selector is nil if there aren't any cpu or memory
+// triggers, it can't be resolved or the object is ScaledJob, which is scaled by its event triggers only
+func (h *scaleHandler) getPodSelector(ctx context.Context, scalableObject interface{}, withTriggers *kedav1alpha1.WithTriggers) labels.Selector {
+	scaledObject, ok := scalableObject.(*kedav1alpha1.ScaledObject)
+	if !ok {
+		return nil
+	}
+
+	hasResourceTriggers := false
+	for _, trigger := range withTriggers.Spec.Triggers {
+		if trigger.Type == "cpu" || trigger.Type == "memory" {
+			hasResourceTriggers = true
+			break
+		}
+	}
+	if !hasResourceTriggers {
+		return nil
+	}
+
+	podSelector, err := kedautil.GetScaleTargetSelector(ctx, h.scaleClient, scaledObject)
+	if err != nil {
+		h.logger.Error(err, "Error getting selector of scale target pods", "scaledObject.Namespace", scaledObject.Namespace, "scaledObject.Name", scaledObject.Name)
+		return nil
 	}
+	return podSelector
 }
 
 // buildScalers returns list of Scalers for the specified triggers
-func (h *scaleHandler) buildScalers(ctx context.Context, withTriggers *kedav1alpha1.WithTriggers, podTemplateSpec *corev1.PodTemplateSpec, containerName string) ([]cache.ScalerBuilder, error) {
+func (h *scaleHandler) buildScalers(ctx context.Context, withTriggers *kedav1alpha1.WithTriggers, podTemplateSpec *corev1.PodTemplateSpec, containerName string, podSelector labels.Selector) ([]cache.ScalerBuilder, error) {
 	logger := h.logger.WithValues("type", withTriggers.Kind, "namespace", withTriggers.Namespace, "name", withTriggers.Name)
 	var err error
 	resolvedEnv := make(map[string]string)
@@ -306,14 +450,17 @@ func (h *scaleHandler) buildScalers(ctx context.Context, withTriggers *kedav1alp
 				}
 			}
 			config := &scalers.ScalerConfig{
-				Name:              withTriggers.Name,
-				Namespace:         withTriggers.Namespace,
-				TriggerMetadata:   trigger.Metadata,
-				ResolvedEnv:       resolvedEnv,
-				AuthParams:        make(map[string]string),
-				GlobalHTTPTimeout: h.globalHTTPTimeout,
-				ScalerIndex:       triggerIndex,
-				MetricType:        trigger.MetricType,
+				Name:                withTriggers.Name,
+				Namespace:           withTriggers.Namespace,
+				TriggerMetadata:     trigger.Metadata,
+				ResolvedEnv:         resolvedEnv,
+				AuthParams:          make(map[string]string),
+				GlobalHTTPTimeout:   h.globalHTTPTimeout,
+				ScalerIndex:         triggerIndex,
+				MetricType:          trigger.MetricType,
+				ActivationThreshold: trigger.ActivationThreshold,
+				QueryBatchWindow:    h.queryBatchWindow,
+				PodSelector:         podSelector,
 			}
 
 			config.AuthParams, config.PodIdentity, err = resolver.ResolveAuthRefAndPodIdentity(ctx, h.client, logger, trigger.AuthenticationRef, podTemplateSpec, withTriggers.Namespace)
@@ -321,7 +468,19 @@ func (h *scaleHandler) buildScalers(ctx context.Context, withTriggers *kedav1alp
 				return nil, err
 			}
 
-			return buildScaler(ctx, h.client, trigger.Type, config)
+			scaler, err := buildScaler(ctx, h.client, trigger.Type, config)
+			if err != nil {
+				return scaler, err
+			}
+
+			if h.predictionStore != nil {
+				scaler, err = prediction.WithPrediction(scaler, trigger, withTriggers, h.predictionStore)
+				if err != nil {
+					return scaler, err
+				}
+			}
+
+			return scalers.WithActivationThreshold(scaler, trigger.Type, config)
 		}
 
 		scaler, err := factory()
@@ -338,14 +497,29 @@ func (h *scaleHandler) buildScalers(ctx context.Context, withTriggers *kedav1alp
 		}
 
 		result = append(result, cache.ScalerBuilder{
-			Scaler:  scaler,
-			Factory: factory,
+			Scaler:          scaler,
+			Factory:         factory,
+			MetricsCacheTTL: getMetricsCacheTTL(withTriggers, trigger),
+			TriggerName:     trigger.Name,
+			TriggerType:     trigger.Type,
 		})
 	}
 
 	return result, nil
 }
 
+// getMetricsCacheTTL returns for how long is the metric value of the trigger cached,
+// the pollingInterval is used if the trigger doesn't specify its own TTL
+func getMetricsCacheTTL(withTriggers *kedav1alpha1.WithTriggers, trigger kedav1alpha1.ScaleTriggers) time.Duration {
+	if !trigger.UseCachedMetrics {
+		return 0
+	}
+	if trigger.CachedMetricsTTL != nil && *trigger.CachedMetricsTTL > 0 {
+		return time.Second * time.Duration(*trigger.CachedMetricsTTL)
+	}
+	return withTriggers.GetPollingInterval()
+}
+
 func buildScaler(ctx context.Context, client client.Client, triggerType string, config *scalers.ScalerConfig) (scalers.Scaler, error) {
 	// TRIGGERS-START
 	switch triggerType {
@@ -382,7 +556,7 @@ func buildScaler(ctx context.Context, client client.Client, triggerType string,
 	case "cassandra":
 		return scalers.NewCassandraScaler(config)
 	case "cpu":
-		return scalers.NewCPUMemoryScaler(corev1.ResourceCPU, config)
+		return scalers.NewCPUMemoryScaler(corev1.ResourceCPU, client, config)
 	case "cron":
 		return scalers.NewCronScaler(config)
 	case "datadog":
@@ -414,7 +588,7 @@ func buildScaler(ctx context.Context, client client.Client, triggerType string,
 	case "liiklus":
 		return scalers.NewLiiklusScaler(config)
 	case "memory":
-		return scalers.NewCPUMemoryScaler(corev1.ResourceMemory, config)
+		return scalers.NewCPUMemoryScaler(corev1.ResourceMemory, client, config)
 	case "metrics-api":
 		return scalers.NewMetricsAPIScaler(config)
 	case "mongodb":
@@ -461,6 +635,19 @@ func buildScaler(ctx context.Context, client client.Client, triggerType string,
 	// TRIGGERS-END
 }
 
+// getTriggerTypes returns the type of each trigger of ScaledObject or ScaledJob
+func getTriggerTypes(scalableObject interface{}) []string {
+	withTriggers, err := asDuckWithTriggers(scalableObject)
+	if err != nil {
+		return nil
+	}
+	triggerTypes := make([]string, 0, len(withTriggers.Spec.Triggers))
+	for _, trigger := range withTriggers.Spec.Triggers {
+		triggerTypes = append(triggerTypes, trigger.Type)
+	}
+	return triggerTypes
+}
+
 func asDuckWithTriggers(scalableObject interface{}) (*kedav1alpha1.WithTriggers, error) {
 	switch obj := scalableObject.(type) {
 	case *kedav1alpha1.ScaledObject:
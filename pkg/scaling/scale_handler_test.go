@@ -19,14 +19,22 @@ package scaling
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
-	"k8s.io/api/autoscaling/v2beta2"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v2 "k8s.io/api/autoscaling/v2"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	scalefake "k8s.io/client-go/scale/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
@@ -80,7 +88,7 @@ func TestCheckScaledObjectFindFirstActiveNotIgnoreOthers(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	recorder := record.NewFakeRecorder(1)
 
-	metricsSpecs := []v2beta2.MetricSpec{createMetricSpec(1)}
+	metricsSpecs := []v2.MetricSpec{createMetricSpec(1)}
 
 	activeFactory := func() (scalers.Scaler, error) {
 		scaler := mock_scalers.NewMockScaler(ctrl)
@@ -134,13 +142,207 @@ func TestCheckScaledObjectFindFirstActiveNotIgnoreOthers(t *testing.T) {
 	assert.Equal(t, true, isError)
 }
 
-func createMetricSpec(averageValue int64) v2beta2.MetricSpec {
+func TestGetMetricsCacheTTL(t *testing.T) {
+	pollingInterval := int32(20)
+	ttl := int32(60)
+	withTriggers := &kedav1alpha1.WithTriggers{
+		Spec: kedav1alpha1.WithTriggersSpec{PollingInterval: &pollingInterval},
+	}
+
+	assert.Equal(t, time.Duration(0), getMetricsCacheTTL(withTriggers, kedav1alpha1.ScaleTriggers{}))
+	assert.Equal(t, time.Duration(0), getMetricsCacheTTL(withTriggers, kedav1alpha1.ScaleTriggers{CachedMetricsTTL: &ttl}))
+	assert.Equal(t, 20*time.Second, getMetricsCacheTTL(withTriggers, kedav1alpha1.ScaleTriggers{UseCachedMetrics: true}))
+	assert.Equal(t, 60*time.Second, getMetricsCacheTTL(withTriggers, kedav1alpha1.ScaleTriggers{UseCachedMetrics: true, CachedMetricsTTL: &ttl}))
+}
+
+func createMetricSpec(averageValue int64) v2.MetricSpec {
 	qty := resource.NewQuantity(averageValue, resource.DecimalSI)
-	return v2beta2.MetricSpec{
-		External: &v2beta2.ExternalMetricSource{
-			Target: v2beta2.MetricTarget{
+	return v2.MetricSpec{
+		External: &v2.ExternalMetricSource{
+			Target: v2.MetricTarget{
 				AverageValue: qty,
 			},
 		},
 	}
 }
+
+// recordingScaleExecutor records the requests to scale ScaledObjects and ScaledJobs
+type recordingScaleExecutor struct {
+	scales    chan bool
+	jobScales chan int64
+}
+
+func (e *recordingScaleExecutor) RequestJobScale(_ context.Context, _ *kedav1alpha1.ScaledJob, isActive bool, scaleTo int64, _ int64, _ int64, _ map[string]error) {
+	if isActive {
+		e.jobScales <- scaleTo
+	}
+}
+
+func (e *recordingScaleExecutor) RequestScale(_ context.Context, _ *kedav1alpha1.ScaledObject, isActive bool, _ bool, _ map[string]error) {
+	if e.scales != nil {
+		e.scales <- isActive
+	}
+}
+
+func TestScaledJobPushScalerRequestsJobScale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	scheme := runtime.NewScheme()
+	assert.NoError(t, kedav1alpha1.AddToScheme(scheme))
+
+	scaledJob := &kedav1alpha1.ScaledJob{
+		TypeMeta: metav1.TypeMeta{Kind: "ScaledJob", APIVersion: "keda.sh/v1alpha1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+	}
+	metricSpecs := []v2.MetricSpec{createMetricSpec(2)}
+	metricSpecs[0].External.Metric.Name = "s0-queueLength"
+
+	pushScaler := mock_scalers.NewMockPushScaler(ctrl)
+	pushScaler.EXPECT().Run(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, active chan<- bool) {
+		active <- false
+		active <- true
+	})
+	pushScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return(metricSpecs).AnyTimes()
+	pushScaler.EXPECT().IsActive(gomock.Any()).Return(true, nil).AnyTimes()
+	pushScaler.EXPECT().GetMetrics(gomock.Any(), "s0-queueLength", gomock.Any()).Return([]external_metrics.ExternalMetricValue{{
+		MetricName: "s0-queueLength",
+		Value:      *resource.NewQuantity(6, resource.DecimalSI),
+	}}, nil).AnyTimes()
+	pushScaler.EXPECT().Close(gomock.Any()).AnyTimes()
+
+	scaleExecutor := &recordingScaleExecutor{jobScales: make(chan int64, 10)}
+	handler := &scaleHandler{
+		client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(scaledJob).Build(),
+		logger:        logf.Log.WithName("scalehandler"),
+		scaleExecutor: scaleExecutor,
+		scalerCaches: map[string]*cache.ScalersCache{
+			"scaledjob.test.test": {
+				Scalers:  []cache.ScalerBuilder{{Scaler: pushScaler}},
+				Logger:   logf.Log.WithName("scalehandler"),
+				Recorder: record.NewFakeRecorder(10),
+			},
+		},
+		lock: &sync.RWMutex{},
+	}
+	withTriggers, err := asDuckWithTriggers(scaledJob)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.startPushScalers(ctx, withTriggers, scaledJob.DeepCopy(), &sync.Mutex{})
+
+	// the deactivation doesn't trigger any check, the activation creates jobs for the queue length without waiting for the scale loop
+	select {
+	case scaleTo := <-scaleExecutor.jobScales:
+		assert.Equal(t, int64(6), scaleTo)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RequestJobScale wasn't called after the activation")
+	}
+	select {
+	case <-scaleExecutor.jobScales:
+		t.Fatal("RequestJobScale was called more than once")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestScaledObjectPushScalerChecksAllTriggers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	scheme := runtime.NewScheme()
+	assert.NoError(t, kedav1alpha1.AddToScheme(scheme))
+
+	scaledObject := &kedav1alpha1.ScaledObject{
+		TypeMeta: metav1.TypeMeta{Kind: "ScaledObject", APIVersion: "keda.sh/v1alpha1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &kedav1alpha1.ScaleTarget{
+				Name: "test",
+			},
+		},
+	}
+	metricSpecs := []v2.MetricSpec{createMetricSpec(2)}
+
+	pushScaler := mock_scalers.NewMockPushScaler(ctrl)
+	pushScaler.EXPECT().Run(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, active chan<- bool) {
+		active <- false
+	})
+	pushScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return(metricSpecs).AnyTimes()
+	pushScaler.EXPECT().IsActive(gomock.Any()).Return(false, nil).AnyTimes()
+	pushScaler.EXPECT().Close(gomock.Any()).AnyTimes()
+
+	pollingScaler := mock_scalers.NewMockScaler(ctrl)
+	pollingScaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return(metricSpecs).AnyTimes()
+	pollingScaler.EXPECT().IsActive(gomock.Any()).Return(true, nil).AnyTimes()
+	pollingScaler.EXPECT().Close(gomock.Any()).AnyTimes()
+
+	scaleExecutor := &recordingScaleExecutor{scales: make(chan bool, 10)}
+	handler := &scaleHandler{
+		client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(scaledObject).Build(),
+		logger:        logf.Log.WithName("scalehandler"),
+		scaleExecutor: scaleExecutor,
+		scalerCaches: map[string]*cache.ScalersCache{
+			"scaledobject.test.test": {
+				Scalers:  []cache.ScalerBuilder{{Scaler: pushScaler}, {Scaler: pollingScaler}},
+				Logger:   logf.Log.WithName("scalehandler"),
+				Recorder: record.NewFakeRecorder(10),
+			},
+		},
+		lock: &sync.RWMutex{},
+	}
+	withTriggers, err := asDuckWithTriggers(scaledObject)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handler.startPushScalers(ctx, withTriggers, scaledObject.DeepCopy(), &sync.Mutex{})
+
+	// the deactivation signaled by the push scaler is a hint, the object is kept active by the other trigger
+	select {
+	case isActive := <-scaleExecutor.scales:
+		assert.True(t, isActive)
+	case <-time.After(5 * time.Second):
+		t.Fatal("RequestScale wasn't called after the push signal")
+	}
+}
+
+func TestGetPodSelector(t *testing.T) {
+	scaledObject := &kedav1alpha1.ScaledObject{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &kedav1alpha1.ScaleTarget{Name: "app"},
+			Triggers:       []kedav1alpha1.ScaleTriggers{{Type: "cpu"}},
+		},
+		Status: kedav1alpha1.ScaledObjectStatus{
+			ScaleTargetGVKR: &kedav1alpha1.GroupVersionKindResource{Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments"},
+		},
+	}
+	scaleClient := &scalefake.FakeScaleClient{}
+	scaleClient.AddReactor("get", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, &autoscalingv1.Scale{Status: autoscalingv1.ScaleStatus{Selector: "app=test,tier in (web)"}}, nil
+	})
+	handler := &scaleHandler{scaleClient: scaleClient, logger: logf.Log.WithName("scalehandler")}
+
+	withTriggers, err := asDuckWithTriggers(scaledObject)
+	assert.NoError(t, err)
+	podSelector := handler.getPodSelector(context.Background(), scaledObject, withTriggers)
+	assert.Equal(t, "app=test,tier in (web)", podSelector.String())
+
+	// the selector isn't needed without cpu and memory triggers
+	scaledObject.Spec.Triggers = []kedav1alpha1.ScaleTriggers{{Type: "kafka"}}
+	withTriggers, err = asDuckWithTriggers(scaledObject)
+	assert.NoError(t, err)
+	assert.Nil(t, handler.getPodSelector(context.Background(), scaledObject, withTriggers))
+
+	scaledJob := &kedav1alpha1.ScaledJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec:       kedav1alpha1.ScaledJobSpec{Triggers: []kedav1alpha1.ScaleTriggers{{Type: "memory"}}},
+	}
+	withTriggers, err = asDuckWithTriggers(scaledJob)
+	assert.NoError(t, err)
+	// ScaledJob ignores cpu and memory triggers
+	assert.Nil(t, handler.getPodSelector(context.Background(), scaledJob, withTriggers))
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schedule evaluates ScheduledWindows of ScaledObject, which temporarily
+// override minReplicaCount and maxReplicaCount or disable scale to zero.
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+type parsedWindow struct {
+	start cron.Schedule
+	end   cron.Schedule
+	loc   *time.Location
+}
+
+func parseWindow(window kedav1alpha1.ScheduledWindow) (*parsedWindow, error) {
+	if window.Timezone == "" {
+		return nil, fmt.Errorf("scheduled window %s doesn't specify timezone", window.Name)
+	}
+	loc, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load timezone of scheduled window %s: %s", window.Name, err)
+	}
+	if window.Start == window.End {
+		return nil, fmt.Errorf("start and end of scheduled window %s can not be the same", window.Name)
+	}
+	start, err := parser.Parse(window.Start)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing start of scheduled window %s: %s", window.Name, err)
+	}
+	end, err := parser.Parse(window.End)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing end of scheduled window %s: %s", window.Name, err)
+	}
+	return &parsedWindow{start: start, end: end, loc: loc}, nil
+}
+
+// isOpen returns true if the window closes before it opens next time, ie. it is open now
+func (w *parsedWindow) isOpen(now time.Time) bool {
+	now = now.In(w.loc)
+	return w.end.Next(now).Before(w.start.Next(now))
+}
+
+// nextTransition returns the time the window opens or closes next time
+func (w *parsedWindow) nextTransition(now time.Time) time.Time {
+	now = now.In(w.loc)
+	start, end := w.start.Next(now), w.end.Next(now)
+	if start.Before(end) {
+		return start
+	}
+	return end
+}
+
+// ValidateScheduledWindows checks that the ScheduledWindows of ScaledObject are correctly specified
+// and that the replica count bounds are valid while any of them is open
+func ValidateScheduledWindows(scaledObject *kedav1alpha1.ScaledObject) error {
+	names := make(map[string]bool, len(scaledObject.Spec.ScheduledWindows))
+	for _, window := range scaledObject.Spec.ScheduledWindows {
+		if window.Name == "" {
+			return fmt.Errorf("scheduled window must have a name")
+		}
+		if names[window.Name] {
+			return fmt.Errorf("scheduled window name %s is not unique", window.Name)
+		}
+		names[window.Name] = true
+
+		if _, err := parseWindow(window); err != nil {
+			return err
+		}
+
+		min := getMinReplicaCount(scaledObject, &window)
+		max := getMaxReplicaCount(scaledObject, &window)
+		if min != nil && max != nil && *min > *max {
+			return fmt.Errorf("MinReplicaCount=%d must be less than MaxReplicaCount=%d in scheduled window %s", *min, *max, window.Name)
+		}
+	}
+	return nil
+}
+
+// GetActiveWindow returns the first open window of ScaledObject, nil is returned if there isn't any.
+// Windows that can't be parsed are ignored, these are reported by ValidateScheduledWindows.
+func GetActiveWindow(scaledObject *kedav1alpha1.ScaledObject, now time.Time) *kedav1alpha1.ScheduledWindow {
+	for i, window := range scaledObject.Spec.ScheduledWindows {
+		parsed, err := parseWindow(window)
+		if err != nil {
+			continue
+		}
+		if parsed.isOpen(now) {
+			return &scaledObject.Spec.ScheduledWindows[i]
+		}
+	}
+	return nil
+}
+
+// GetNextTransition returns the time any of the windows opens or closes next time,
+// the second return value is false if the ScaledObject doesn't have any valid window
+func GetNextTransition(scaledObject *kedav1alpha1.ScaledObject, now time.Time) (time.Time, bool) {
+	var next time.Time
+	found := false
+	for _, window := range scaledObject.Spec.ScheduledWindows {
+		parsed, err := parseWindow(window)
+		if err != nil {
+			continue
+		}
+		if t := parsed.nextTransition(now); !found || t.Before(next) {
+			next = t
+			found = true
+		}
+	}
+	return next, found
+}
+
+// GetMinReplicaCount returns minReplicaCount of ScaledObject, overridden by the active window
+func GetMinReplicaCount(scaledObject *kedav1alpha1.ScaledObject, now time.Time) *int32 {
+	return getMinReplicaCount(scaledObject, GetActiveWindow(scaledObject, now))
+}
+
+// GetMaxReplicaCount returns maxReplicaCount of ScaledObject, overridden by the active window
+func GetMaxReplicaCount(scaledObject *kedav1alpha1.ScaledObject, now time.Time) *int32 {
+	return getMaxReplicaCount(scaledObject, GetActiveWindow(scaledObject, now))
+}
+
+// IsScaleToZeroDisabled returns true if the active window disables scale to zero (and idle replica count)
+func IsScaleToZeroDisabled(scaledObject *kedav1alpha1.ScaledObject, now time.Time) bool {
+	window := GetActiveWindow(scaledObject, now)
+	return window != nil && window.DisableScaleToZero
+}
+
+func getMinReplicaCount(scaledObject *kedav1alpha1.ScaledObject, window *kedav1alpha1.ScheduledWindow) *int32 {
+	min := scaledObject.Spec.MinReplicaCount
+	if window == nil {
+		return min
+	}
+	if window.MinReplicaCount != nil {
+		min = window.MinReplicaCount
+	}
+	if window.DisableScaleToZero && (min == nil || *min < 1) {
+		one := int32(1)
+		min = &one
+	}
+	return min
+}
+
+func getMaxReplicaCount(scaledObject *kedav1alpha1.ScaledObject, window *kedav1alpha1.ScheduledWindow) *int32 {
+	if window != nil && window.MaxReplicaCount != nil {
+		return window.MaxReplicaCount
+	}
+	return scaledObject.Spec.MaxReplicaCount
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/pointer"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+var (
+	businessHours = kedav1alpha1.ScheduledWindow{
+		Name:            "business-hours",
+		Timezone:        "UTC",
+		Start:           "0 9 * * 1-5",
+		End:             "0 17 * * 1-5",
+		MinReplicaCount: pointer.Int32(5),
+	}
+	nightly = kedav1alpha1.ScheduledWindow{
+		Name:               "nightly",
+		Timezone:           "UTC",
+		Start:              "0 22 * * *",
+		End:                "0 6 * * *",
+		MaxReplicaCount:    pointer.Int32(2),
+		DisableScaleToZero: true,
+	}
+)
+
+func TestGetActiveWindow(t *testing.T) {
+	so := &kedav1alpha1.ScaledObject{
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			MinReplicaCount:  pointer.Int32(0),
+			MaxReplicaCount:  pointer.Int32(10),
+			ScheduledWindows: []kedav1alpha1.ScheduledWindow{businessHours, nightly},
+		},
+	}
+
+	tests := []struct {
+		time           string
+		expectedWindow string
+		expectedMin    int32
+		expectedMax    int32
+		zeroDisabled   bool
+	}{
+		// Wednesday
+		{time: "2022-06-01T10:00:00Z", expectedWindow: "business-hours", expectedMin: 5, expectedMax: 10},
+		{time: "2022-06-01T18:00:00Z", expectedWindow: "", expectedMin: 0, expectedMax: 10},
+		{time: "2022-06-01T23:00:00Z", expectedWindow: "nightly", expectedMin: 1, expectedMax: 2, zeroDisabled: true},
+		{time: "2022-06-02T05:59:00Z", expectedWindow: "nightly", expectedMin: 1, expectedMax: 2, zeroDisabled: true},
+		// Saturday
+		{time: "2022-06-04T10:00:00Z", expectedWindow: "", expectedMin: 0, expectedMax: 10},
+	}
+
+	for _, test := range tests {
+		now, err := time.Parse(time.RFC3339, test.time)
+		assert.NoError(t, err)
+
+		window := GetActiveWindow(so, now)
+		if test.expectedWindow == "" {
+			assert.Nil(t, window, test.time)
+		} else {
+			assert.Equal(t, test.expectedWindow, window.Name, test.time)
+		}
+		assert.Equal(t, test.expectedMin, *GetMinReplicaCount(so, now), test.time)
+		assert.Equal(t, test.expectedMax, *GetMaxReplicaCount(so, now), test.time)
+		assert.Equal(t, test.zeroDisabled, IsScaleToZeroDisabled(so, now), test.time)
+	}
+}
+
+func TestGetNextTransition(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2022-06-01T10:00:00Z")
+
+	tests := []struct {
+		name               string
+		windows            []kedav1alpha1.ScheduledWindow
+		expectedTransition string
+	}{
+		{name: "end of business hours", windows: []kedav1alpha1.ScheduledWindow{businessHours, nightly}, expectedTransition: "2022-06-01T17:00:00Z"},
+		{name: "no windows"},
+	}
+
+	for _, test := range tests {
+		so := &kedav1alpha1.ScaledObject{Spec: kedav1alpha1.ScaledObjectSpec{ScheduledWindows: test.windows}}
+		next, found := GetNextTransition(so, now)
+		if test.expectedTransition == "" {
+			assert.False(t, found, test.name)
+		} else {
+			assert.True(t, found, test.name)
+			assert.Equal(t, test.expectedTransition, next.UTC().Format(time.RFC3339), test.name)
+		}
+	}
+}
+
+func TestValidateScheduledWindows(t *testing.T) {
+	invalidCron := businessHours
+	invalidCron.Start = "invalid"
+	invalidTimezone := businessHours
+	invalidTimezone.Timezone = "Mars/Olympus"
+	sameStartEnd := businessHours
+	sameStartEnd.End = sameStartEnd.Start
+	invalidBounds := businessHours
+	invalidBounds.MinReplicaCount = pointer.Int32(20)
+
+	tests := []struct {
+		name    string
+		windows []kedav1alpha1.ScheduledWindow
+		isError bool
+	}{
+		{name: "valid", windows: []kedav1alpha1.ScheduledWindow{businessHours, nightly}},
+		{name: "no windows"},
+		{name: "duplicate name", windows: []kedav1alpha1.ScheduledWindow{businessHours, businessHours}, isError: true},
+		{name: "invalid cron", windows: []kedav1alpha1.ScheduledWindow{invalidCron}, isError: true},
+		{name: "invalid timezone", windows: []kedav1alpha1.ScheduledWindow{invalidTimezone}, isError: true},
+		{name: "same start and end", windows: []kedav1alpha1.ScheduledWindow{sameStartEnd}, isError: true},
+		{name: "min greater than max", windows: []kedav1alpha1.ScheduledWindow{invalidBounds}, isError: true},
+	}
+
+	for _, test := range tests {
+		so := &kedav1alpha1.ScaledObject{
+			Spec: kedav1alpha1.ScaledObjectSpec{
+				MinReplicaCount:  pointer.Int32(0),
+				MaxReplicaCount:  pointer.Int32(10),
+				ScheduledWindows: test.windows,
+			},
+		}
+		err := ValidateScheduledWindows(so)
+		if test.isError {
+			assert.Error(t, err, test.name)
+		} else {
+			assert.NoError(t, err, test.name)
+		}
+	}
+}
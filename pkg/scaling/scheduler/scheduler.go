@@ -0,0 +1,99 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scheduler bounds the checks of triggers run by the scale loops of ScaledObjects and ScaledJobs,
+// so thousands of them don't overload KEDA Operator or the metric sources at the same time,
+// and shards the objects across the replicas of KEDA Operator.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// Scheduler admits the checks of triggers of the scale loops. The number of checks running at once is capped
+// and the checks of triggers of each type are rate limited. A nil Scheduler admits all checks right away.
+type Scheduler struct {
+	// workers holds a token for each running check, it is nil if the concurrency isn't capped
+	workers  chan struct{}
+	limiters map[string]*rate.Limiter
+}
+
+// New returns a Scheduler running at most maxConcurrentChecks checks at once, 0 means unbounded.
+// The rateLimits are the maximal number of checks per second of triggers of each type.
+func New(maxConcurrentChecks int, rateLimits map[string]float64) *Scheduler {
+	s := &Scheduler{limiters: make(map[string]*rate.Limiter, len(rateLimits))}
+	if maxConcurrentChecks > 0 {
+		s.workers = make(chan struct{}, maxConcurrentChecks)
+	}
+	for triggerType, limit := range rateLimits {
+		// the burst allows the checks of an object with several triggers of the same type
+		s.limiters[triggerType] = rate.NewLimiter(rate.Limit(limit), int(math.Max(1, math.Ceil(limit))))
+	}
+	return s
+}
+
+// Acquire blocks until the check of an object with the triggers of the given types can run, the returned
+// function must be called once the check finishes. The rate limits are waited for first, so the waiting
+// doesn't hold a worker. An error is returned if ctx is done before the check can run.
+func (s *Scheduler) Acquire(ctx context.Context, triggerTypes []string) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	for _, triggerType := range triggerTypes {
+		if limiter, ok := s.limiters[triggerType]; ok {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if s.workers == nil {
+		return func() {}, nil
+	}
+	select {
+	case s.workers <- struct{}{}:
+		return func() { <-s.workers }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ParseRateLimits parses the rate limits of trigger types in the format "type=checksPerSecond,...", eg. "prometheus=50,kafka=10"
+func ParseRateLimits(value string) (map[string]float64, error) {
+	rateLimits := make(map[string]float64)
+	if value == "" {
+		return rateLimits, nil
+	}
+	for _, rateLimit := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(rateLimit), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("rate limit %q must be in the format type=checksPerSecond", rateLimit)
+		}
+		limit, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("rate limit of %s must be a positive number, got %q", parts[0], parts[1])
+		}
+		rateLimits[parts[0]] = limit
+	}
+	return rateLimits, nil
+}
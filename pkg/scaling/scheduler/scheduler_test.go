@@ -0,0 +1,123 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNilSchedulerAdmitsAllChecks(t *testing.T) {
+	var s *Scheduler
+	release, err := s.Acquire(context.Background(), []string{"prometheus"})
+	assert.NoError(t, err)
+	release()
+}
+
+func TestSchedulerCapsConcurrentChecks(t *testing.T) {
+	s := New(2, nil)
+
+	var running, maxRunning int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.Acquire(context.Background(), []string{"prometheus"})
+			assert.NoError(t, err)
+			defer release()
+
+			current := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxRunning)
+}
+
+func TestSchedulerAcquireFailsWhenContextIsDone(t *testing.T) {
+	s := New(1, nil)
+	release, err := s.Acquire(context.Background(), nil)
+	assert.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = s.Acquire(ctx, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSchedulerRateLimitsTriggerTypes(t *testing.T) {
+	s := New(0, map[string]float64{"kafka": 20})
+
+	start := time.Now()
+	for i := 0; i < 21; i++ {
+		release, err := s.Acquire(context.Background(), []string{"kafka"})
+		assert.NoError(t, err)
+		release()
+	}
+	// the first 20 checks are the burst, the last one waits for a token
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// other trigger types aren't limited
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		release, err := s.Acquire(context.Background(), []string{"prometheus"})
+		assert.NoError(t, err)
+		release()
+	}
+	assert.Less(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestParseRateLimits(t *testing.T) {
+	tests := []struct {
+		value      string
+		rateLimits map[string]float64
+		isError    bool
+	}{
+		{value: "", rateLimits: map[string]float64{}},
+		{value: "prometheus=50", rateLimits: map[string]float64{"prometheus": 50}},
+		{value: "prometheus=50, kafka=0.5", rateLimits: map[string]float64{"prometheus": 50, "kafka": 0.5}},
+		{value: "prometheus", isError: true},
+		{value: "=50", isError: true},
+		{value: "prometheus=fast", isError: true},
+		{value: "prometheus=-1", isError: true},
+	}
+
+	for _, test := range tests {
+		rateLimits, err := ParseRateLimits(test.value)
+		if test.isError {
+			assert.Error(t, err, test.value)
+			continue
+		}
+		assert.NoError(t, err, test.value)
+		assert.Equal(t, test.rateLimits, rateLimits, test.value)
+	}
+}
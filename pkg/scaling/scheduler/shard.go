@@ -0,0 +1,89 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// virtualNodes is the number of points of each shard on the hash ring, the more there are
+// the more evenly the objects are distributed across the shards
+const virtualNodes = 100
+
+type ringPoint struct {
+	hash  uint64
+	shard int
+}
+
+// Shard is the part of ScaledObjects and ScaledJobs handled by a replica of KEDA Operator. The objects are
+// distributed across the shards by consistent hashing of their identifiers, so only a fraction of them is moved
+// to other replicas when the number of shards changes. A nil Shard owns all objects.
+type Shard struct {
+	index int
+	ring  []ringPoint
+}
+
+// NewShard returns the shard with the index out of count shards
+func NewShard(index int, count int) (*Shard, error) {
+	if count < 1 {
+		return nil, fmt.Errorf("number of shards must be at least 1, got %d", count)
+	}
+	if index < 0 || index >= count {
+		return nil, fmt.Errorf("shard index %d must be within [0, %d)", index, count)
+	}
+
+	ring := make([]ringPoint, 0, count*virtualNodes)
+	for shard := 0; shard < count; shard++ {
+		for node := 0; node < virtualNodes; node++ {
+			ring = append(ring, ringPoint{hash: hash(fmt.Sprintf("shard-%d-%d", shard, node)), shard: shard})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+	return &Shard{index: index, ring: ring}, nil
+}
+
+// Owns returns true if the object with the identifier (see WithTriggers.GenerateIdenitifier) is handled by this shard
+func (s *Shard) Owns(identifier string) bool {
+	if s == nil {
+		return true
+	}
+	return s.OwnerOf(identifier) == s.index
+}
+
+// OwnerOf returns the index of the shard handling the object with the identifier, it is the shard
+// of the first point on the ring following the hash of the identifier. A nil Shard returns 0
+func (s *Shard) OwnerOf(identifier string) int {
+	if s == nil {
+		return 0
+	}
+	h := hash(identifier)
+	i := sort.Search(len(s.ring), func(i int) bool { return s.ring[i].hash >= h })
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].shard
+}
+
+// hash spreads the identifiers evenly on the ring, non-cryptographic hashes like FNV cluster
+// the identifiers differing only in a few trailing characters
+func hash(value string) uint64 {
+	sum := sha256.Sum256([]byte(value))
+	return binary.BigEndian.Uint64(sum[:8])
+}
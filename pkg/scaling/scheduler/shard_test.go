@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getIdentifiers(count int) []string {
+	identifiers := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		identifiers = append(identifiers, fmt.Sprintf("scaledobject.namespace-%d.name-%d", i%7, i))
+	}
+	return identifiers
+}
+
+func getShards(t *testing.T, count int) []*Shard {
+	shards := make([]*Shard, 0, count)
+	for i := 0; i < count; i++ {
+		shard, err := NewShard(i, count)
+		assert.NoError(t, err)
+		shards = append(shards, shard)
+	}
+	return shards
+}
+
+func getOwner(shards []*Shard, identifier string) int {
+	for i, shard := range shards {
+		if shard.Owns(identifier) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestNewShardValidatesIndex(t *testing.T) {
+	_, err := NewShard(0, 0)
+	assert.Error(t, err)
+	_, err = NewShard(3, 3)
+	assert.Error(t, err)
+	_, err = NewShard(-1, 3)
+	assert.Error(t, err)
+}
+
+func TestNilShardOwnsAllObjects(t *testing.T) {
+	var shard *Shard
+	assert.True(t, shard.Owns("scaledobject.namespace.name"))
+	assert.Equal(t, 0, shard.OwnerOf("scaledobject.namespace.name"))
+}
+
+func TestOwnerOfMatchesOwningShard(t *testing.T) {
+	shards := getShards(t, 3)
+	for _, identifier := range getIdentifiers(300) {
+		// any shard of the same count routes the object to its owner
+		assert.Equal(t, getOwner(shards, identifier), shards[0].OwnerOf(identifier), identifier)
+		assert.Equal(t, getOwner(shards, identifier), shards[2].OwnerOf(identifier), identifier)
+	}
+}
+
+func TestShardsOwnEachObjectOnce(t *testing.T) {
+	shards := getShards(t, 3)
+	owned := make([]int, len(shards))
+	for _, identifier := range getIdentifiers(3000) {
+		owners := 0
+		for i, shard := range shards {
+			if shard.Owns(identifier) {
+				owners++
+				owned[i]++
+			}
+		}
+		assert.Equal(t, 1, owners, identifier)
+	}
+
+	// each shard owns roughly a third of the objects
+	for i, count := range owned {
+		assert.InDelta(t, 1000, count, 250, "shard %d", i)
+	}
+}
+
+func TestAddingShardMovesFewObjects(t *testing.T) {
+	before := getShards(t, 3)
+	after := getShards(t, 4)
+
+	moved := 0
+	identifiers := getIdentifiers(3000)
+	for _, identifier := range identifiers {
+		owner := getOwner(after, identifier)
+		if owner != getOwner(before, identifier) {
+			// objects only move to the new shard
+			assert.Equal(t, 3, owner, identifier)
+			moved++
+		}
+	}
+	assert.InDelta(t, len(identifiers)/4, moved, 250)
+}
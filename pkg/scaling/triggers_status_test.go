@@ -0,0 +1,87 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaling
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	v2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+	mock_scalers "github.com/kedacore/keda/v2/pkg/mock/mock_scaler"
+	"github.com/kedacore/keda/v2/pkg/scaling/cache"
+)
+
+func TestTriggersStatusIsRecordedInStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	scheme := runtime.NewScheme()
+	assert.NoError(t, kedav1alpha1.AddToScheme(scheme))
+
+	scaledObject := &kedav1alpha1.ScaledObject{
+		TypeMeta: metav1.TypeMeta{Kind: "ScaledObject", APIVersion: "keda.sh/v1alpha1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+		Spec: kedav1alpha1.ScaledObjectSpec{
+			ScaleTargetRef: &kedav1alpha1.ScaleTarget{Name: "test"},
+			Triggers:       []kedav1alpha1.ScaleTriggers{{Name: "queue", Type: "rabbitmq"}},
+		},
+	}
+	metricSpecs := []v2.MetricSpec{createMetricSpec(2)}
+	metricSpecs[0].External.Metric.Name = "s0-queueLength"
+
+	scaler := mock_scalers.NewMockScaler(ctrl)
+	scaler.EXPECT().GetMetricSpecForScaling(gomock.Any()).Return(metricSpecs).AnyTimes()
+	scaler.EXPECT().IsActive(gomock.Any()).Return(true, nil)
+
+	kubeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(scaledObject).Build()
+	scalersCache := &cache.ScalersCache{
+		Scalers:  []cache.ScalerBuilder{{Scaler: scaler, TriggerName: "queue", TriggerType: "rabbitmq"}},
+		Logger:   logf.Log.WithName("scalehandler"),
+		Recorder: record.NewFakeRecorder(10),
+	}
+	handler := &scaleHandler{
+		client: kubeClient,
+		logger: logf.Log.WithName("scalehandler"),
+		lock:   &sync.RWMutex{},
+	}
+
+	isActive, _, _ := scalersCache.IsScaledObjectActive(context.Background(), scaledObject)
+	assert.True(t, isActive)
+	handler.updateTriggersStatus(context.Background(), scalersCache, scaledObject)
+
+	current := &kedav1alpha1.ScaledObject{}
+	assert.NoError(t, kubeClient.Get(context.Background(), types.NamespacedName{Name: "test", Namespace: "test"}, current))
+	assert.Len(t, current.Status.Triggers, 1)
+	assert.Equal(t, "queue", current.Status.Triggers[0].Name)
+	assert.Equal(t, "rabbitmq", current.Status.Triggers[0].Type)
+	assert.Equal(t, "s0-queueLength", current.Status.Triggers[0].MetricName)
+	assert.Equal(t, "2", current.Status.Triggers[0].Target)
+	assert.True(t, current.Status.Triggers[0].Active)
+	assert.NotNil(t, current.Status.Triggers[0].LastSuccessTime)
+}
@@ -17,9 +17,11 @@ limitations under the License.
 package util
 
 import (
+	"fmt"
 	"strconv"
 
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
 )
 
 // K8sVersion holds parsed data from a K8s version
@@ -53,3 +55,35 @@ func NewK8sVersion(version *version.Info) K8sVersion {
 
 	return *k8sVersion
 }
+
+const (
+	// AutoscalingV2 is the autoscaling API with HPA metrics served since Kubernetes 1.23
+	AutoscalingV2 = "autoscaling/v2"
+	// AutoscalingV2beta2 is the autoscaling API with HPA metrics served until Kubernetes 1.26
+	AutoscalingV2beta2 = "autoscaling/v2beta2"
+)
+
+// GetHighestAutoscalingAPIVersion returns the highest autoscaling API version with HPA metrics served by the cluster
+func GetHighestAutoscalingAPIVersion(client discovery.ServerGroupsInterface) (string, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return "", err
+	}
+
+	served := map[string]bool{}
+	for _, group := range groups.Groups {
+		if group.Name != "autoscaling" {
+			continue
+		}
+		for _, version := range group.Versions {
+			served[version.GroupVersion] = true
+		}
+	}
+
+	for _, version := range []string{AutoscalingV2, AutoscalingV2beta2} {
+		if served[version] {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("neither %s nor %s API is served by the cluster", AutoscalingV2, AutoscalingV2beta2)
+}
@@ -19,7 +19,10 @@ package util
 import (
 	"testing"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
 )
 
 type testMetadata struct {
@@ -92,3 +95,53 @@ func TestResolveK8sVersion(t *testing.T) {
 		}
 	}
 }
+
+type autoscalingAPITestData struct {
+	comment         string
+	groupVersions   []string
+	expectedVersion string
+	isError         bool
+}
+
+var autoscalingAPITestDatas = []autoscalingAPITestData{
+	{
+		comment:         "Testing both v2 and v2beta2",
+		groupVersions:   []string{"autoscaling/v1", "autoscaling/v2", "autoscaling/v2beta2"},
+		expectedVersion: AutoscalingV2,
+	},
+	{
+		comment:         "Testing v2beta2 only",
+		groupVersions:   []string{"autoscaling/v1", "autoscaling/v2beta1", "autoscaling/v2beta2"},
+		expectedVersion: AutoscalingV2beta2,
+	},
+	{
+		comment:       "Testing v1 only",
+		groupVersions: []string{"autoscaling/v1"},
+		isError:       true,
+	},
+}
+
+func TestGetHighestAutoscalingAPIVersion(t *testing.T) {
+	for _, testData := range autoscalingAPITestDatas {
+		t.Log(testData.comment)
+
+		client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		for _, groupVersion := range testData.groupVersions {
+			client.Resources = append(client.Resources, &metav1.APIResourceList{
+				GroupVersion: groupVersion,
+				APIResources: []metav1.APIResource{{Name: "horizontalpodautoscalers", Kind: "HorizontalPodAutoscaler"}},
+			})
+		}
+
+		version, err := GetHighestAutoscalingAPIVersion(client)
+		if testData.isError && err == nil {
+			t.Error("Expected error but got success")
+		}
+		if !testData.isError && err != nil {
+			t.Error("Expected success but got error", err)
+		}
+		if version != testData.expectedVersion {
+			t.Error("Failed to resolve autoscaling API version correctly", "wants", testData.expectedVersion, "got", version)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2022 The KEDA Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/scale"
+
+	kedav1alpha1 "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
+)
+
+// GetCurrentReplicas returns the replica count of the scale target, it is read from the /scale subresource
+// of the target, so it works with any scalable resource regardless of where it stores the replica count
+func GetCurrentReplicas(ctx context.Context, scaleClient scale.ScalesGetter, scaledObject *kedav1alpha1.ScaledObject) (int32, error) {
+	gvkr := scaledObject.Status.ScaleTargetGVKR
+	if gvkr == nil {
+		return 0, fmt.Errorf("scale target of ScaledObject %s/%s isn't resolved yet", scaledObject.Namespace, scaledObject.Name)
+	}
+
+	scale, err := scaleClient.Scales(scaledObject.Namespace).Get(ctx, gvkr.GroupResource(), scaledObject.Spec.ScaleTargetRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	return scale.Spec.Replicas, nil
+}
+
+// GetScaleTargetSelector returns the selector of the scale target pods, it is read from the /scale subresource
+// of the target, so it matches the pods the same way as the HPA does. The selector is nil if the target doesn't report it
+func GetScaleTargetSelector(ctx context.Context, scaleClient scale.ScalesGetter, scaledObject *kedav1alpha1.ScaledObject) (labels.Selector, error) {
+	gvkr := scaledObject.Status.ScaleTargetGVKR
+	if gvkr == nil {
+		return nil, fmt.Errorf("scale target of ScaledObject %s/%s isn't resolved yet", scaledObject.Namespace, scaledObject.Name)
+	}
+
+	scale, err := scaleClient.Scales(scaledObject.Namespace).Get(ctx, gvkr.GroupResource(), scaledObject.Spec.ScaleTargetRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if scale.Status.Selector == "" {
+		return nil, nil
+	}
+	return labels.Parse(scale.Status.Selector)
+}